@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/minami110/gdunit4-test-runner/internal/bundle"
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+)
+
+// writeBundle packs the run and the report files it wrote into the --bundle archive.
+func writeBundle(cfg *config.Config, res *pipeline.Result) error {
+	var files []string
+	for _, p := range []string{cfg.JUnitOutput, cfg.TRXOutput, cfg.SonarOutput, cfg.TextOutput, cfg.WarningsNG, cfg.Checkstyle, cfg.RDJSON, cfg.CoverageOut, cfg.GitLabCodeQuality, cfg.Badge} {
+		if p != "" {
+			files = append(files, p)
+		}
+	}
+	err := bundle.Write(cfg.Bundle, bundle.Run{
+		ID:         res.RunID,
+		Output:     res.Output,
+		Suites:     res.Suites,
+		ProjectDir: res.ProjectDir,
+		ReportDir:  res.ReportDir,
+		Files:      files,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "bundled the run into %s\n", cfg.Bundle)
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/minami110/gdunit4-test-runner/internal/cache"
+	"github.com/minami110/gdunit4-test-runner/internal/clean"
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+)
+
+// runCache implements the cache subcommand.
+func runCache(args []string) int {
+	cfg, err := config.ParseCache(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	dir := cache.Dir()
+	if dir == "" {
+		fmt.Fprintf(os.Stderr, "error: no cache directory; set %s\n", cache.EnvDir)
+		return 2
+	}
+
+	switch cfg.Action {
+	case config.CacheInfo:
+		usage, err := cache.Info(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+		if cfg.JSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(struct {
+				Dir        string        `json:"dir"`
+				Categories []cache.Usage `json:"categories"`
+			}{dir, usage})
+			return 0
+		}
+		fmt.Println(dir)
+		var total int64
+		for _, u := range usage {
+			fmt.Printf("  %-10s %9s  %d files\n", u.Category, clean.FormatSize(u.Size), u.Files)
+			total += u.Size
+		}
+		fmt.Printf("  %-10s %9s\n", "total", clean.FormatSize(total))
+
+	case config.CacheClear:
+		if err := cache.Clear(dir, cfg.Categories); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+	}
+	return 0
+}
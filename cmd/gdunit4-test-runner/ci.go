@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/minami110/gdunit4-test-runner/internal/ci"
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+)
+
+// reportCI writes annotations and summaries for the detected CI provider to stderr.
+// Failures are reported as warnings and do not change the exit code.
+func reportCI(cfg *config.Config, res *pipeline.Result) {
+	opts := ci.Options{PathPrefix: projectPrefix(res.ProjectDir, ci.Workspace(cfg.CI)), Lang: cfg.Lang}
+	if cfg.JUnitOutput != "" {
+		// Service messages are resolved against the checkout, not our working directory.
+		opts.JUnitPath, _ = filepath.Abs(cfg.JUnitOutput)
+	}
+	if cfg.TRXOutput != "" {
+		opts.TRXPath, _ = filepath.Abs(cfg.TRXOutput)
+	}
+	if err := ci.Report(os.Stderr, cfg.CI, res.Output, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ci (%s): %v\n", cfg.CI, err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/cache"
+	"github.com/minami110/gdunit4-test-runner/internal/clean"
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+)
+
+// runClean implements the clean subcommand.
+func runClean(args []string) int {
+	cfg, err := config.ParseClean(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	detected, err := detector.Detect(cfg.Paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	targets, err := clean.Find(clean.Options{
+		ProjectDir: detected.ProjectDir,
+		StateDir:   config.StateDir,
+		CacheDir:   cache.Dir(),
+		TempDir:    os.TempDir(),
+		RunsDir:    config.TempDirName,
+		Now:        time.Now(),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	var total int64
+	for _, t := range targets {
+		fmt.Printf("%-8s %9s  %s\n", t.Kind, clean.FormatSize(t.Size), t.Path)
+		total += t.Size
+	}
+	if cfg.DryRun {
+		fmt.Fprintf(os.Stderr, "would remove %d items (%s)\n", len(targets), clean.FormatSize(total))
+		return 0
+	}
+	if err := clean.Remove(targets); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	fmt.Fprintf(os.Stderr, "removed %d items (%s)\n", len(targets), clean.FormatSize(total))
+	return 0
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/minami110/gdunit4-test-runner/internal/cmake"
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+)
+
+// runCMake implements the cmake subcommand: print add_test() entries for discovered tests.
+func runCMake(args []string) int {
+	paths, err := config.ParsePaths("cmake", "print CMake add_test() entries for every discovered test case", args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	detected, err := detector.Detect(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	suites, err := discovery.Discover(detected.ProjectDir, detected.ResPaths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	if err := cmake.WriteTests(os.Stdout, detected.ProjectDir, suites); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	return 0
+}
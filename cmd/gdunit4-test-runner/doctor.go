@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/runner"
+)
+
+// doctorReport is the doctor subcommand's JSON output.
+type doctorReport struct {
+	Godot        string                  `json:"godot,omitempty"` // the binary a run would use
+	GodotVersion string                  `json:"godot_version,omitempty"`
+	Error        string                  `json:"error,omitempty"`
+	Candidates   []config.GodotCandidate `json:"candidates"`
+}
+
+// runDoctor implements the doctor subcommand. It exits 1 if no usable Godot
+// binary is found.
+func runDoctor(args []string) int {
+	cfg, err := config.ParseDoctor(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	rep := doctorReport{Candidates: []config.GodotCandidate{}}
+	if cfg.GodotPath != "" {
+		rep.Candidates = append(rep.Candidates, config.GodotCandidate{Path: cfg.GodotPath, Source: config.SourceFlag})
+	}
+	if env := os.Getenv("GODOT_PATH"); env != "" {
+		rep.Candidates = append(rep.Candidates, config.GodotCandidate{Path: env, Source: config.SourceEnv})
+	}
+	rep.Candidates = append(rep.Candidates, config.FindGodot()...)
+
+	if rep.Godot, err = config.ResolveGodotPath(cfg.GodotPath); err != nil {
+		rep.Error = err.Error()
+	} else if rep.GodotVersion, err = runner.Version(context.Background(), rep.Godot); err != nil {
+		rep.Error = err.Error()
+	}
+
+	if cfg.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+	} else {
+		if rep.Godot != "" {
+			fmt.Printf("godot: %s (%s)\n", rep.Godot, rep.GodotVersion)
+		}
+		if rep.Error != "" {
+			fmt.Println("error:", rep.Error)
+		}
+		fmt.Println("candidates:")
+		for _, c := range rep.Candidates {
+			mark := " "
+			if c.Path == rep.Godot {
+				mark = "*"
+			}
+			fmt.Printf("  %s %-8s %-8s %s\n", mark, c.Source, c.Version, c.Path)
+		}
+	}
+	if rep.Godot == "" {
+		return 1
+	}
+	return 0
+}
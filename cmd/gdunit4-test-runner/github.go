@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/github"
+	"github.com/minami110/gdunit4-test-runner/internal/owners"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+)
+
+// checkRun is a GitHub check run created before Godot starts and completed with the result.
+type checkRun struct {
+	client *github.Client
+	id     int64
+}
+
+// startCheckRun creates an in-progress check run for the current commit.
+// Failures are reported as warnings and yield nil; the tests still run.
+func startCheckRun() *checkRun {
+	client, err := github.NewClientFromEnv()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: github-check:", err)
+		return nil
+	}
+	sha := github.LoadEvent().HeadSHA()
+	if sha == "" {
+		fmt.Fprintln(os.Stderr, "warning: github-check: GITHUB_SHA is not set")
+		return nil
+	}
+	name := "gdUnit4"
+	if job := os.Getenv("GITHUB_JOB"); job != "" {
+		name += " (" + job + ")"
+	}
+	id, err := client.CreateCheckRun(context.Background(), name, sha)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: github-check:", err)
+		return nil
+	}
+	return &checkRun{client: client, id: id}
+}
+
+// finish completes the check run with the run's result, or with runErr when there is none.
+func (c *checkRun) finish(res *pipeline.Result, runErr error) {
+	errMsg := "no result was produced"
+	if runErr != nil {
+		errMsg = runErr.Error()
+	}
+
+	prefix := projectPrefix(res.ProjectDir, os.Getenv("GITHUB_WORKSPACE"))
+	if err := c.client.CompleteCheckRun(context.Background(), c.id, res.Output, prefix, errMsg); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: github-check:", err)
+	}
+}
+
+// postPRComment posts or updates the pull request comment with the run's summary.
+// Outside a pull request event, and on API errors, it only prints a warning.
+func postPRComment(res *pipeline.Result) {
+	ev := github.LoadEvent()
+	if ev.PullRequest == nil {
+		fmt.Fprintln(os.Stderr, "warning: github-comment: not running for a pull request; skipping")
+		return
+	}
+	client, err := github.NewClientFromEnv()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: github-comment:", err)
+		return
+	}
+
+	// One comment per job, so that matrix jobs do not overwrite each other.
+	marker := "<!-- gdunit4-test-runner -->"
+	if job := os.Getenv("GITHUB_JOB"); job != "" {
+		marker = "<!-- gdunit4-test-runner:" + job + " -->"
+	}
+	created, err := client.UpsertComment(context.Background(), ev.PullRequest.Number, marker, github.CommentBody(res.Output, marker))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: github-comment:", err)
+		return
+	}
+	verb := "updated"
+	if created {
+		verb = "posted"
+	}
+	fmt.Fprintf(os.Stderr, "%s comment on pull request #%d\n", verb, ev.PullRequest.Number)
+}
+
+// projectPrefix returns projectDir relative to the repository root, as a slash-separated
+// path ("" when they are the same). root is the CI checkout directory; when empty the
+// enclosing git repository is used.
+func projectPrefix(projectDir, root string) string {
+	if projectDir == "" {
+		return ""
+	}
+	if root == "" {
+		root = owners.RepoRoot(projectDir)
+	}
+	rel, err := filepath.Rel(root, projectDir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
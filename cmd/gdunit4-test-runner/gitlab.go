@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/minami110/gdunit4-test-runner/internal/github"
+	"github.com/minami110/gdunit4-test-runner/internal/gitlab"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+)
+
+// postMRNote posts or updates the merge request note with the run's summary.
+// Outside a merge request pipeline, and on API errors, it only prints a warning.
+func postMRNote(res *pipeline.Result) {
+	iid, err := strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: gitlab-note: not running in a merge request pipeline; skipping")
+		return
+	}
+	client, err := gitlab.NewClientFromEnv()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: gitlab-note:", err)
+		return
+	}
+
+	// One note per job, so that parallel jobs do not overwrite each other.
+	marker := "<!-- gdunit4-test-runner -->"
+	if job := os.Getenv("CI_JOB_NAME"); job != "" {
+		marker = "<!-- gdunit4-test-runner:" + job + " -->"
+	}
+	// GitLab renders the same markdown (tables, <details>, emoji) as GitHub comments.
+	created, err := client.UpsertNote(context.Background(), iid, marker, github.CommentBody(res.Output, marker))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: gitlab-note:", err)
+		return
+	}
+	verb := "updated"
+	if created {
+		verb = "posted"
+	}
+	fmt.Fprintf(os.Stderr, "%s note on merge request !%d\n", verb, iid)
+}
@@ -1,15 +1,24 @@
 package main
 
 import (
+	"compress/gzip"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/minami110/gdunit4-test-runner/internal/config"
-	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/coverage"
+	"github.com/minami110/gdunit4-test-runner/internal/gitlab"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
 	"github.com/minami110/gdunit4-test-runner/internal/report"
-	"github.com/minami110/gdunit4-test-runner/internal/runner"
 )
 
 var version = "dev"
@@ -19,74 +28,299 @@ func main() {
 }
 
 func run() int {
-	cfg, err := config.Parse(os.Args[1:])
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "serve":
+			return runServe(args[1:])
+		case "cmake":
+			return runCMake(args[1:])
+		case "mutate":
+			return runMutate(args[1:])
+		case "new":
+			return runNew(args[1:])
+		case "snapshots":
+			return runSnapshots(args[1:])
+		case "clean":
+			return runClean(args[1:])
+		case "cache":
+			return runCache(args[1:])
+		case "doctor":
+			return runDoctor(args[1:])
+		case "smoke":
+			return runSmoke(args[1:])
+		case "rerun":
+			return runRerun(args[1:])
+		case "verify":
+			return runVerify(args[1:])
+		case "stats":
+			return runStats(args[1:])
+		}
+	}
+
+	cfg, err := config.Parse(args)
 	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			return 0
 		}
 		if errors.Is(err, config.ErrVersion) {
-			fmt.Fprintln(os.Stderr, "gdunit4-test-runner", version)
+			// JSON is for machines and goes to stdout; the text stays on stderr where it always was.
+			if errors.Is(err, config.ErrVersionJSON) {
+				writeVersion(os.Stdout, true)
+			} else {
+				writeVersion(os.Stderr, false)
+			}
 			return 0
 		}
-		fmt.Fprintln(os.Stderr, "error:", err)
-		return 2
+		return toolError(config.WantsJSONErrors(args), err, "config")
 	}
 
-	detected, err := detector.Detect(cfg.TestPaths)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "error:", err)
-		return 2
+	if cfg.Daemon != "" {
+		return runRemote(cfg)
 	}
 
-	result, err := runner.Run(cfg.GodotPath, detected.ProjectDir, detected.ResPaths, cfg.Verbose, cfg.Timeout)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "error:", err)
-		return 2
+	var check *checkRun
+	if cfg.GitHubCheck {
+		check = startCheckRun()
 	}
-	defer os.Remove(result.LogFile)
 
-	// Detect crashes in the Godot output log.
-	crash, err := report.DetectCrash(result.LogFile)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "error:", err)
-		return 2
+	// Stop Godot through --kill-grace on Ctrl-C or a CI cancellation, so that
+	// whatever it wrote is still reported.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	started := time.Now()
+	opts := pipeline.Options{Version: version}
+	if !cfg.Bazel {
+		opts.HistoryDir = historyDir()
 	}
-
-	// If the process crashed (non-zero exit without a parseable report), emit crash-only JSON.
-	xmlPath, xmlErr := report.FindReportXML(detected.ProjectDir)
-	if xmlErr != nil {
-		// No XML report found — emit crash/error output and exit.
-		out := report.BuildOutput(nil, crash)
-		if writeErr := report.WriteJSON(os.Stdout, out); writeErr != nil {
+	res, err := pipeline.Execute(ctx, cfg, opts)
+	stop()
+	if check != nil {
+		check.finish(res, err)
+	}
+	if res.Output != nil {
+		if cfg.SignOutput {
+			if signErr := report.Sign(res.Output, cfg.SignKey); signErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", signErr)
+				return 2
+			}
+		}
+		if writeErr := writeStdout(cfg.Compress, func(w io.Writer) error { return writeOutput(w, cfg.Format, res) }); writeErr != nil {
 			fmt.Fprintln(os.Stderr, "error:", writeErr)
+			return 2
+		}
+		if cfg.JUnitOutput != "" {
+			if writeErr := writeJUnit(cfg.JUnitOutput, res); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.TRXOutput != "" {
+			if writeErr := writeReportFile(cfg.TRXOutput, func(w io.Writer) error { return report.WriteTRX(w, res.Suites, res.Output.CrashDetails, time.Now()) }); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.SonarOutput != "" {
+			// SonarQube resolves paths against the project base directory, usually the repository root.
+			prefix := projectPrefix(res.ProjectDir, "")
+			if writeErr := writeReportFile(cfg.SonarOutput, func(w io.Writer) error { return report.WriteSonar(w, res.Suites, prefix) }); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.AllureDir != "" {
+			if writeErr := report.WriteAllure(cfg.AllureDir, res.Suites, res.Output, res.ProjectDir, time.Now()); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.TextOutput != "" {
+			if writeErr := writeReportFile(cfg.TextOutput, func(w io.Writer) error { return report.WriteText(w, res.Output, cfg.Lang) }); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.Jenkins || cfg.EditorLinks != "" {
+			// The failures for humans, in the Jenkins console or linked to the editor.
+			report.WriteTerminalText(os.Stderr, res.Output, cfg.Lang, cfg.EditorLinks, res.ProjectDir)
+		}
+		if cfg.WarningsNG != "" {
+			prefix := projectPrefix(res.ProjectDir, os.Getenv("WORKSPACE"))
+			if writeErr := writeReportFile(cfg.WarningsNG, func(w io.Writer) error { return report.WriteWarningsNG(w, res.Output, prefix) }); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.Checkstyle != "" {
+			prefix := projectPrefix(res.ProjectDir, "")
+			if writeErr := writeReportFile(cfg.Checkstyle, func(w io.Writer) error { return report.WriteCheckstyle(w, res.Output, prefix) }); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.RDJSON != "" {
+			prefix := projectPrefix(res.ProjectDir, "")
+			lines := strings.EqualFold(filepath.Ext(strings.TrimSuffix(cfg.RDJSON, report.GzipExt)), ".jsonl")
+			if writeErr := writeReportFile(cfg.RDJSON, func(w io.Writer) error { return report.WriteRDJSON(w, res.Output, prefix, lines) }); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.CoverageOut != "" && res.Coverage != nil {
+			if writeErr := coverage.WriteFile(cfg.CoverageOut, res.Coverage, res.ProjectDir); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.CI != "" {
+			reportCI(cfg, res)
+		}
+		if cfg.Upload != "" {
+			uploadResults(cfg.Upload, res)
+		}
+		if len(cfg.Notify.Rules) > 0 {
+			notifyResults(cfg.Notify, res)
+		}
+		if cfg.GitHubComment {
+			postPRComment(res)
+		}
+		if cfg.GitLabCodeQuality != "" {
+			prefix := projectPrefix(res.ProjectDir, os.Getenv("CI_PROJECT_DIR"))
+			if writeErr := gitlab.WriteCodeQualityFile(cfg.GitLabCodeQuality, res.Output, prefix); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.Badge != "" {
+			if writeErr := writeReportFile(cfg.Badge, func(w io.Writer) error { return report.WriteBadge(w, res.Output) }); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.Bundle != "" {
+			if writeErr := writeBundle(cfg, res); writeErr != nil {
+				fmt.Fprintln(os.Stderr, "error:", writeErr)
+				return 2
+			}
+		}
+		if cfg.GitLabNote {
+			postMRNote(res)
 		}
-		if crash != nil {
+		if cfg.OpenReport {
+			openReport(res)
+		}
+		// The verdict last, whatever the stdout format, for humans scanning CI logs.
+		fmt.Fprintln(os.Stderr, report.SummaryLine(res.Output, time.Since(started), cfg.Lang))
+	}
+	if err != nil {
+		// With a result on stdout, the error is part of it.
+		return toolError(cfg.JSONErrors && res.Output == nil, err, "run")
+	}
+	if cfg.GdUnitExit {
+		// A Godot killed by a timeout or signal has no gdUnit4 exit code.
+		if res.GodotExitCode < 0 {
 			return 2
 		}
-		// Godot ran but produced no report (unexpected).
-		fmt.Fprintln(os.Stderr, "warning: Godot produced no test report")
-		return 2
+		return res.GodotExitCode
 	}
+	if cfg.Bazel && res.ExitCode != 0 {
+		// Bazel only distinguishes pass from fail; a crash is a failed test, not a runner error.
+		return 1
+	}
+	return res.ExitCode
+}
 
-	suites, err := report.ParseXML(xmlPath)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "error:", err)
-		return 2
+// toolError reports err, a failure of the runner itself, on stderr and, if
+// asJSON, as a report.ToolError on stdout, and returns exit code 2. kind
+// classifies the error unless it says more about itself.
+func toolError(asJSON bool, err error, kind string) int {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	if asJSON {
+		var stage *pipeline.StageError
+		switch {
+		case errors.Is(err, config.ErrGodotNotFound):
+			kind = "godot_not_found"
+		case errors.As(err, &stage):
+			kind = stage.Stage
+		}
+		report.WriteError(os.Stdout, report.ToolError{Kind: kind, Message: err.Error(), ExitCode: 2})
 	}
+	return 2
+}
 
-	out := report.BuildOutput(suites, crash)
-	if err := report.WriteJSON(os.Stdout, out); err != nil {
-		fmt.Fprintln(os.Stderr, "error:", err)
-		return 2
+// writeOutput writes the result of the run to w in the configured format.
+func writeOutput(w io.Writer, format string, res *pipeline.Result) error {
+	switch format {
+	case config.FormatCTest:
+		return report.WriteCTest(w, res.Output)
+	case config.FormatXUnit:
+		return report.WriteXUnit(w, res.Suites, res.Output.CrashDetails, time.Now())
+	case config.FormatQuickfix:
+		return report.WriteQuickfix(w, res.Output, quickfixPrefix(res.ProjectDir))
+	case config.FormatPlain:
+		// Problem matchers resolve files against the workspace, usually the repository root.
+		return report.WritePlain(w, res.Output, projectPrefix(res.ProjectDir, ""))
 	}
+	return report.WriteJSON(w, res.Output)
+}
 
-	// Determine exit code based on results.
-	switch out.Summary.Status {
-	case "crashed":
-		return 2
-	case "failed":
-		return 1
-	default:
-		return 0
+// quickfixPrefix returns the path the quickfix list's files are relative to:
+// projectDir relative to the working directory, where the editor that runs
+// the command resolves them, or projectDir itself if it is not below it.
+func quickfixPrefix(projectDir string) string {
+	wd, err := os.Getwd()
+	if err != nil || projectDir == "" {
+		return filepath.ToSlash(projectDir)
+	}
+	rel, err := filepath.Rel(wd, projectDir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(projectDir)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// writeStdout fills stdout with write, gzip-compressed if compress.
+func writeStdout(compress bool, write func(w io.Writer) error) error {
+	if !compress {
+		return write(os.Stdout)
+	}
+	gz := gzip.NewWriter(os.Stdout)
+	writeErr := write(gz)
+	if closeErr := gz.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	return writeErr
+}
+
+// writeJUnit writes the run's JUnit XML report to path.
+func writeJUnit(path string, res *pipeline.Result) error {
+	return writeReportFile(path, func(w io.Writer) error {
+		return report.WriteJUnitXML(w, res.Suites, res.Output.CrashDetails)
+	})
+}
+
+// writeReportFile creates path, including missing parent directories, and fills
+// it with write, gzip-compressed if path ends in report.GzipExt.
+func writeReportFile(path string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	var writeErr error
+	if report.Compressed(path) {
+		gz := gzip.NewWriter(f)
+		writeErr = write(gz)
+		if closeErr := gz.Close(); writeErr == nil {
+			writeErr = closeErr
+		}
+	} else {
+		writeErr = write(f)
+	}
+	if closeErr := f.Close(); writeErr == nil {
+		writeErr = closeErr
 	}
+	return writeErr
 }
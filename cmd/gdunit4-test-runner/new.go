@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/scaffold"
+)
+
+// runNew implements the new subcommand: generate a test suite skeleton per source script.
+func runNew(args []string) int {
+	cfg, err := config.ParseNew(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	for _, source := range cfg.Sources {
+		path, err := newSuite(source, cfg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+		fmt.Println(path)
+	}
+	return 0
+}
+
+// newSuite writes the test suite for source and returns its path.
+func newSuite(source string, cfg *config.NewConfig) (string, error) {
+	if filepath.Ext(source) != ".gd" {
+		return "", fmt.Errorf("%s is not a GDScript file", source)
+	}
+	src, err := os.ReadFile(source)
+	if err != nil {
+		return "", err
+	}
+	detected, err := detector.Detect([]string{source})
+	if err != nil {
+		return "", err
+	}
+
+	resPath := detected.ResPaths[0]
+	testRes := scaffold.TestPath(resPath, cfg.TestsDir)
+	testPath := filepath.Join(detected.ProjectDir, filepath.FromSlash(strings.TrimPrefix(testRes, "res://")))
+
+	if _, err := os.Stat(testPath); err == nil && !cfg.Force {
+		return "", fmt.Errorf("%s already exists; use --force to overwrite", testPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(testPath), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(testPath, scaffold.Suite(resPath, src), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write test suite: %w", err)
+	}
+	return testPath, nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/notify"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+)
+
+// notifyResults sends the run's results to the notifiers selected by the routing rules.
+// Notification failures are reported as warnings and do not change the exit code.
+func notifyResults(n config.Notify, res *pipeline.Result) {
+	sent, err := notify.Send(context.Background(), n, res.Output, res.ProjectDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: notify:", err)
+	}
+	if len(sent) > 0 {
+		fmt.Fprintf(os.Stderr, "notified %s\n", strings.Join(sent, ", "))
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+)
+
+// htmlReport is the entry page of the HTML report gdUnit4 writes next to results.xml.
+const htmlReport = "index.html"
+
+// openReport opens the gdUnit4 HTML report of the run in the default browser
+// (--open-report). It does not wait for the browser; failing to open the
+// report is a warning.
+func openReport(res *pipeline.Result) {
+	if res.ReportDir == "" {
+		fmt.Fprintln(os.Stderr, "warning: open report: the run has no gdUnit4 report")
+		return
+	}
+	page := filepath.Join(res.ReportDir, htmlReport)
+	if _, err := os.Stat(page); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: open report:", err)
+		return
+	}
+	name, args := browserCommand(runtime.GOOS, page)
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: open report:", err)
+		return
+	}
+	cmd.Process.Release()
+	fmt.Fprintln(os.Stderr, "opened", page)
+}
+
+// browserCommand returns the command opening path in the default browser of goos.
+func browserCommand(goos, path string) (string, []string) {
+	switch goos {
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", path}
+	case "darwin":
+		return "open", []string{path}
+	}
+	return "xdg-open", []string{path}
+}
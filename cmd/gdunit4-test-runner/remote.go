@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+	"github.com/minami110/gdunit4-test-runner/internal/serve"
+)
+
+// remoteCancelTimeout bounds the wait for the daemon to stop a cancelled run,
+// which takes up to its --kill-grace.
+const remoteCancelTimeout = time.Minute
+
+// runRemote delegates the run to a daemon and prints its result like a local run.
+func runRemote(cfg *config.Config) int {
+	paths := make([]string, 0, len(cfg.TestPaths))
+	for _, p := range cfg.TestPaths {
+		if detector.IsResPath(p) {
+			paths = append(paths, p)
+			continue
+		}
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return toolError(cfg.JSONErrors, err, "run")
+		}
+		paths = append(paths, abs)
+	}
+
+	// On Ctrl-C or a CI cancellation, stop the run on the daemon too and report
+	// the tests it got to, as a local run does.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	started := time.Now()
+	info, err := serve.RunRemote(ctx, cfg.Daemon, paths, cfg.Filter)
+	interrupted := ctx.Err() != nil
+	stop()
+	if interrupted && info.ID != "" {
+		cancelCtx, cancel := context.WithTimeout(context.Background(), remoteCancelTimeout)
+		info, err = serve.CancelRemote(cancelCtx, cfg.Daemon, info.ID)
+		cancel()
+	}
+	if err != nil {
+		return toolError(cfg.JSONErrors, err, "daemon")
+	}
+	if info.Output != nil {
+		if cfg.SignOutput {
+			if err := report.Sign(info.Output, cfg.SignKey); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				return 2
+			}
+		}
+		if err := writeStdout(cfg.Compress, func(w io.Writer) error { return report.WriteJSON(w, info.Output) }); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+	}
+	if info.Error != "" {
+		fmt.Fprintln(os.Stderr, "error:", info.Error)
+		if cfg.JSONErrors && info.Output == nil {
+			report.WriteError(os.Stdout, report.ToolError{Kind: "run", Message: info.Error, ExitCode: 2})
+		}
+	}
+	if info.Output != nil {
+		fmt.Fprintln(os.Stderr, report.SummaryLine(info.Output, time.Since(started), cfg.Lang))
+	}
+	if info.ExitCode == nil {
+		return 2
+	}
+	return *info.ExitCode
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/serve"
+	"github.com/minami110/gdunit4-test-runner/internal/testutil"
+)
+
+func TestRunRemote_InterruptCancelsDaemonRun(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "godot.pid")
+	godot := sleepingGodot(t, pidFile)
+	root := testutil.Project(t, map[string]string{
+		"tests/test_math.gd": "extends GdUnitTestSuite\n\nfunc test_add() -> void:\n\tpass\n",
+	})
+	tests := filepath.Join(root, "tests")
+	m := serve.NewManager(&config.Config{TestPaths: []string{tests}, GodotPath: godot})
+	srv := httptest.NewServer(serve.NewHTTPHandler(m, false))
+	defer srv.Close()
+	defer m.Shutdown()
+
+	code := make(chan int, 1)
+	go func() {
+		code <- runRemote(&config.Config{Daemon: srv.URL, TestPaths: []string{tests}, Format: config.FormatJSON})
+	}()
+	pid := waitPID(t, pidFile)
+	signalSelf(t, syscall.SIGINT)
+
+	select {
+	case got := <-code:
+		if got != 2 {
+			t.Errorf("runRemote = %d, want 2 for a run stopped before gdUnit4 reported", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("runRemote did not return after SIGINT")
+	}
+	runs := m.List()
+	if len(runs) != 1 || runs[0].Status != serve.StatusCancelled {
+		t.Errorf("daemon runs = %+v, want one cancelled run", runs)
+	}
+	checkStopped(t, pid)
+}
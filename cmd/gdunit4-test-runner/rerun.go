@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+	"github.com/minami110/gdunit4-test-runner/internal/serve"
+)
+
+// runRerun implements the rerun subcommand: it looks the named test up among
+// the suites under the paths and runs only it, locally or on --daemon.
+func runRerun(args []string) int {
+	cfg, err := config.ParseRerun(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	base := cfg.Base
+
+	detected, err := detector.DetectIn(base.ProjectDir, base.TestPaths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	suites, err := discovery.Discover(detected.ProjectDir, detected.ResPaths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	suite, test, err := discovery.Find(suites, cfg.Test)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	// The suite's file, absolute so that a daemon resolves it too.
+	base.TestPaths = []string{filepath.Join(detected.ProjectDir, filepath.FromSlash(strings.TrimPrefix(suite.ResPath, "res://")))}
+	base.Filter = []string{suite.Class + "." + test}
+	fmt.Fprintf(os.Stderr, "running %s.%s (%s)\n", suite.Class, test, suite.ResPath)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var out *report.Output
+	var code int
+	if base.Daemon != "" {
+		info, err := serve.RunRemote(ctx, base.Daemon, base.TestPaths, base.Filter)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+		// The daemon only hands out its output once the run is over.
+		if log, err := serve.RemoteLog(ctx, base.Daemon, info.ID); err == nil {
+			fmt.Fprint(os.Stderr, log)
+		}
+		if info.Error != "" {
+			fmt.Fprintln(os.Stderr, "error:", info.Error)
+		}
+		out, code = info.Output, 2
+		if info.ExitCode != nil {
+			code = *info.ExitCode
+		}
+	} else {
+		res, err := pipeline.Execute(ctx, base, pipeline.Options{Version: version, HistoryDir: historyDir()})
+		out, code = res.Output, res.ExitCode
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			code = 2
+		}
+	}
+
+	if out != nil {
+		if err := writeRerunResult(out, base, detected.ProjectDir); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+	}
+	return code
+}
+
+// writeRerunResult prints the result of the test to stdout: the plain-text
+// summary in the language of cfg with the expected and actual values, linked to
+// the editor with --editor-links, and, for a snapshot test, the diff of the
+// received snapshot.
+func writeRerunResult(out *report.Output, cfg *config.Config, projectDir string) error {
+	if err := report.WriteTerminalText(os.Stdout, out, cfg.Lang, cfg.EditorLinks, projectDir); err != nil {
+		return err
+	}
+	for _, f := range out.Failures {
+		if f.Snapshot != nil && f.Snapshot.Diff != "" {
+			if _, err := fmt.Fprintf(os.Stdout, "\n%s", f.Snapshot.Diff); err != nil {
+				return fmt.Errorf("failed to write text output: %w", err)
+			}
+		}
+	}
+	return nil
+}
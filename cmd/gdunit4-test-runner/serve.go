@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/serve"
+)
+
+// runServe implements the serve subcommand.
+func runServe(args []string) int {
+	cfg, err := config.ParseServe(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	if cfg.Editor && cfg.Base.ProjectDir == "" {
+		// The plugin names tests by res:// path, which resolve against the project.
+		detected, err := detector.DetectIn("", cfg.Base.TestPaths)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+		cfg.Base.ProjectDir = detected.ProjectDir
+	}
+
+	m := serve.NewManager(cfg.Base)
+	m.RecordHistory(historyDir())
+	if cfg.GRPC != "" {
+		l, err := net.Listen("tcp", cfg.GRPC)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, "serving gRPC on", l.Addr())
+		// gRPC clients speak HTTP/2 without TLS (h2c) to a plain-text server.
+		var protocols http.Protocols
+		protocols.SetUnencryptedHTTP2(true)
+		return serveListener(l, &http.Server{Handler: serve.NewGRPCHandler(m), Protocols: &protocols}, m)
+	}
+	if cfg.HTTP != "" {
+		l, err := net.Listen("tcp", cfg.HTTP)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+		switch {
+		case cfg.Editor:
+			url := "http://" + l.Addr().String()
+			path, err := serve.WriteEditorInfo(cfg.Base.ProjectDir, serve.EditorInfo{URL: url, PID: os.Getpid(), Version: version})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				return 2
+			}
+			defer os.Remove(path)
+			fmt.Fprintf(os.Stderr, "serving the Godot editor at %s/ (advertised in %s)\n", url, path)
+		case cfg.UI:
+			fmt.Fprintf(os.Stderr, "dashboard at http://%s/\n", cfg.HTTP)
+		default:
+			fmt.Fprintln(os.Stderr, "listening on", cfg.HTTP)
+		}
+		return serveListener(l, &http.Server{Handler: serve.NewHTTPHandler(m, cfg.UI)}, m)
+	}
+	if err := serve.ServeStdio(m, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	return 0
+}
+
+// serveListener serves srv on l until a signal or a shutdown request, such as
+// the editor plugin stopping the server, closes it. It then shuts m down, so
+// the Godot process of the active run does not outlive the server, and
+// returns to let the deferred cleanup run.
+func serveListener(l net.Listener, srv *http.Server, m *serve.Manager) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-m.Done():
+		}
+		srv.Close()
+	}()
+	err := srv.Serve(l)
+	m.Shutdown()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	return 0
+}
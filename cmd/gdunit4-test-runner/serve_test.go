@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/cache"
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/serve"
+	"github.com/minami110/gdunit4-test-runner/internal/testutil"
+)
+
+// sleepingGodot returns a fake Godot that writes its PID to pidFile and
+// sleeps until it is stopped.
+func sleepingGodot(t *testing.T, pidFile string) string {
+	t.Helper()
+	return testutil.FakeGodot(t, "echo $$ > "+pidFile+"\nexec sleep 30\n")
+}
+
+// waitPID waits for the fake Godot of sleepingGodot to start and returns its PID.
+func waitPID(t *testing.T, pidFile string) int {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		data, err := os.ReadFile(pidFile)
+		if err != nil {
+			continue
+		}
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			return pid
+		}
+	}
+	t.Fatal("fake Godot did not start")
+	return 0
+}
+
+// signalSelf sends sig to the test process, which serveListener traps.
+func signalSelf(t *testing.T, sig os.Signal) {
+	t.Helper()
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(sig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkStopped fails t if the process pid is still running.
+func checkStopped(t *testing.T, pid int) {
+	t.Helper()
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return
+	}
+	if err := p.Signal(syscall.Signal(0)); err == nil {
+		p.Kill()
+		t.Errorf("Godot process %d is still running after the server stopped", pid)
+	}
+}
+
+// startRun queues a run of the default paths on the HTTP server at addr.
+func startRun(t *testing.T, addr string) serve.RunInfo {
+	t.Helper()
+	resp, err := http.Post("http://"+addr+"/runs", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var info serve.RunInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestServeListener_SignalShutsDownManager(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "godot.pid")
+	godot := sleepingGodot(t, pidFile)
+	root := testutil.Project(t, map[string]string{
+		"tests/test_math.gd": "extends GdUnitTestSuite\n\nfunc test_add() -> void:\n\tpass\n",
+	})
+	m := serve.NewManager(&config.Config{TestPaths: []string{filepath.Join(root, "tests")}, GodotPath: godot})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := make(chan int, 1)
+	go func() { code <- serveListener(l, &http.Server{Handler: serve.NewHTTPHandler(m, false)}, m) }()
+
+	info := startRun(t, l.Addr().String())
+	pid := waitPID(t, pidFile)
+	signalSelf(t, syscall.SIGTERM)
+
+	select {
+	case got := <-code:
+		if got != 0 {
+			t.Errorf("serveListener = %d, want 0", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("serveListener did not return after SIGTERM")
+	}
+	if got, _ := m.Get(info.ID); got.Status != serve.StatusCancelled {
+		t.Errorf("run Status = %q, want %q", got.Status, serve.StatusCancelled)
+	}
+	checkStopped(t, pid)
+}
+
+func TestRunServe_EditorStop(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(t *testing.T, url string)
+	}{
+		{"signal", func(t *testing.T, url string) { signalSelf(t, syscall.SIGTERM) }},
+		{"shutdown request", func(t *testing.T, url string) {
+			resp, err := http.Post(url+"/shutdown", "application/json", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(cache.EnvDir, t.TempDir())
+			pidFile := filepath.Join(t.TempDir(), "godot.pid")
+			godot := sleepingGodot(t, pidFile)
+			root := testutil.Project(t, map[string]string{
+				"tests/test_math.gd": "extends GdUnitTestSuite\n\nfunc test_add() -> void:\n\tpass\n",
+			})
+
+			code := make(chan int, 1)
+			go func() {
+				code <- runServe([]string{"--editor", "--project", root, "--godot-path", godot, "tests"})
+			}()
+
+			var info serve.EditorInfo
+			path := serve.EditorFile(root)
+			for deadline := time.Now().Add(5 * time.Second); info.URL == ""; time.Sleep(10 * time.Millisecond) {
+				if time.Now().After(deadline) {
+					t.Fatal("serve --editor did not advertise itself")
+				}
+				if data, err := os.ReadFile(path); err == nil {
+					json.Unmarshal(data, &info)
+				}
+			}
+			run := startRun(t, strings.TrimPrefix(info.URL, "http://"))
+			pid := waitPID(t, pidFile)
+			tt.stop(t, info.URL)
+
+			select {
+			case got := <-code:
+				if got != 0 {
+					t.Errorf("runServe = %d, want 0", got)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatalf("serve --editor did not stop (run %s)", run.ID)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("%s still exists after the server stopped", path)
+			}
+			checkStopped(t, pid)
+		})
+	}
+}
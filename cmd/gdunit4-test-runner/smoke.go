@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+	"github.com/minami110/gdunit4-test-runner/internal/smoke"
+)
+
+// runSmoke implements the smoke subcommand. It exits like a test run: 0 when
+// the build ran fine, 1 when it exited with an error code, 2 when it crashed or
+// could not be exported.
+func runSmoke(args []string) int {
+	cfg, err := config.ParseSmoke(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	exportPath := cfg.ExportPath
+	if exportPath == "" {
+		dir, err := os.MkdirTemp("", "gdunit4-smoke-*")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+		defer os.RemoveAll(dir)
+		exportPath = filepath.Join(dir, config.DefaultExportName())
+	}
+	opts := smoke.Options{
+		GodotPath:  cfg.GodotPath,
+		ProjectDir: cfg.ProjectDir,
+		Preset:     cfg.Preset,
+		ExportPath: exportPath,
+		Run:        cfg.Run,
+		Timeout:    cfg.Timeout,
+		LogFile:    cfg.LogFile,
+	}
+
+	if err := smoke.Export(ctx, opts); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	out, err := smoke.Run(ctx, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	out.LogFile = cfg.LogFile
+	if err := report.WriteJSON(os.Stdout, out); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	return pipeline.ExitCode(out)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+	"github.com/minami110/gdunit4-test-runner/internal/snapshot"
+)
+
+// runSnapshots implements the snapshots subcommand.
+func runSnapshots(args []string) int {
+	cfg, err := config.ParseSnapshots(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	if err := snapshots(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	return 0
+}
+
+func snapshots(cfg *config.SnapshotsConfig) error {
+	detected, err := detector.Detect(cfg.Paths)
+	if err != nil {
+		return err
+	}
+	suites, err := discovery.Discover(detected.ProjectDir, detected.ResPaths)
+	if err != nil {
+		return err
+	}
+	entries, err := snapshot.Entries(detected.ProjectDir, suites)
+	if err != nil {
+		return err
+	}
+
+	switch cfg.Action {
+	case config.SnapshotsList:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+
+	case config.SnapshotsApprove:
+		for _, e := range entries {
+			if e.Received == "" || !matchesFilter(cfg.Filter, e.Suite, e.Test) {
+				continue
+			}
+			for _, f := range e.Files() {
+				if f.Kind != snapshot.Received {
+					continue
+				}
+				if err := snapshot.Approve(f); err != nil {
+					return err
+				}
+				fmt.Println(f.ApprovedPath())
+			}
+		}
+
+	case config.SnapshotsPrune:
+		for _, e := range entries {
+			if e.Status != snapshot.StatusOrphaned {
+				continue
+			}
+			for _, f := range e.Files() {
+				if !cfg.DryRun {
+					if err := os.Remove(f.Path); err != nil {
+						return err
+					}
+				}
+				fmt.Println(f.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// matchesFilter reports whether the test matches any of patterns; an empty filter matches everything.
+func matchesFilter(patterns []string, class, test string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := discovery.MatchTest(p, class, test); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/cache"
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/history"
+)
+
+// runStats implements the stats subcommand.
+func runStats(args []string) int {
+	cfg, err := config.ParseStats(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	dir := cache.Dir()
+	if dir == "" {
+		fmt.Fprintf(os.Stderr, "error: no cache directory; set %s\n", cache.EnvDir)
+		return 2
+	}
+	project := ""
+	if len(cfg.Paths) > 0 {
+		detected, err := detector.Detect(cfg.Paths)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+		project = detected.ProjectDir
+	}
+
+	runs, err := history.Read(filepath.Join(dir, cache.History), project, time.Now().Add(-cfg.Since))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	flaky := history.Flaky(runs)
+	switch cfg.Format {
+	case config.StatsMarkdown:
+		err = history.WriteFlakyMarkdown(os.Stdout, flaky, len(runs), cfg.Since)
+	case config.StatsJSON:
+		if flaky == nil {
+			flaky = []history.FlakyTest{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(struct {
+			Since string              `json:"since"`
+			Runs  int                 `json:"runs"`
+			Tests []history.FlakyTest `json:"tests"`
+		}{history.FormatSince(cfg.Since), len(runs), flaky})
+	default:
+		err = history.WriteFlakyText(os.Stdout, flaky, len(runs), cfg.Since)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	return 0
+}
+
+// historyDir returns the run history in the cache for runs to be recorded in,
+// which stats reads, or "" with a warning if there is no cache directory: the
+// history is a convenience.
+func historyDir() string {
+	dir, err := cache.Path(cache.History)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: not recording the run history:", err)
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+	"github.com/minami110/gdunit4-test-runner/internal/upload"
+)
+
+// uploadResults pushes the run's results to the object store at rawURL.
+// Upload failures are reported as warnings and do not change the exit code.
+func uploadResults(rawURL string, res *pipeline.Result) {
+	store, err := upload.Open(rawURL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: upload:", err)
+		return
+	}
+
+	run := upload.Run{
+		Commit:    upload.Commit(res.ProjectDir),
+		ID:        res.RunID,
+		Output:    res.Output,
+		Suites:    res.Suites,
+		ReportDir: res.ReportDir,
+	}
+	keys, err := upload.Upload(context.Background(), store, run)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: upload:", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "uploaded %d files to %s/%s/%s/\n", len(keys), rawURL, run.Commit, run.ID)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// runVerify implements the verify subcommand. It exits 1 if the result was
+// changed after it was signed or lacks a valid signature.
+func runVerify(args []string) int {
+	cfg, err := config.ParseVerify(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	var key ed25519.PublicKey
+	if cfg.Key != "" {
+		if key, err = report.LoadPublicKey(cfg.Key); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+	}
+	in := io.Reader(os.Stdin)
+	if cfg.File != "" && cfg.File != "-" {
+		f, err := os.Open(cfg.File)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+		defer f.Close()
+		in = f
+	}
+	r, err := report.Decompress(in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	integrity, err := report.Verify(data, key)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid:", err)
+		return 1
+	}
+	if key != nil {
+		fmt.Fprintf(os.Stderr, "valid: sha256 %s, signed\n", integrity.Digest)
+	} else {
+		fmt.Fprintf(os.Stderr, "valid: sha256 %s\n", integrity.Digest)
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Build metadata, set with -ldflags "-X main.commit=... -X main.date=...".
+// When left empty, they are taken from the VCS information Go embeds, where
+// the date is that of the commit.
+var (
+	commit = ""
+	date   = ""
+)
+
+// versionInfo is the output of --version --json.
+type versionInfo struct {
+	Version   string            `json:"version"`
+	Commit    string            `json:"commit,omitempty"`
+	Modified  bool              `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	BuildDate string            `json:"build_date,omitempty"`
+	GoVersion string            `json:"go_version"`
+	Platform  string            `json:"platform"`
+	Schemas   map[string]string `json:"schemas"`
+}
+
+// buildInfo returns the version and build metadata of the binary.
+func buildInfo() versionInfo {
+	info := versionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Schemas:   report.Schemas,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// writeVersion prints the version and build metadata to w, as JSON if asJSON.
+func writeVersion(w io.Writer, asJSON bool) error {
+	info := buildInfo()
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+
+	fmt.Fprintln(w, "gdunit4-test-runner", info.Version)
+	if info.Commit != "" {
+		modified := ""
+		if info.Modified {
+			modified = " (modified)"
+		}
+		fmt.Fprintf(w, "commit: %s%s\n", info.Commit, modified)
+	}
+	if info.BuildDate != "" {
+		fmt.Fprintln(w, "built:", info.BuildDate)
+	}
+	fmt.Fprintf(w, "go: %s %s\n", info.GoVersion, info.Platform)
+	schemas := make([]string, 0, len(info.Schemas))
+	for _, name := range slices.Sorted(maps.Keys(info.Schemas)) {
+		schemas = append(schemas, name+" "+info.Schemas[name])
+	}
+	_, err := fmt.Fprintln(w, "schemas:", strings.Join(schemas, ", "))
+	return err
+}
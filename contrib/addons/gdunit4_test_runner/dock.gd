@@ -0,0 +1,190 @@
+@tool
+extends VBoxContainer
+
+## The test runner dock: runs the tests of the project, or of the script open
+## in the script editor, on the serve --editor server and lists the failures of
+## the run. Double-click a failure to open its script at the failed line.
+
+const POLL_SECONDS := 0.5
+const CONNECT_TRIES := 20
+
+var plugin: EditorPlugin
+
+var _url := ""
+var _run_id := ""
+var _status: Label
+var _failures: Tree
+var _cancel: Button
+var _poll: Timer
+
+
+func _ready() -> void:
+	var buttons := HBoxContainer.new()
+	add_child(buttons)
+	var run_all := Button.new()
+	run_all.text = "Run All"
+	run_all.pressed.connect(func(): _run([]))
+	buttons.add_child(run_all)
+	var run_script := Button.new()
+	run_script.text = "Run Script"
+	run_script.tooltip_text = "Run the test suite open in the script editor"
+	run_script.pressed.connect(_run_current_script)
+	buttons.add_child(run_script)
+	_cancel = Button.new()
+	_cancel.text = "Cancel"
+	_cancel.disabled = true
+	_cancel.pressed.connect(_cancel_run)
+	buttons.add_child(_cancel)
+
+	_status = Label.new()
+	_status.autowrap_mode = TextServer.AUTOWRAP_WORD_SMART
+	_status.text = "Not run yet."
+	add_child(_status)
+
+	_failures = Tree.new()
+	_failures.size_flags_vertical = Control.SIZE_EXPAND_FILL
+	_failures.columns = 2
+	_failures.hide_root = true
+	_failures.item_activated.connect(_open_failure)
+	add_child(_failures)
+
+	_poll = Timer.new()
+	_poll.wait_time = POLL_SECONDS
+	_poll.timeout.connect(_poll_run)
+	add_child(_poll)
+
+
+func _run_current_script() -> void:
+	var script := EditorInterface.get_script_editor().get_current_script()
+	if script == null:
+		_status.text = "Open a test suite in the script editor first."
+		return
+	_run([script.resource_path])
+
+
+## Starts a run of paths (res:// paths; empty for the server's default paths).
+func _run(paths: Array) -> void:
+	if _run_id != "":
+		return
+	_status.text = "Connecting to gdunit4-test-runner..."
+	_connect(func():
+		_request("/runs", HTTPClient.METHOD_POST, JSON.stringify({"paths": paths}), _on_started)
+	)
+
+
+## Calls then once a server answers, starting one if needed.
+func _connect(then: Callable, tries := CONNECT_TRIES) -> void:
+	_url = plugin.server_url()
+	if _url == "":
+		_retry_connect(then, tries)
+		return
+	_request("/runs", HTTPClient.METHOD_GET, "", func(code: int, _body):
+		if code == 200:
+			then.call()
+		else:
+			_retry_connect(then, tries)
+	)
+
+
+func _retry_connect(then: Callable, tries: int) -> void:
+	if tries <= 0 or not plugin.start_server():
+		_status.text = "Cannot reach gdunit4-test-runner serve --editor; see the Output panel."
+		return
+	await get_tree().create_timer(POLL_SECONDS).timeout
+	_connect(then, tries - 1)
+
+
+func _on_started(code: int, info) -> void:
+	if code != 202 or not info is Dictionary:
+		_status.text = "The run could not be started: %s" % _error_of(info)
+		return
+	_run_id = info["run_id"]
+	_status.text = "Running..."
+	_cancel.disabled = false
+	_failures.clear()
+	_poll.start()
+
+
+func _poll_run() -> void:
+	if _run_id == "":
+		_poll.stop()
+		return
+	_request("/runs/" + _run_id, HTTPClient.METHOD_GET, "", func(code: int, info):
+		if code != 200 or not info is Dictionary:
+			return
+		if info["status"] == "finished" or info["status"] == "cancelled":
+			_poll.stop()
+			_run_id = ""
+			_cancel.disabled = true
+			_show(info)
+	)
+
+
+func _cancel_run() -> void:
+	if _run_id != "":
+		_request("/runs/%s/cancel" % _run_id, HTTPClient.METHOD_POST, "", func(_code: int, _body): pass)
+
+
+## Shows the result of the finished run info.
+func _show(info: Dictionary) -> void:
+	var output = info.get("output")
+	if not output is Dictionary:
+		_status.text = "%s: %s" % [info["status"], info.get("error", "no result")]
+		return
+	var summary: Dictionary = output["summary"]
+	_status.text = "%s: %d passed, %d failed, %d errors of %d" % [
+		summary["status"], summary["passed"], summary["failed"], summary["errors"], summary["total"],
+	]
+	if info.has("error"):
+		_status.text += "\n" + info["error"]
+
+	_failures.clear()
+	var root := _failures.create_item()
+	for f in output.get("failures", []):
+		var item := _failures.create_item(root)
+		item.set_text(0, "%s.%s" % [f["class"], f["method"]])
+		item.set_text(1, "%s:%d" % [f["file"].get_file(), f["line"]])
+		var detail: String = f["message"]
+		if f["expected"] != "" or f["actual"] != "":
+			detail = "expected: %s\nactual:   %s" % [f["expected"], f["actual"]]
+		item.set_tooltip_text(0, detail)
+		item.set_tooltip_text(1, f["file"])
+		item.set_metadata(0, f)
+	var crash = output.get("crash_details")
+	if crash is Dictionary:
+		var item := _failures.create_item(root)
+		item.set_text(0, "Godot crashed")
+		item.set_tooltip_text(0, crash.get("crash_info", crash.get("script_errors", "")))
+
+
+func _open_failure() -> void:
+	var f = _failures.get_selected().get_metadata(0)
+	if not f is Dictionary or not ResourceLoader.exists(f["file"]):
+		return
+	var script = load(f["file"])
+	if script is Script:
+		EditorInterface.edit_script(script, int(f["line"]))
+		EditorInterface.set_main_screen_editor("Script")
+
+
+## Sends a request to the server and calls on_done with the status code (-1 if
+## the server did not answer) and the decoded JSON body.
+func _request(path: String, method: int, body: String, on_done: Callable) -> void:
+	var http := HTTPRequest.new()
+	add_child(http)
+	http.request_completed.connect(func(result: int, code: int, _headers, data: PackedByteArray):
+		http.queue_free()
+		if result != HTTPRequest.RESULT_SUCCESS:
+			on_done.call(-1, null)
+			return
+		on_done.call(code, JSON.parse_string(data.get_string_from_utf8()))
+	)
+	if http.request(_url + path, PackedStringArray(["Content-Type: application/json"]), method, body) != OK:
+		http.queue_free()
+		on_done.call(-1, null)
+
+
+func _error_of(body) -> String:
+	if body is Dictionary and body.has("error"):
+		return body["error"]
+	return "no answer from the server"
@@ -0,0 +1,105 @@
+@tool
+extends EditorPlugin
+
+## Adds the test runner dock and starts `gdunit4-test-runner serve --editor` for
+## the project when the dock first needs it and no server is running. The
+## server advertises its URL in EDITOR_FILE.
+
+const SETTING_BINARY := "gdunit4_test_runner/binary"
+const EDITOR_FILE := "res://.gdunit4-runner/editor.json"
+const SHUTDOWN_TIMEOUT_MSEC := 2000
+
+var _dock: Control
+var _pid := -1
+
+
+func _enter_tree() -> void:
+	var settings := EditorInterface.get_editor_settings()
+	if not settings.has_setting(SETTING_BINARY):
+		settings.set_setting(SETTING_BINARY, "gdunit4-test-runner")
+	settings.set_initial_value(SETTING_BINARY, "gdunit4-test-runner", false)
+	settings.add_property_info({
+		"name": SETTING_BINARY,
+		"type": TYPE_STRING,
+		"hint": PROPERTY_HINT_GLOBAL_FILE,
+	})
+
+	_dock = preload("dock.gd").new()
+	_dock.name = "Tests"
+	_dock.plugin = self
+	add_control_to_dock(DOCK_SLOT_RIGHT_UL, _dock)
+
+
+func _exit_tree() -> void:
+	remove_control_from_docks(_dock)
+	_dock.queue_free()
+	stop_server()
+
+
+## Starts the server unless the one this plugin started is still running.
+## Returns false if the binary could not be started.
+func start_server() -> bool:
+	if _pid > 0 and OS.is_process_running(_pid):
+		return true
+	var binary: String = EditorInterface.get_editor_settings().get_setting(SETTING_BINARY)
+	var project := ProjectSettings.globalize_path("res://")
+	_pid = OS.create_process(binary, PackedStringArray(["serve", "--editor", "--project", project]))
+	if _pid < 0:
+		push_error("gdunit4-test-runner: cannot start %s; set %s in the Editor Settings" % [binary, SETTING_BINARY])
+		return false
+	return true
+
+
+## Stops the server this plugin started, if any. It is asked to shut down, so
+## that it stops the Godot process of its run and removes EDITOR_FILE, and is
+## killed only if it does not answer: OS.kill gives it no chance to clean up.
+func stop_server() -> void:
+	if _pid > 0 and OS.is_process_running(_pid) and not _request_shutdown():
+		OS.kill(_pid)
+	_pid = -1
+
+
+## Returns the URL of the advertised server, or "" if none is.
+func server_url() -> String:
+	return _server_info().get("url", "")
+
+
+## Returns the contents of EDITOR_FILE, or an empty Dictionary.
+func _server_info() -> Dictionary:
+	if not FileAccess.file_exists(EDITOR_FILE):
+		return {}
+	var info = JSON.parse_string(FileAccess.get_file_as_string(EDITOR_FILE))
+	if info is Dictionary:
+		return info
+	return {}
+
+
+## Sends POST /shutdown to the server this plugin started and waits up to
+## SHUTDOWN_TIMEOUT_MSEC for it to be accepted. The editor may be closing, so
+## the request is made synchronously rather than with an HTTPRequest node.
+func _request_shutdown() -> bool:
+	var info := _server_info()
+	if int(info.get("pid", -1)) != _pid:
+		return false
+	var address: PackedStringArray = String(info.get("url", "")).trim_prefix("http://").rsplit(":", true, 1)
+	if address.size() != 2:
+		return false
+	var client := HTTPClient.new()
+	if client.connect_to_host(address[0], int(address[1])) != OK:
+		return false
+	var deadline := Time.get_ticks_msec() + SHUTDOWN_TIMEOUT_MSEC
+	while client.get_status() in [HTTPClient.STATUS_RESOLVING, HTTPClient.STATUS_CONNECTING]:
+		if Time.get_ticks_msec() > deadline:
+			return false
+		client.poll()
+		OS.delay_msec(10)
+	if client.get_status() != HTTPClient.STATUS_CONNECTED:
+		return false
+	if client.request(HTTPClient.METHOD_POST, "/shutdown", PackedStringArray(), "") != OK:
+		return false
+	while client.get_status() == HTTPClient.STATUS_REQUESTING:
+		if Time.get_ticks_msec() > deadline:
+			return false
+		client.poll()
+		OS.delay_msec(10)
+	return client.has_response() and client.get_response_code() == HTTPClient.RESPONSE_ACCEPTED
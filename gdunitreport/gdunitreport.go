@@ -0,0 +1,70 @@
+// Package gdunitreport reads gdUnit4 results incrementally.
+//
+// It exposes the runner's streaming parsers for tools that post-process very large
+// results.xml files or Godot logs and cannot afford to decode them whole:
+//
+//	f, _ := os.Open("reports/report_1/results.xml")
+//	defer f.Close()
+//	_, err := gdunitreport.StreamXML(f, func(s *gdunitreport.TestSuite, tc *gdunitreport.TestCase) error {
+//		if tc.Failure != nil {
+//			fmt.Println(s.Name, tc.Name, tc.Failure.Message)
+//		}
+//		return nil
+//	})
+package gdunitreport
+
+import (
+	"io"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+type (
+	// TestSuites holds the attributes of the root <testsuites> element.
+	TestSuites = report.JUnitTestSuites
+	// TestSuite holds the attributes of a <testsuite> element.
+	TestSuite = report.JUnitTestSuite
+	// TestCase is a single <testcase> element.
+	TestCase = report.JUnitTestCase
+	// Failure is a <failure> or <error> element.
+	Failure = report.JUnitFailure
+	// Output is the runner's JSON output document.
+	Output = report.Output
+)
+
+// DefaultMaxLineLength is the longest log line StreamLog passes on by default.
+const DefaultMaxLineLength = report.DefaultMaxLineLength
+
+// Log line kinds reported by ClassifyLine.
+const (
+	LineCrash       = report.LineCrash
+	LineScriptError = report.LineScriptError
+)
+
+// StreamXML decodes a gdUnit4 JUnit XML document from r one test case at a time,
+// calling fn with each test case and the attributes of its suite. Returning an error
+// from fn stops decoding and is returned as is. Both the gdUnit4 4.x layout and
+// the 5.x one with a <testsuite> root are read. The result holds the totals of
+// the document, summed up from its suites when the root does not carry them.
+func StreamXML(r io.Reader, fn func(suite *TestSuite, tc *TestCase) error) (*TestSuites, error) {
+	return report.StreamXML(r, fn)
+}
+
+// BuildOutput streams the JUnit XML in r into the runner's JSON output document,
+// keeping only failed test cases in memory.
+func BuildOutput(r io.Reader) (*Output, error) {
+	return report.BuildOutputFromXML(r, nil)
+}
+
+// StreamLog calls fn for each line of a Godot log read from r. Lines longer than
+// maxLen bytes (DefaultMaxLineLength if maxLen <= 0) are truncated. Lines are
+// converted to UTF-8 and stripped of ANSI escape sequences.
+func StreamLog(r io.Reader, maxLen int, fn func(line string) error) error {
+	return report.StreamLog(r, maxLen, fn)
+}
+
+// ClassifyLine reports whether a log line signals a crash (LineCrash), a GDScript
+// error (LineScriptError), or neither ("").
+func ClassifyLine(line string) string {
+	return report.ClassifyLine(line)
+}
@@ -0,0 +1,26 @@
+package gdunitreport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildOutput(t *testing.T) {
+	f, err := os.Open(filepath.Join("..", "testdata", "sample_results.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	out, err := BuildOutput(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Summary.Status != "failed" {
+		t.Errorf("Status = %q, want failed", out.Summary.Status)
+	}
+	if len(out.Failures) != out.Summary.Failed+out.Summary.Errors {
+		t.Errorf("len(Failures) = %d, want %d", len(out.Failures), out.Summary.Failed+out.Summary.Errors)
+	}
+}
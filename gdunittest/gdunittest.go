@@ -0,0 +1,128 @@
+// Package gdunittest runs a gdUnit4 test suite from inside a Go test.
+//
+// Each gdUnit4 test case is reported as a Go subtest, so repositories that mix Go
+// and GDScript get a single `go test ./...` result:
+//
+//	func TestGodot(t *testing.T) {
+//		gdunittest.Run(t, "../game", gdunittest.Options{Paths: []string{"tests"}})
+//	}
+//
+// Subtests are named <suite>/<test>, so `go test -run 'TestGodot/TestPlayer/test_jump'`
+// selects them for reporting (Godot still runs every suite under Paths).
+package gdunittest
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/cache"
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Options controls how the suite is run.
+type Options struct {
+	// Paths are test directories or files relative to the project dir. Defaults to the project dir itself.
+	Paths []string
+	// GodotPath is the Godot binary. Defaults to GODOT_PATH, then "godot" on PATH.
+	GodotPath string
+	// Timeout kills Godot after this duration; 0 means no timeout.
+	Timeout time.Duration
+	// Verbose logs every line of Godot output via t.Log.
+	Verbose bool
+	// RequireGodot fails the test instead of skipping it when no Godot binary is found.
+	RequireGodot bool
+}
+
+// Run executes the gdUnit4 tests of the Godot project in dir and reports each test case
+// as a subtest of t. Failures and errors fail the matching subtest, a test case gdUnit4
+// skipped skips it, and a Godot crash or a tool error fails t itself. If no Godot binary
+// can be found, t is skipped unless opts.RequireGodot is set. Like a CLI run, the run is
+// recorded in the run history of the cache, for stats flaky.
+func Run(t *testing.T, dir string, opts Options) {
+	t.Helper()
+
+	godotPath, err := config.ResolveGodotPath(opts.GodotPath)
+	if err != nil {
+		if opts.RequireGodot {
+			t.Fatalf("gdunittest: %v", err)
+		}
+		t.Skipf("gdunittest: %v", err)
+	}
+
+	paths := opts.Paths
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	testPaths := make([]string, 0, len(paths))
+	for _, p := range paths {
+		testPaths = append(testPaths, filepath.Join(dir, p))
+	}
+
+	cfg := &config.Config{
+		TestPaths: testPaths,
+		GodotPath: godotPath,
+		Timeout:   opts.Timeout,
+	}
+	var pipeOpts pipeline.Options
+	if opts.Verbose {
+		pipeOpts.OnLine = func(line string) { t.Log(line) }
+	}
+	if dir, err := cache.Path(cache.History); err == nil {
+		pipeOpts.HistoryDir = dir
+	}
+
+	res, err := pipeline.Execute(context.Background(), cfg, pipeOpts)
+	if err != nil {
+		t.Fatalf("gdunittest: %v", err)
+	}
+
+	if res.Suites != nil {
+		reportSubtests(t, res.Suites)
+	}
+	if res.Output.Summary.Crashed {
+		crash := res.Output.CrashDetails
+		t.Fatalf("gdunittest: Godot crashed\n%s\n%s", crash.CrashInfo, crash.ScriptErrors)
+	}
+	if res.Suites == nil {
+		t.Fatal("gdunittest: Godot produced no test report")
+	}
+}
+
+// reportSubtests runs one subtest per test case in suites.
+func reportSubtests(t *testing.T, suites *report.JUnitTestSuites) {
+	t.Helper()
+	for _, suite := range suites.Suites {
+		t.Run(suite.Name, func(t *testing.T) {
+			for _, tc := range suite.TestCases {
+				t.Run(tc.Name, func(t *testing.T) {
+					for _, f := range []*report.JUnitFailure{tc.Failure, tc.Error} {
+						if f != nil {
+							t.Errorf("%s\n%s", f.Message, f.Text)
+						}
+					}
+					if reason, ok := skipReason(&tc); ok && !t.Failed() {
+						t.Skip(reason)
+					}
+				})
+			}
+		})
+	}
+}
+
+// skipReason returns why gdUnit4 skipped tc, if it did.
+func skipReason(tc *report.JUnitTestCase) (string, bool) {
+	if tc.Skipped == nil {
+		return "", false
+	}
+	for _, reason := range []string{tc.Skipped.Message, strings.TrimSpace(tc.Skipped.Text)} {
+		if reason != "" {
+			return reason, true
+		}
+	}
+	return "skipped by gdUnit4", true
+}
@@ -0,0 +1,76 @@
+package gdunittest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/cache"
+	"github.com/minami110/gdunit4-test-runner/internal/history"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+	"github.com/minami110/gdunit4-test-runner/internal/testutil"
+)
+
+func TestRun_ReportsSubtests(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv(cache.EnvDir, cacheDir)
+
+	xml := `<testsuites tests="3" failures="0" errors="0">
+  <testsuite name="test_math" tests="3">
+    <testcase name="test_add" classname="test_math"/>
+    <testcase name="test_sub" classname="test_math"/>
+    <testcase name="test_mul" classname="test_math"><skipped message="not on CI"/></testcase>
+  </testsuite>
+</testsuites>`
+	root := testutil.Project(t, map[string]string{
+		"tests/results.xml":  xml,
+		"tests/test_math.gd": "extends GdUnitTestSuite\n\nfunc test_add():\n\tpass\n",
+	})
+	script := testutil.FakeGodot(t, "mkdir -p reports/report_1 && cp tests/results.xml reports/report_1/results.xml\n")
+
+	Run(t, root, Options{Paths: []string{"tests"}, GodotPath: script})
+
+	runs, err := history.Read(filepath.Join(cacheDir, cache.History), "", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || len(runs[0].Passed) != 2 {
+		t.Errorf("history = %+v, want the run with its 2 passed tests", runs)
+	}
+}
+
+func TestRun_SkipsWithoutGodot(t *testing.T) {
+	t.Setenv("GODOT_PATH", "")
+	t.Setenv("PATH", t.TempDir())
+
+	skipped := false
+	t.Run("inner", func(t *testing.T) {
+		defer func() { skipped = t.Skipped() }()
+		Run(t, t.TempDir(), Options{})
+	})
+	if !skipped {
+		t.Error("Run should skip when no Godot binary is found")
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		skipped *report.JUnitFailure
+		want    string
+		wantOK  bool
+	}{
+		{"not skipped", nil, "", false},
+		{"message", &report.JUnitFailure{Message: "not on CI", Text: "details"}, "not on CI", true},
+		{"text", &report.JUnitFailure{Text: "\n  disabled\n"}, "disabled", true},
+		{"no reason", &report.JUnitFailure{}, "skipped by gdUnit4", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := skipReason(&report.JUnitTestCase{Skipped: tt.skipped})
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("skipReason() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
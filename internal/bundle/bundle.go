@@ -0,0 +1,314 @@
+// Package bundle packs everything needed to debug a run into one zip archive
+// with an index.html entry point, to upload as a single CI artifact.
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Run describes the results of one test run to bundle.
+type Run struct {
+	ID         string                  // unique run ID; see pipeline.NewRunID
+	Output     *report.Output          // JSON output of the run
+	Suites     *report.JUnitTestSuites // parsed report; nil if Godot produced none
+	ProjectDir string                  // project directory, or the common parent of all projects
+	ReportDir  string                  // gdUnit4 report directory (results.xml, HTML report); empty if none
+	Files      []string                // further files the run wrote, such as the --junit-out report
+}
+
+// Write writes the bundle of run to dst, creating its directory:
+//
+//	index.html         summary, failures and links to the files below
+//	output.json        JSON output
+//	junit.xml          JUnit XML report
+//	run-manifest.json  how the run was made
+//	logs/              the Godot log and the logs of failed suites
+//	snapshots/         received snapshots of failed tests
+//	user_data/         user:// data of the run, with --isolate-user-data
+//	crash_dumps/       core or minidump files, with --crash-dumps
+//	reports/           the files of Run.Files
+//	report/            the gdUnit4 report directory
+//
+// Files that are gone are left out. On error, no bundle is left at dst.
+func Write(dst string, run Run) (err error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(dst)
+		}
+	}()
+
+	b := &builder{zw: zip.NewWriter(f), names: map[string]bool{}, seen: map[string]bool{}}
+	if err := b.add(run); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := b.zw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// builder adds the entries of a bundle, each file of the run once.
+type builder struct {
+	zw    *zip.Writer
+	names map[string]bool // entry names written
+	seen  map[string]bool // absolute paths of the files added
+	index index
+}
+
+// index is the data of index.html.
+type index struct {
+	ID       string
+	Output   *report.Output
+	Failures []failure
+	Images   []string // entry names of screenshots and image snapshots
+	Entries  []string // entry names of every other file
+}
+
+// failure is a failed test with the entries that help debug it.
+type failure struct {
+	report.Failure
+	SuiteLog string // entry name of the log of its suite
+	Snapshot string // entry name of its received snapshot
+}
+
+func (b *builder) add(run Run) error {
+	out := run.Output
+	b.index = index{ID: run.ID, Output: out}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf, out); err != nil {
+		return err
+	}
+	if err := b.write("output.json", buf.Bytes()); err != nil {
+		return err
+	}
+	buf.Reset()
+	if err := report.WriteJUnitXML(&buf, run.Suites, out.CrashDetails); err != nil {
+		return err
+	}
+	if err := b.write("junit.xml", buf.Bytes()); err != nil {
+		return err
+	}
+	if _, err := b.file("", out.Manifest); err != nil {
+		return err
+	}
+
+	if _, err := b.file("logs", out.LogFile); err != nil {
+		return err
+	}
+	for _, p := range out.Projects {
+		if _, err := b.file("logs", p.LogFile); err != nil {
+			return err
+		}
+	}
+	suiteLogs := map[string]string{}
+	for _, l := range out.SuiteLogs {
+		name, err := b.file("logs/suites", l.LogFile)
+		if err != nil {
+			return err
+		}
+		suiteLogs[l.Suite] = name
+	}
+
+	for _, f := range out.Failures {
+		fl := failure{Failure: f, SuiteLog: suiteLogs[cmp.Or(f.Suite, f.File)]}
+		if s := f.Snapshot; s != nil && strings.HasPrefix(s.Received, "res://") {
+			rel := path.Join(f.Project, strings.TrimPrefix(s.Received, "res://"))
+			name, err := b.fileAs(path.Join("snapshots", rel), filepath.Join(run.ProjectDir, filepath.FromSlash(rel)))
+			if err != nil {
+				return err
+			}
+			fl.Snapshot = name
+		}
+		b.index.Failures = append(b.index.Failures, fl)
+	}
+
+	if err := b.dir("user_data", out.UserDataDir); err != nil {
+		return err
+	}
+	if out.CrashDetails != nil {
+		for _, d := range out.CrashDetails.Dumps {
+			if _, err := b.file("crash_dumps", d); err != nil {
+				return err
+			}
+		}
+	}
+	for _, p := range run.Files {
+		if _, err := b.file("reports", p); err != nil {
+			return err
+		}
+	}
+	if err := b.dir("report", run.ReportDir); err != nil {
+		return err
+	}
+
+	buf.Reset()
+	if err := indexTemplate.Execute(&buf, b.index); err != nil {
+		return err
+	}
+	return b.write("index.html", buf.Bytes())
+}
+
+// file adds the file at src under dir, keeping its base name, and returns its
+// entry name. It adds nothing and returns "" if src is empty, gone or added.
+func (b *builder) file(dir, src string) (string, error) {
+	if src == "" {
+		return "", nil
+	}
+	return b.fileAs(path.Join(dir, filepath.Base(src)), src)
+}
+
+// fileAs adds the file at src as name, made unique, like file.
+func (b *builder) fileAs(name, src string) (string, error) {
+	abs, err := filepath.Abs(src)
+	if err != nil || b.seen[abs] {
+		return "", nil
+	}
+	data, err := os.ReadFile(abs)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	b.seen[abs] = true
+	name = b.unique(name)
+	if err := b.write(name, data); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// dir adds the files under the directory src under name, keeping their paths.
+func (b *builder) dir(name, src string) error {
+	if src == "" {
+		return nil
+	}
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		_, err = b.fileAs(path.Join(name, filepath.ToSlash(rel)), p)
+		return err
+	})
+}
+
+// unique returns name, or name with a number before its extension if an entry
+// of that name was written.
+func (b *builder) unique(name string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; b.names[name]; i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	return name
+}
+
+// write writes the entry name, listing it in the index.
+func (b *builder) write(name string, data []byte) error {
+	w, err := b.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	b.names[name] = true
+	if isImage(name) {
+		b.index.Images = append(b.index.Images, name)
+	} else if name != "index.html" {
+		b.index.Entries = append(b.index.Entries, name)
+	}
+	return nil
+}
+
+// imageExts are the extensions of files index.html shows inline.
+var imageExts = []string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg"}
+
+func isImage(name string) bool {
+	return slices.Contains(imageExts, strings.ToLower(path.Ext(name)))
+}
+
+// indexTemplate renders index.html: the summary, the failures with links to
+// their suite logs and snapshots, the screenshots and every other file.
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>gdunit4-test-runner {{.ID}}</title>
+<style>
+  body { font-family: system-ui, sans-serif; margin: 1.5rem; color: #222; }
+  table { border-collapse: collapse; font-size: .9rem; }
+  td, th { text-align: left; padding: .3rem .5rem; border-bottom: 1px solid #eee; vertical-align: top; }
+  pre { background: #f6f7f9; padding: .5rem; margin: 0; white-space: pre-wrap; }
+  .passed { color: #1a7f37; } .failed { color: #cf222e; } .crashed, .error { color: #8250df; }
+  figure { display: inline-block; margin: 0 1rem 1rem 0; } img { max-width: 24rem; border: 1px solid #dde1e6; }
+</style>
+</head>
+<body>
+{{- with .Output}}
+<h1 class="{{.Summary.Status}}">{{.Summary.Status}}</h1>
+<p>{{.Summary.Counts}} tests{{if $.ID}}, run {{$.ID}}{{end}}.{{if .Incomplete}} The run was stopped before all tests ran.{{end}}</p>
+{{- with .CrashDetails}}{{if .CrashInfo}}
+<h2>Godot crashed</h2>
+<pre>{{.CrashInfo}}</pre>
+{{- end}}{{if .ScriptErrors}}
+<h2>Script errors</h2>
+<pre>{{.ScriptErrors}}</pre>
+{{- end}}{{end}}
+{{- end}}
+{{- if .Failures}}
+<h2>Failures</h2>
+<table>
+<tr><th>Test</th><th>Location</th><th>Message</th><th></th></tr>
+{{- range .Failures}}
+<tr><td class="{{.Kind}}">{{.Class}}.{{.Method}}</td><td>{{.File}}:{{.Line}}</td><td><pre>{{.Message}}{{if .Expected}}
+expected: {{.Expected}}
+actual:   {{.Actual}}{{end}}</pre></td><td>{{if .SuiteLog}}<a href="{{.SuiteLog}}">suite log</a> {{end}}{{if .Snapshot}}<a href="{{.Snapshot}}">snapshot</a>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Images}}
+<h2>Screenshots</h2>
+{{- range .Images}}
+<figure><a href="{{.}}"><img src="{{.}}" alt="{{.}}"></a><figcaption>{{.}}</figcaption></figure>
+{{- end}}
+{{- end}}
+<h2>Files</h2>
+<ul>
+{{- range .Entries}}
+<li><a href="{{.}}">{{.}}</a></li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
@@ -0,0 +1,107 @@
+package bundle
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, content string) string {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	reportDir := filepath.Join(dir, "reports", "run1")
+	logFile := write("reports/run1/godot.log", "Run Test Suite: res://tests/test_math.gd\n")
+	write("reports/run1/results.xml", "<testsuites/>")
+	write("reports/run1/css/style.css", "body{}")
+	suiteLog := write("reports/run1/godot-suites/tests_test_math.log", "suite output\n")
+	write("project/tests/__snapshots__/test_math.test_sub.received.png", "png")
+	junit := write("out/junit.xml", "<testsuites/>")
+
+	out := report.BuildOutput(&report.JUnitTestSuites{Tests: 2, Failures: 1}, nil)
+	out.Summary.Status = "failed"
+	out.LogFile = logFile
+	out.SuiteLogs = []report.SuiteLog{{Suite: "res://tests/test_math.gd", LogFile: suiteLog}}
+	out.Failures = []report.Failure{{
+		Kind: report.KindFailure, Class: "test_math", Method: "test_sub", File: "res://tests/test_math.gd", Line: 7,
+		Message:  "Expected '1' but was '2'",
+		Snapshot: &report.SnapshotDiff{Received: "res://tests/__snapshots__/test_math.test_sub.received.png"},
+	}}
+
+	path := filepath.Join(dir, "artifacts", "bundle.zip")
+	run := Run{ID: "run1", Output: out, ProjectDir: filepath.Join(dir, "project"), ReportDir: reportDir, Files: []string{junit, filepath.Join(dir, "gone.xml")}}
+	if err := Write(path, run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	entries := map[string]string{}
+	var names []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		entries[f.Name] = string(data)
+		names = append(names, f.Name)
+	}
+
+	// The log and suite log inside the report directory are added once, under logs/;
+	// the report's own junit.xml does not clash with that of --junit-out.
+	want := []string{
+		"output.json", "junit.xml",
+		"logs/godot.log", "logs/suites/tests_test_math.log",
+		"snapshots/tests/__snapshots__/test_math.test_sub.received.png",
+		"reports/junit.xml",
+		"report/css/style.css", "report/results.xml",
+		"index.html",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("entries = %q, want %q", names, want)
+	}
+	index := entries["index.html"]
+	for _, s := range []string{
+		"test_math.test_sub",
+		`<a href="logs/suites/tests_test_math.log">suite log</a>`,
+		`<img src="snapshots/tests/__snapshots__/test_math.test_sub.received.png"`,
+		`<a href="report/results.xml">`,
+		"Expected &#39;1&#39; but was &#39;2&#39;",
+	} {
+		if !strings.Contains(index, s) {
+			t.Errorf("index.html lacks %q:\n%s", s, index)
+		}
+	}
+	if !strings.Contains(entries["output.json"], `"status": "failed"`) {
+		t.Errorf("output.json = %s", entries["output.json"])
+	}
+}
+
+func TestUnique(t *testing.T) {
+	b := &builder{names: map[string]bool{"logs/godot.log": true, "logs/godot-2.log": true}}
+	if got := b.unique("logs/godot.log"); got != "logs/godot-3.log" {
+		t.Errorf("unique = %q, want logs/godot-3.log", got)
+	}
+	if got := b.unique("logs/other.log"); got != "logs/other.log" {
+		t.Errorf("unique = %q, want logs/other.log", got)
+	}
+}
@@ -0,0 +1,108 @@
+// Package cache locates the runner's cache directory and the categories of data
+// kept in it.
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// EnvDir overrides the cache directory.
+const EnvDir = "GDUNIT4_RUNNER_CACHE_DIR"
+
+// Cache categories, each a subdirectory of the cache directory.
+const (
+	Godot     = "godot"     // downloaded Godot builds
+	Addons    = "addons"    // gdUnit4 and other addon archives
+	Discovery = "discovery" // parsed test suites, keyed by file content
+	History   = "history"   // run history database
+)
+
+// Categories lists every cache category.
+var Categories = []string{Godot, Addons, Discovery, History}
+
+// Dir returns the cache directory: $GDUNIT4_RUNNER_CACHE_DIR if set, otherwise
+// gdunit4-test-runner/ under $XDG_CACHE_HOME if set (on any OS), otherwise under
+// the OS user cache directory (~/.cache, ~/Library/Caches or %LocalAppData%).
+// It returns "" if none of these is known.
+func Dir() string {
+	if dir := os.Getenv(EnvDir); dir != "" {
+		return dir
+	}
+	base := os.Getenv("XDG_CACHE_HOME")
+	if !filepath.IsAbs(base) {
+		// The XDG spec says relative paths are invalid and must be ignored.
+		var err error
+		if base, err = os.UserCacheDir(); err != nil {
+			return ""
+		}
+	}
+	return filepath.Join(base, "gdunit4-test-runner")
+}
+
+// Path returns the directory of category, creating it. It fails if no cache
+// directory is known.
+func Path(category string) (string, error) {
+	dir := Dir()
+	if dir == "" {
+		return "", errors.New("no cache directory; set " + EnvDir)
+	}
+	dir = filepath.Join(dir, category)
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// Usage is the disk usage of one category.
+type Usage struct {
+	Category string `json:"category"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"` // bytes
+	Files    int    `json:"files"`
+}
+
+// Info returns the usage of every category in dir, including absent ones.
+func Info(dir string) ([]Usage, error) {
+	var list []Usage
+	for _, c := range Categories {
+		u := Usage{Category: c, Path: filepath.Join(dir, c)}
+		err := filepath.WalkDir(u.Path, func(_ string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			u.Files++
+			u.Size += info.Size()
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	return list, nil
+}
+
+// Clear removes the given categories from dir; no categories means all of them.
+func Clear(dir string, categories []string) error {
+	if len(categories) == 0 {
+		categories = Categories
+	}
+	for _, c := range categories {
+		if !slices.Contains(Categories, c) {
+			return fmt.Errorf("unknown cache category %q", c)
+		}
+		if err := os.RemoveAll(filepath.Join(dir, c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
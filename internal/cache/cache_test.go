@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	t.Setenv(EnvDir, "")
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+	if got, want := Dir(), filepath.Join("/xdg/cache", "gdunit4-test-runner"); got != want {
+		t.Errorf("Dir() with XDG_CACHE_HOME = %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_CACHE_HOME", "relative")
+	if got := Dir(); got == filepath.Join("relative", "gdunit4-test-runner") {
+		t.Errorf("Dir() used relative XDG_CACHE_HOME: %q", got)
+	}
+
+	t.Setenv(EnvDir, "/custom")
+	if got := Dir(); got != "/custom" {
+		t.Errorf("Dir() with %s = %q, want /custom", EnvDir, got)
+	}
+}
+
+func TestInfoAndClear(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvDir, dir)
+	godot, err := Path(Godot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range map[string]int{"a.zip": 100, "sub/b.zip": 20} {
+		path := filepath.Join(godot, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage, err := Info(dir)
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if len(usage) != len(Categories) {
+		t.Fatalf("Info returned %d categories, want %d", len(usage), len(Categories))
+	}
+	if u := usage[0]; u.Category != Godot || u.Size != 120 || u.Files != 2 {
+		t.Errorf("godot usage = %+v, want 120 bytes in 2 files", u)
+	}
+	if u := usage[1]; u.Size != 0 || u.Files != 0 {
+		t.Errorf("addons usage = %+v, want empty", u)
+	}
+
+	if err := Clear(dir, []string{"nope"}); err == nil {
+		t.Error("Clear with an unknown category should fail")
+	}
+	if err := Clear(dir, []string{Godot}); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, err := os.Stat(godot); !os.IsNotExist(err) {
+		t.Errorf("godot cache still exists: %v", err)
+	}
+}
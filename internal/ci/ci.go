@@ -0,0 +1,207 @@
+// Package ci detects the CI system the runner executes on and reports results
+// in the form that system understands (annotations, job summaries, service messages).
+package ci
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/github"
+	"github.com/minami110/gdunit4-test-runner/internal/i18n"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Supported CI providers.
+const (
+	GitHubActions = "github"
+	GitLab        = "gitlab"
+	Buildkite     = "buildkite"
+	TeamCity      = "teamcity"
+	Azure         = "azure"
+	Jenkins       = "jenkins"
+)
+
+// Providers lists the supported providers in detection order.
+var Providers = []string{GitHubActions, GitLab, Buildkite, TeamCity, Azure, Jenkins}
+
+// Detect returns the provider whose environment variables are set, or "" outside CI.
+func Detect(getenv func(string) string) string {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return GitHubActions
+	case getenv("GITLAB_CI") != "":
+		return GitLab
+	case getenv("BUILDKITE") == "true":
+		return Buildkite
+	case getenv("TEAMCITY_VERSION") != "":
+		return TeamCity
+	case strings.EqualFold(getenv("TF_BUILD"), "true"):
+		return Azure
+	case getenv("JENKINS_URL") != "" || getenv("JENKINS_HOME") != "":
+		return Jenkins
+	}
+	return ""
+}
+
+// Workspace returns the checkout directory of provider from the environment, or "" if unknown.
+func Workspace(provider string) string {
+	switch provider {
+	case GitHubActions:
+		return os.Getenv("GITHUB_WORKSPACE")
+	case GitLab:
+		return os.Getenv("CI_PROJECT_DIR")
+	case Buildkite:
+		return os.Getenv("BUILDKITE_BUILD_CHECKOUT_PATH")
+	case Azure:
+		return os.Getenv("BUILD_SOURCESDIRECTORY")
+	case Jenkins:
+		return os.Getenv("WORKSPACE")
+	}
+	return ""
+}
+
+// Options holds what Report needs besides the output.
+type Options struct {
+	PathPrefix string    // project directory relative to the checkout, for annotation paths
+	JUnitPath  string    // JUnit XML report written for this run, if any
+	TRXPath    string    // TRX report written for this run, if any
+	Lang       i18n.Lang // language of plain-text summaries
+}
+
+// Report writes provider-specific results for out to w (stderr, which CI systems
+// scan for annotations; stdout stays reserved for the runner's output format):
+//
+//   - GitHub Actions: ::error workflow commands and a job summary in GITHUB_STEP_SUMMARY
+//   - Azure Pipelines: ##vso[task.logissue] commands, and a results.publish
+//     command for the TRX report
+//   - TeamCity: an importData service message for the JUnit report
+//   - Buildkite: a build annotation via buildkite-agent, or a text summary without it
+//   - GitLab: a plain-text summary
+//
+// Jenkins needs nothing here; its defaults are applied by the config (see --jenkins).
+func Report(w io.Writer, provider string, out *report.Output, opts Options) error {
+	switch provider {
+	case GitHubActions:
+		writeGitHubAnnotations(w, out, opts.PathPrefix)
+		if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+			return appendFile(path, "## gdUnit4: "+github.Title(out)+"\n\n"+github.Summary(out)+"\n")
+		}
+	case Azure:
+		writeAzureIssues(w, out, opts.PathPrefix)
+		if opts.TRXPath != "" {
+			fmt.Fprintf(w, "##vso[results.publish type=VSTest;runTitle=gdUnit4;]%s\n", opts.TRXPath)
+		}
+	case TeamCity:
+		if opts.JUnitPath != "" {
+			fmt.Fprintf(w, "##teamcity[importData type='junit' path='%s']\n", teamcityEscape(opts.JUnitPath))
+		}
+	case Buildkite:
+		if err := buildkiteAnnotate(out); err != nil {
+			return report.WriteText(w, out, opts.Lang)
+		}
+	case GitLab:
+		return report.WriteText(w, out, opts.Lang)
+	}
+	return nil
+}
+
+// failureLocation returns the checkout-relative path of f's file, or "" if it has none.
+func failureLocation(f report.Failure, pathPrefix string) string {
+	rel := f.RelPath()
+	if rel != "" && pathPrefix != "" {
+		rel = pathPrefix + "/" + rel
+	}
+	return rel
+}
+
+// failureMessage renders the annotation text of f.
+func failureMessage(f report.Failure) string {
+	if f.Expected != "" || f.Actual != "" {
+		return fmt.Sprintf("expected '%s' but was '%s'", f.Expected, f.Actual)
+	}
+	return strings.TrimSpace(f.Message)
+}
+
+// writeGitHubAnnotations writes an ::error workflow command per failure.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions.
+func writeGitHubAnnotations(w io.Writer, out *report.Output, pathPrefix string) {
+	for _, f := range out.Failures {
+		props := "title=" + githubEscape(f.Class+"."+f.Method, true)
+		if file := failureLocation(f, pathPrefix); file != "" {
+			props = fmt.Sprintf("file=%s,line=%d,%s", githubEscape(file, true), max(f.Line, 1), props)
+		}
+		fmt.Fprintf(w, "::error %s::%s\n", props, githubEscape(failureMessage(f), false))
+	}
+	if c := out.CrashDetails; c != nil {
+		fmt.Fprintf(w, "::error title=Godot crashed::%s\n", githubEscape(strings.TrimSpace(c.CrashInfo+"\n"+c.ScriptErrors), false))
+	}
+}
+
+func githubEscape(s string, property bool) string {
+	s = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+	if property {
+		s = strings.NewReplacer(":", "%3A", ",", "%2C").Replace(s)
+	}
+	return s
+}
+
+// writeAzureIssues writes a ##vso[task.logissue] command per failure.
+// See https://learn.microsoft.com/en-us/azure/devops/pipelines/scripts/logging-commands.
+func writeAzureIssues(w io.Writer, out *report.Output, pathPrefix string) {
+	for _, f := range out.Failures {
+		props := "type=error;"
+		if file := failureLocation(f, pathPrefix); file != "" {
+			props += fmt.Sprintf("sourcepath=%s;linenumber=%d;", azureEscape(file), max(f.Line, 1))
+		}
+		fmt.Fprintf(w, "##vso[task.logissue %s]%s\n", props, azureEscape(f.Class+"."+f.Method+": "+failureMessage(f)))
+	}
+	if c := out.CrashDetails; c != nil {
+		fmt.Fprintf(w, "##vso[task.logissue type=error;]%s\n", azureEscape("Godot crashed: "+strings.TrimSpace(c.CrashInfo+"\n"+c.ScriptErrors)))
+	}
+}
+
+func azureEscape(s string) string {
+	return strings.NewReplacer("%", "%AZP25", ";", "%3B", "\r", "%0D", "\n", "%0A", "]", "%5D").Replace(s)
+}
+
+// teamcityEscape escapes a service message attribute value.
+// See https://www.jetbrains.com/help/teamcity/service-messages.html#Escaped+Values.
+func teamcityEscape(s string) string {
+	return strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace(s)
+}
+
+// buildkiteAnnotate adds the markdown summary as a build annotation.
+func buildkiteAnnotate(out *report.Output) error {
+	agent, err := exec.LookPath("buildkite-agent")
+	if err != nil {
+		return err
+	}
+	style := "error"
+	if out.Summary.Status == "passed" {
+		style = "success"
+	}
+	cmd := exec.Command(agent, "annotate", "--style", style, "--context", "gdunit4")
+	cmd.Stdin = strings.NewReader("### gdUnit4: " + github.Title(out) + "\n\n" + github.Summary(out))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("buildkite-agent annotate: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, writeErr := f.WriteString(content)
+	if closeErr := f.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	return writeErr
+}
@@ -0,0 +1,86 @@
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{"GITHUB_ACTIONS": "true", "CI": "true"}, GitHubActions},
+		{map[string]string{"GITLAB_CI": "true"}, GitLab},
+		{map[string]string{"BUILDKITE": "true"}, Buildkite},
+		{map[string]string{"TEAMCITY_VERSION": "2024.03"}, TeamCity},
+		{map[string]string{"TF_BUILD": "True"}, Azure},
+		{map[string]string{"JENKINS_URL": "https://ci.example.com/"}, Jenkins},
+	}
+	for _, tt := range tests {
+		got := Detect(func(k string) string { return tt.env[k] })
+		if got != tt.want {
+			t.Errorf("Detect(%v) = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func sampleOutput() *report.Output {
+	return &report.Output{
+		Summary: report.Summary{Total: 2, Passed: 1, Failed: 1, Status: "failed"},
+		Failures: []report.Failure{
+			{Class: "MathTest", Method: "test_sub", File: "res://tests/MathTest.gd", Line: 7, Message: "100% wrong;\nsee [log]"},
+		},
+	}
+}
+
+func TestReport(t *testing.T) {
+	tests := []struct {
+		provider string
+		opts     Options
+		want     string
+	}{
+		{GitHubActions, Options{PathPrefix: "game"}, "::error file=game/tests/MathTest.gd,line=7,title=MathTest.test_sub::100%25 wrong;%0Asee [log]\n"},
+		{Azure, Options{}, "##vso[task.logissue type=error;sourcepath=tests/MathTest.gd;linenumber=7;]MathTest.test_sub: 100%AZP25 wrong%3B%0Asee [log%5D\n"},
+		{Azure, Options{TRXPath: "/ws/out/results.trx"}, "##vso[task.logissue type=error;sourcepath=tests/MathTest.gd;linenumber=7;]MathTest.test_sub: 100%AZP25 wrong%3B%0Asee [log%5D\n##vso[results.publish type=VSTest;runTitle=gdUnit4;]/ws/out/results.trx\n"},
+		{TeamCity, Options{JUnitPath: "/ws/out/junit.xml"}, "##teamcity[importData type='junit' path='/ws/out/junit.xml']\n"},
+		{GitLab, Options{}, "gdUnit4: failed (1 passed, 1 failed of 2)\n"},
+		{Jenkins, Options{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			t.Setenv("GITHUB_STEP_SUMMARY", "")
+			var sb strings.Builder
+			if err := Report(&sb, tt.provider, sampleOutput(), tt.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want == "" && sb.Len() != 0 || !strings.HasPrefix(sb.String(), tt.want) {
+				t.Errorf("Report =\n%q\nwant prefix\n%q", sb.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReport_GitHubStepSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(path, []byte("previous step\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	if err := Report(&strings.Builder{}, GitHubActions, sampleOutput(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "previous step\n## gdUnit4: 1 of 2 tests failed\n\n| Total |") {
+		t.Errorf("step summary = %q", data)
+	}
+}
@@ -0,0 +1,157 @@
+// Package clean finds and removes what the runner and gdUnit4 leave behind:
+// reports, runner state, cached downloads and stale temp files.
+package clean
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Target kinds.
+const (
+	KindReports = "reports" // gdUnit4 reports in the project
+	KindState   = "state"   // runner state in the project
+	KindCache   = "cache"   // the runner cache (see package cache)
+	KindTemp    = "temp"    // temp logs and directories of past runs
+)
+
+// StaleAfter is how old a temp file must be before it is considered left over;
+// younger ones may belong to a run in progress.
+const StaleAfter = time.Hour
+
+// tempPrefixes are the name prefixes of the temp files and directories of the
+// smoke subcommand and of older versions of the runner.
+var tempPrefixes = []string{"gdunit4-runner-", "gdunit4-run-", "gdunit4-coverage-", "gdunit4-smoke-"}
+
+// Target is a file or directory to remove.
+type Target struct {
+	Kind string
+	Path string
+	Size int64 // bytes, including the contents of a directory
+}
+
+// Options locates what Find looks at.
+type Options struct {
+	ProjectDir string    // Godot project whose reports and state are cleaned
+	StateDir   string    // name of the runner state directory in the project
+	CacheDir   string    // runner cache directory; empty skips cached downloads
+	TempDir    string    // directory holding the runner's temp files
+	RunsDir    string    // name of the directory in TempDir holding a temp directory per run; empty skips it
+	Now        time.Time // reference time for StaleAfter
+}
+
+// Find returns everything to remove, in a stable order: reports, state, cache, temp.
+func Find(opts Options) ([]Target, error) {
+	var targets []Target
+	add := func(kind, path string) error {
+		size, err := Size(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		targets = append(targets, Target{Kind: kind, Path: path, Size: size})
+		return nil
+	}
+
+	if err := add(KindReports, filepath.Join(opts.ProjectDir, "reports")); err != nil {
+		return nil, err
+	}
+	if err := add(KindState, filepath.Join(opts.ProjectDir, opts.StateDir)); err != nil {
+		return nil, err
+	}
+	if opts.CacheDir != "" {
+		if err := add(KindCache, opts.CacheDir); err != nil {
+			return nil, err
+		}
+	}
+
+	addStale := func(dir string, match func(name string) bool) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		for _, e := range entries {
+			if !match(e.Name()) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil || opts.Now.Sub(info.ModTime()) < StaleAfter {
+				continue
+			}
+			if err := add(KindTemp, filepath.Join(dir, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if opts.RunsDir != "" {
+		// Left behind by runs that were killed or ran with --keep-temp.
+		if err := addStale(filepath.Join(opts.TempDir, opts.RunsDir), func(string) bool { return true }); err != nil {
+			return nil, err
+		}
+	}
+	if err := addStale(opts.TempDir, hasTempPrefix); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+func hasTempPrefix(name string) bool {
+	for _, p := range tempPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Size returns the size of the file at path, or the total size of the files in
+// the directory at path.
+func Size(path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// Remove deletes every target.
+func Remove(targets []Target) error {
+	for _, t := range targets {
+		if err := os.RemoveAll(t.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatSize renders n bytes for humans, e.g. "1.5 MB".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return strconv.FormatFloat(float64(n)/float64(div), 'f', 1, 64) + " " + string("KMGTPE"[exp]) + "B"
+}
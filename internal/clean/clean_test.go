@@ -0,0 +1,103 @@
+package clean
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindAndRemove(t *testing.T) {
+	project, cache, tmp := t.TempDir(), t.TempDir(), t.TempDir()
+	now := time.Now()
+	old := now.Add(-2 * StaleAfter)
+
+	write(t, filepath.Join(project, "reports", "report_1", "results.xml"), 10)
+	write(t, filepath.Join(project, "reports", "run", "report_1", "results.xml"), 5)
+	write(t, filepath.Join(project, ".gdunit4-runner", "state.json"), 3)
+	write(t, filepath.Join(cache, "godot.zip"), 7)
+	write(t, filepath.Join(tmp, "gdunit4-runner-1.log"), 4)
+	write(t, filepath.Join(tmp, "gdunit4-run-abc", "x.stdout"), 2)
+	write(t, filepath.Join(tmp, "gdunit4-runner-fresh.log"), 1)
+	write(t, filepath.Join(tmp, "unrelated.log"), 1)
+	write(t, filepath.Join(tmp, "gdunit4-runner", "20260102T150405Z-1a2b3c4d", "x.log"), 6)
+	write(t, filepath.Join(tmp, "gdunit4-runner", "20260102T160405Z-5e6f7a8b", "x.log"), 1)
+	for _, name := range []string{"gdunit4-runner-1.log", "gdunit4-run-abc", "unrelated.log", "gdunit4-runner/20260102T150405Z-1a2b3c4d"} {
+		if err := os.Chtimes(filepath.Join(tmp, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	targets, err := Find(Options{ProjectDir: project, StateDir: ".gdunit4-runner", CacheDir: cache, TempDir: tmp, RunsDir: "gdunit4-runner", Now: now})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Target{
+		{KindReports, filepath.Join(project, "reports"), 15},
+		{KindState, filepath.Join(project, ".gdunit4-runner"), 3},
+		{KindCache, cache, 7},
+		{KindTemp, filepath.Join(tmp, "gdunit4-runner", "20260102T150405Z-1a2b3c4d"), 6},
+		{KindTemp, filepath.Join(tmp, "gdunit4-run-abc"), 2},
+		{KindTemp, filepath.Join(tmp, "gdunit4-runner-1.log"), 4},
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("targets = %+v, want %+v", targets, want)
+	}
+	for i := range want {
+		if targets[i] != want[i] {
+			t.Errorf("targets[%d] = %+v, want %+v", i, targets[i], want[i])
+		}
+	}
+
+	if err := Remove(targets); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	for _, tg := range targets {
+		if _, err := os.Stat(tg.Path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", tg.Path)
+		}
+	}
+	for _, keep := range []string{"gdunit4-runner-fresh.log", "unrelated.log", "gdunit4-runner/20260102T160405Z-5e6f7a8b"} {
+		if _, err := os.Stat(filepath.Join(tmp, keep)); err != nil {
+			t.Errorf("%s was removed: %v", keep, err)
+		}
+	}
+}
+
+func TestFind_NothingToClean(t *testing.T) {
+	targets, err := Find(Options{ProjectDir: t.TempDir(), StateDir: ".gdunit4-runner", TempDir: filepath.Join(t.TempDir(), "missing"), Now: time.Now()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(targets) != 0 {
+		t.Errorf("targets = %+v, want none", targets)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KB"},
+		{5 << 20, "5.0 MB"},
+		{3 << 30, "3.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.n); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package cmake
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+)
+
+// unsafeNameRe matches characters that are replaced in CTest test names and lock names.
+var unsafeNameRe = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// WriteTests writes a CMake script with one add_test() per test case in suites.
+// Each test invokes the runner in CTest mode for a single test of a single suite file.
+// All tests of a project share a RESOURCE_LOCK because concurrent gdUnit4 runs collide
+// on the project's reports directory.
+func WriteTests(w io.Writer, projectDir string, suites []discovery.Suite) error {
+	var sb strings.Builder
+	sb.WriteString("# Generated by gdunit4-test-runner cmake. Do not edit.\n")
+	sb.WriteString("if(NOT DEFINED GDUNIT4_TEST_RUNNER)\n  set(GDUNIT4_TEST_RUNNER gdunit4-test-runner)\nendif()\n\n")
+
+	lock := "gdunit4_" + sanitize(filepath.Base(projectDir))
+	for _, s := range suites {
+		file := filepath.Join(projectDir, filepath.FromSlash(strings.TrimPrefix(s.ResPath, "res://")))
+		for _, test := range s.Tests {
+			name := "gdunit4." + sanitize(s.Class) + "." + test
+			fmt.Fprintf(&sb, "add_test(NAME %s COMMAND ${GDUNIT4_TEST_RUNNER} --format ctest --filter %s %s)\n",
+				name, quote(s.Class+"."+test), quote(filepath.ToSlash(file)))
+			fmt.Fprintf(&sb, "set_tests_properties(%s PROPERTIES RESOURCE_LOCK %s LABELS gdunit4)\n", name, lock)
+		}
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write CMake script: %w", err)
+	}
+	return nil
+}
+
+// sanitize makes s safe to use as an unquoted CMake argument.
+func sanitize(s string) string {
+	return unsafeNameRe.ReplaceAllString(s, "_")
+}
+
+// quote returns s as a quoted CMake argument.
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+	return `"` + r.Replace(s) + `"`
+}
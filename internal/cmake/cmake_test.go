@@ -0,0 +1,39 @@
+package cmake
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+)
+
+func TestWriteTests(t *testing.T) {
+	suites := []discovery.Suite{
+		{ResPath: "res://tests/test_math.gd", Class: "test_math", Tests: []string{"test_add", "test_sub"}},
+	}
+
+	var sb strings.Builder
+	if err := WriteTests(&sb, "/home/user/my game", suites); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := sb.String()
+
+	wantLines := []string{
+		`add_test(NAME gdunit4.test_math.test_add COMMAND ${GDUNIT4_TEST_RUNNER} --format ctest --filter "test_math.test_add" "/home/user/my game/tests/test_math.gd")`,
+		`set_tests_properties(gdunit4.test_math.test_sub PROPERTIES RESOURCE_LOCK gdunit4_my_game LABELS gdunit4)`,
+	}
+	for _, want := range wantLines {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("output missing line:\n%s\ngot:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "add_test(") != 2 {
+		t.Errorf("expected 2 add_test entries, got:\n%s", out)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got := quote(`a "b" $c`); got != `"a \"b\" \$c"` {
+		t.Errorf("quote = %s", got)
+	}
+}
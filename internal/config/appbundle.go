@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// bundleExecutableRe matches the executable name in a bundle's Info.plist.
+var bundleExecutableRe = regexp.MustCompile(`<key>CFBundleExecutable</key>\s*<string>([^<]+)</string>`)
+
+// isAppBundle reports whether path is a macOS application bundle directory.
+func isAppBundle(path string) bool {
+	info, err := os.Stat(filepath.Join(path, "Contents", "MacOS"))
+	return err == nil && info.IsDir()
+}
+
+// bundleExecutable returns the executable inside the macOS application bundle
+// at bundle, e.g. Godot.app/Contents/MacOS/Godot: the CFBundleExecutable of its
+// Info.plist, or the only file in Contents/MacOS.
+func bundleExecutable(bundle string) (string, error) {
+	macOS := filepath.Join(bundle, "Contents", "MacOS")
+	if plist, err := os.ReadFile(filepath.Join(bundle, "Contents", "Info.plist")); err == nil {
+		if m := bundleExecutableRe.FindSubmatch(plist); m != nil {
+			return filepath.Join(macOS, strings.TrimSpace(string(m[1]))), nil
+		}
+	}
+	entries, err := os.ReadDir(macOS)
+	if err != nil {
+		return "", err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			files = append(files, e.Name())
+		}
+	}
+	if len(files) != 1 {
+		return "", fmt.Errorf("cannot tell the executable of %s: %d files in Contents/MacOS and no CFBundleExecutable in Info.plist", bundle, len(files))
+	}
+	return filepath.Join(macOS, files[0]), nil
+}
+
+// checkBundle returns an error explaining how to fix a bundle macOS would refuse
+// to run: one still quarantined after a browser download, which Gatekeeper
+// blocks without a dialog when started from a terminal, or one whose code
+// signature is broken, e.g. after files were added to it. It does nothing on
+// other systems.
+func checkBundle(bundle string) error {
+	if runtime.GOOS != "darwin" {
+		return nil
+	}
+	// xattr -p exits 0 only if the attribute is set.
+	if exec.Command("xattr", "-p", "com.apple.quarantine", bundle).Run() == nil {
+		return fmt.Errorf("%s is quarantined by Gatekeeper and will not start from a terminal; clear it with: xattr -dr com.apple.quarantine %q", bundle, bundle)
+	}
+	if out, err := exec.Command("codesign", "--verify", bundle).CombinedOutput(); err != nil {
+		// Unsigned bundles, e.g. custom builds, run as long as they are not quarantined.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && !strings.Contains(string(out), "not signed at all") {
+			return fmt.Errorf("%s has an invalid code signature (%s); re-sign it with: codesign --force --deep -s - %q", bundle, strings.TrimSpace(string(out)), bundle)
+		}
+	}
+	return nil
+}
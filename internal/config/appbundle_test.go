@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeBundle(t *testing.T, exe, plist string) string {
+	t.Helper()
+	bundle := filepath.Join(t.TempDir(), "Godot.app")
+	macOS := filepath.Join(bundle, "Contents", "MacOS")
+	if err := os.MkdirAll(macOS, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(macOS, exe), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if plist != "" {
+		if err := os.WriteFile(filepath.Join(bundle, "Contents", "Info.plist"), []byte(plist), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bundle
+}
+
+func TestBundleExecutable(t *testing.T) {
+	plist := "<plist><dict>\n\t<key>CFBundleExecutable</key>\n\t<string>Godot_mono</string>\n</dict></plist>\n"
+	bundle := makeBundle(t, "Godot_mono", plist)
+	if err := os.WriteFile(filepath.Join(bundle, "Contents", "MacOS", "helper"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	got, err := bundleExecutable(bundle)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(bundle, "Contents", "MacOS", "Godot_mono"); got != want {
+		t.Errorf("bundleExecutable = %q, want %q", got, want)
+	}
+
+	// Without Info.plist the only file in Contents/MacOS is taken.
+	bundle = makeBundle(t, "Godot", "")
+	got, err = bundleExecutable(bundle)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(bundle, "Contents", "MacOS", "Godot"); got != want {
+		t.Errorf("bundleExecutable = %q, want %q", got, want)
+	}
+}
+
+func TestResolveGodotPath_AppBundle(t *testing.T) {
+	bundle := makeBundle(t, "Godot", "")
+	got, err := ResolveGodotPath(bundle)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(bundle, "Contents", "MacOS", "Godot"); got != want {
+		t.Errorf("ResolveGodotPath = %q, want %q", got, want)
+	}
+}
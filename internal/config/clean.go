@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// StateDir is the directory in the project where the runner keeps state between runs.
+const StateDir = ".gdunit4-runner"
+
+// TempDirName is the directory under the OS temp directory holding the temp
+// directory of each run, named after its run ID.
+const TempDirName = "gdunit4-runner"
+
+// TimingFileName is the file in StateDir keeping suite durations between runs.
+const TimingFileName = "timings.json"
+
+// CleanConfig holds settings for the clean subcommand.
+type CleanConfig struct {
+	Paths  []string // paths inside the project to clean
+	DryRun bool     // only print what would be removed
+}
+
+// ParseClean parses the arguments following "clean".
+func ParseClean(args []string) (*CleanConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner clean", flag.ContinueOnError)
+
+	var dryRun bool
+	fs.BoolVar(&dryRun, "dry-run", false, "print what would be removed without removing it")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner clean [options] [paths...]\n\n")
+		fmt.Fprintf(os.Stderr, "Remove gdUnit4 reports (reports/), runner state (%s/), the cache directory\n", StateDir)
+		fmt.Fprintf(os.Stderr, "and temp files of runs that ended more than an hour ago.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  --dry-run            print what would be removed without removing it\n")
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+		fmt.Fprintf(os.Stderr, "\nPaths select the project; default is the current directory.\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	return &CleanConfig{Paths: paths, DryRun: dryRun}, nil
+}
+
+// Cache actions.
+const (
+	CacheInfo  = "info"
+	CacheClear = "clear"
+)
+
+// CacheConfig holds settings for the cache subcommand.
+type CacheConfig struct {
+	Action     string   // CacheInfo or CacheClear
+	Categories []string // clear: categories to remove; empty means all
+	JSON       bool     // info: print JSON instead of a table
+}
+
+// ParseCache parses the arguments following "cache".
+func ParseCache(args []string) (*CacheConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner cache", flag.ContinueOnError)
+
+	var jsonOut bool
+	fs.BoolVar(&jsonOut, "json", false, "info: print JSON instead of a table")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner cache (info | clear) [options] [categories...]\n\n")
+		fmt.Fprintf(os.Stderr, "Manage the runner cache (downloaded Godot builds, addon archives, discovery caches, run history).\n\n")
+		fmt.Fprintf(os.Stderr, "  info                 print the cache directory and the size of each category\n")
+		fmt.Fprintf(os.Stderr, "  clear                remove the given categories, or the whole cache\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  --json               info: print JSON instead of a table\n")
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		return nil, errors.New("cache requires an action: info or clear")
+	}
+	action := args[0]
+	if action == "-h" || action == "--help" || action == "-help" {
+		fs.Usage()
+		return nil, flag.ErrHelp
+	}
+	if action != CacheInfo && action != CacheClear {
+		return nil, fmt.Errorf("unknown cache action %q; want info or clear", action)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+	cfg := &CacheConfig{Action: action, JSON: jsonOut, Categories: fs.Args()}
+	if action == CacheInfo && len(cfg.Categories) > 0 {
+		return nil, errors.New("cache info takes no categories")
+	}
+	return cfg, nil
+}
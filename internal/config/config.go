@@ -1,83 +1,928 @@
 package config
 
 import (
+	"cmp"
+	"crypto/ed25519"
 	"errors"
 	"flag"
 	"fmt"
+	"net"
 	"os"
-	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/ci"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/i18n"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+	"github.com/minami110/gdunit4-test-runner/internal/runner"
+)
+
+// Output formats for stdout.
+const (
+	FormatJSON     = "json"
+	FormatCTest    = "ctest"
+	FormatXUnit    = "xunit"
+	FormatQuickfix = "quickfix"
+	FormatPlain    = "plain"
 )
 
+// Output modes of concurrent project runs (--output-mode).
+const (
+	OutputInterleaved = "interleaved" // lines as they come, tagged with their project
+	OutputGrouped     = "grouped"     // each project's output at once when its run finishes
+)
+
+// DefaultDebugPort is the port the Godot editor's debugger listens on.
+const DefaultDebugPort = "6007"
+
+// DefaultMaxLogSize caps the captured Godot log when --max-log-size is not given.
+const DefaultMaxLogSize = "100MB"
+
+// DefaultHeartbeat is the heartbeat interval used on CI when --heartbeat is not given.
+const DefaultHeartbeat = time.Minute
+
+// DefaultKillGrace is how long a stopped Godot gets to quit before it is killed.
+const DefaultKillGrace = 10 * time.Second
+
+// ResultsDir is where --jenkins (and TeamCity auto-detection) writes reports
+// unless their paths are given explicitly.
+const ResultsDir = "gdunit4-results"
+
+// mainCommand is the name of the default command's flag set.
+const mainCommand = "gdunit4-test-runner"
+
 // ErrVersion is returned by Parse when the user requests --version.
 var ErrVersion = errors.New("version requested")
 
+// ErrVersionJSON is returned by Parse for --version --json. It wraps ErrVersion.
+var ErrVersionJSON = fmt.Errorf("%w as JSON", ErrVersion)
+
+// ErrGodotNotFound is wrapped by the error ResolveGodotPath returns when no
+// usable Godot binary is found.
+var ErrGodotNotFound = errors.New("Godot binary not found")
+
 // Config holds all runtime settings for the tool.
 type Config struct {
 	TestPaths []string
 	GodotPath string
 	Verbose   bool
 	Timeout   time.Duration
+	Budget    time.Duration // stop at a suite boundary after this long and report the tests run; 0 means no budget
+	KillGrace time.Duration // time a stopped Godot gets to quit before it is killed; 0 kills it at once
+	Hooks     Hooks
+	Owners    Owners
+	Notify    Notify
+	Daemon    string // base URL of a serve --http daemon to delegate the run to
+
+	// ProjectDir is the absolute Godot project directory given with --project;
+	// empty means it is found by walking up from the first test path.
+	ProjectDir string
+
+	// ProjectJobs is how many projects of a run spanning several Godot projects
+	// run at once; 0 or 1 runs them one after another.
+	ProjectJobs int
+
+	// TimingFile is the absolute path of the file keeping suite durations
+	// between runs (--timing-file); empty means timings.json in the project's
+	// StateDir.
+	TimingFile string
+
+	// OutputMode is how the stderr output of concurrent project runs is
+	// written: OutputInterleaved (also when empty) or OutputGrouped.
+	OutputMode string
+
+	// TransientErrors are the signatures of Godot failures retried once when
+	// they are the only errors of a run; empty disables the retry.
+	TransientErrors []*regexp.Regexp
+
+	// ReportGlob matches the results files of a run in its report directory
+	// (--report-glob); empty means report.DefaultReportGlob.
+	ReportGlob string
+
+	// Lang is the language of the human-readable output (--lang, or the
+	// locale of the environment).
+	Lang i18n.Lang
+
+	// EditorLinks is the link template (see report.EditorLinkTemplate) of the
+	// failure locations in terminal output (--editor-links); empty for none.
+	EditorLinks string
+
+	// XFail are --filter patterns of tests expected to fail, from the config
+	// file, in addition to the tests tagged report.TagXFail.
+	XFail []string
+
+	// Schedule orders the suites of a run and marks those that must not run
+	// alongside other projects, from the config file.
+	Schedule Schedule
+
+	// DebugServer is the tcp://host:port of a Godot editor debugger that Godot
+	// connects to (--debug-server); empty runs without a debugger.
+	DebugServer string
+
+	// Interactive gives Godot the runner's stdin and terminal and turns on its
+	// local debugger (--interactive), for debugging on a developer machine.
+	Interactive bool
+
+	// CrashDumps lets Godot write a core dump (a minidump on Windows) when it
+	// crashes and collects it into the run's report directory.
+	CrashDumps bool
+
+	// Render runs Godot on a display and GPU instead of --headless.
+	Render bool
+
+	// ProfileStartup runs Godot with --verbose and reports the slowest
+	// resource loads.
+	ProfileStartup bool
+
+	// MaxFailures stops Godot once this many tests have failed or errored;
+	// 0 runs every test.
+	MaxFailures int
+
+	// CmdTool is the res:// path of gdUnit4's GdUnitCmdTool.gd given with
+	// --cmd-tool; empty means it is found in the project.
+	CmdTool string
+
+	VerboseStreams string // Godot output channels Verbose streams (runner.StreamStdout, StreamStderr or StreamAll)
+	MaxLogSize     int64  // cap on the captured Godot log in bytes; 0 means unlimited
+	LogFile        string // keep the Godot log at this absolute path instead of deleting it, if set
+
+	IsolateUserData bool // point user:// at a per-run sandbox, captured under the run's report directory
+
+	Filter   []string // test name patterns to run; empty runs everything under TestPaths
+	Tags     []string // run only tests carrying one of these tags
+	SkipTags []string // skip tests carrying any of these tags
+	Format   string   // stdout format: "json", "ctest", "xunit", "quickfix" or "plain"
+
+	Bazel       bool   // behave as a Bazel test runner
+	GdUnitExit  bool   // exit with Godot's own exit code (gdUnit4's 0/100/101) instead of the runner's
+	JSONErrors  bool   // print tool errors as a JSON object on stdout as well as on stderr
+	SignOutput  bool   // add an integrity digest to the JSON output
+	Compress    bool   // gzip stdout; report file paths then end in report.GzipExt
+	Bundle      string // write a zip of everything needed to debug the run to this absolute path, if set
+	OpenReport  bool   // open the gdUnit4 HTML report in the default browser after the run
+	Badge       string // write an SVG badge of the test counts and coverage to this path, if set
+	JUnitOutput string // write a JUnit XML report to this path, if set
+	AllureDir   string // write Allure 2 results into this directory, if set
+	TRXOutput   string // write a Visual Studio TRX report to this path, if set
+	SonarOutput string // write a SonarQube generic test execution report to this path, if set
+	TempDir     string // directory for temp files; empty means the OS default
+	KeepTemp    bool   // keep the run's temp directory instead of removing it, for debugging
+
+	CI string // CI provider to report to (see package ci); empty outside CI or with --ci none
+
+	Heartbeat  time.Duration // print a progress line to stderr this often while Godot runs; 0 disables
+	Jenkins    bool          // also print a plain-text summary to stderr and write reports to ResultsDir
+	TextOutput string        // write a plain-text summary to this path, if set
+	WarningsNG string        // write script errors as a warnings-ng issue report to this path, if set
+	Checkstyle string        // write script errors as a Checkstyle XML report to this path, if set
+	RDJSON     string        // write failures and script errors as reviewdog diagnostics to this path (rdjsonl for .jsonl), if set
+
+	CoverageOut string             // write a Cobertura (or lcov, for .info/.lcov) coverage report to this path, if set
+	Coverage    CoverageThresholds // fail the run when line coverage is below these levels
+
+	UpdateSnapshots bool // approve all received snapshots after the run
+
+	Upload string // object store URL to upload results to (s3://, gs://, azblob://, file://), if set
+
+	GitHubCheck   bool // report the run as a GitHub check run
+	GitHubComment bool // post the summary as a comment on the pull request
+
+	GitLabCodeQuality string // write a GitLab code quality report to this path, if set
+	GitLabNote        bool   // post the summary as a note on the merge request
+
+	// SignKey signs the integrity digest of the JSON output (--sign-key); nil
+	// leaves it unsigned. It is never serialized, e.g. into the run manifest.
+	SignKey ed25519.PrivateKey `json:"-"`
+}
+
+// runFlags holds the raw values of flags shared by the default command and subcommands.
+type runFlags struct {
+	godotPath  string
+	verbose    verboseFlag
+	maxLogSize string
+	timeout    time.Duration
+	killGrace  time.Duration
+	retryTrans bool
+	keepTemp   bool
+	reportGlob string
+	lang       string
+	editor     string
+	configPath string
+	profile    string
+	project    string
+	cmdTool    string
+	file       *File // loaded by parse
+	daemon     string
+	bazel      bool
+	gdunitExit bool
+	jsonErrors bool
+	signOutput bool
+	compress   bool
+	bundle     string
+	openReport bool
+	badge      string
+	signKey    string
+	filter     string
+	tags       string
+	skipTags   string
+	format     string
+	upload     string
+	coverage   string
+	covMin     string
+	updateSnap bool
+	ghCheck    bool
+	ghComment  bool
+	glQuality  string
+	glNote     bool
+	junitOut   string
+	allureDir  string
+	trxOut     string
+	sonarOut   string
+	logFile    string
+	isolateUD  bool
+	jenkins    bool
+	warningsNG string
+	checkstyle string
+	rdjson     string
+	ci         string
+	heartbeat  time.Duration // negative means not set
+	projJobs   int
+	outMode    string
+	timingFile string
+	maxFail    int
+	budget     time.Duration
+	profStart  bool
+	render     bool
+	crashDumps bool
+	debugSrv   string
+	interact   bool
+}
+
+// verboseFlag is --verbose: a boolean flag that also accepts the Godot output
+// channel to stream. It holds "" when off, otherwise a runner.Stream* value.
+type verboseFlag string
+
+func (v *verboseFlag) String() string { return string(*v) }
+
+func (v *verboseFlag) IsBoolFlag() bool { return true }
+
+func (v *verboseFlag) Set(s string) error {
+	switch s {
+	case "true", runner.StreamAll:
+		*v = runner.StreamAll
+	case "false":
+		*v = ""
+	case runner.StreamStdout, runner.StreamStderr:
+		*v = verboseFlag(s)
+	default:
+		return fmt.Errorf("want %s, %s or %s", runner.StreamStdout, runner.StreamStderr, runner.StreamAll)
+	}
+	return nil
+}
+
+// register defines the shared flags on fs.
+func (f *runFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.godotPath, "godot-path", "", "path to Godot binary")
+	fs.StringVar(&f.project, "project", "", "Godot project directory; skips the search for project.godot and resolves relative paths against it")
+	fs.StringVar(&f.cmdTool, "cmd-tool", "", "res:// path of gdUnit4's GdUnitCmdTool.gd, for projects that keep the addon elsewhere")
+	fs.Var(&f.verbose, "verbose", "stream Godot output to stderr; optionally only stdout or stderr (--verbose=stderr)")
+	fs.BoolVar(&f.isolateUD, "isolate-user-data", false, "give Godot a per-run user:// directory, kept under reports/<run id>/user_data")
+	fs.StringVar(&f.maxLogSize, "max-log-size", DefaultMaxLogSize, "cap the captured Godot log, keeping its head and tail (e.g. 50MB); 0 means unlimited")
+	fs.DurationVar(&f.timeout, "timeout", 0, "stop Godot after this duration (e.g. 30s); 0 means no timeout")
+	fs.DurationVar(&f.killGrace, "kill-grace", DefaultKillGrace, "when stopping Godot, send SIGTERM (CTRL_BREAK on Windows) and wait this long before killing it; 0 kills at once")
+	fs.BoolVar(&f.retryTrans, "retry-transient", true, "retry the run once when Godot failed only with a known transient error (lost GPU device, no display, ...)")
+	fs.BoolVar(&f.keepTemp, "keep-temp", false, "keep the run's temp directory (logs, runner config, user data) and print its path, for debugging")
+	fs.StringVar(&f.reportGlob, "report-glob", report.DefaultReportGlob, "results files of a run, relative to its report directory (** matches any directories); all matches are merged")
+	fs.StringVar(&f.editor, "editor-links", "", "link failures in terminal output to this editor: "+strings.Join(report.EditorNames(), ", ")+", or a URI template with {path}, {res} and {line}")
+	fs.StringVar(&f.lang, "lang", "", "language of the human-readable output: "+langNames()+" (default: from LC_ALL, LC_MESSAGES or LANG)")
+	fs.StringVar(&f.configPath, "config", "", "path to config file (default: "+DefaultFileName+" if present)")
+	fs.StringVar(&f.profile, "profile", "", "apply the named profile from the config file")
+	fs.StringVar(&f.filter, "filter", "", "comma-separated test names to run (e.g. test_add,Player*.test_jump)")
+	fs.StringVar(&f.tags, "tags", "", "comma-separated tags; run only tests carrying one of them")
+	fs.StringVar(&f.skipTags, "skip-tags", "", "comma-separated tags; skip tests carrying any of them")
+}
+
+// printUsage writes the help text for the shared flags.
+func (f *runFlags) printUsage() {
+	fmt.Fprintf(os.Stderr, "  -p, --godot-path <path> path to Godot binary\n")
+	fmt.Fprintf(os.Stderr, "  --project <dir>      Godot project directory; skips the search for project.godot and resolves relative paths against it\n")
+	fmt.Fprintf(os.Stderr, "  --cmd-tool <res://path> res:// path of gdUnit4's GdUnitCmdTool.gd, for projects that keep the addon elsewhere\n")
+	fmt.Fprintf(os.Stderr, "  -v, --verbose[=<ch>] stream Godot output to stderr; <ch> is stdout, stderr or all (default)\n")
+	fmt.Fprintf(os.Stderr, "  --isolate-user-data  give Godot a per-run user:// directory, kept under reports/<run id>/user_data\n")
+	fmt.Fprintf(os.Stderr, "  --max-log-size <size> cap the captured Godot log, keeping its head and tail (default: %s); 0 means unlimited\n", DefaultMaxLogSize)
+	fmt.Fprintf(os.Stderr, "  -t, --timeout <duration> stop Godot after this duration (e.g. 30s); 0 means no timeout\n")
+	fmt.Fprintf(os.Stderr, "  --kill-grace <duration> when stopping Godot, send SIGTERM (CTRL_BREAK on Windows) and wait this long before killing it (default: %s); 0 kills at once\n", DefaultKillGrace)
+	fmt.Fprintf(os.Stderr, "  --retry-transient    retry the run once when Godot failed only with a known transient error (default: true)\n")
+	fmt.Fprintf(os.Stderr, "  --keep-temp          keep the run's temp directory (logs, runner config, user data) and print its path, for debugging\n")
+	fmt.Fprintf(os.Stderr, "  --report-glob <pattern> results files of a run, relative to its report directory (default: %s; ** matches any directories); all matches are merged\n", report.DefaultReportGlob)
+	fmt.Fprintf(os.Stderr, "  --editor-links <editor> link failures in terminal output to this editor: %s, or a URI template with {path}, {res} and {line}\n", strings.Join(report.EditorNames(), ", "))
+	fmt.Fprintf(os.Stderr, "  --lang <code>        language of the human-readable output: %s (default: from LC_ALL, LC_MESSAGES or LANG)\n", langNames())
+	fmt.Fprintf(os.Stderr, "  --config <path>      path to config file (default: %s if present)\n", DefaultFileName)
+	fmt.Fprintf(os.Stderr, "  --profile <name>     apply the named profile from the config file\n")
+	fmt.Fprintf(os.Stderr, "  --filter <names>     comma-separated test names to run (e.g. test_add,Player*.test_jump)\n")
+	fmt.Fprintf(os.Stderr, "  --tags <tags>        comma-separated tags; run only tests carrying one of them\n")
+	fmt.Fprintf(os.Stderr, "  --skip-tags <tags>   comma-separated tags; skip tests carrying any of them\n")
+}
+
+// resolve resolves the Godot binary and builds a Config for testPaths from the
+// flags and the config file loaded by parse.
+func (f *runFlags) resolve(testPaths []string) (*Config, error) {
+	if len(testPaths) == 0 {
+		testPaths = []string{"."}
+	}
+
+	file := f.file
+	var err error
+
+	var projectDir string
+	if f.project != "" {
+		if projectDir, err = filepath.Abs(f.project); err != nil {
+			return nil, fmt.Errorf("invalid --project: %w", err)
+		}
+		resolved := make([]string, len(testPaths))
+		for i, p := range testPaths {
+			resolved[i] = p
+			if !filepath.IsAbs(p) && !detector.IsResPath(p) {
+				resolved[i] = filepath.Join(projectDir, p)
+			}
+		}
+		testPaths = resolved
+	}
+
+	// A thin client never launches Godot itself.
+	var resolvedGodot string
+	if f.daemon == "" {
+		resolvedGodot, err = ResolveGodotPath(f.godotPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	format := f.format
+	if format == "" {
+		format = FormatJSON
+	}
+	if format != FormatJSON && format != FormatCTest && format != FormatXUnit && format != FormatQuickfix && format != FormatPlain {
+		return nil, fmt.Errorf("unknown format %q; want %s, %s, %s, %s or %s", format, FormatJSON, FormatCTest, FormatXUnit, FormatQuickfix, FormatPlain)
+	}
+
+	cfg := &Config{
+		TestPaths: testPaths,
+		GodotPath: resolvedGodot,
+		Verbose:   f.verbose != "",
+		Timeout:   f.timeout,
+		KillGrace: f.killGrace,
+		KeepTemp:  f.keepTemp,
+		Hooks:     file.Hooks,
+		Owners:    file.Owners,
+		Notify:    file.Notify,
+		Daemon:    f.daemon,
+		Filter:    splitList(f.filter),
+		Tags:      splitList(f.tags),
+		SkipTags:  splitList(f.skipTags),
+		Format:    format,
+		Upload:    f.upload,
+
+		CoverageOut: f.coverage,
+		Coverage:    file.Coverage,
+
+		UpdateSnapshots: f.updateSnap,
+		GitHubCheck:     f.ghCheck,
+		GitHubComment:   f.ghComment,
+
+		GitLabCodeQuality: f.glQuality,
+		GitLabNote:        f.glNote,
+
+		VerboseStreams: string(f.verbose),
+
+		ProjectDir:  projectDir,
+		ProjectJobs: f.projJobs,
+		OutputMode:  f.outMode,
+		MaxFailures: f.maxFail,
+		Budget:      f.budget,
+		CmdTool:     f.cmdTool,
+
+		ProfileStartup: f.profStart,
+		Render:         f.render,
+		CrashDumps:     f.crashDumps,
+		Interactive:    f.interact,
+
+		IsolateUserData: f.isolateUD,
+
+		JUnitOutput: f.junitOut,
+		AllureDir:   f.allureDir,
+		TRXOutput:   f.trxOut,
+		SonarOutput: f.sonarOut,
+		Jenkins:     f.jenkins,
+		WarningsNG:  f.warningsNG,
+		Checkstyle:  f.checkstyle,
+		RDJSON:      f.rdjson,
+		Badge:       f.badge,
+	}
+	if f.logFile != "" {
+		// Godot runs from the project directory, so the path must not depend on the working directory.
+		if cfg.LogFile, err = filepath.Abs(f.logFile); err != nil {
+			return nil, fmt.Errorf("invalid --log-file: %w", err)
+		}
+	}
+	if f.bundle != "" {
+		if cfg.Bundle, err = filepath.Abs(f.bundle); err != nil {
+			return nil, fmt.Errorf("invalid --bundle: %w", err)
+		}
+	}
+	if f.debugSrv != "" {
+		if cfg.DebugServer, err = debugServerURI(f.debugSrv); err != nil {
+			return nil, err
+		}
+	}
+	if f.timingFile != "" {
+		if cfg.TimingFile, err = filepath.Abs(f.timingFile); err != nil {
+			return nil, fmt.Errorf("invalid --timing-file: %w", err)
+		}
+	}
+	if f.cmdTool != "" && (!detector.IsResPath(f.cmdTool) || !strings.HasSuffix(f.cmdTool, ".gd")) {
+		return nil, fmt.Errorf("invalid --cmd-tool %q: want the res:// path of a .gd script", f.cmdTool)
+	}
+	if f.projJobs < 0 {
+		return nil, fmt.Errorf("invalid --project-jobs %d: must not be negative", f.projJobs)
+	}
+	if f.interact && f.projJobs > 1 {
+		return nil, errors.New("--interactive cannot be combined with --project-jobs: only one Godot can read the terminal")
+	}
+	if f.outMode != "" && f.outMode != OutputInterleaved && f.outMode != OutputGrouped {
+		return nil, fmt.Errorf("invalid --output-mode %q: want %s or %s", f.outMode, OutputInterleaved, OutputGrouped)
+	}
+	if f.killGrace < 0 {
+		return nil, fmt.Errorf("invalid --kill-grace %s: must not be negative", f.killGrace)
+	}
+	if f.budget < 0 {
+		return nil, fmt.Errorf("invalid --budget %s: must not be negative", f.budget)
+	}
+	if f.maxFail < 0 {
+		return nil, fmt.Errorf("invalid --max-failures %d: must not be negative", f.maxFail)
+	}
+	if f.retryTrans {
+		for _, p := range append(slices.Clone(report.TransientErrors), file.TransientErrors...) {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid transient_errors pattern %q: %w", p, err)
+			}
+			cfg.TransientErrors = append(cfg.TransientErrors, re)
+		}
+	}
+	cfg.Lang = i18n.FromEnv()
+	if f.lang != "" {
+		if cfg.Lang, err = i18n.Parse(f.lang); err != nil {
+			return nil, fmt.Errorf("invalid --lang: %w; want %s", err, langNames())
+		}
+	}
+	if f.editor != "" {
+		if cfg.EditorLinks, err = report.EditorLinkTemplate(f.editor); err != nil {
+			return nil, fmt.Errorf("invalid --editor-links: %w", err)
+		}
+	}
+	if f.reportGlob != "" {
+		if path.IsAbs(filepath.ToSlash(f.reportGlob)) || filepath.IsAbs(f.reportGlob) {
+			return nil, fmt.Errorf("invalid --report-glob %q: must be relative to the report directory", f.reportGlob)
+		}
+		if _, err := path.Match(filepath.ToSlash(f.reportGlob), ""); err != nil {
+			return nil, fmt.Errorf("invalid --report-glob %q: %w", f.reportGlob, err)
+		}
+		cfg.ReportGlob = filepath.ToSlash(f.reportGlob)
+	}
+	for _, p := range file.XFail {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid xfail pattern %q: %w", p, err)
+		}
+	}
+	cfg.XFail = file.XFail
+	for _, p := range slices.Concat(file.Schedule.Order, file.Schedule.Serial) {
+		if _, err := path.Match(p, ""); err != nil || strings.Trim(p, "/") == "" {
+			return nil, fmt.Errorf("invalid schedule pattern %q", p)
+		}
+	}
+	for tag, n := range file.Schedule.Limits {
+		if n < 1 {
+			return nil, fmt.Errorf("invalid schedule limit %d for tag %q: must be at least 1", n, tag)
+		}
+	}
+	cfg.Schedule = file.Schedule
+	if cfg.MaxLogSize, err = parseSize(f.maxLogSize); err != nil {
+		return nil, fmt.Errorf("invalid --max-log-size: %w", err)
+	}
+	if f.covMin != "" {
+		if cfg.Coverage.Min, err = parsePercent(f.covMin); err != nil {
+			return nil, fmt.Errorf("invalid --coverage-min: %w", err)
+		}
+	}
+	if err := validateThresholds(cfg.Coverage); err != nil {
+		return nil, err
+	}
+	if err := validateNotify(cfg.Notify); err != nil {
+		return nil, err
+	}
+	ciFlag := f.ci
+	if f.daemon != "" && ciFlag == "auto" {
+		// A thin client reports to no CI; see checkDaemon.
+		ciFlag = "none"
+	}
+	if cfg.CI, err = resolveCI(ciFlag); err != nil {
+		return nil, err
+	}
+	switch cfg.CI {
+	case ci.Jenkins:
+		cfg.Jenkins = true
+	case ci.TeamCity:
+		// TeamCity imports the JUnit report through a service message.
+		if cfg.JUnitOutput == "" {
+			cfg.JUnitOutput = filepath.Join(ResultsDir, "junit.xml")
+		}
+	}
+	if cfg.Jenkins {
+		applyJenkins(cfg)
+	}
+	cfg.Heartbeat = f.heartbeat
+	if f.heartbeat < 0 {
+		// Keep CI systems with no-output timeouts from killing long runs.
+		cfg.Heartbeat = 0
+		if cfg.CI != "" {
+			cfg.Heartbeat = DefaultHeartbeat
+		}
+	}
+	cfg.GdUnitExit = f.gdunitExit
+	cfg.JSONErrors = f.jsonErrors
+	cfg.OpenReport = f.openReport
+	if f.signOutput || f.signKey != "" {
+		if cfg.Format != FormatJSON {
+			return nil, fmt.Errorf("--sign-output needs --format %s, got %s", FormatJSON, cfg.Format)
+		}
+		cfg.SignOutput = true
+		if f.signKey != "" {
+			if cfg.SignKey, err = report.LoadSigningKey(f.signKey); err != nil {
+				return nil, fmt.Errorf("--sign-key: %w", err)
+			}
+		}
+	}
+	if f.bazel && f.gdunitExit {
+		return nil, errors.New("--gdunit-exit-codes cannot be combined with --bazel, which needs exit code 0 or 1")
+	}
+	if f.bazel {
+		if err := applyBazelEnv(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if f.compress {
+		if f.bazel {
+			return nil, errors.New("--compress cannot be combined with --bazel, which reads XML_OUTPUT_FILE as written")
+		}
+		cfg.Compress = true
+		for _, p := range []*string{&cfg.JUnitOutput, &cfg.TRXOutput, &cfg.SonarOutput, &cfg.Checkstyle, &cfg.RDJSON, &cfg.WarningsNG, &cfg.TextOutput, &cfg.LogFile} {
+			if *p != "" && !report.Compressed(*p) {
+				*p += report.GzipExt
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// resolveCI turns the --ci value into a provider: "auto" detects it from the
+// environment and "none" disables CI integration.
+func resolveCI(v string) (string, error) {
+	switch v {
+	case "", "auto":
+		return ci.Detect(os.Getenv), nil
+	case "none":
+		return "", nil
+	}
+	if !slices.Contains(ci.Providers, v) {
+		return "", fmt.Errorf("unknown CI provider %q; want auto, none or one of %s", v, strings.Join(ci.Providers, ", "))
+	}
+	return v, nil
+}
+
+// applyJenkins points the reports Jenkins picks up at stable paths under ResultsDir,
+// for use with the junit step and, given --warnings-ng, recordIssues.
+func applyJenkins(cfg *Config) {
+	if cfg.JUnitOutput == "" {
+		cfg.JUnitOutput = filepath.Join(ResultsDir, "junit.xml")
+	}
+	cfg.TextOutput = filepath.Join(ResultsDir, "summary.txt")
+}
+
+// applyBazelEnv configures cfg from the environment Bazel provides to test binaries.
+// See https://bazel.build/reference/test-encyclopedia.
+func applyBazelEnv(cfg *Config) error {
+	cfg.Bazel = true
+	cfg.JUnitOutput = os.Getenv("XML_OUTPUT_FILE")
+	cfg.TempDir = os.Getenv("TEST_TMPDIR")
+
+	// Kill Godot slightly before Bazel's own deadline so the report can still be written.
+	if v := os.Getenv("TEST_TIMEOUT"); v != "" && cfg.Timeout == 0 {
+		secs, err := strconv.Atoi(v)
+		if err != nil || secs <= 0 {
+			return fmt.Errorf("invalid TEST_TIMEOUT %q", v)
+		}
+		cfg.Timeout = time.Duration(secs) * time.Second * 95 / 100
+	}
+	return nil
 }
 
 // Parse parses CLI arguments and resolves configuration.
 // args should be os.Args[1:] in normal usage.
 func Parse(args []string) (*Config, error) {
-	fs := flag.NewFlagSet("gdunit4-test-runner", flag.ContinueOnError)
+	fs := flag.NewFlagSet(mainCommand, flag.ContinueOnError)
 
-	var godotPath string
-	var verbose bool
-	var showVersion bool
-	var timeout time.Duration
+	var rf runFlags
+	var showVersion, versionJSON bool
 
-	fs.StringVar(&godotPath, "godot-path", "", "path to Godot binary")
-	fs.BoolVar(&verbose, "verbose", false, "stream Godot output to stderr")
+	rf.register(fs)
 	fs.BoolVar(&showVersion, "version", false, "print version and exit")
-	fs.DurationVar(&timeout, "timeout", 0, "kill Godot after this duration (e.g. 30s); 0 means no timeout")
+	fs.BoolVar(&versionJSON, "json", false, "with --version, print the version and build metadata as JSON")
+	fs.StringVar(&rf.daemon, "daemon", "", "send the run to a serve --http daemon at this URL")
+	fs.StringVar(&rf.format, "format", FormatJSON, "stdout format: json, ctest, xunit, quickfix or plain")
+	fs.StringVar(&rf.coverage, "coverage-out", "", "write a Cobertura coverage report (lcov for .info/.lcov) collected from a coverage addon")
+	fs.StringVar(&rf.covMin, "coverage-min", "", "fail the run when line coverage is below this percentage (e.g. 80%)")
+	fs.BoolVar(&rf.updateSnap, "update-snapshots", false, "approve every received snapshot after the run (sets GDUNIT4_UPDATE_SNAPSHOTS=1 for Godot)")
+	fs.StringVar(&rf.upload, "upload", "", "upload results to an object store (s3://bucket/prefix, gs://, azblob://, file://)")
+	fs.BoolVar(&rf.ghCheck, "github-check", false, "report the run as a GitHub check run with annotations (needs GITHUB_TOKEN)")
+	fs.BoolVar(&rf.ghComment, "github-comment", false, "post the summary as a pull request comment, updated in place (needs GITHUB_TOKEN)")
+	fs.StringVar(&rf.glQuality, "gitlab-codequality", "", "write failures as a GitLab code quality report to this path")
+	fs.BoolVar(&rf.glNote, "gitlab-note", false, "post the summary as a merge request note, updated in place (needs GITLAB_TOKEN)")
+	fs.StringVar(&rf.junitOut, "junit-out", "", "write a JUnit XML report to this path")
+	fs.StringVar(&rf.trxOut, "trx-out", "", "write a Visual Studio TRX report (Azure DevOps, VS tooling) to this path")
+	fs.StringVar(&rf.sonarOut, "sonar-out", "", "write a SonarQube generic test execution report to this path")
+	fs.StringVar(&rf.allureDir, "allure-dir", "", "write Allure 2 results (a result per test, logs and snapshots attached) into this directory")
+	fs.IntVar(&rf.projJobs, "project-jobs", 1, "run this many Godot projects at once when paths span several projects")
+	fs.StringVar(&rf.outMode, "output-mode", OutputInterleaved, "stderr of concurrent projects: interleaved (lines tagged with their project) or grouped (each project's output once it finishes)")
+	fs.StringVar(&rf.timingFile, "timing-file", "", "keep suite durations, used to start the longest projects first, in this file instead of "+StateDir+"/"+TimingFileName)
+	fs.IntVar(&rf.maxFail, "max-failures", 0, "stop Godot once this many tests have failed and report the tests run so far; 0 runs all")
+	fs.DurationVar(&rf.budget, "budget", 0, "wall-clock budget: stop at the next suite boundary after this long and report the tests run so far as incomplete")
+	fs.BoolVar(&rf.render, "render", false, "run Godot on a display and GPU instead of --headless, for rendering tests (needs a display, e.g. xvfb-run)")
+	fs.StringVar(&rf.debugSrv, "debug-server", "", "connect Godot to the debugger of a Godot editor at [host:]port (default host 127.0.0.1, editor port "+DefaultDebugPort+"), waiting for it if needed")
+	fs.BoolVar(&rf.interact, "interactive", false, "give Godot this terminal's stdin and run it with its local debugger (-d), to use the debugger prompt on script errors")
+	fs.BoolVar(&rf.crashDumps, "crash-dumps", false, "let a crashing Godot write a core dump (minidump on Windows) and keep it under reports/<run id>/crash_dumps/")
+	fs.BoolVar(&rf.profStart, "profile-startup", false, "run Godot with --verbose and report the slowest resource loads")
+	fs.StringVar(&rf.logFile, "log-file", "", "keep the raw Godot output at this path (referenced as log_file in the JSON output)")
+	fs.BoolVar(&rf.jenkins, "jenkins", false, "Jenkins mode: JUnit XML and a plain-text summary under "+ResultsDir+"/, summary also on stderr")
+	fs.StringVar(&rf.warningsNG, "warnings-ng", "", "write script errors as a Jenkins warnings-ng issue report to this path")
+	fs.StringVar(&rf.rdjson, "rdjson-out", "", "write failures and script errors as reviewdog diagnostics (rdjson; rdjsonl for a .jsonl path) to this path")
+	fs.StringVar(&rf.checkstyle, "checkstyle-out", "", "write script and parse errors as a Checkstyle XML report (e.g. for reviewdog) to this path")
+	fs.DurationVar(&rf.heartbeat, "heartbeat", -1, "print a progress line to stderr at this interval while Godot runs; 0 disables (default: 1m on CI)")
+	fs.StringVar(&rf.ci, "ci", "auto", "CI integration: auto (detect), none, or one of "+strings.Join(ci.Providers, ", "))
+	fs.BoolVar(&rf.bazel, "bazel", false, "act as a Bazel test runner (honors XML_OUTPUT_FILE, TEST_TMPDIR, TEST_TIMEOUT)")
+	fs.BoolVar(&rf.gdunitExit, "gdunit-exit-codes", false, "exit with gdUnit4's own exit code (0 passed, 100 failed, 101 warnings) instead of 0/1/2")
+	fs.BoolVar(&rf.compress, "compress", false, "gzip stdout and the report files, adding .gz to their paths, and --log-file")
+	fs.StringVar(&rf.bundle, "bundle", "", "write a zip of the log, reports, JSON output and screenshots of the run, with an index.html, to this path")
+	fs.BoolVar(&rf.openReport, "open-report", false, "open the gdUnit4 HTML report in the default browser after the run")
+	fs.StringVar(&rf.badge, "badge", "", "write a shields.io-style SVG badge of the passed and failed tests, and coverage if collected, to this path")
+	fs.BoolVar(&rf.signOutput, "sign-output", false, "add the SHA-256 digest of the canonical JSON output as \"integrity\", for verify")
+	fs.StringVar(&rf.signKey, "sign-key", "", "also sign the digest with this Ed25519 private key (PEM, PKCS #8); implies --sign-output")
+	fs.BoolVar(&rf.jsonErrors, "json-errors", false, "on a tool error (exit code 2 without test results), also print {\"error\": {...}} on stdout")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner [options] [paths...]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner [options] [paths...]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner serve [options]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner cmake [paths...]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner mutate --source <files> [options] [paths...]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner new [options] <source.gd>...\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner snapshots (list | approve | prune) [options] [paths...]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner clean [--dry-run] [paths...]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner cache (info | clear) [categories...]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner doctor [--json]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner smoke --preset <name> [options] [project]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner rerun [options] <Class.test_name> [paths...]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner verify [--key <public.pem>] [result.json]\n")
+		fmt.Fprintf(os.Stderr, "       gdunit4-test-runner stats flaky [--since <period>] [--format <name>] [paths...]\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
-		fmt.Fprintf(os.Stderr, "  --godot-path <path>  path to Godot binary\n")
-		fmt.Fprintf(os.Stderr, "  --verbose            stream Godot output to stderr\n")
-		fmt.Fprintf(os.Stderr, "  --timeout <duration> kill Godot after this duration (e.g. 30s); 0 means no timeout\n")
+		rf.printUsage()
+		fmt.Fprintf(os.Stderr, "  -f, --format <name>  stdout format: json (default), ctest, xunit (xUnit.net v2 XML), quickfix (Vim) or plain\n")
+		fmt.Fprintf(os.Stderr, "  --daemon <url>       send the run to a serve --http daemon at this URL\n")
+		fmt.Fprintf(os.Stderr, "  --coverage-out <path> write a Cobertura coverage report (lcov for .info/.lcov) collected from a coverage addon\n")
+		fmt.Fprintf(os.Stderr, "  --coverage-min <pct> fail the run when line coverage is below this percentage (e.g. 80%%)\n")
+		fmt.Fprintf(os.Stderr, "  --update-snapshots   approve every received snapshot after the run (sets GDUNIT4_UPDATE_SNAPSHOTS=1 for Godot)\n")
+		fmt.Fprintf(os.Stderr, "  --upload <url>       upload results to an object store (s3://bucket/prefix, gs://, azblob://, file://)\n")
+		fmt.Fprintf(os.Stderr, "  --github-check       report the run as a GitHub check run with annotations (needs GITHUB_TOKEN)\n")
+		fmt.Fprintf(os.Stderr, "  --github-comment     post the summary as a pull request comment, updated in place (needs GITHUB_TOKEN)\n")
+		fmt.Fprintf(os.Stderr, "  --gitlab-codequality <path> write failures as a GitLab code quality report to this path\n")
+		fmt.Fprintf(os.Stderr, "  --gitlab-note        post the summary as a merge request note, updated in place (needs GITLAB_TOKEN)\n")
+		fmt.Fprintf(os.Stderr, "  -o, --junit-out <path> write a JUnit XML report to this path; -o is not the stdout output, which --format selects\n")
+		fmt.Fprintf(os.Stderr, "  --trx-out <path>     write a Visual Studio TRX report (Azure DevOps, VS tooling) to this path\n")
+		fmt.Fprintf(os.Stderr, "  --sonar-out <path>   write a SonarQube generic test execution report to this path\n")
+		fmt.Fprintf(os.Stderr, "  --allure-dir <dir>   write Allure 2 results (a result per test, logs and snapshots attached) into this directory\n")
+		fmt.Fprintf(os.Stderr, "  --project-jobs <n>   run this many Godot projects at once when paths span several projects (default: 1)\n")
+		fmt.Fprintf(os.Stderr, "  --output-mode <mode> stderr of concurrent projects: interleaved (default; lines tagged with their project) or grouped (each project's output once it finishes)\n")
+		fmt.Fprintf(os.Stderr, "  --timing-file <path> keep suite durations, used to start the longest projects first, in this file instead of %s/%s\n", StateDir, TimingFileName)
+		fmt.Fprintf(os.Stderr, "  --max-failures <n>   stop Godot once this many tests have failed and report the tests run so far; 0 runs all (default)\n")
+		fmt.Fprintf(os.Stderr, "  --budget <duration>  wall-clock budget: stop at the next suite boundary after this long and report the tests run so far as incomplete\n")
+		fmt.Fprintf(os.Stderr, "  --render             run Godot on a display and GPU instead of --headless, for rendering tests (needs a display, e.g. xvfb-run)\n")
+		fmt.Fprintf(os.Stderr, "  --debug-server <[host:]port> connect Godot to the debugger of a Godot editor (default host 127.0.0.1, editor port %s), waiting for it if needed\n", DefaultDebugPort)
+		fmt.Fprintf(os.Stderr, "  --interactive        give Godot this terminal's stdin and run it with its local debugger (-d), to use the debugger prompt on script errors\n")
+		fmt.Fprintf(os.Stderr, "  --crash-dumps        let a crashing Godot write a core dump (minidump on Windows) and keep it under reports/<run id>/crash_dumps/\n")
+		fmt.Fprintf(os.Stderr, "  --profile-startup    run Godot with --verbose and report the slowest resource loads\n")
+		fmt.Fprintf(os.Stderr, "  --log-file <path>    keep the raw Godot output at this path (referenced as log_file in the JSON output)\n")
+		fmt.Fprintf(os.Stderr, "  --jenkins            Jenkins mode: JUnit XML and a plain-text summary under %s/, summary also on stderr\n", ResultsDir)
+		fmt.Fprintf(os.Stderr, "  --warnings-ng <path> write script errors as a Jenkins warnings-ng issue report to this path\n")
+		fmt.Fprintf(os.Stderr, "  --rdjson-out <path>  write failures and script errors as reviewdog diagnostics (rdjson; rdjsonl for a .jsonl path) to this path\n")
+		fmt.Fprintf(os.Stderr, "  --checkstyle-out <path> write script and parse errors as a Checkstyle XML report (e.g. for reviewdog) to this path\n")
+		fmt.Fprintf(os.Stderr, "  --heartbeat <duration> print a progress line to stderr at this interval while Godot runs; 0 disables (default: 1m on CI)\n")
+		fmt.Fprintf(os.Stderr, "  --ci <name>          CI integration: auto (default; detect), none, or one of %s\n", strings.Join(ci.Providers, ", "))
+		fmt.Fprintf(os.Stderr, "  --bazel              act as a Bazel test runner (honors XML_OUTPUT_FILE, TEST_TMPDIR, TEST_TIMEOUT)\n")
+		fmt.Fprintf(os.Stderr, "  --gdunit-exit-codes  exit with gdUnit4's own exit code (0 passed, 100 failed, 101 warnings) instead of 0/1/2\n")
+		fmt.Fprintf(os.Stderr, "  --compress           gzip stdout and the report files, adding .gz to their paths, and --log-file\n")
+		fmt.Fprintf(os.Stderr, "  --bundle <path>      write a zip of the log, reports, JSON output and screenshots of the run, with an index.html, to this path\n")
+		fmt.Fprintf(os.Stderr, "  --open-report        open the gdUnit4 HTML report in the default browser after the run\n")
+		fmt.Fprintf(os.Stderr, "  --badge <path>       write a shields.io-style SVG badge of the passed and failed tests, and coverage if collected, to this path\n")
+		fmt.Fprintf(os.Stderr, "  --sign-output        add the SHA-256 digest of the canonical JSON output as \"integrity\", for verify\n")
+		fmt.Fprintf(os.Stderr, "  --sign-key <path>    also sign the digest with this Ed25519 private key (PEM, PKCS #8); implies --sign-output\n")
+		fmt.Fprintf(os.Stderr, "  --json-errors        on a tool error (exit code 2 without test results), also print {\"error\": {...}} on stdout\n")
 		fmt.Fprintf(os.Stderr, "  --version            print version and exit\n")
+		fmt.Fprintf(os.Stderr, "  --json               with --version, print the version and build metadata as JSON\n")
 		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
 		fmt.Fprintf(os.Stderr, "\nIf no paths are given, the current directory is used.\n")
 	}
 
-	if err := fs.Parse(args); err != nil {
+	if err := rf.parse(fs, args); err != nil {
 		return nil, err
 	}
 
 	if showVersion {
+		if versionJSON {
+			return nil, ErrVersionJSON
+		}
 		return nil, ErrVersion
 	}
 
-	testPaths := fs.Args()
-	if len(testPaths) == 0 {
-		testPaths = []string{"."}
+	cfg, err := rf.resolve(fs.Args())
+	if err != nil {
+		return nil, err
 	}
+	if cfg.Daemon != "" {
+		if err := checkDaemon(fs, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
 
-	resolvedGodot, err := resolveGodotPath(godotPath)
+// WantsJSONErrors reports whether args or the environment enable --json-errors,
+// for errors Parse returns before the flag's value is known.
+func WantsJSONErrors(args []string) bool {
+	want, _ := strconv.ParseBool(os.Getenv(FlagEnv("json-errors")))
+	for _, a := range args {
+		if a == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !strings.HasPrefix(a, "-") || name != "json-errors" {
+			continue
+		}
+		want = true
+		if hasValue {
+			want, _ = strconv.ParseBool(value)
+		}
+	}
+	return want
+}
+
+// CollectCoverage reports whether the run needs coverage data.
+func (c *Config) CollectCoverage() bool {
+	return c.CoverageOut != "" || c.Coverage.Min > 0 || len(c.Coverage.Packages) > 0
+}
+
+// parsePercent parses a percentage such as "80%" or "80".
+func parsePercent(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("%q is not a percentage", s)
 	}
+	return v, nil
+}
 
-	return &Config{
-		TestPaths: testPaths,
-		GodotPath: resolvedGodot,
-		Verbose:   verbose,
-		Timeout:   timeout,
-	}, nil
+// parseSize parses a byte count such as "512KB", "100MB", "1GB" or "4096".
+// Units are binary (1KB = 1024 bytes).
+func parseSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(v, u.suffix) {
+			v, mult = strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a size", s)
+	}
+	return n * mult, nil
 }
 
-// resolveGodotPath resolves the Godot binary path using the priority:
+// validateThresholds checks that every coverage threshold is within 0-100.
+func validateThresholds(t CoverageThresholds) error {
+	if t.Min < 0 || t.Min > 100 {
+		return fmt.Errorf("coverage minimum %g%% is outside 0-100", t.Min)
+	}
+	for dir, min := range t.Packages {
+		if min < 0 || min > 100 {
+			return fmt.Errorf("coverage minimum %g%% for %s is outside 0-100", min, dir)
+		}
+	}
+	return nil
+}
+
+// runStatuses are the statuses a run can end with, as notify rules match them.
+var runStatuses = []string{"passed", "failed", "error", "crashed", "aborted_max_failures"}
+
+// validateNotify checks that every notifier has a known type and every rule
+// refers to defined notifiers and valid statuses.
+func validateNotify(n Notify) error {
+	for name, nt := range n.Notifiers {
+		switch nt.Type {
+		case NotifierSlack, NotifierWebhook:
+			if nt.URL == "" {
+				return fmt.Errorf("notifier %q has no url", name)
+			}
+		case NotifierEmail:
+			if nt.SMTP == "" || nt.From == "" || len(nt.To) == 0 {
+				return fmt.Errorf("email notifier %q needs smtp, from and to", name)
+			}
+		default:
+			return fmt.Errorf("notifier %q has unknown type %q; want %s, %s or %s", name, nt.Type, NotifierSlack, NotifierWebhook, NotifierEmail)
+		}
+	}
+	for i, r := range n.Rules {
+		name := r.Name
+		if name == "" {
+			name = "#" + strconv.Itoa(i+1)
+		}
+		if len(r.Notify) == 0 {
+			return fmt.Errorf("notify rule %s has no notifiers", name)
+		}
+		for _, target := range r.Notify {
+			if _, ok := n.Notifiers[target]; !ok {
+				return fmt.Errorf("notify rule %s refers to unknown notifier %q", name, target)
+			}
+		}
+		for _, st := range r.Status {
+			if !slices.Contains(runStatuses, st) {
+				return fmt.Errorf("notify rule %s has unknown status %q; want one of %s", name, st, strings.Join(runStatuses, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// debugServerURI normalizes a --debug-server address, "6007", "host:6007" or
+// "tcp://host:6007", to the tcp:// URI Godot's --remote-debug takes.
+func debugServerURI(addr string) (string, error) {
+	hostPort := strings.TrimPrefix(addr, "tcp://")
+	if !strings.Contains(hostPort, ":") {
+		hostPort = ":" + hostPort
+	}
+	host, port, err := net.SplitHostPort(hostPort)
+	if n, convErr := strconv.Atoi(port); err != nil || convErr != nil || n <= 0 || n > 65535 {
+		return "", fmt.Errorf("invalid --debug-server %q: want [host:]port", addr)
+	}
+	return "tcp://" + net.JoinHostPort(cmp.Or(host, "127.0.0.1"), port), nil
+}
+
+// langNames lists the supported --lang values for help and error messages.
+func langNames() string {
+	names := make([]string, len(i18n.Langs))
+	for i, l := range i18n.Langs {
+		names[i] = string(l)
+	}
+	return strings.Join(names, ", ")
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ResolveGodotPath resolves the Godot binary path using the priority:
 // 1. explicit flag value
 // 2. GODOT_PATH environment variable
 // 3. "godot" found via PATH lookup
-func resolveGodotPath(flagValue string) (string, error) {
+// 4. the best of the other binaries FindGodot finds
+// A macOS application bundle (Godot.app) given by flag or environment resolves
+// to the executable inside it.
+func ResolveGodotPath(flagValue string) (string, error) {
 	candidates := []string{}
 	if flagValue != "" {
 		candidates = append(candidates, flagValue)
@@ -87,18 +932,28 @@ func resolveGodotPath(flagValue string) (string, error) {
 	}
 
 	for _, c := range candidates {
+		// macOS users point at Godot.app rather than the binary inside it.
+		if isAppBundle(c) {
+			if err := checkBundle(c); err != nil {
+				return "", err
+			}
+			exe, err := bundleExecutable(c)
+			if err != nil {
+				return "", err
+			}
+			c = exe
+		}
 		if isExecutable(c) {
 			return c, nil
 		}
-		return "", fmt.Errorf("Godot binary not found or not executable: %s", c)
+		return "", fmt.Errorf("%w or not executable: %s", ErrGodotNotFound, c)
 	}
 
-	// Fall back to PATH lookup.
-	path, err := exec.LookPath("godot")
-	if err != nil {
-		return "", errors.New("Godot binary not found; set --godot-path or GODOT_PATH")
+	// Fall back to PATH lookup, then to the install locations.
+	if found := FindGodot(); len(found) > 0 {
+		return found[0].Path, nil
 	}
-	return path, nil
+	return "", fmt.Errorf("%w; set --godot-path or GODOT_PATH", ErrGodotNotFound)
 }
 
 // isExecutable reports whether path exists and is executable.
@@ -1,12 +1,19 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/i18n"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
 )
 
 // makeDummyExecutable creates a dummy executable file in dir and returns its path.
@@ -81,6 +88,16 @@ func TestParse_VersionReturnsErrVersion(t *testing.T) {
 	}
 }
 
+func TestParse_VersionJSON(t *testing.T) {
+	_, err := Parse([]string{"--version", "--json"})
+	if err != ErrVersionJSON {
+		t.Fatalf("expected ErrVersionJSON, got %v", err)
+	}
+	if !errors.Is(err, ErrVersion) {
+		t.Error("ErrVersionJSON does not wrap ErrVersion")
+	}
+}
+
 func TestParse_UnknownFlag(t *testing.T) {
 	_, err := Parse([]string{"--unknown-flag"})
 	if err == nil {
@@ -148,6 +165,42 @@ func TestParse_VerboseLongFlag(t *testing.T) {
 	}
 }
 
+func TestParse_VerboseStreams(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+
+	tests := []struct {
+		arg     string
+		verbose bool
+		streams string
+		wantErr bool
+	}{
+		{"--verbose", true, "all", false},
+		{"--verbose=all", true, "all", false},
+		{"--verbose=stderr", true, "stderr", false},
+		{"--verbose=stdout", true, "stdout", false},
+		{"--verbose=false", false, "", false},
+		{"--verbose=both", false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			cfg, err := Parse([]string{"--godot-path", godot, tt.arg})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.Verbose != tt.verbose || cfg.VerboseStreams != tt.streams {
+				t.Errorf("Verbose = %v, VerboseStreams = %q, want %v, %q", cfg.Verbose, cfg.VerboseStreams, tt.verbose, tt.streams)
+			}
+		})
+	}
+}
+
 func TestParse_GodotPathNotExecutable(t *testing.T) {
 	dir := t.TempDir()
 	// Create a non-executable file
@@ -194,3 +247,896 @@ func TestParse_TimeoutDefaultsToZero(t *testing.T) {
 		t.Errorf("Timeout = %v, want 0", cfg.Timeout)
 	}
 }
+
+func TestParse_MaxLogSize(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+
+	tests := []struct {
+		args    []string
+		want    int64
+		wantErr bool
+	}{
+		{nil, 100 << 20, false},
+		{[]string{"--max-log-size", "512KB"}, 512 << 10, false},
+		{[]string{"--max-log-size", "2gb"}, 2 << 30, false},
+		{[]string{"--max-log-size", "4096"}, 4096, false},
+		{[]string{"--max-log-size", "0"}, 0, false},
+		{[]string{"--max-log-size", "lots"}, 0, true},
+		{[]string{"--max-log-size", "-1MB"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.args), func(t *testing.T) {
+			cfg, err := Parse(append([]string{"--godot-path", godot}, tt.args...))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.MaxLogSize != tt.want {
+				t.Errorf("MaxLogSize = %d, want %d", cfg.MaxLogSize, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_ConfigFileHooks(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	path := filepath.Join(dir, "runner.json")
+	content := `{"hooks": {"pre_run": "make seed", "post_run": "make upload"}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Parse([]string{"--godot-path", godot, "--config", path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Hooks.PreRun != "make seed" {
+		t.Errorf("Hooks.PreRun = %q, want %q", cfg.Hooks.PreRun, "make seed")
+	}
+	if cfg.Hooks.PostRun != "make upload" {
+		t.Errorf("Hooks.PostRun = %q, want %q", cfg.Hooks.PostRun, "make upload")
+	}
+}
+
+func TestParse_ConfigFileNotFound(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+
+	_, err := Parse([]string{"--godot-path", godot, "--config", filepath.Join(dir, "missing.json")})
+	if err == nil {
+		t.Fatal("expected error for missing explicit config file, got nil")
+	}
+}
+
+func TestParse_ConfigFileInvalidJSON(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	path := filepath.Join(dir, "runner.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Parse([]string{"--godot-path", godot, "--config", path})
+	if err == nil {
+		t.Fatal("expected error for invalid config file, got nil")
+	}
+}
+
+func TestParse_TransientErrors(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	path := filepath.Join(dir, "runner.json")
+	if err := os.WriteFile(path, []byte(`{"transient_errors": ["license server unreachable"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Parse([]string{"--godot-path", godot, "--config", path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(report.TransientErrors) + 1; len(cfg.TransientErrors) != n {
+		t.Errorf("got %d transient error patterns, want the %d defaults and the configured one", len(cfg.TransientErrors), n)
+	}
+	cfg, err = Parse([]string{"--godot-path", godot, "--config", path, "--retry-transient=false"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.TransientErrors != nil {
+		t.Errorf("TransientErrors = %v, want none with --retry-transient=false", cfg.TransientErrors)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"transient_errors": ["("]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--config", path}); err == nil {
+		t.Error("expected error for an invalid pattern, got nil")
+	}
+}
+
+func TestParse_XFail(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	path := filepath.Join(dir, "runner.json")
+	if err := os.WriteFile(path, []byte(`{"xfail": ["PlayerTest.test_jump"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Parse([]string{"--godot-path", godot, "--config", path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.XFail) != 1 || cfg.XFail[0] != "PlayerTest.test_jump" {
+		t.Errorf("XFail = %v, want [PlayerTest.test_jump]", cfg.XFail)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"xfail": ["test_["]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--config", path}); err == nil {
+		t.Error("expected error for an invalid pattern, got nil")
+	}
+}
+
+func TestParse_Schedule(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	path := filepath.Join(dir, "runner.json")
+	if err := os.WriteFile(path, []byte(`{"schedule": {"order": ["tests/unit/", "tests/integration/"], "serial": ["*_save_test.gd"], "limits": {"gpu": 1}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Parse([]string{"--godot-path", godot, "--config", path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Schedule{Order: []string{"tests/unit/", "tests/integration/"}, Serial: []string{"*_save_test.gd"}, Limits: map[string]int{"gpu": 1}}
+	if !reflect.DeepEqual(cfg.Schedule, want) {
+		t.Errorf("Schedule = %+v, want %+v", cfg.Schedule, want)
+	}
+
+	for _, bad := range []string{`{"schedule": {"order": ["tests/["]}}`, `{"schedule": {"serial": ["/"]}}`, `{"schedule": {"limits": {"gpu": 0}}}`} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Parse([]string{"--godot-path", godot, "--config", path}); err == nil {
+			t.Errorf("%s: expected error for an invalid schedule, got nil", bad)
+		}
+	}
+}
+
+func TestParse_ReportGlob(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+
+	cfg, err := Parse([]string{"--godot-path", godot})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ReportGlob != "report_*/results.xml" {
+		t.Errorf("ReportGlob = %q, want the default", cfg.ReportGlob)
+	}
+	cfg, err = Parse([]string{"--godot-path", godot, "--report-glob", "**/results.xml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ReportGlob != "**/results.xml" {
+		t.Errorf("ReportGlob = %q, want **/results.xml", cfg.ReportGlob)
+	}
+
+	for _, glob := range []string{"/tmp/*.xml", "report_[/results.xml"} {
+		if _, err := Parse([]string{"--godot-path", godot, "--report-glob", glob}); err == nil {
+			t.Errorf("--report-glob %q: expected error, got nil", glob)
+		}
+	}
+}
+
+func TestParse_Lang(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "ja_JP.UTF-8")
+
+	cfg, err := Parse([]string{"--godot-path", godot})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Lang != i18n.Japanese {
+		t.Errorf("Lang = %q, want ja from LANG", cfg.Lang)
+	}
+	cfg, err = Parse([]string{"--godot-path", godot, "--lang", "en"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Lang != i18n.English {
+		t.Errorf("Lang = %q, want en from --lang", cfg.Lang)
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--lang", "fr"}); err == nil {
+		t.Error("expected error for an unsupported language, got nil")
+	}
+}
+
+func TestParseServe_RequiresTransport(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+
+	if _, err := ParseServe([]string{"--godot-path", godot}); err == nil {
+		t.Fatal("expected error when no transport is given, got nil")
+	}
+	if _, err := ParseServe([]string{"--godot-path", godot, "--stdio", "--http", ":8080"}); err == nil {
+		t.Fatal("expected error when --stdio and --http are combined, got nil")
+	}
+}
+
+func TestParseServe_HTTP(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+
+	cfg, err := ParseServe([]string{"--godot-path", godot, "--http", ":8080", "tests"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.HTTP != ":8080" {
+		t.Errorf("HTTP = %q, want :8080", cfg.HTTP)
+	}
+	if len(cfg.Base.TestPaths) != 1 || cfg.Base.TestPaths[0] != "tests" {
+		t.Errorf("Base.TestPaths = %v, want [tests]", cfg.Base.TestPaths)
+	}
+}
+
+func TestParseServe_GRPC(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+
+	cfg, err := ParseServe([]string{"--godot-path", godot, "--grpc", ":9090"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.GRPC != ":9090" || cfg.HTTP != "" || cfg.Stdio {
+		t.Errorf("GRPC = %q, HTTP = %q, Stdio = %v, want only gRPC on :9090", cfg.GRPC, cfg.HTTP, cfg.Stdio)
+	}
+	for _, extra := range [][]string{{"--stdio"}, {"--http", ":8080"}, {"--ui"}, {"--editor"}} {
+		args := append([]string{"--godot-path", godot, "--grpc", ":9090"}, extra...)
+		if _, err := ParseServe(args); err == nil {
+			t.Errorf("ParseServe(%v): expected error, got nil", args)
+		}
+	}
+}
+
+func TestParseServe_Editor(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+
+	cfg, err := ParseServe([]string{"--godot-path", godot, "--editor"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Editor || cfg.HTTP != DefaultEditorAddr {
+		t.Errorf("Editor = %v, HTTP = %q, want the editor on %s", cfg.Editor, cfg.HTTP, DefaultEditorAddr)
+	}
+	if cfg, err := ParseServe([]string{"--godot-path", godot, "--editor", "--http", "127.0.0.1:9000"}); err != nil || cfg.HTTP != "127.0.0.1:9000" {
+		t.Errorf("HTTP = %v (%v), want the --http address", cfg, err)
+	}
+	if _, err := ParseServe([]string{"--godot-path", godot, "--editor", "--stdio"}); err == nil {
+		t.Error("expected error combining --editor with --stdio, got nil")
+	}
+}
+
+func TestParse_DaemonSkipsGodotResolution(t *testing.T) {
+	t.Setenv("GODOT_PATH", "")
+	t.Setenv("PATH", t.TempDir())
+
+	cfg, err := Parse([]string{"--daemon", "http://localhost:8080", "tests"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Daemon != "http://localhost:8080" {
+		t.Errorf("Daemon = %q, want http://localhost:8080", cfg.Daemon)
+	}
+	if cfg.GodotPath != "" {
+		t.Errorf("GodotPath = %q, want empty for thin client", cfg.GodotPath)
+	}
+}
+
+func TestParse_DaemonRejectsLocalFlags(t *testing.T) {
+	t.Setenv("GODOT_PATH", "")
+	t.Setenv("PATH", t.TempDir())
+	dir := t.TempDir()
+	hooks := filepath.Join(dir, "hooks.json")
+	if err := os.WriteFile(hooks, []byte(`{"hooks": {"pre_run": "make seed"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string // empty means no error
+	}{
+		{"honored flags", []string{"--filter", "test_add", "--sign-output", "--compress", "--lang", "en", "--ci", "none"}, ""},
+		{"format json", []string{"-f", "json"}, ""},
+		{"report files", []string{"-o", "junit.xml", "--trx-out", "run.trx", "--badge", "badge.svg"}, "--badge, --junit-out, --trx-out"},
+		{"timeout", []string{"--timeout", "30s"}, "--timeout"},
+		{"format", []string{"--format", "ctest"}, "--format ctest"},
+		{"ci provider", []string{"--ci", "github"}, "--ci github"},
+		{"hooks", []string{"--config", hooks}, "hooks of the config file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(append([]string{"--daemon", "http://localhost:8080"}, tt.args...))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("error = %v, want one naming %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_DaemonSkipsCIDetection(t *testing.T) {
+	t.Setenv("GODOT_PATH", "")
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("JENKINS_URL", "http://jenkins.example.com/")
+
+	cfg, err := Parse([]string{"--daemon", "http://localhost:8080"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CI != "" || cfg.JUnitOutput != "" {
+		t.Errorf("CI = %q, JUnitOutput = %q; want no CI integration for a thin client", cfg.CI, cfg.JUnitOutput)
+	}
+}
+
+func TestParse_BazelEnv(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	t.Setenv("XML_OUTPUT_FILE", filepath.Join(dir, "test.xml"))
+	t.Setenv("TEST_TMPDIR", dir)
+	t.Setenv("TEST_TIMEOUT", "300")
+
+	cfg, err := Parse([]string{"--godot-path", godot, "--bazel"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Bazel {
+		t.Error("Bazel should be true")
+	}
+	if cfg.JUnitOutput != filepath.Join(dir, "test.xml") {
+		t.Errorf("JUnitOutput = %q, want XML_OUTPUT_FILE", cfg.JUnitOutput)
+	}
+	if cfg.TempDir != dir {
+		t.Errorf("TempDir = %q, want TEST_TMPDIR", cfg.TempDir)
+	}
+	if cfg.Timeout != 285*time.Second {
+		t.Errorf("Timeout = %v, want 285s (95%% of TEST_TIMEOUT)", cfg.Timeout)
+	}
+}
+
+func TestParse_BazelExplicitTimeoutWins(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	t.Setenv("TEST_TIMEOUT", "300")
+
+	cfg, err := Parse([]string{"--godot-path", godot, "--bazel", "--timeout", "10s"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Timeout != 10*time.Second {
+		t.Errorf("Timeout = %v, want 10s", cfg.Timeout)
+	}
+}
+
+func TestParse_BazelIgnoredWithoutFlag(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	t.Setenv("XML_OUTPUT_FILE", filepath.Join(dir, "test.xml"))
+
+	cfg, err := Parse([]string{"--godot-path", godot})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.JUnitOutput != "" {
+		t.Errorf("JUnitOutput = %q, want empty without --bazel", cfg.JUnitOutput)
+	}
+}
+
+func TestParse_CoverageThresholds(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	path := filepath.Join(dir, "runner.json")
+	content := `{"coverage": {"min": 70, "packages": {"scripts/core": 90}}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantMin float64
+		wantErr bool
+	}{
+		{"from file", nil, 70, false},
+		{"flag with percent sign", []string{"--coverage-min", "85%"}, 85, false},
+		{"flag without percent sign", []string{"--coverage-min", "82.5"}, 82.5, false},
+		{"not a number", []string{"--coverage-min", "high"}, 0, true},
+		{"out of range", []string{"--coverage-min", "120%"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--godot-path", godot, "--config", path}, tt.args...)
+			cfg, err := Parse(args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.Coverage.Min != tt.wantMin {
+				t.Errorf("Coverage.Min = %g, want %g", cfg.Coverage.Min, tt.wantMin)
+			}
+			if cfg.Coverage.Packages["scripts/core"] != 90 {
+				t.Errorf("Coverage.Packages = %v", cfg.Coverage.Packages)
+			}
+			if !cfg.CollectCoverage() {
+				t.Error("CollectCoverage() = false, want true")
+			}
+		})
+	}
+}
+
+func TestParse_NotifyValidation(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+
+	tests := []struct {
+		name    string
+		notify  string
+		wantErr bool
+	}{
+		{"valid", `{"notifiers": {"qa": {"type": "slack", "url": "$SLACK_URL"}}, "rules": [{"tags": ["slow"], "notify": ["qa"]}]}`, false},
+		{"unknown type", `{"notifiers": {"qa": {"type": "pager", "url": "x"}}}`, true},
+		{"missing url", `{"notifiers": {"qa": {"type": "webhook"}}}`, true},
+		{"unknown notifier", `{"rules": [{"name": "net", "notify": ["qa"]}]}`, true},
+		{"no notifiers", `{"notifiers": {"qa": {"type": "slack", "url": "x"}}, "rules": [{"name": "net"}]}`, true},
+		{"unknown status", `{"notifiers": {"qa": {"type": "slack", "url": "x"}}, "rules": [{"status": ["red"], "notify": ["qa"]}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "runner.json")
+			if err := os.WriteFile(path, []byte(`{"notify": `+tt.notify+`}`), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Parse([]string{"--godot-path", godot, "--config", path})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(cfg.Notify.Rules) != 1 || cfg.Notify.Notifiers["qa"].URL != "$SLACK_URL" {
+				t.Errorf("Notify = %+v", cfg.Notify)
+			}
+		})
+	}
+}
+
+func TestParse_Jenkins(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantJUnit string
+		wantText  string
+	}{
+		{"off", nil, "", ""},
+		{"junit only", []string{"--junit-out", "out/junit.xml"}, "out/junit.xml", ""},
+		{"jenkins defaults", []string{"--jenkins"}, filepath.Join(ResultsDir, "junit.xml"), filepath.Join(ResultsDir, "summary.txt")},
+		{"jenkins keeps explicit junit path", []string{"--jenkins", "--junit-out", "out/junit.xml"}, "out/junit.xml", filepath.Join(ResultsDir, "summary.txt")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse(append([]string{"--godot-path", godot, "--ci", "none"}, tt.args...))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.JUnitOutput != tt.wantJUnit || cfg.TextOutput != tt.wantText {
+				t.Errorf("JUnitOutput = %q, TextOutput = %q; want %q, %q", cfg.JUnitOutput, cfg.TextOutput, tt.wantJUnit, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestParseSnapshots(t *testing.T) {
+	cfg, err := ParseSnapshots([]string{"approve", "--filter", "Player.*", "tests"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Action != SnapshotsApprove || len(cfg.Filter) != 1 || cfg.Paths[0] != "tests" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+
+	for _, args := range [][]string{nil, {"rename"}} {
+		if _, err := ParseSnapshots(args); err == nil {
+			t.Errorf("ParseSnapshots(%v) expected error, got nil", args)
+		}
+	}
+}
+
+func TestParseSmoke(t *testing.T) {
+	dir := t.TempDir()
+	godot := makeDummyExecutable(t, dir, "godot")
+	if err := os.WriteFile(filepath.Join(dir, "project.godot"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "scenes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := ParseSmoke([]string{"--godot-path", godot, "--preset", "Linux", "--run", "res://smoke.tscn", filepath.Join(dir, "scenes")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ProjectDir != dir || cfg.Preset != "Linux" || cfg.Run != "res://smoke.tscn" || cfg.Timeout != DefaultSmokeTimeout {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	for _, args := range [][]string{
+		{"--godot-path", godot, dir},
+		{"--godot-path", godot, "--preset", "Linux", "--run", "smoke.tscn", dir},
+	} {
+		if _, err := ParseSmoke(args); err == nil {
+			t.Errorf("ParseSmoke(%q): expected error, got nil", args)
+		}
+	}
+}
+
+func TestParseRerun(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := ParseRerun([]string{"--godot-path", godot, "--filter", "test_other", "TestMath.test_add", "tests"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Test != "TestMath.test_add" || len(cfg.Base.TestPaths) != 1 || cfg.Base.TestPaths[0] != "tests" {
+		t.Errorf("cfg = %+v, base = %+v", cfg, cfg.Base)
+	}
+	if !cfg.Base.Verbose || cfg.Base.Filter != nil {
+		t.Errorf("Verbose = %v, Filter = %v; want the output streamed and no filter", cfg.Base.Verbose, cfg.Base.Filter)
+	}
+
+	if _, err := ParseRerun([]string{"--godot-path", godot}); err == nil {
+		t.Error("expected error without a test name, got nil")
+	}
+}
+
+func TestParse_GdUnitExitCodes(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot, "--gdunit-exit-codes"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.GdUnitExit {
+		t.Error("GdUnitExit = false, want true")
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--gdunit-exit-codes", "--bazel"}); err == nil {
+		t.Error("expected error combining --gdunit-exit-codes with --bazel, got nil")
+	}
+}
+
+func TestParse_MaxFailures(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot, "--max-failures", "5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MaxFailures != 5 {
+		t.Errorf("MaxFailures = %d, want 5", cfg.MaxFailures)
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--max-failures", "-1"}); err == nil {
+		t.Error("expected error for negative --max-failures, got nil")
+	}
+}
+
+func TestParse_KillGrace(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.KillGrace != DefaultKillGrace {
+		t.Errorf("KillGrace = %v, want %v", cfg.KillGrace, DefaultKillGrace)
+	}
+	if cfg, err = Parse([]string{"--godot-path", godot, "--kill-grace", "0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.KillGrace != 0 {
+		t.Errorf("--kill-grace 0: KillGrace = %v, want 0", cfg.KillGrace)
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--kill-grace", "-1s"}); err == nil {
+		t.Error("expected error for negative --kill-grace, got nil")
+	}
+}
+
+func TestParse_Budget(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot, "--budget", "10m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Budget != 10*time.Minute {
+		t.Errorf("Budget = %v, want 10m", cfg.Budget)
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--budget", "-1m"}); err == nil {
+		t.Error("expected error for negative --budget, got nil")
+	}
+}
+
+func TestParse_OutputMode(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.OutputMode != OutputInterleaved {
+		t.Errorf("OutputMode = %q, want %q by default", cfg.OutputMode, OutputInterleaved)
+	}
+	if cfg, err = Parse([]string{"--godot-path", godot, "--output-mode", "grouped"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.OutputMode != OutputGrouped {
+		t.Errorf("OutputMode = %q, want %q", cfg.OutputMode, OutputGrouped)
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--output-mode", "sorted"}); err == nil {
+		t.Error("expected error for unknown --output-mode, got nil")
+	}
+}
+
+func TestParse_TimingFile(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot, "--timing-file", "cache/timings.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !filepath.IsAbs(cfg.TimingFile) || filepath.Base(cfg.TimingFile) != "timings.json" {
+		t.Errorf("TimingFile = %q, want an absolute path", cfg.TimingFile)
+	}
+}
+
+func TestParse_DebugServer(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	tests := []struct {
+		addr    string
+		want    string
+		wantErr bool
+	}{
+		{"6007", "tcp://127.0.0.1:6007", false},
+		{"192.168.1.5:6010", "tcp://192.168.1.5:6010", false},
+		{"tcp://localhost:6007", "tcp://localhost:6007", false},
+		{"[::1]:6007", "tcp://[::1]:6007", false},
+		{"localhost", "", true},
+		{"99999", "", true},
+	}
+	for _, tt := range tests {
+		cfg, err := Parse([]string{"--godot-path", godot, "--debug-server", tt.addr})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("--debug-server %s: expected error, got nil", tt.addr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("--debug-server %s: unexpected error: %v", tt.addr, err)
+		}
+		if cfg.DebugServer != tt.want {
+			t.Errorf("--debug-server %s: DebugServer = %q, want %q", tt.addr, cfg.DebugServer, tt.want)
+		}
+	}
+}
+
+func TestParse_Interactive(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot, "--interactive"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Interactive {
+		t.Error("Interactive = false, want true")
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--interactive", "--project-jobs", "2"}); err == nil {
+		t.Error("expected error for --interactive with --project-jobs, got nil")
+	}
+}
+
+func TestParse_CmdTool(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot, "--cmd-tool", "res://vendor/gdUnit4/bin/GdUnitCmdTool.gd"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CmdTool != "res://vendor/gdUnit4/bin/GdUnitCmdTool.gd" {
+		t.Errorf("CmdTool = %q", cfg.CmdTool)
+	}
+	for _, v := range []string{"vendor/gdUnit4/bin/GdUnitCmdTool.gd", "res://vendor/gdUnit4"} {
+		if _, err := Parse([]string{"--godot-path", godot, "--cmd-tool", v}); err == nil {
+			t.Errorf("--cmd-tool %s: expected error, got nil", v)
+		}
+	}
+}
+
+func TestParse_Project(t *testing.T) {
+	project := t.TempDir()
+	godot := makeDummyExecutable(t, project, "godot")
+	if err := os.WriteFile(filepath.Join(project, DefaultFileName), []byte(`{"hooks": {"pre_run": "make seed"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+
+	cfg, err := Parse([]string{"--godot-path", godot, "--project", project, "tests/unit", "/abs/tests", "res://tests/e2e"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ProjectDir != project {
+		t.Errorf("ProjectDir = %q, want %q", cfg.ProjectDir, project)
+	}
+	want := []string{filepath.Join(project, "tests", "unit"), "/abs/tests", "res://tests/e2e"}
+	if strings.Join(cfg.TestPaths, " ") != strings.Join(want, " ") {
+		t.Errorf("TestPaths = %q, want %q", cfg.TestPaths, want)
+	}
+	if cfg.Hooks.PreRun != "make seed" {
+		t.Errorf("Hooks.PreRun = %q, want the project's config file to be read", cfg.Hooks.PreRun)
+	}
+
+	cfg, err = Parse([]string{"--godot-path", godot, "--project", project})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.TestPaths) != 1 || cfg.TestPaths[0] != project {
+		t.Errorf("TestPaths = %q, want the project directory", cfg.TestPaths)
+	}
+}
+
+func TestParse_JSONErrors(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot, "--json-errors"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.JSONErrors {
+		t.Error("JSONErrors = false, want true")
+	}
+}
+
+func TestWantsJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  string
+		want bool
+	}{
+		{name: "absent", args: []string{"--timeout", "5m", "tests"}},
+		{name: "long", args: []string{"--json-errors", "tests"}, want: true},
+		{name: "single dash", args: []string{"-json-errors"}, want: true},
+		{name: "value", args: []string{"--json-errors=false"}},
+		{name: "after --", args: []string{"--", "--json-errors"}},
+		{name: "env", env: "1", want: true},
+		{name: "flag overrides env", args: []string{"--json-errors=0"}, env: "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(FlagEnv("json-errors"), tt.env)
+			if got := WantsJSONErrors(tt.args); got != tt.want {
+				t.Errorf("WantsJSONErrors(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveGodotPath_NotFound(t *testing.T) {
+	t.Setenv("GODOT_PATH", "")
+	_, err := ResolveGodotPath(filepath.Join(t.TempDir(), "missing"))
+	if !errors.Is(err, ErrGodotNotFound) {
+		t.Errorf("err = %v, want ErrGodotNotFound", err)
+	}
+}
+
+func TestParse_SignOutput(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot, "--sign-output"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.SignOutput || cfg.SignKey != nil {
+		t.Errorf("SignOutput = %v, SignKey = %v, want an unsigned digest", cfg.SignOutput, cfg.SignKey)
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--sign-output", "--format", "ctest"}); err == nil {
+		t.Error("expected error for --sign-output with --format ctest, got nil")
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--sign-key", filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("expected error for a missing --sign-key, got nil")
+	}
+}
+
+func TestParse_Compress(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot, "--compress", "--junit-out", "out/junit.xml", "--log-file", "godot.log"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Compress || cfg.JUnitOutput != "out/junit.xml.gz" || !strings.HasSuffix(cfg.LogFile, "godot.log.gz") {
+		t.Errorf("Compress = %v, JUnitOutput = %q, LogFile = %q, want .gz paths", cfg.Compress, cfg.JUnitOutput, cfg.LogFile)
+	}
+	if cfg.TRXOutput != "" {
+		t.Errorf("TRXOutput = %q, want empty", cfg.TRXOutput)
+	}
+	if _, err := Parse([]string{"--godot-path", godot, "--compress", "--bazel"}); err == nil {
+		t.Error("expected error combining --compress with --bazel, got nil")
+	}
+}
+
+func TestParse_Bundle(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	cfg, err := Parse([]string{"--godot-path", godot, "--bundle", "out/bundle.zip"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !filepath.IsAbs(cfg.Bundle) || !strings.HasSuffix(cfg.Bundle, filepath.Join("out", "bundle.zip")) {
+		t.Errorf("Bundle = %q, want an absolute path ending in out/bundle.zip", cfg.Bundle)
+	}
+}
+
+func TestParse_OpenReport(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	t.Setenv(FlagEnv("open-report"), "true")
+	cfg, err := Parse([]string{"--godot-path", godot})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.OpenReport {
+		t.Error("OpenReport = false, want true from the environment")
+	}
+}
+
+func TestParse_Badge(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	// The badge is for display, so --compress leaves it as it is.
+	cfg, err := Parse([]string{"--godot-path", godot, "--badge", "badge.svg", "--compress"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Badge != "badge.svg" {
+		t.Errorf("Badge = %q, want badge.svg", cfg.Badge)
+	}
+}
+
+func TestParse_EditorLinks(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"vscode", "vscode://file{path}:{line}", false},
+		{"godot://open?file={res}&line={line}", "godot://open?file={res}&line={line}", false},
+		{"notepad", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			cfg, err := Parse([]string{"--godot-path", godot, "--editor-links", tt.value})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.EditorLinks != tt.want {
+				t.Errorf("EditorLinks = %q, want %q", cfg.EditorLinks, tt.want)
+			}
+		})
+	}
+}
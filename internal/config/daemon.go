@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// daemonFlags are the flags of the default command a --daemon run honors. The
+// daemon runs the tests with the settings it was started with and hands back
+// only the JSON output, so the client rejects the other flags rather than
+// ignore them. --godot-path is accepted and unused: the daemon's Godot runs.
+var daemonFlags = []string{
+	"ci", "compress", "config", "daemon", "filter", "format", "godot-path",
+	"json-errors", "lang", "profile", "project", "sign-key", "sign-output",
+}
+
+// checkDaemon returns an error if cfg, a --daemon run parsed by fs, asks for
+// something the thin client would not do.
+func checkDaemon(fs *flag.FlagSet, cfg *Config) error {
+	var rejected []string
+	fs.Visit(func(fl *flag.Flag) {
+		name := "--" + longName(fl.Name)
+		if !slices.Contains(daemonFlags, longName(fl.Name)) && !slices.Contains(rejected, name) {
+			rejected = append(rejected, name)
+		}
+	})
+	if cfg.Format != FormatJSON {
+		rejected = append(rejected, "--format "+cfg.Format)
+	}
+	if cfg.CI != "" {
+		rejected = append(rejected, "--ci "+cfg.CI)
+	}
+	if len(rejected) > 0 {
+		return fmt.Errorf("--daemon cannot be combined with %s: the daemon runs the tests with its own settings and returns only the JSON output",
+			strings.Join(rejected, ", "))
+	}
+	if cfg.Hooks != (Hooks{}) {
+		return errors.New("--daemon cannot be combined with the hooks of the config file: the daemon runs the hooks of its own")
+	}
+	if len(cfg.Notify.Rules) > 0 {
+		return errors.New("--daemon cannot be combined with the notify rules of the config file")
+	}
+	return nil
+}
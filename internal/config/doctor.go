@@ -0,0 +1,40 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// DoctorConfig holds settings for the doctor subcommand.
+type DoctorConfig struct {
+	GodotPath string // --godot-path, checked like a run would
+	JSON      bool   // print the report as JSON
+}
+
+// ParseDoctor parses the arguments following "doctor".
+func ParseDoctor(args []string) (*DoctorConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner doctor", flag.ContinueOnError)
+
+	cfg := &DoctorConfig{}
+	fs.StringVar(&cfg.GodotPath, "godot-path", "", "path to Godot binary")
+	fs.BoolVar(&cfg.JSON, "json", false, "print the report as JSON")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner doctor [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Show the Godot binary a run would use and every candidate found on this machine.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  --godot-path <path>  path to Godot binary\n")
+		fmt.Fprintf(os.Stderr, "  --json               print the report as JSON\n")
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return nil, fmt.Errorf("doctor takes no arguments, got %q", fs.Arg(0))
+	}
+	return cfg, nil
+}
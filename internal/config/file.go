@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// DefaultFileName is the config file looked up in the current directory
+// when --config is not given.
+const DefaultFileName = "gdunit4-runner.json"
+
+// File holds settings read from the JSON config file.
+type File struct {
+	Hooks    Hooks              `json:"hooks"`
+	Coverage CoverageThresholds `json:"coverage"`
+	TestsDir string             `json:"tests_dir"` // project-relative directory new test suites are created in
+	Owners   Owners             `json:"owners"`
+	Notify   Notify             `json:"notify"`
+	Profiles map[string]Profile `json:"profiles"` // selected with --profile
+
+	// TransientErrors are regular expressions of further Godot failures to
+	// retry once, in addition to report.TransientErrors.
+	TransientErrors []string `json:"transient_errors"`
+
+	// XFail quarantines tests known to fail: --filter patterns of tests
+	// reported as expected failures instead of failures.
+	XFail []string `json:"xfail"`
+
+	Schedule Schedule `json:"schedule"`
+}
+
+// Schedule constrains when suites run, for suites that share state such as
+// autoload singletons. Patterns are CODEOWNERS-style paths of test files,
+// relative to the project.
+type Schedule struct {
+	// Order lists the stages of a run: a suite belongs to the stage of the
+	// first pattern matching it and runs after the suites of earlier stages.
+	// Suites matching no pattern run first.
+	Order []string `json:"order"`
+
+	// Serial matches suites that must not run while another project does:
+	// with --project-jobs, a project holding one runs alone. So do suites
+	// tagged TagSerial.
+	Serial []string `json:"serial"`
+
+	// Limits caps, by tag, how many projects with suites of that tag run at
+	// once, e.g. {"gpu": 1} for suites needing the agent's GPU.
+	Limits map[string]int `json:"limits"`
+}
+
+// TagSerial is the tag of suites and tests that run as if they matched
+// Schedule.Serial.
+const TagSerial = "serial"
+
+// Hooks holds shell commands run around the Godot process.
+type Hooks struct {
+	PreRun  string `json:"pre_run"`
+	PostRun string `json:"post_run"`
+}
+
+// Owners configures how failures are attributed to owners. By default the
+// repository's CODEOWNERS file is used.
+type Owners struct {
+	File string              `json:"file"` // CODEOWNERS file to read instead of the default locations
+	Map  map[string][]string `json:"map"`  // CODEOWNERS-style patterns relative to the project → owners; replaces CODEOWNERS when set
+}
+
+// Notifier types.
+const (
+	NotifierSlack   = "slack"
+	NotifierWebhook = "webhook"
+	NotifierEmail   = "email"
+)
+
+// Notify configures where results are sent after a run.
+type Notify struct {
+	Notifiers map[string]Notifier `json:"notifiers"` // by name, referenced from rules
+	Rules     []NotifyRule        `json:"rules"`
+}
+
+// Notifier is a destination for notifications. URL, Username and Password may
+// reference environment variables as $NAME or ${NAME} so that secrets stay out of
+// the config file.
+type Notifier struct {
+	Type    string `json:"type"`    // NotifierSlack, NotifierWebhook or NotifierEmail
+	URL     string `json:"url"`     // Slack incoming webhook or HTTP endpoint receiving a JSON POST
+	Channel string `json:"channel"` // Slack channel overriding the webhook's default, if set
+
+	// Email settings. Port 465 uses implicit TLS; other ports upgrade with STARTTLS when offered.
+	SMTP     string   `json:"smtp"` // SMTP server as host:port
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Username string   `json:"username"` // PLAIN auth user; no authentication if empty
+	Password string   `json:"password"`
+}
+
+// NotifyRule routes the failures matching all of its criteria to the notifiers in Notify.
+// Empty criteria match everything.
+type NotifyRule struct {
+	Name   string   `json:"name"`
+	Status []string `json:"status"` // run statuses; default all but "passed"
+	Paths  []string `json:"paths"`  // CODEOWNERS-style patterns for test files, relative to the project
+	Tags   []string `json:"tags"`   // test tags, see --tags
+	Owners []string `json:"owners"` // failure owners, see Owners
+	Notify []string `json:"notify"` // notifier names
+}
+
+// CoverageThresholds holds minimum line coverage percentages.
+type CoverageThresholds struct {
+	Min      float64            `json:"min"`      // minimum overall line coverage in percent; 0 disables the check
+	Packages map[string]float64 `json:"packages"` // minimum per directory, including its subdirectories
+}
+
+// LoadFile reads and decodes the config file at path.
+// If path is empty, DefaultFileName is used and a missing file is not an error.
+func LoadFile(path string) (*File, error) {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultFileName
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return &File{}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &f, nil
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"cmp"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/cache"
+)
+
+// Sources of Godot binaries found by FindGodot.
+const (
+	SourceFlag    = "flag"    // --godot-path
+	SourceEnv     = "env"     // GODOT_PATH
+	SourcePath    = "path"    // godot or godot4 on PATH
+	SourceInstall = "install" // a default install location of the OS
+	SourceFlatpak = "flatpak"
+	SourceSnap    = "snap"
+	SourceScoop   = "scoop"
+	SourceWinget  = "winget"
+	SourceCache   = "cache" // the runner's download cache
+)
+
+// GodotCandidate is a Godot binary found on this machine.
+type GodotCandidate struct {
+	Path    string `json:"path"`
+	Source  string `json:"source"`
+	Version string `json:"version,omitempty"` // as spelled in the path, e.g. "4.3"; empty if it names none
+}
+
+// searchPath is a glob pattern where a source installs Godot.
+type searchPath struct {
+	source  string
+	pattern string
+}
+
+// pathVersionRe matches a Godot version in a file or directory name, e.g. the
+// 4.3 of Godot_v4.3-stable_linux.x86_64 or the 4.2.1 of godot/4.2.1-stable/.
+var pathVersionRe = regexp.MustCompile(`(?:^|[^\d.])v?(\d+\.\d+(?:\.\d+)?)(?:[^\d.]|$)`)
+
+// godotSearchPaths returns the install locations searched on goos, in order.
+func godotSearchPaths(goos string, getenv func(string) string) []searchPath {
+	home := getenv("HOME")
+	var paths []searchPath
+	add := func(source, base string, elem ...string) {
+		if base != "" {
+			paths = append(paths, searchPath{source, filepath.Join(append([]string{base}, elem...)...)})
+		}
+	}
+	switch goos {
+	case "windows":
+		add(SourceInstall, getenv("ProgramFiles"), "Godot*", "Godot*.exe")
+		add(SourceInstall, getenv("LocalAppData"), "Programs", "Godot*", "Godot*.exe")
+		add(SourceScoop, cmp.Or(getenv("SCOOP"), join(getenv("USERPROFILE"), "scoop")), "shims", "godot*.exe")
+		add(SourceWinget, getenv("LocalAppData"), "Microsoft", "WinGet", "Links", "godot*.exe")
+	case "darwin":
+		add(SourceInstall, "/Applications", "Godot*.app", "Contents", "MacOS", "Godot")
+		add(SourceInstall, home, "Applications", "Godot*.app", "Contents", "MacOS", "Godot")
+	default:
+		add(SourceFlatpak, home, ".local", "share", "flatpak", "exports", "bin", "org.godotengine.Godot*")
+		add(SourceFlatpak, "/var/lib/flatpak", "exports", "bin", "org.godotengine.Godot*")
+		add(SourceSnap, "/snap/bin", "godot*")
+		add(SourceInstall, home, ".local", "bin", "Godot_v*")
+	}
+	return paths
+}
+
+func join(base, elem string) string {
+	if base == "" {
+		return ""
+	}
+	return filepath.Join(base, elem)
+}
+
+// FindGodot lists the Godot binaries on this machine outside of --godot-path and
+// GODOT_PATH: godot and godot4 on PATH, the default install locations of the OS
+// (Program Files, /Applications/Godot*.app, flatpak, snap, scoop and winget
+// shims) and the runner's download cache. Binaries on PATH come first, the
+// others newest version first.
+func FindGodot() []GodotCandidate {
+	return findGodot(godotSearchPaths(runtime.GOOS, os.Getenv), cache.Dir())
+}
+
+func findGodot(paths []searchPath, cacheDir string) []GodotCandidate {
+	var onPath, found []GodotCandidate
+	seen := map[string]bool{}
+	add := func(list *[]GodotCandidate, source, p string) {
+		if seen[p] || !isExecutable(p) {
+			return
+		}
+		seen[p] = true
+		*list = append(*list, GodotCandidate{Path: p, Source: source, Version: pathVersion(p)})
+	}
+
+	for _, name := range []string{"godot", "godot4"} {
+		if p, err := exec.LookPath(name); err == nil {
+			add(&onPath, SourcePath, p)
+		}
+	}
+	for _, sp := range paths {
+		matches, _ := filepath.Glob(sp.pattern)
+		for _, m := range matches {
+			add(&found, sp.source, m)
+		}
+	}
+	if cacheDir != "" {
+		root := filepath.Join(cacheDir, cache.Godot)
+		filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() && strings.Count(p[len(root):], string(filepath.Separator)) > 4 {
+				return filepath.SkipDir
+			}
+			name := strings.ToLower(d.Name())
+			if runtime.GOOS == "windows" && !strings.HasSuffix(name, ".exe") {
+				return nil // isExecutable accepts any file on Windows
+			}
+			if !d.IsDir() && strings.HasPrefix(name, "godot") {
+				add(&found, SourceCache, p)
+			}
+			return nil
+		})
+	}
+
+	slices.SortStableFunc(found, func(a, b GodotCandidate) int {
+		return compareVersions(b.Version, a.Version)
+	})
+	return append(onPath, found...)
+}
+
+// pathVersion returns the last Godot version named in p, e.g. "4.3".
+func pathVersion(p string) string {
+	version := ""
+	for _, elem := range strings.Split(filepath.ToSlash(p), "/") {
+		if m := pathVersionRe.FindStringSubmatch(elem); m != nil {
+			version = m[1]
+		}
+	}
+	return version
+}
+
+// compareVersions compares dotted versions numerically; "" sorts first.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	if a == "" || b == "" {
+		return cmp.Compare(len(a), len(b))
+	}
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if c := cmp.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
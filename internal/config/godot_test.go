@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFindGodot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping executable bit test on Windows")
+	}
+	t.Setenv("PATH", t.TempDir())
+	home := t.TempDir()
+	cacheDir := t.TempDir()
+	for _, p := range []string{
+		filepath.Join(home, ".local", "bin", "Godot_v4.2.1-stable_linux.x86_64"),
+		filepath.Join(home, ".local", "share", "flatpak", "exports", "bin", "org.godotengine.Godot"),
+		filepath.Join(cacheDir, "godot", "4.3-stable", "Godot_v4.3-stable_linux.x86_64"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		makeDummyExecutable(t, filepath.Dir(p), filepath.Base(p))
+	}
+	// Not executable: a download left unpacked.
+	if err := os.WriteFile(filepath.Join(cacheDir, "godot", "Godot_v4.4-stable_linux.x86_64.zip"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	getenv := func(key string) string { return map[string]string{"HOME": home}[key] }
+	got := findGodot(godotSearchPaths("linux", getenv), cacheDir)
+	want := []GodotCandidate{
+		{Path: filepath.Join(cacheDir, "godot", "4.3-stable", "Godot_v4.3-stable_linux.x86_64"), Source: SourceCache, Version: "4.3"},
+		{Path: filepath.Join(home, ".local", "bin", "Godot_v4.2.1-stable_linux.x86_64"), Source: SourceInstall, Version: "4.2.1"},
+		{Path: filepath.Join(home, ".local", "share", "flatpak", "exports", "bin", "org.godotengine.Godot"), Source: SourceFlatpak},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("findGodot = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("candidate %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGodotSearchPaths(t *testing.T) {
+	getenv := func(key string) string {
+		return map[string]string{
+			"HOME":         "/home/me",
+			"ProgramFiles": `C:\Program Files`,
+			"USERPROFILE":  `C:\Users\me`,
+		}[key]
+	}
+	tests := []struct {
+		goos string
+		want searchPath
+	}{
+		{"darwin", searchPath{SourceInstall, filepath.Join("/Applications", "Godot*.app", "Contents", "MacOS", "Godot")}},
+		{"linux", searchPath{SourceSnap, filepath.Join("/snap/bin", "godot*")}},
+		{"windows", searchPath{SourceScoop, filepath.Join(`C:\Users\me`, "scoop", "shims", "godot*.exe")}},
+	}
+	for _, tt := range tests {
+		found := false
+		for _, sp := range godotSearchPaths(tt.goos, getenv) {
+			found = found || sp == tt.want
+		}
+		if !found {
+			t.Errorf("godotSearchPaths(%s) = %v, want it to include %v", tt.goos, godotSearchPaths(tt.goos, getenv), tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"4.3", "4.2.1", 1},
+		{"4.10", "4.9", 1},
+		{"4.2", "4.2.0", 0},
+		{"", "3.5", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// MutateConfig holds settings for the mutate subcommand.
+type MutateConfig struct {
+	Sources    []string // GDScript files to mutate
+	MaxMutants int      // stop after this many mutants; 0 means all
+	Base       *Config  // settings for the test runs; TestPaths holds the tests to run against mutants
+}
+
+// ParseMutate parses the arguments following "mutate".
+func ParseMutate(args []string) (*MutateConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner mutate", flag.ContinueOnError)
+
+	var rf runFlags
+	var sources string
+	var maxMutants int
+
+	rf.register(fs)
+	fs.StringVar(&sources, "source", "", "comma-separated GDScript files to mutate (required)")
+	fs.IntVar(&maxMutants, "max-mutants", 0, "stop after this many mutants; 0 means all")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner mutate --source <files> [options] [paths...]\n\n")
+		fmt.Fprintf(os.Stderr, "Apply mutations to the source files one at a time, run the tests against each\n")
+		fmt.Fprintf(os.Stderr, "mutant, and print the mutation score as JSON.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  --source <files>     comma-separated GDScript files to mutate (required)\n")
+		fmt.Fprintf(os.Stderr, "  --max-mutants <n>    stop after this many mutants; 0 means all\n")
+		rf.printUsage()
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+		fmt.Fprintf(os.Stderr, "\nPaths are the tests to run; if none are given, the current directory is used.\n")
+	}
+
+	if err := rf.parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	cfg := &MutateConfig{Sources: splitList(sources), MaxMutants: maxMutants}
+	if len(cfg.Sources) == 0 {
+		return nil, errors.New("mutate requires --source")
+	}
+	if cfg.MaxMutants < 0 {
+		return nil, fmt.Errorf("invalid --max-mutants %d", cfg.MaxMutants)
+	}
+
+	base, err := rf.resolve(fs.Args())
+	if err != nil {
+		return nil, err
+	}
+	cfg.Base = base
+	return cfg, nil
+}
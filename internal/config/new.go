@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// DefaultTestsDir is the project-relative directory new test suites are created in,
+// matching gdUnit4's default test folder.
+const DefaultTestsDir = "test"
+
+// NewConfig holds settings for the new subcommand.
+type NewConfig struct {
+	Sources  []string // GDScript files to generate test suites for
+	TestsDir string   // project-relative directory for the generated suites
+	Force    bool     // overwrite existing test suites
+}
+
+// ParseNew parses the arguments following "new".
+func ParseNew(args []string) (*NewConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner new", flag.ContinueOnError)
+
+	var configPath, testsDir string
+	var force bool
+
+	fs.StringVar(&configPath, "config", "", "path to config file (default: "+DefaultFileName+" if present)")
+	fs.StringVar(&testsDir, "tests-dir", "", "project-relative directory for the generated suites (default: tests_dir from the config file, then "+DefaultTestsDir+")")
+	fs.BoolVar(&force, "force", false, "overwrite existing test suites")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner new [options] <source.gd>...\n\n")
+		fmt.Fprintf(os.Stderr, "Generate a gdUnit4 test suite skeleton for each source script.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  --tests-dir <dir>    project-relative directory for the generated suites\n")
+		fmt.Fprintf(os.Stderr, "                       (default: tests_dir from the config file, then %s)\n", DefaultTestsDir)
+		fmt.Fprintf(os.Stderr, "  --force              overwrite existing test suites\n")
+		fmt.Fprintf(os.Stderr, "  --config <path>      path to config file (default: %s if present)\n", DefaultFileName)
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() == 0 {
+		return nil, errors.New("new requires at least one source script")
+	}
+
+	file, err := LoadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if testsDir == "" {
+		testsDir = file.TestsDir
+	}
+	if testsDir == "" {
+		testsDir = DefaultTestsDir
+	}
+
+	return &NewConfig{Sources: fs.Args(), TestsDir: testsDir, Force: force}, nil
+}
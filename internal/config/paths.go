@@ -0,0 +1,27 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// ParsePaths parses the arguments of a subcommand that only takes test paths.
+// name and summary are used in the help text.
+func ParsePaths(name, summary string, args []string) ([]string, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner "+name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner %s [paths...]\n\n", name)
+		fmt.Fprintf(os.Stderr, "%s.\n", summary)
+		fmt.Fprintf(os.Stderr, "\nIf no paths are given, the current directory is used.\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	return paths, nil
+}
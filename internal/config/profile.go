@@ -0,0 +1,143 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Profile is a named set of settings in the config file, selected with --profile.
+type Profile struct {
+	// Flags holds flag values by flag name without dashes, e.g. {"timeout": "10m",
+	// "github-check": true}. Flags given on the command line take precedence.
+	Flags map[string]any `json:"flags"`
+
+	// Sections of the config file the profile replaces, if set.
+	Hooks    *Hooks              `json:"hooks"`
+	Coverage *CoverageThresholds `json:"coverage"`
+	Notify   *Notify             `json:"notify"`
+}
+
+// EnvPrefix prefixes the environment variable of every flag: --junit-out can be
+// given as GDUNIT4_RUNNER_JUNIT_OUT.
+const EnvPrefix = "GDUNIT4_RUNNER_"
+
+// FlagEnv returns the environment variable that sets the flag name.
+func FlagEnv(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// parse parses args into fs, accepting the aliases in shortFlags, then fills
+// in flags not given in args, first from their environment variables (see
+// FlagEnv) and then from the --profile selected in the config file. Profile
+// sections replace those of the file. Flags only other commands define are
+// skipped, so that one profile can serve the default command and serve or
+// mutate.
+func (f *runFlags) parse(fs *flag.FlagSet, args []string) error {
+	addShortFlags(fs)
+	if err := fs.Parse(expandShortFlags(fs, args)); err != nil {
+		return err
+	}
+	given := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) { given[longName(fl.Name)] = true })
+	var envErr error
+	fs.VisitAll(func(fl *flag.Flag) {
+		value, ok := os.LookupEnv(FlagEnv(fl.Name))
+		if !ok || given[fl.Name] || longName(fl.Name) != fl.Name || fl.Name == "version" || envErr != nil {
+			return
+		}
+		if err := fs.Set(fl.Name, value); err != nil {
+			envErr = fmt.Errorf("invalid value %q for %s: %w", value, FlagEnv(fl.Name), err)
+		}
+		given[fl.Name] = true
+	})
+	if envErr != nil {
+		return envErr
+	}
+
+	configPath := f.configPath
+	if configPath == "" && f.project != "" {
+		// The default config file lives in the project, not the working directory.
+		if _, err := os.Stat(filepath.Join(f.project, DefaultFileName)); err == nil {
+			configPath = filepath.Join(f.project, DefaultFileName)
+		}
+	}
+	file, err := LoadFile(configPath)
+	if err != nil {
+		return err
+	}
+	f.file = file
+	if f.profile == "" {
+		return nil
+	}
+
+	p, ok := file.Profiles[f.profile]
+	if !ok {
+		names := slices.Sorted(maps.Keys(file.Profiles))
+		if len(names) == 0 {
+			return fmt.Errorf("unknown profile %q; the config file defines none", f.profile)
+		}
+		return fmt.Errorf("unknown profile %q; want one of %s", f.profile, strings.Join(names, ", "))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(p.Flags)) {
+		if given[name] {
+			continue
+		}
+		if name == "profile" || name == "config" {
+			return fmt.Errorf("profile %q: %s cannot be set by a profile", f.profile, name)
+		}
+		if fs.Lookup(name) == nil {
+			if fs.Name() == mainCommand {
+				return fmt.Errorf("profile %q: unknown flag %q", f.profile, name)
+			}
+			continue
+		}
+		value, err := flagValue(p.Flags[name])
+		if err != nil {
+			return fmt.Errorf("profile %q: %s: %w", f.profile, name, err)
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("profile %q: invalid value %q for %s: %w", f.profile, value, name, err)
+		}
+	}
+
+	if p.Hooks != nil {
+		file.Hooks = *p.Hooks
+	}
+	if p.Coverage != nil {
+		file.Coverage = *p.Coverage
+	}
+	if p.Notify != nil {
+		file.Notify = *p.Notify
+	}
+	return nil
+}
+
+// flagValue renders a JSON value as a flag value: lists become comma-separated.
+func flagValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		parts := make([]string, len(v))
+		for i, e := range v {
+			s, err := flagValue(e)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value %v; want a string, number, boolean or list", v)
+}
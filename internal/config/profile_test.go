@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const profilesFile = `{
+  "hooks": {"pre_run": "make seed"},
+  "profiles": {
+    "ci": {
+      "flags": {"timeout": "10m", "format": "ctest", "tags": ["fast", "net"], "github-check": true, "ci": "none"},
+      "hooks": {"post_run": "make upload"}
+    },
+    "serve": {"flags": {"http": ":9000", "timeout": "1m"}},
+    "typo": {"flags": {"timout": "1m"}}
+  }
+}`
+
+func writeProfiles(t *testing.T) (godot, path string) {
+	t.Helper()
+	dir := t.TempDir()
+	path = filepath.Join(dir, "runner.json")
+	if err := os.WriteFile(path, []byte(profilesFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return makeDummyExecutable(t, dir, "godot"), path
+}
+
+func TestParse_Profile(t *testing.T) {
+	godot, path := writeProfiles(t)
+
+	cfg, err := Parse([]string{"--godot-path", godot, "--config", path, "--profile", "ci", "--format", "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Timeout != 10*time.Minute {
+		t.Errorf("Timeout = %v, want 10m from the profile", cfg.Timeout)
+	}
+	if cfg.Format != FormatJSON {
+		t.Errorf("Format = %q, want the command line's json to win", cfg.Format)
+	}
+	if strings.Join(cfg.Tags, ",") != "fast,net" || !cfg.GitHubCheck {
+		t.Errorf("Tags = %q, GitHubCheck = %v", cfg.Tags, cfg.GitHubCheck)
+	}
+	if cfg.Hooks.PreRun != "" || cfg.Hooks.PostRun != "make upload" {
+		t.Errorf("Hooks = %+v, want the profile's hooks", cfg.Hooks)
+	}
+}
+
+func TestParse_ProfileErrors(t *testing.T) {
+	godot, path := writeProfiles(t)
+
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{"nightly", `unknown profile "nightly"; want one of ci, serve, typo`},
+		{"typo", `unknown flag "timout"`},
+		// serve-only flags are rejected by the default command.
+		{"serve", `unknown flag "http"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			_, err := Parse([]string{"--godot-path", godot, "--config", path, "--profile", tt.profile})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseServe_Profile(t *testing.T) {
+	godot, path := writeProfiles(t)
+
+	cfg, err := ParseServe([]string{"--godot-path", godot, "--config", path, "--profile", "serve"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.HTTP != ":9000" || cfg.Base.Timeout != time.Minute {
+		t.Errorf("HTTPAddr = %q, Timeout = %v; want the profile's values", cfg.HTTP, cfg.Base.Timeout)
+	}
+}
+
+func TestParse_EnvOverrides(t *testing.T) {
+	godot, path := writeProfiles(t)
+	t.Setenv("GDUNIT4_RUNNER_TIMEOUT", "3m")
+	t.Setenv("GDUNIT4_RUNNER_FORMAT", "json")
+	t.Setenv("GDUNIT4_RUNNER_GITHUB_CHECK", "false")
+
+	cfg, err := Parse([]string{"--godot-path", godot, "--config", path, "--profile", "ci", "--format", "ctest"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Timeout != 3*time.Minute {
+		t.Errorf("Timeout = %v, want 3m from the environment over the profile", cfg.Timeout)
+	}
+	if cfg.Format != FormatCTest {
+		t.Errorf("Format = %q, want the command line's ctest to win", cfg.Format)
+	}
+	if cfg.GitHubCheck {
+		t.Error("GitHubCheck = true, want false from the environment")
+	}
+
+	t.Setenv("GDUNIT4_RUNNER_TIMEOUT", "soon")
+	_, err = Parse([]string{"--godot-path", godot})
+	if err == nil || !strings.Contains(err.Error(), "GDUNIT4_RUNNER_TIMEOUT") {
+		t.Errorf("err = %v, want it to name GDUNIT4_RUNNER_TIMEOUT", err)
+	}
+}
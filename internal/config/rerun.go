@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/minami110/gdunit4-test-runner/internal/runner"
+)
+
+// RerunConfig holds settings for the rerun subcommand.
+type RerunConfig struct {
+	Test string  // "Class.test_name" or "test_name"; wildcards are allowed but must match one test
+	Base *Config // settings for the run; TestPaths holds where to look for the test
+}
+
+// ParseRerun parses the arguments following "rerun".
+func ParseRerun(args []string) (*RerunConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner rerun", flag.ContinueOnError)
+
+	var rf runFlags
+	rf.register(fs)
+	fs.StringVar(&rf.daemon, "daemon", "", "run the test on a serve --http daemon at this URL")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner rerun [options] <Class.test_name> [paths...]\n\n")
+		fmt.Fprintf(os.Stderr, "Find the test among the suites under the paths and run only it, streaming\n")
+		fmt.Fprintf(os.Stderr, "Godot's output to stderr and printing the result with its diff to stdout.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  --daemon <url>       run the test on a serve --http daemon at this URL\n")
+		rf.printUsage()
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+		fmt.Fprintf(os.Stderr, "\nPaths limit the search; if none are given, the current directory is used.\n")
+	}
+
+	if err := rf.parse(fs, args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() == 0 {
+		return nil, errors.New("rerun requires a test name (Class.test_name)")
+	}
+
+	base, err := rf.resolve(fs.Args()[1:])
+	if err != nil {
+		return nil, err
+	}
+	// The named test is run whatever the filters of the config file say.
+	base.Filter, base.Tags, base.SkipTags = nil, nil, nil
+	if !base.Verbose {
+		base.Verbose = true
+		base.VerboseStreams = runner.StreamAll
+	}
+	return &RerunConfig{Test: fs.Arg(0), Base: base}, nil
+}
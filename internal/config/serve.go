@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// DefaultUIAddr is the listen address used by serve --ui when --http is not given.
+const DefaultUIAddr = "localhost:8080"
+
+// DefaultEditorAddr is the listen address used by serve --editor when --http
+// is not given: a free loopback port, advertised to the editor plugin.
+const DefaultEditorAddr = "127.0.0.1:0"
+
+// ServeConfig holds settings for the serve subcommand.
+type ServeConfig struct {
+	Stdio  bool
+	HTTP   string  // listen address for the HTTP server, e.g. ":8080"
+	GRPC   string  // listen address for the gRPC server, e.g. ":9090"
+	UI     bool    // serve the web dashboard on the HTTP server
+	Editor bool    // advertise the HTTP server to the Godot editor plugin in the project's state directory
+	Base   *Config // defaults for runs started by clients; TestPaths is the fallback when a request names none
+}
+
+// ParseServe parses the arguments following "serve".
+func ParseServe(args []string) (*ServeConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner serve", flag.ContinueOnError)
+
+	var rf runFlags
+	var stdio bool
+	var httpAddr, grpcAddr string
+	var ui, editor bool
+
+	rf.register(fs)
+	fs.BoolVar(&stdio, "stdio", false, "speak JSON-RPC 2.0 over stdin/stdout")
+	fs.StringVar(&httpAddr, "http", "", "serve the HTTP API on this address (e.g. :8080)")
+	fs.StringVar(&grpcAddr, "grpc", "", "serve the gRPC API on this address over h2c (e.g. :9090)")
+	fs.BoolVar(&ui, "ui", false, "serve the web dashboard (implies --http "+DefaultUIAddr+" if --http is not set)")
+	fs.BoolVar(&editor, "editor", false, "serve the Godot editor plugin of contrib/ (implies --http "+DefaultEditorAddr+" if --http is not set)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner serve (--stdio | --http <addr> | --grpc <addr>) [options] [paths...]\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  --stdio              speak JSON-RPC 2.0 over stdin/stdout\n")
+		fmt.Fprintf(os.Stderr, "  --http <addr>        serve the HTTP API on this address (e.g. :8080)\n")
+		fmt.Fprintf(os.Stderr, "  --grpc <addr>        serve the gRPC API on this address over h2c (e.g. :9090)\n")
+		fmt.Fprintf(os.Stderr, "  --ui                 serve the web dashboard (implies --http %s if --http is not set)\n", DefaultUIAddr)
+		fmt.Fprintf(os.Stderr, "  --editor             serve the Godot editor plugin of contrib/ (implies --http %s if --http is not set)\n", DefaultEditorAddr)
+		rf.printUsage()
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+		fmt.Fprintf(os.Stderr, "\nPaths are used when a run or discover request names none.\n")
+	}
+
+	if err := rf.parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	if grpcAddr != "" && (stdio || httpAddr != "" || ui || editor) {
+		return nil, errors.New("--grpc cannot be combined with --stdio, --http, --ui or --editor")
+	}
+	if stdio && editor {
+		return nil, errors.New("--stdio cannot be combined with --editor")
+	}
+	if ui && httpAddr == "" {
+		httpAddr = DefaultUIAddr
+	}
+	if editor && httpAddr == "" {
+		httpAddr = DefaultEditorAddr
+	}
+
+	switch {
+	case !stdio && httpAddr == "" && grpcAddr == "":
+		return nil, errors.New("serve requires a transport; use --stdio, --http <addr> or --grpc <addr>")
+	case stdio && httpAddr != "":
+		return nil, errors.New("--stdio cannot be combined with --http or --ui")
+	}
+
+	base, err := rf.resolve(fs.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServeConfig{
+		Stdio:  stdio,
+		HTTP:   httpAddr,
+		GRPC:   grpcAddr,
+		UI:     ui,
+		Editor: editor,
+		Base:   base,
+	}, nil
+}
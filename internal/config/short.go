@@ -0,0 +1,91 @@
+package config
+
+import (
+	"flag"
+	"strings"
+)
+
+// shortFlags maps single-letter aliases to the long flags they stand for.
+var shortFlags = map[string]string{
+	"f": "format",
+	"o": "junit-out",
+	"p": "godot-path",
+	"t": "timeout",
+	"v": "verbose",
+}
+
+// shortNotes qualifies the usage of aliases whose letter suggests something
+// else than the flag they stand for.
+var shortNotes = map[string]string{
+	// Other runners' -o redirects the main output; here -f picks its format
+	// and it always goes to stdout.
+	"o": "the JUnit XML report file, not the stdout output",
+}
+
+// addShortFlags registers the aliases in shortFlags whose long flag fs defines.
+// An alias shares the long flag's value, so either spelling sets it.
+func addShortFlags(fs *flag.FlagSet) {
+	for short, long := range shortFlags {
+		if fl := fs.Lookup(long); fl != nil {
+			usage := "short for --" + long
+			if note, ok := shortNotes[short]; ok {
+				usage += ": " + note
+			}
+			fs.Var(fl.Value, short, usage)
+		}
+	}
+}
+
+// longName returns the long flag name an alias stands for, or name itself.
+func longName(name string) string {
+	if long, ok := shortFlags[name]; ok {
+		return long
+	}
+	return name
+}
+
+// expandShortFlags rewrites combined short flags the way POSIX tools accept
+// them: "-vt30s" becomes "-v", "-t", "30s" and "-pgodot" becomes "-p", "godot".
+// Letters up to the first one taking a value must be boolean flags; the rest of
+// the argument is that flag's value. Arguments that are not made of aliases,
+// and everything after "--", are left alone for flag.Parse.
+func expandShortFlags(fs *flag.FlagSet, args []string) []string {
+	var out []string
+	for i, arg := range args {
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+		expanded, ok := expandShort(fs, arg)
+		if !ok {
+			out = append(out, arg)
+			continue
+		}
+		out = append(out, expanded...)
+	}
+	return out
+}
+
+func expandShort(fs *flag.FlagSet, arg string) ([]string, bool) {
+	if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' || strings.Contains(arg, "=") {
+		return nil, false
+	}
+	var out []string
+	for i := 1; i < len(arg); i++ {
+		name := arg[i : i+1]
+		if _, ok := shortFlags[name]; !ok {
+			return nil, false
+		}
+		fl := fs.Lookup(name)
+		if fl == nil {
+			return nil, false
+		}
+		out = append(out, "-"+name)
+		if b, ok := fl.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+			if rest := arg[i+1:]; rest != "" {
+				out = append(out, rest)
+			}
+			return out, true
+		}
+	}
+	return out, true
+}
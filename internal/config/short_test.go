@@ -0,0 +1,87 @@
+package config
+
+import (
+	"flag"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse_ShortFlags(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"separate", []string{"-p", godot, "-v", "-t", "30s", "-f", "ctest", "-o", "junit.xml"}},
+		{"combined", []string{"-vt30s", "-f", "ctest", "-p" + godot, "-ojunit.xml"}},
+		{"combined with -o", []string{"-vojunit.xml", "-t30s", "-fctest", "-p", godot}},
+		{"combined with value", []string{"-vt", "30s", "-fctest", "--godot-path", godot, "--junit-out", "junit.xml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse(append(tt.args, "tests/"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.GodotPath != godot || !cfg.Verbose || cfg.Timeout != 30*time.Second || cfg.Format != FormatCTest || filepath.Base(cfg.JUnitOutput) != "junit.xml" {
+				t.Errorf("cfg = %+v", cfg)
+			}
+			if !slices.Equal(cfg.TestPaths, []string{"tests/"}) {
+				t.Errorf("TestPaths = %q", cfg.TestPaths)
+			}
+		})
+	}
+}
+
+func TestParse_ShortFlagsBeatEnvironment(t *testing.T) {
+	godot := makeDummyExecutable(t, t.TempDir(), "godot")
+	t.Setenv("GDUNIT4_RUNNER_TIMEOUT", "1m")
+
+	cfg, err := Parse([]string{"-p", godot, "-t", "5s"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v, want -t to win over the environment", cfg.Timeout)
+	}
+}
+
+func TestExpandShortFlags(t *testing.T) {
+	fs := flag.NewFlagSet(mainCommand, flag.ContinueOnError)
+	var rf runFlags
+	rf.register(fs)
+	addShortFlags(fs)
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-vt30s"}, []string{"-v", "-t", "30s"}},
+		{[]string{"-tv"}, []string{"-t", "v"}},
+		{[]string{"-vx"}, []string{"-vx"}},
+		{[]string{"-v=stderr", "--", "-vt1s"}, []string{"-v=stderr", "--", "-vt1s"}},
+		{[]string{"--timeout", "-1"}, []string{"--timeout", "-1"}},
+		{[]string{filepath.Join("tests", "a.gd")}, []string{filepath.Join("tests", "a.gd")}},
+	}
+	for _, tt := range tests {
+		if got := expandShortFlags(fs, tt.args); !slices.Equal(got, tt.want) {
+			t.Errorf("expandShortFlags(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestAddShortFlags_Usage(t *testing.T) {
+	fs := flag.NewFlagSet(mainCommand, flag.ContinueOnError)
+	fs.String("junit-out", "", "")
+	fs.String("format", "", "")
+	addShortFlags(fs)
+
+	if got, want := fs.Lookup("f").Usage, "short for --format"; got != want {
+		t.Errorf("-f usage = %q, want %q", got, want)
+	}
+	if got, want := fs.Lookup("o").Usage, "short for --junit-out: the JUnit XML report file, not the stdout output"; got != want {
+		t.Errorf("-o usage = %q, want %q", got, want)
+	}
+}
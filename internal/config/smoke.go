@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+)
+
+// DefaultSmokeTimeout limits the run of an exported build.
+const DefaultSmokeTimeout = 2 * time.Minute
+
+// SmokeConfig holds settings for the smoke subcommand.
+type SmokeConfig struct {
+	GodotPath  string
+	ProjectDir string
+	Preset     string        // export preset to build with
+	ExportPath string        // absolute path of the build; empty means a temp directory
+	Run        string        // res:// scene or script to run; empty runs the main scene
+	Timeout    time.Duration // limit for the run of the build; 0 means none
+	LogFile    string        // keep the output of the export and the run at this path, if set
+}
+
+// ParseSmoke parses the arguments following "smoke".
+func ParseSmoke(args []string) (*SmokeConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner smoke", flag.ContinueOnError)
+
+	cfg := &SmokeConfig{}
+	var godotPath string
+	fs.StringVar(&godotPath, "godot-path", "", "path to Godot binary")
+	fs.StringVar(&cfg.Preset, "preset", "", "export preset from export_presets.cfg (required)")
+	fs.StringVar(&cfg.ExportPath, "export-path", "", "where to write the build (default: a temp directory)")
+	fs.StringVar(&cfg.Run, "run", "", "res:// scene or .gd script the build runs (default: the main scene)")
+	fs.DurationVar(&cfg.Timeout, "timeout", DefaultSmokeTimeout, "stop the build after this duration; 0 means no timeout")
+	fs.StringVar(&cfg.LogFile, "log-file", "", "keep the output of the export and of the build at this path")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner smoke --preset <name> [options] [project]\n\n")
+		fmt.Fprintf(os.Stderr, "Export the project with an export preset, run a scene or script with the exported\n")
+		fmt.Fprintf(os.Stderr, "build and print the result in the runner's JSON format.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  -p, --godot-path <path> path to Godot binary\n")
+		fmt.Fprintf(os.Stderr, "  --preset <name>      export preset from export_presets.cfg (required)\n")
+		fmt.Fprintf(os.Stderr, "  --export-path <path> where to write the build (default: a temp directory)\n")
+		fmt.Fprintf(os.Stderr, "  --run <res://path>   scene or .gd script the build runs (default: the main scene)\n")
+		fmt.Fprintf(os.Stderr, "  --timeout <duration> stop the build after this duration (default: %s); 0 means no timeout\n", DefaultSmokeTimeout)
+		fmt.Fprintf(os.Stderr, "  --log-file <path>    keep the output of the export and of the build at this path\n")
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+		fmt.Fprintf(os.Stderr, "\nThe project is found from the given path, or the current directory.\n")
+	}
+
+	addShortFlags(fs)
+	if err := fs.Parse(expandShortFlags(fs, args)); err != nil {
+		return nil, err
+	}
+	if cfg.Preset == "" {
+		return nil, errors.New("smoke requires --preset")
+	}
+	if cfg.Run != "" && !detector.IsResPath(cfg.Run) {
+		return nil, fmt.Errorf("invalid --run %q: want a res:// scene or script", cfg.Run)
+	}
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("invalid --timeout %s: must not be negative", cfg.Timeout)
+	}
+	if fs.NArg() > 1 {
+		return nil, fmt.Errorf("smoke takes one project path, got %d", fs.NArg())
+	}
+
+	project := "."
+	if fs.NArg() == 1 {
+		project = fs.Arg(0)
+	}
+	var err error
+	if cfg.ProjectDir, err = detector.FindProject(project); err != nil {
+		return nil, err
+	}
+	if cfg.ExportPath != "" {
+		if cfg.ExportPath, err = filepath.Abs(cfg.ExportPath); err != nil {
+			return nil, fmt.Errorf("invalid --export-path: %w", err)
+		}
+	}
+	if cfg.GodotPath, err = ResolveGodotPath(godotPath); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// DefaultExportName is the file name of a smoke build when --export-path is not
+// given, with the extension the Godot export of this OS expects.
+func DefaultExportName() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "game.exe"
+	case "darwin":
+		return "game.app"
+	}
+	return "game.x86_64"
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Snapshot actions.
+const (
+	SnapshotsList    = "list"
+	SnapshotsApprove = "approve"
+	SnapshotsPrune   = "prune"
+)
+
+// SnapshotsConfig holds settings for the snapshots subcommand.
+type SnapshotsConfig struct {
+	Action string   // SnapshotsList, SnapshotsApprove or SnapshotsPrune
+	Paths  []string // paths inside the project; the whole project is searched for snapshots
+	Filter []string // test name patterns limiting approve; empty means all
+	DryRun bool     // prune: only print what would be removed
+}
+
+// ParseSnapshots parses the arguments following "snapshots".
+func ParseSnapshots(args []string) (*SnapshotsConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner snapshots", flag.ContinueOnError)
+
+	var filter string
+	var dryRun bool
+	fs.StringVar(&filter, "filter", "", "approve: comma-separated test names to approve (e.g. test_render,Player*.test_*)")
+	fs.BoolVar(&dryRun, "dry-run", false, "prune: print the files that would be removed without removing them")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner snapshots (list | approve | prune) [options] [paths...]\n\n")
+		fmt.Fprintf(os.Stderr, "Manage approval test snapshots (<Suite>.<test>.approved.* / .received.*).\n\n")
+		fmt.Fprintf(os.Stderr, "  list                 print every snapshot and its status as JSON\n")
+		fmt.Fprintf(os.Stderr, "  approve              accept received snapshots as the new approved ones\n")
+		fmt.Fprintf(os.Stderr, "  prune                remove snapshots of tests that no longer exist\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  --filter <names>     approve: comma-separated test names to approve (e.g. test_render,Player*.test_*)\n")
+		fmt.Fprintf(os.Stderr, "  --dry-run            prune: print the files that would be removed without removing them\n")
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+		fmt.Fprintf(os.Stderr, "\nPaths select the project and the tests considered to exist; default is the current directory.\n")
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		return nil, errors.New("snapshots requires an action: list, approve or prune")
+	}
+	action := args[0]
+	if action == "-h" || action == "--help" || action == "-help" {
+		fs.Usage()
+		return nil, flag.ErrHelp
+	}
+	switch action {
+	case SnapshotsList, SnapshotsApprove, SnapshotsPrune:
+	default:
+		return nil, fmt.Errorf("unknown snapshots action %q; want list, approve or prune", action)
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	return &SnapshotsConfig{Action: action, Paths: paths, Filter: splitList(filter), DryRun: dryRun}, nil
+}
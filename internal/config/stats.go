@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatsFlaky is the stats report of flaky tests.
+const StatsFlaky = "flaky"
+
+// Formats of stats reports.
+const (
+	StatsText     = "text"
+	StatsMarkdown = "markdown"
+	StatsJSON     = "json"
+)
+
+// StatsConfig holds settings for the stats subcommand.
+type StatsConfig struct {
+	Report string        // StatsFlaky
+	Since  time.Duration // look at the runs started this long ago or later
+	Format string        // StatsText, StatsMarkdown or StatsJSON
+	Paths  []string      // paths inside the project; empty reports on every project
+}
+
+// ParseStats parses the arguments following "stats".
+func ParseStats(args []string) (*StatsConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner stats", flag.ContinueOnError)
+
+	var since string
+	cfg := &StatsConfig{}
+	fs.StringVar(&since, "since", "7d", "look at the runs of this period, e.g. 7d or 36h")
+	fs.StringVar(&cfg.Format, "format", StatsText, "output format: text, markdown or json")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner stats flaky [options] [paths...]\n\n")
+		fmt.Fprintf(os.Stderr, "Report statistics from the run history in the cache.\n\n")
+		fmt.Fprintf(os.Stderr, "  flaky                tests that both passed and failed, with their flake rate, last failure and owner\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  --since <period>     look at the runs of this period, e.g. 7d (default) or 36h\n")
+		fmt.Fprintf(os.Stderr, "  --format <name>      output format: text (default), markdown or json\n")
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+		fmt.Fprintf(os.Stderr, "\nPaths select the project; without them, the runs of every project are counted.\n")
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		return nil, errors.New("stats requires a report: flaky")
+	}
+	cfg.Report = args[0]
+	if cfg.Report == "-h" || cfg.Report == "--help" || cfg.Report == "-help" {
+		fs.Usage()
+		return nil, flag.ErrHelp
+	}
+	if cfg.Report != StatsFlaky {
+		return nil, fmt.Errorf("unknown stats report %q; want %s", cfg.Report, StatsFlaky)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+	d, err := ParsePeriod(since)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --since: %w", err)
+	}
+	cfg.Since = d
+	if cfg.Format != StatsText && cfg.Format != StatsMarkdown && cfg.Format != StatsJSON {
+		return nil, fmt.Errorf("unknown format %q; want %s, %s or %s", cfg.Format, StatsText, StatsMarkdown, StatsJSON)
+	}
+	cfg.Paths = fs.Args()
+	return cfg, nil
+}
+
+// ParsePeriod parses a positive duration that may also be given in days, e.g.
+// "7d", as stats --since takes it.
+func ParsePeriod(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number of days", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%q is not a positive period", s)
+	}
+	return d, nil
+}
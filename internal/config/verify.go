@@ -0,0 +1,40 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// VerifyConfig holds settings for the verify subcommand.
+type VerifyConfig struct {
+	Key  string // Ed25519 public key (PEM) the result must be signed with; empty checks the digest only
+	File string // JSON result to check; empty or "-" reads stdin
+}
+
+// ParseVerify parses the arguments following "verify".
+func ParseVerify(args []string) (*VerifyConfig, error) {
+	fs := flag.NewFlagSet("gdunit4-test-runner verify", flag.ContinueOnError)
+
+	cfg := &VerifyConfig{}
+	fs.StringVar(&cfg.Key, "key", "", "require a signature by this Ed25519 public key (PEM)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: gdunit4-test-runner verify [--key <public.pem>] [result.json]\n\n")
+		fmt.Fprintf(os.Stderr, "Check the integrity digest, and with --key the signature, of a JSON result written with --sign-output.\n")
+		fmt.Fprintf(os.Stderr, "Reads stdin when no file is given. Exits 0 if valid, 1 if not.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  --key <path>         require a signature by this Ed25519 public key (PEM)\n")
+		fmt.Fprintf(os.Stderr, "  --help               show this help\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return nil, fmt.Errorf("verify takes one result file, got %d", fs.NArg())
+	}
+	cfg.File = fs.Arg(0)
+	return cfg, nil
+}
@@ -0,0 +1,187 @@
+// Package coverage aggregates GDScript line coverage and writes Cobertura and lcov reports.
+//
+// The runner does not instrument scripts itself. A coverage addon running inside Godot
+// writes its data to the directory named by the GDUNIT4_RUNNER_COVERAGE_DIR environment
+// variable, either as lcov tracefiles (*.info, *.lcov) or as JSON files (*.json) of the form
+//
+//	{"files": {"res://scripts/player.gd": {"12": 3, "13": 0}}}
+//
+// mapping each line to its hit count. A line listed with 0 hits is executable but was
+// not run. All files found are merged.
+package coverage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvDir is the environment variable that tells the coverage addon where to write its data.
+const EnvDir = "GDUNIT4_RUNNER_COVERAGE_DIR"
+
+// Profile maps project-relative script paths ("scripts/player.gd") to line hit counts.
+type Profile map[string]map[int]int
+
+// Merge adds the hit counts of q to p.
+func (p Profile) Merge(q Profile) {
+	for file, lines := range q {
+		dst := p[file]
+		if dst == nil {
+			dst = map[int]int{}
+			p[file] = dst
+		}
+		for line, hits := range lines {
+			dst[line] += hits
+		}
+	}
+}
+
+// Files returns the profile's file paths in sorted order.
+func (p Profile) Files() []string {
+	files := make([]string, 0, len(p))
+	for f := range p {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Lines returns the number of executable and covered lines of file, or of the whole
+// profile if file is empty.
+func (p Profile) Lines(file string) (valid, covered int) {
+	for f, lines := range p {
+		if file != "" && f != file {
+			continue
+		}
+		for _, hits := range lines {
+			valid++
+			if hits > 0 {
+				covered++
+			}
+		}
+	}
+	return valid, covered
+}
+
+// ReadDir reads and merges every coverage file in dir. Paths are made relative to
+// projectDir and scripts under addons/ are dropped, so the test framework and the
+// coverage addon do not count towards the project's coverage.
+func ReadDir(dir, projectDir string) (Profile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage dir: %w", err)
+	}
+
+	merged := Profile{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		var parse func(io.Reader) (Profile, error)
+		switch filepath.Ext(e.Name()) {
+		case ".info", ".lcov":
+			parse = ParseLCOV
+		case ".json":
+			parse = ParseJSON
+		default:
+			continue
+		}
+
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		p, err := parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse coverage file %s: %w", e.Name(), err)
+		}
+
+		for file, lines := range p {
+			rel := normalize(file, projectDir)
+			if strings.HasPrefix(rel, "addons/") {
+				continue
+			}
+			merged.Merge(Profile{rel: lines})
+		}
+	}
+	return merged, nil
+}
+
+// normalize converts a res://, absolute or relative script path to a slash-separated
+// path relative to projectDir.
+func normalize(file, projectDir string) string {
+	if rest, ok := strings.CutPrefix(file, "res://"); ok {
+		return rest
+	}
+	if filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(projectDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(file))
+}
+
+// ParseJSON parses the JSON coverage format described in the package documentation.
+func ParseJSON(r io.Reader) (Profile, error) {
+	var doc struct {
+		Files map[string]map[string]int `json:"files"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	p := Profile{}
+	for file, lines := range doc.Files {
+		m := map[int]int{}
+		for k, hits := range lines {
+			line, err := strconv.Atoi(k)
+			if err != nil || line <= 0 {
+				return nil, fmt.Errorf("invalid line number %q in %s", k, file)
+			}
+			m[line] = hits
+		}
+		p[file] = m
+	}
+	return p, nil
+}
+
+// ParseLCOV parses the SF and DA records of an lcov tracefile; other records are ignored.
+func ParseLCOV(r io.Reader) (Profile, error) {
+	p := Profile{}
+	var lines map[int]int
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		rec := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(rec, "SF:"):
+			file := strings.TrimPrefix(rec, "SF:")
+			if lines = p[file]; lines == nil {
+				lines = map[int]int{}
+				p[file] = lines
+			}
+		case strings.HasPrefix(rec, "DA:"):
+			if lines == nil {
+				return nil, fmt.Errorf("DA record outside a file: %q", rec)
+			}
+			fields := strings.Split(strings.TrimPrefix(rec, "DA:"), ",")
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("malformed DA record: %q", rec)
+			}
+			line, err1 := strconv.Atoi(fields[0])
+			hits, err2 := strconv.Atoi(fields[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("malformed DA record: %q", rec)
+			}
+			lines[line] += hits
+		case rec == "end_of_record":
+			lines = nil
+		}
+	}
+	return p, scanner.Err()
+}
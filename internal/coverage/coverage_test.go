@@ -0,0 +1,174 @@
+package coverage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLCOV(t *testing.T) {
+	input := "TN:\nSF:res://scripts/a.gd\nDA:1,3\nDA:2,0\nLF:2\nLH:1\nend_of_record\nSF:/proj/b.gd\nDA:5,1\nend_of_record\n"
+	got, err := ParseLCOV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Profile{
+		"res://scripts/a.gd": {1: 3, 2: 0},
+		"/proj/b.gd":         {5: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseLCOV = %v, want %v", got, want)
+	}
+}
+
+func TestParseLCOV_Malformed(t *testing.T) {
+	for _, input := range []string{"DA:1,1\n", "SF:a.gd\nDA:x,1\n", "SF:a.gd\nDA:1\n"} {
+		if _, err := ParseLCOV(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseLCOV(%q) expected error, got nil", input)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	got, err := ParseJSON(strings.NewReader(`{"files": {"res://a.gd": {"3": 1, "4": 0}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Profile{"res://a.gd": {3: 1, 4: 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseJSON = %v, want %v", got, want)
+	}
+
+	if _, err := ParseJSON(strings.NewReader(`{"files": {"a.gd": {"x": 1}}}`)); err == nil {
+		t.Error("expected error for invalid line number, got nil")
+	}
+}
+
+func TestReadDir_MergesAndNormalizes(t *testing.T) {
+	project := t.TempDir()
+	dir := t.TempDir()
+	lcov := "SF:" + filepath.Join(project, "scripts", "a.gd") + "\nDA:1,1\nDA:2,0\nend_of_record\n"
+	os.WriteFile(filepath.Join(dir, "one.info"), []byte(lcov), 0o644)
+	os.WriteFile(filepath.Join(dir, "two.json"), []byte(`{"files": {"res://scripts/a.gd": {"2": 4}, "res://addons/gdUnit4/src/x.gd": {"1": 1}}}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("junk"), 0o644)
+
+	got, err := ReadDir(dir, project)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Profile{"scripts/a.gd": {1: 1, 2: 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadDir = %v, want %v", got, want)
+	}
+}
+
+func TestWriteLCOV(t *testing.T) {
+	p := Profile{"b.gd": {2: 0, 1: 5}, "a.gd": {7: 1}}
+	var buf bytes.Buffer
+	if err := WriteLCOV(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	want := "SF:a.gd\nDA:7,1\nLF:1\nLH:1\nend_of_record\nSF:b.gd\nDA:1,5\nDA:2,0\nLF:2\nLH:1\nend_of_record\n"
+	if buf.String() != want {
+		t.Errorf("WriteLCOV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteCobertura(t *testing.T) {
+	now = func() time.Time { return time.Unix(1700000000, 0) }
+	t.Cleanup(func() { now = time.Now })
+
+	p := Profile{
+		"scripts/player.gd": {1: 1, 2: 0},
+		"scripts/enemy.gd":  {1: 1},
+		"main.gd":           {3: 0},
+	}
+	var buf bytes.Buffer
+	if err := WriteCobertura(&buf, p, "/proj"); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`<coverage line-rate="0.5000" branch-rate="0" lines-covered="2" lines-valid="4"`,
+		`timestamp="1700000000"`,
+		`<source>/proj</source>`,
+		`<package name="." line-rate="0.0000"`,
+		`<package name="scripts" line-rate="0.6667"`,
+		`<class name="player" filename="scripts/player.gd" line-rate="0.5000"`,
+		`<line number="2" hits="0"></line>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Cobertura output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteFile_ChoosesFormatByExtension(t *testing.T) {
+	dir := t.TempDir()
+	p := Profile{"a.gd": {1: 1}}
+	for name, prefix := range map[string]string{"cov.info": "SF:", "cov.xml": "<?xml"} {
+		path := filepath.Join(dir, name)
+		if err := WriteFile(path, p, dir); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := os.ReadFile(path)
+		if !strings.HasPrefix(string(data), prefix) {
+			t.Errorf("%s starts with %q, want %q", name, string(data[:10]), prefix)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	p := Profile{
+		"main.gd":               {1: 1, 2: 1},
+		"scripts/player.gd":     {1: 1, 2: 0},
+		"scripts/ai/enemy.gd":   {1: 0, 2: 0},
+		"scripts/ai/pathing.gd": {1: 1, 2: 1},
+	}
+
+	tests := []struct {
+		name       string
+		min        float64
+		packages   map[string]float64
+		wantPassed bool
+		wantDirs   []string
+	}{
+		{"no thresholds", 0, nil, true, []string{".", "scripts", "scripts/ai"}},
+		{"global met", 62.5, nil, true, []string{".", "scripts", "scripts/ai"}},
+		{"global missed", 70, nil, false, []string{".", "scripts", "scripts/ai"}},
+		{"package tree missed", 0, map[string]float64{"res://scripts/": 60}, false, []string{".", "scripts", "scripts/ai"}},
+		{"package met", 0, map[string]float64{"scripts/ai": 50}, true, []string{".", "scripts", "scripts/ai"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum := Summarize(p, tt.min, tt.packages)
+			if sum.LinesValid != 8 || sum.LinesCovered != 5 || sum.Percent != 62.5 {
+				t.Errorf("totals = %d/%d (%g%%), want 5/8 (62.5%%)", sum.LinesCovered, sum.LinesValid, sum.Percent)
+			}
+			if sum.Passed != tt.wantPassed {
+				t.Errorf("Passed = %v, want %v", sum.Passed, tt.wantPassed)
+			}
+			var dirs []string
+			for _, d := range sum.Directories {
+				dirs = append(dirs, d.Path)
+			}
+			if !reflect.DeepEqual(dirs, tt.wantDirs) {
+				t.Errorf("directories = %v, want %v", dirs, tt.wantDirs)
+			}
+		})
+	}
+}
+
+func TestSummarize_PackageTreeCountsSubdirs(t *testing.T) {
+	p := Profile{"scripts/player.gd": {1: 1, 2: 0}, "scripts/ai/enemy.gd": {1: 0, 2: 0}}
+	sum := Summarize(p, 0, map[string]float64{"scripts": 50})
+	d := sum.Directories[0]
+	if d.Path != "scripts" || d.LinesValid != 4 || d.LinesCovered != 1 || d.Percent != 25 || d.Passed {
+		t.Errorf("scripts = %+v, want 1/4 lines, 25%%, not passed", d)
+	}
+}
@@ -0,0 +1,83 @@
+package coverage
+
+import (
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Summarize computes the coverage summary of p and checks it against min (overall,
+// in percent; 0 disables the check) and packages (per directory tree). Directories
+// holding scripts are listed with their own lines; a directory with a threshold is
+// listed with the lines of its whole tree.
+func Summarize(p Profile, min float64, packages map[string]float64) *report.Coverage {
+	valid, covered := p.Lines("")
+	sum := &report.Coverage{
+		LinesValid:   valid,
+		LinesCovered: covered,
+		Percent:      percent(covered, valid),
+		Min:          min,
+		Directories:  []report.CoverageDir{},
+	}
+	sum.Passed = sum.Percent >= min
+
+	thresholds := map[string]float64{}
+	for pkg, pkgMin := range packages {
+		pkg = strings.Trim(path.Clean("/"+strings.TrimPrefix(pkg, "res://")), "/")
+		if pkg == "" {
+			pkg = "."
+		}
+		thresholds[pkg] = pkgMin
+	}
+
+	dirs := map[string]*report.CoverageDir{}
+	for file := range p {
+		dir := path.Dir(file)
+		if _, ok := thresholds[dir]; ok {
+			continue // counted below as a tree
+		}
+		d := dirs[dir]
+		if d == nil {
+			d = &report.CoverageDir{Path: dir, Passed: true}
+			dirs[dir] = d
+		}
+		v, c := p.Lines(file)
+		d.LinesValid += v
+		d.LinesCovered += c
+	}
+
+	for pkg, pkgMin := range thresholds {
+		d := &report.CoverageDir{Path: pkg, Min: pkgMin}
+		for file := range p {
+			if pkg == "." || file == pkg || strings.HasPrefix(file, pkg+"/") {
+				v, c := p.Lines(file)
+				d.LinesValid += v
+				d.LinesCovered += c
+			}
+		}
+		dirs[pkg] = d
+	}
+
+	for _, d := range dirs {
+		d.Percent = percent(d.LinesCovered, d.LinesValid)
+		d.Passed = d.Percent >= d.Min
+		if !d.Passed {
+			sum.Passed = false
+		}
+		sum.Directories = append(sum.Directories, *d)
+	}
+	sort.Slice(sum.Directories, func(i, j int) bool { return sum.Directories[i].Path < sum.Directories[j].Path })
+	return sum
+}
+
+// percent returns covered/valid as a percentage rounded to two decimals.
+// An empty set counts as fully covered.
+func percent(covered, valid int) float64 {
+	if valid == 0 {
+		return 100
+	}
+	return math.Round(float64(covered)*10000/float64(valid)) / 100
+}
@@ -0,0 +1,178 @@
+package coverage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// now is stubbed in tests to make Cobertura timestamps deterministic.
+var now = time.Now
+
+// WriteFile writes p to filename as lcov if it ends in .info or .lcov, and as
+// Cobertura XML otherwise.
+func WriteFile(filename string, p Profile, projectDir string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create coverage report: %w", err)
+	}
+	switch filepath.Ext(filename) {
+	case ".info", ".lcov":
+		err = WriteLCOV(f, p)
+	default:
+		err = WriteCobertura(f, p, projectDir)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// WriteLCOV writes p as an lcov tracefile with project-relative paths.
+func WriteLCOV(w io.Writer, p Profile) error {
+	var b strings.Builder
+	for _, file := range p.Files() {
+		fmt.Fprintf(&b, "SF:%s\n", file)
+		for _, line := range sortedLines(p[file]) {
+			fmt.Fprintf(&b, "DA:%d,%d\n", line, p[file][line])
+		}
+		valid, covered := p.Lines(file)
+		fmt.Fprintf(&b, "LF:%d\nLH:%d\nend_of_record\n", valid, covered)
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write lcov report: %w", err)
+	}
+	return nil
+}
+
+// ---- Cobertura XML structures ----
+
+type cobertura struct {
+	XMLName         xml.Name       `xml:"coverage"`
+	LineRate        string         `xml:"line-rate,attr"`
+	BranchRate      string         `xml:"branch-rate,attr"`
+	LinesCovered    int            `xml:"lines-covered,attr"`
+	LinesValid      int            `xml:"lines-valid,attr"`
+	BranchesCovered int            `xml:"branches-covered,attr"`
+	BranchesValid   int            `xml:"branches-valid,attr"`
+	Complexity      string         `xml:"complexity,attr"`
+	Version         string         `xml:"version,attr"`
+	Timestamp       int64          `xml:"timestamp,attr"`
+	Sources         []string       `xml:"sources>source"`
+	Packages        []coberturaPkg `xml:"packages>package"`
+}
+
+type coberturaPkg struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity string           `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   string          `xml:"line-rate,attr"`
+	BranchRate string          `xml:"branch-rate,attr"`
+	Complexity string          `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// WriteCobertura writes p as a Cobertura XML report. Each directory becomes a
+// package and each script a class; projectDir is listed as the source root.
+func WriteCobertura(w io.Writer, p Profile, projectDir string) error {
+	valid, covered := p.Lines("")
+	doc := cobertura{
+		LineRate:     rate(covered, valid),
+		BranchRate:   "0",
+		LinesCovered: covered,
+		LinesValid:   valid,
+		Complexity:   "0",
+		Version:      "gdunit4-test-runner",
+		Timestamp:    now().Unix(),
+		Sources:      []string{projectDir},
+	}
+
+	pkgIndex := map[string]int{}
+	for _, file := range p.Files() {
+		dir := path.Dir(file)
+		i, ok := pkgIndex[dir]
+		if !ok {
+			i = len(doc.Packages)
+			pkgIndex[dir] = i
+			doc.Packages = append(doc.Packages, coberturaPkg{
+				Name:       strings.ReplaceAll(dir, "/", "."),
+				BranchRate: "0",
+				Complexity: "0",
+			})
+		}
+
+		fileValid, fileCovered := p.Lines(file)
+		class := coberturaClass{
+			Name:       strings.TrimSuffix(path.Base(file), path.Ext(file)),
+			Filename:   file,
+			LineRate:   rate(fileCovered, fileValid),
+			BranchRate: "0",
+			Complexity: "0",
+		}
+		for _, line := range sortedLines(p[file]) {
+			class.Lines = append(class.Lines, coberturaLine{Number: line, Hits: p[file][line]})
+		}
+		doc.Packages[i].Classes = append(doc.Packages[i].Classes, class)
+	}
+
+	for i := range doc.Packages {
+		var pv, pc int
+		for _, c := range doc.Packages[i].Classes {
+			v, cv := p.Lines(c.Filename)
+			pv += v
+			pc += cv
+		}
+		doc.Packages[i].LineRate = rate(pc, pv)
+	}
+
+	if _, err := io.WriteString(w, xml.Header+`<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`+"\n"); err != nil {
+		return fmt.Errorf("failed to write Cobertura report: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write Cobertura report: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write Cobertura report: %w", err)
+	}
+	return nil
+}
+
+// rate formats covered/valid as a Cobertura rate; an empty set counts as fully covered.
+func rate(covered, valid int) string {
+	if valid == 0 {
+		return "1"
+	}
+	return strconv.FormatFloat(float64(covered)/float64(valid), 'f', 4, 64)
+}
+
+// sortedLines returns the line numbers of lines in ascending order.
+func sortedLines(lines map[int]int) []int {
+	nums := make([]int, 0, len(lines))
+	for n := range lines {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	return nums
+}
@@ -0,0 +1,189 @@
+// Package crashdump makes crashing Godot processes leave a dump (a core file on
+// Linux and macOS, a Windows Error Reporting minidump on Windows) and collects
+// the dump of a crashed run for post-mortem debugging.
+package crashdump
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Process is a finished Godot process whose dumps are collected.
+type Process struct {
+	PID   int
+	Exe   string    // path of the Godot binary
+	Dir   string    // working directory, where relative core patterns put the core
+	Start time.Time // dumps older than this belong to other processes
+}
+
+// LocalDumpsKey is the registry key that turns on local dumps of Windows Error
+// Reporting.
+const LocalDumpsKey = `HKLM\SOFTWARE\Microsoft\Windows\Windows Error Reporting\LocalDumps`
+
+// envVarRe matches a %VARIABLE% of a REG_EXPAND_SZ registry value.
+var envVarRe = regexp.MustCompile(`%[^%]+%`)
+
+// ErrPiped is returned by Collect when the kernel hands core dumps to a
+// program (core_pattern starting with "|") the runner cannot read them from.
+var ErrPiped = errors.New("core dumps are piped to a crash handler")
+
+// Collect moves the dumps p left into dst and returns their paths; none if it
+// left none.
+func Collect(p Process, dst string) ([]string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return collectLinux(p, dst)
+	case "darwin":
+		return move(matching(filepath.Join("/cores", "core."+strconv.Itoa(p.PID)), p.Start), dst)
+	case "windows":
+		return move(matching(filepath.Join(dumpFolder(), filepath.Base(p.Exe)+"."+strconv.Itoa(p.PID)+".dmp"), p.Start), dst)
+	}
+	return nil, nil
+}
+
+func collectLinux(p Process, dst string) ([]string, error) {
+	data, err := os.ReadFile("/proc/sys/kernel/core_pattern")
+	if err != nil {
+		return nil, err
+	}
+	pattern := strings.TrimSpace(string(data))
+	if handler, ok := strings.CutPrefix(pattern, "|"); ok {
+		if strings.Contains(handler, "systemd-coredump") {
+			return coredumpctl(p, dst)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrPiped, handler)
+	}
+	usesPID, _ := os.ReadFile("/proc/sys/kernel/core_uses_pid")
+	glob := corePattern(pattern, strings.TrimSpace(string(usesPID)) == "1", p.PID)
+	if !filepath.IsAbs(glob) {
+		glob = filepath.Join(p.Dir, glob)
+	}
+	return move(matching(glob, p.Start), dst)
+}
+
+// specifierRe matches a %-specifier of core_pattern.
+var specifierRe = regexp.MustCompile(`%.`)
+
+// corePattern turns a core_pattern (see core(5)) into a glob matching the core
+// of pid: %p and %P become the PID, %% a percent sign and every other
+// specifier (executable, time, host name, ...) a wildcard. With usesPID, as
+// /proc/sys/kernel/core_uses_pid, a pattern without %p gets ".<pid>" appended.
+func corePattern(pattern string, usesPID bool, pid int) string {
+	hasPID := false
+	glob := specifierRe.ReplaceAllStringFunc(pattern, func(s string) string {
+		switch s {
+		case "%p", "%P":
+			hasPID = true
+			return strconv.Itoa(pid)
+		case "%%":
+			return "%"
+		}
+		return "*"
+	})
+	if usesPID && !hasPID {
+		glob += "." + strconv.Itoa(pid)
+	}
+	return glob
+}
+
+// coredumpctl extracts the core of p from systemd-coredump's store.
+func coredumpctl(p Process, dst string) ([]string, error) {
+	if _, err := exec.LookPath("coredumpctl"); err != nil {
+		return nil, fmt.Errorf("%w: systemd-coredump, and coredumpctl is not installed", ErrPiped)
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return nil, err
+	}
+	out := filepath.Join(dst, "core."+strconv.Itoa(p.PID))
+	// systemd-coredump stores the core asynchronously; give it a moment.
+	var err error
+	for range 5 {
+		var msg []byte
+		msg, err = exec.Command("coredumpctl", "dump", "--output="+out, strconv.Itoa(p.PID)).CombinedOutput()
+		if err == nil {
+			return []string{out}, nil
+		}
+		err = fmt.Errorf("coredumpctl: %s", strings.TrimSpace(string(msg)))
+		time.Sleep(time.Second)
+	}
+	os.Remove(out)
+	return nil, err
+}
+
+// dumpFolder returns where Windows Error Reporting writes local dumps: the
+// DumpFolder of its LocalDumps key, or its default %LOCALAPPDATA%\CrashDumps.
+func dumpFolder() string {
+	out, err := exec.Command("reg", "query", LocalDumpsKey, "/v", "DumpFolder").Output()
+	if err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && fields[0] == "DumpFolder" {
+				return envVarRe.ReplaceAllStringFunc(strings.Join(fields[2:], " "), func(v string) string {
+					return os.Getenv(strings.Trim(v, "%"))
+				})
+			}
+		}
+	}
+	return filepath.Join(os.Getenv("LOCALAPPDATA"), "CrashDumps")
+}
+
+// matching returns the files matching glob modified at or after since.
+func matching(glob string, since time.Time) []string {
+	paths, _ := filepath.Glob(glob)
+	var found []string
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() && !info.ModTime().Before(since.Truncate(time.Second)) {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+// move moves paths into dst and returns their new paths.
+func move(paths []string, dst string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return nil, err
+	}
+	var moved []string
+	for _, p := range paths {
+		to := filepath.Join(dst, filepath.Base(p))
+		if err := os.Rename(p, to); err != nil {
+			// Cores often live on another file system than the reports.
+			if err := copyFile(p, to); err != nil {
+				return moved, err
+			}
+			os.Remove(p)
+		}
+		moved = append(moved, to)
+	}
+	return moved, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
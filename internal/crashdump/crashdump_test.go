@@ -0,0 +1,59 @@
+package crashdump
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCorePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		usesPID bool
+		want    string
+	}{
+		{"core", false, "core"},
+		{"core", true, "core.42"},
+		{"/var/crash/core.%e.%p.%t", true, "/var/crash/core.*.42.*"},
+		{"/tmp/cores/%h-%P", false, "/tmp/cores/*-42"},
+		{"core-100%%", true, "core-100%.42"},
+	}
+	for _, tt := range tests {
+		if got := corePattern(tt.pattern, tt.usesPID, 42); got != tt.want {
+			t.Errorf("corePattern(%q, %v) = %q, want %q", tt.pattern, tt.usesPID, got, tt.want)
+		}
+	}
+}
+
+func TestMoveMatching(t *testing.T) {
+	dir, dst := t.TempDir(), filepath.Join(t.TempDir(), "crash_dumps")
+	start := time.Now()
+	for _, name := range []string{"core.42", "core.7"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("ELF"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := filepath.Join(dir, "core.43")
+	if err := os.WriteFile(stale, []byte("ELF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := start.Add(-time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := move(matching(filepath.Join(dir, "core.4*"), start), dst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(dst, "core.42"); len(moved) != 1 || moved[0] != want {
+		t.Fatalf("moved = %q, want [%q]", moved, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "core.42")); !os.IsNotExist(err) {
+		t.Errorf("core.42 was not moved: %v", err)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Errorf("a dump older than the run was moved: %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package crashdump
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Enable lets processes started from now on write core dumps, raising the
+// soft core size limit of the runner, which Godot inherits, to its hard limit.
+func Enable() error {
+	var lim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_CORE, &lim); err != nil {
+		return err
+	}
+	if lim.Max == 0 {
+		return errors.New("core dumps are disabled by the hard limit (ulimit -Hc 0)")
+	}
+	lim.Cur = lim.Max
+	return syscall.Setrlimit(syscall.RLIMIT_CORE, &lim)
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package crashdump
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Enable checks that Windows Error Reporting writes local dumps. Turning them
+// on takes an administrator, so it is left to the machine's setup.
+func Enable() error {
+	if exec.Command("reg", "query", LocalDumpsKey).Run() != nil {
+		return fmt.Errorf(`Windows Error Reporting does not write local dumps; as administrator, run: reg add "%s" /v DumpType /t REG_DWORD /d 2 /f`, LocalDumpsKey)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package detector
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultCmdTool is the res:// path of gdUnit4's command line tool in a project
+// that installs the addon where the Asset Library puts it.
+const defaultCmdTool = "res://addons/gdUnit4/bin/GdUnitCmdTool.gd"
+
+// cmdToolName is the file name of gdUnit4's command line tool.
+const cmdToolName = "GdUnitCmdTool.gd"
+
+// errFound stops the walk of findCmdTool at the first match.
+var errFound = errors.New("found")
+
+// findCmdTool returns the res:// path of GdUnitCmdTool.gd in projectDir. An
+// addons/gdUnit4/ directory means the default location; otherwise the addon is
+// looked for under addons/*/bin/ and then anywhere in the project, for projects
+// that vendor it under another name. Godot's .godot/ cache, hidden directories
+// and directories with a .gdignore file are not searched.
+func findCmdTool(projectDir string) (string, error) {
+	if info, err := os.Stat(filepath.Join(projectDir, "addons", "gdUnit4")); err == nil && info.IsDir() {
+		return defaultCmdTool, nil
+	}
+
+	// Listed rather than globbed; see report.FindReportXMLIn.
+	addons, _ := os.ReadDir(filepath.Join(projectDir, "addons"))
+	for _, e := range addons {
+		p := filepath.Join(projectDir, "addons", e.Name(), "bin", cmdToolName)
+		if _, err := os.Stat(p); err == nil {
+			return toResPath(projectDir, p)
+		}
+	}
+
+	var found string
+	err := filepath.WalkDir(projectDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != projectDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(p, ".gdignore")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == cmdToolName {
+			found = p
+			return errFound
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errFound) {
+		return "", err
+	}
+	if found == "" {
+		return "", fmt.Errorf("addons/gdUnit4/ not found under %s, and no %s elsewhere in the project", projectDir, cmdToolName)
+	}
+	return toResPath(projectDir, found)
+}
@@ -0,0 +1,46 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindCmdTool(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"default", []string{"addons/gdUnit4/plugin.cfg"}, "res://addons/gdUnit4/bin/GdUnitCmdTool.gd"},
+		{"renamed addon", []string{"addons/gdunit4-5.0/bin/GdUnitCmdTool.gd"}, "res://addons/gdunit4-5.0/bin/GdUnitCmdTool.gd"},
+		{"vendored", []string{"third_party/gdUnit4/bin/GdUnitCmdTool.gd"}, "res://third_party/gdUnit4/bin/GdUnitCmdTool.gd"},
+		{"cache and ignored dirs skipped", []string{
+			".godot/imported/GdUnitCmdTool.gd",
+			"export/.gdignore",
+			"export/GdUnitCmdTool.gd",
+			"vendor/gdUnit4/bin/GdUnitCmdTool.gd",
+		}, "res://vendor/gdUnit4/bin/GdUnitCmdTool.gd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, f := range tt.files {
+				p := filepath.Join(root, filepath.FromSlash(f))
+				if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := findCmdTool(root)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("findCmdTool = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
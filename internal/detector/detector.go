@@ -4,55 +4,194 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
 // Result holds the outcome of project detection.
 type Result struct {
 	ProjectDir string   // absolute path to the directory containing project.godot
 	ResPaths   []string // res://-relative paths for the test targets
+	CmdTool    string   // res:// path of gdUnit4's GdUnitCmdTool.gd; see findCmdTool
 }
 
 // Detect finds the Godot project root for testPaths and converts each path to a res:// path.
-// It walks up from the first path looking for project.godot, then verifies addons/gdUnit4/ exists.
-// All paths must belong to the same Godot project.
+// It walks up from the first path looking for project.godot, then locates the gdUnit4 addon.
+// All paths must belong to the same Godot project. A path may also be given as
+// res://..., in which case the project is searched from the working directory,
+// and any path may be a glob pattern (see glob), expanded before conversion.
 func Detect(testPaths []string) (*Result, error) {
 	if len(testPaths) == 0 {
 		return nil, errors.New("no test paths provided")
 	}
 
-	// Use the first path to determine project root.
-	firstAbs, err := filepath.Abs(testPaths[0])
+	testPaths, err := expandGlobs(testPaths, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the first path to determine project root; a res:// path says nothing
+	// about where the project is, so search from the working directory instead.
+	start := testPaths[0]
+	if IsResPath(start) {
+		start = "."
+	}
+	firstAbs, err := absolute(start)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
 	}
 
-	projectDir, err := findProjectRoot(firstAbs)
+	projectDir, _, err := projectOf(firstAbs)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := verifyGdUnit4(projectDir); err != nil {
+	cmdTool, err := findCmdTool(projectDir)
+	if err != nil {
 		return nil, err
 	}
 
 	resPaths := make([]string, 0, len(testPaths))
 	for _, p := range testPaths {
-		absPath, err := filepath.Abs(p)
+		if IsResPath(p) {
+			matched, err := resolveResPath(projectDir, p)
+			if err != nil {
+				return nil, err
+			}
+			resPaths = append(resPaths, matched...)
+			continue
+		}
+		absPath, err := absolute(p)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve absolute path for %s: %w", p, err)
 		}
 
 		// Verify this path belongs to the same project by finding its root.
-		root, err := findProjectRoot(absPath)
+		root, resPath, err := projectOf(absPath)
 		if err != nil {
 			return nil, fmt.Errorf("path %s: %w", p, err)
 		}
-		if root != projectDir {
+		if !samePath(root, projectDir) {
 			return nil, fmt.Errorf("path %s belongs to a different Godot project (%s), expected %s", p, root, projectDir)
 		}
+		resPaths = append(resPaths, resPath)
+	}
 
-		resPath, err := toResPath(projectDir, absPath)
+	return &Result{
+		ProjectDir: projectDir,
+		ResPaths:   resPaths,
+		CmdTool:    cmdTool,
+	}, nil
+}
+
+// Group splits testPaths by the Godot project each belongs to, in the order the
+// projects first appear, and detects each group like Detect. It is how a run
+// spanning several projects of a monorepo is planned.
+func Group(testPaths []string) ([]*Result, error) {
+	if len(testPaths) == 0 {
+		return nil, errors.New("no test paths provided")
+	}
+	testPaths, err := expandGlobs(testPaths, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	byRoot := map[string][]string{}
+	for _, p := range testPaths {
+		start := p
+		if IsResPath(p) {
+			start = "."
+		}
+		absPath, err := absolute(start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve absolute path for %s: %w", p, err)
+		}
+		root, _, err := projectOf(absPath)
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", p, err)
+		}
+		key := pathKey(root)
+		if _, ok := byRoot[key]; !ok {
+			roots = append(roots, key)
+		}
+		byRoot[key] = append(byRoot[key], p)
+	}
+
+	results := make([]*Result, 0, len(roots))
+	for _, key := range roots {
+		result, err := Detect(byRoot[key])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// IsResPath reports whether p is a Godot res:// path rather than a filesystem path.
+func IsResPath(p string) bool {
+	return strings.HasPrefix(p, "res://")
+}
+
+// DetectIn is like Detect but takes projectDir as the project root instead of
+// searching for it, and resolves relative testPaths against projectDir rather
+// than the working directory. A path is accepted if it lies inside projectDir
+// with symlinks resolved or as spelled. An empty projectDir falls back to Detect.
+func DetectIn(projectDir string, testPaths []string) (*Result, error) {
+	if projectDir == "" {
+		return Detect(testPaths)
+	}
+	if len(testPaths) == 0 {
+		return nil, errors.New("no test paths provided")
+	}
+
+	lexicalDir, err := absolute(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+	projectDir = realPath(lexicalDir)
+	testPaths, err = expandGlobs(testPaths, lexicalDir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "project.godot")); err != nil {
+		return nil, fmt.Errorf("project.godot not found in %s", projectDir)
+	}
+	cmdTool, err := findCmdTool(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	resPaths := make([]string, 0, len(testPaths))
+	for _, p := range testPaths {
+		if IsResPath(p) {
+			matched, err := resolveResPath(projectDir, p)
+			if err != nil {
+				return nil, err
+			}
+			resPaths = append(resPaths, matched...)
+			continue
+		}
+		absPath := p
+		if !filepath.IsAbs(p) {
+			absPath = filepath.Join(lexicalDir, p)
+		}
+		absPath = normalizePath(filepath.Clean(absPath))
+		if _, err := os.Stat(absPath); err != nil {
+			return nil, fmt.Errorf("path %s: cannot access path: %w", p, err)
+		}
+		// Like projectOf: resolved symlinks first, then the path as spelled.
+		var resPath string
+		switch real := realPath(absPath); {
+		case within(projectDir, real):
+			resPath, err = toResPath(projectDir, real)
+		case within(lexicalDir, absPath):
+			resPath, err = toResPath(lexicalDir, absPath)
+		default:
+			return nil, fmt.Errorf("path %s is outside the project %s", p, projectDir)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -62,9 +201,53 @@ func Detect(testPaths []string) (*Result, error) {
 	return &Result{
 		ProjectDir: projectDir,
 		ResPaths:   resPaths,
+		CmdTool:    cmdTool,
 	}, nil
 }
 
+// projectOf returns the project root of absPath and absPath as a res:// path of
+// that project. Symlinks are resolved first, so that a project checked out
+// through a symlinked workspace has a single root however its paths are spelled.
+// If the resolved path lies in no project, as with a directory symlinked into
+// the project from elsewhere, the path is looked up as spelled instead.
+func projectOf(absPath string) (root, resPath string, err error) {
+	real := realPath(absPath)
+	if root, err := findProjectRoot(real); err == nil {
+		resPath, err := toResPath(root, real)
+		return root, resPath, err
+	}
+	lexical, err := findProjectRoot(absPath)
+	if err != nil {
+		return "", "", err
+	}
+	resPath, err = toResPath(lexical, absPath)
+	return realPath(lexical), resPath, err
+}
+
+// within reports whether p is dir or inside it.
+func within(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// realPath returns p with symlinks resolved, or p itself if they cannot be.
+func realPath(p string) string {
+	if real, err := filepath.EvalSymlinks(p); err == nil {
+		return normalizePath(real)
+	}
+	return p
+}
+
+// FindProject returns the Godot project directory containing p, for commands
+// that need a project but not gdUnit4.
+func FindProject(p string) (string, error) {
+	abs, err := absolute(p)
+	if err != nil {
+		return "", err
+	}
+	return findProjectRoot(abs)
+}
+
 // findProjectRoot walks up from startPath looking for a directory containing project.godot.
 func findProjectRoot(startPath string) (string, error) {
 	// Start from startPath itself; if it's a file, start from its directory.
@@ -94,14 +277,37 @@ func findProjectRoot(startPath string) (string, error) {
 	return "", errors.New("project.godot not found; point the path to a subdirectory of your Godot project")
 }
 
-// verifyGdUnit4 checks that addons/gdUnit4/ exists under projectDir.
-func verifyGdUnit4(projectDir string) error {
-	addonPath := filepath.Join(projectDir, "addons", "gdUnit4")
-	info, err := os.Stat(addonPath)
-	if err != nil || !info.IsDir() {
-		return fmt.Errorf("addons/gdUnit4/ not found under %s", projectDir)
+// resolveResPath verifies that the res:// path p names a file or directory
+// inside projectDir and returns it in the form toResPath produces. A pattern is
+// expanded to the res:// paths it matches.
+func resolveResPath(projectDir, p string) ([]string, error) {
+	rel := path.Clean(strings.TrimPrefix(p, "res://"))
+	if rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+		return nil, fmt.Errorf("path %s is outside the project %s", p, projectDir)
+	}
+	if !hasMeta(rel) {
+		if _, err := os.Stat(filepath.Join(projectDir, filepath.FromSlash(rel))); err != nil {
+			return nil, fmt.Errorf("path %s not found in project %s", p, projectDir)
+		}
+		return []string{"res://" + rel}, nil
+	}
+
+	matches, err := glob(filepath.Join(projectDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("pattern %s matches nothing", p)
+	}
+	resPaths := make([]string, 0, len(matches))
+	for _, m := range matches {
+		resPath, err := toResPath(projectDir, m)
+		if err != nil {
+			return nil, err
+		}
+		resPaths = append(resPaths, resPath)
 	}
-	return nil
+	return resPaths, nil
 }
 
 // toResPath converts an absolute testPath to a res://-relative path.
@@ -3,6 +3,7 @@ package detector
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -10,7 +11,12 @@ import (
 // makeProject creates a minimal Godot project structure in a temp dir and returns its root.
 func makeProject(t *testing.T) string {
 	t.Helper()
-	root := t.TempDir()
+	// Resolve symlinks in the temp dir itself (/var -> /private/var on macOS),
+	// as Detect reports resolved project directories.
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
 	if err := os.WriteFile(filepath.Join(root, "project.godot"), []byte("[application]\n"), 0o644); err != nil {
 		t.Fatal(err)
 	}
@@ -176,3 +182,160 @@ func TestDetect_CrossProjectError(t *testing.T) {
 		t.Errorf("error message should mention different project, got: %v", err)
 	}
 }
+
+func TestDetectIn(t *testing.T) {
+	root := makeProject(t)
+	if err := os.MkdirAll(filepath.Join(root, "tests", "unit"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A nested project.godot would stop Detect's walk-up; DetectIn ignores it.
+	if err := os.WriteFile(filepath.Join(root, "tests", "project.godot"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+
+	result, err := DetectIn(root, []string{".", filepath.Join("tests", "unit"), filepath.Join(root, "tests")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ProjectDir != root {
+		t.Errorf("ProjectDir = %q, want %q", result.ProjectDir, root)
+	}
+	want := []string{"res://.", "res://tests/unit", "res://tests"}
+	if strings.Join(result.ResPaths, " ") != strings.Join(want, " ") {
+		t.Errorf("ResPaths = %q, want %q", result.ResPaths, want)
+	}
+}
+
+func TestDetectIn_Errors(t *testing.T) {
+	root := makeProject(t)
+	other := t.TempDir()
+
+	tests := []struct {
+		name       string
+		projectDir string
+		paths      []string
+		want       string
+	}{
+		{"no project.godot", other, []string{"."}, "project.godot not found"},
+		{"missing path", root, []string{"tests"}, "cannot access"},
+		{"outside the project", root, []string{other}, "outside the project"},
+		{"relative escape", root, []string{".."}, "outside the project"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DetectIn(tt.projectDir, tt.paths)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetect_ResPaths(t *testing.T) {
+	root := makeProject(t)
+	if err := os.MkdirAll(filepath.Join(root, "tests", "unit"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("with project", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		result, err := DetectIn(root, []string{"res://tests/unit", "res://tests/../tests", "res://"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []string{"res://tests/unit", "res://tests", "res://."}
+		if strings.Join(result.ResPaths, " ") != strings.Join(want, " ") {
+			t.Errorf("ResPaths = %q, want %q", result.ResPaths, want)
+		}
+	})
+
+	t.Run("from working directory", func(t *testing.T) {
+		t.Chdir(filepath.Join(root, "tests"))
+		result, err := Detect([]string{"res://tests/unit", "unit"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.ProjectDir != root {
+			t.Errorf("ProjectDir = %q, want %q", result.ProjectDir, root)
+		}
+		want := []string{"res://tests/unit", "res://tests/unit"}
+		if strings.Join(result.ResPaths, " ") != strings.Join(want, " ") {
+			t.Errorf("ResPaths = %q, want %q", result.ResPaths, want)
+		}
+	})
+
+	for _, p := range []string{"res://tests/missing", "res://../outside"} {
+		if _, err := DetectIn(root, []string{p}); err == nil {
+			t.Errorf("DetectIn(%q) succeeded, want an error", p)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	a := makeProject(t)
+	b := makeProject(t)
+	for _, dir := range []string{filepath.Join(a, "tests"), filepath.Join(b, "tests", "unit")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := Group([]string{filepath.Join(a, "tests"), filepath.Join(b, "tests", "unit"), a})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 2 || groups[0].ProjectDir != a || groups[1].ProjectDir != b {
+		t.Fatalf("groups = %+v, want one per project in order of appearance", groups)
+	}
+	if strings.Join(groups[0].ResPaths, " ") != "res://tests res://." || strings.Join(groups[1].ResPaths, " ") != "res://tests/unit" {
+		t.Errorf("ResPaths = %q, %q", groups[0].ResPaths, groups[1].ResPaths)
+	}
+
+	if _, err := Group([]string{t.TempDir()}); err == nil {
+		t.Error("Group outside any project succeeded, want an error")
+	}
+}
+
+func TestDetect_Symlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping symlink test on Windows")
+	}
+	root := makeProject(t)
+	if err := os.MkdirAll(filepath.Join(root, "tests", "unit"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A workspace reaching the project through a symlink, and a directory of
+	// shared tests symlinked into the project from outside any project.
+	link := filepath.Join(t.TempDir(), "game")
+	if err := os.Symlink(root, link); err != nil {
+		t.Fatal(err)
+	}
+	shared := t.TempDir()
+	if err := os.Symlink(shared, filepath.Join(root, "tests", "shared")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Detect([]string{filepath.Join(link, "tests", "unit"), filepath.Join(root, "tests"), filepath.Join(link, "tests", "shared")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ProjectDir != root {
+		t.Errorf("ProjectDir = %q, want the resolved %q", result.ProjectDir, root)
+	}
+	want := "res://tests/unit res://tests res://tests/shared"
+	if got := strings.Join(result.ResPaths, " "); got != want {
+		t.Errorf("ResPaths = %q, want %q", got, want)
+	}
+
+	result, err = DetectIn(link, []string{"tests/unit", filepath.Join(root, "tests"), "tests/shared"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ProjectDir != root {
+		t.Errorf("DetectIn ProjectDir = %q, want the resolved %q", result.ProjectDir, root)
+	}
+	if got := strings.Join(result.ResPaths, " "); got != want {
+		t.Errorf("DetectIn ResPaths = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+package detector
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// hasMeta reports whether p contains glob metacharacters.
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// expandGlobs replaces each pattern in paths with the paths it matches, keeping
+// other paths as they are. Relative patterns are matched against base, or the
+// working directory if base is empty. res:// paths are left to the caller,
+// which knows the project they belong to.
+func expandGlobs(paths []string, base string) ([]string, error) {
+	var out []string
+	for _, p := range paths {
+		// The ? of a \\?\ long path prefix is not a pattern.
+		p = normalizePath(p)
+		if !hasMeta(p) || IsResPath(p) {
+			out = append(out, p)
+			continue
+		}
+		pattern := p
+		if base != "" && !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(base, pattern)
+		}
+		matches, err := glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("pattern %s matches nothing", p)
+		}
+		out = append(out, matches...)
+	}
+	return out, nil
+}
+
+// glob returns the files and directories matching pattern in lexical order.
+// Besides the syntax of path.Match within a path element, a "**" element
+// matches any number of directories, so tests/**/test_*.gd finds test files at
+// any depth under tests/. A match inside an already matched directory is left
+// out, since running the directory runs it too.
+func glob(pattern string) ([]string, error) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+	first := len(parts)
+	for i, part := range parts {
+		if _, err := path.Match(part, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		if first == len(parts) && hasMeta(part) {
+			first = i
+		}
+	}
+
+	root := strings.Join(parts[:first], "/")
+	switch {
+	case root == "":
+		root = "."
+		if first > 0 {
+			root = "/"
+		}
+	case filepath.VolumeName(root) == root:
+		root += "/"
+	}
+	rest := parts[first:]
+	deep := false
+	for _, part := range rest {
+		deep = deep || part == "**"
+	}
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == filepath.FromSlash(root) {
+				// A missing root simply matches nothing.
+				return fs.SkipAll
+			}
+			return err
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(root), p)
+		if err != nil || rel == "." {
+			return nil
+		}
+		name := strings.Split(filepath.ToSlash(rel), "/")
+		if matchElements(rest, name) {
+			matches = append(matches, p)
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+		}
+		if d.IsDir() && !deep && len(name) >= len(rest) {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// matchElements matches the path elements name against the pattern elements pat.
+func matchElements(pat, name []string) bool {
+	if len(pat) == 0 {
+		return len(name) == 0
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchElements(pat[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pat[0], name[0])
+	return ok && matchElements(pat[1:], name[1:])
+}
@@ -0,0 +1,86 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{
+		"tests/test_a_integration.gd",
+		"tests/unit/test_b_integration.gd",
+		"tests/unit/test_b.gd",
+		"tests/unit/deep/test_c_integration.gd",
+		"tests/e2e/test_d.gd",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"tests/**/test_*_integration.gd", []string{"tests/test_a_integration.gd", "tests/unit/deep/test_c_integration.gd", "tests/unit/test_b_integration.gd"}},
+		{"tests/*/test_?.gd", []string{"tests/e2e/test_d.gd", "tests/unit/test_b.gd"}},
+		{"tests/[eu]*", []string{"tests/e2e", "tests/unit"}},
+		{"tests/**", []string{"tests/e2e", "tests/test_a_integration.gd", "tests/unit"}},
+		{"missing/*.gd", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := glob(filepath.Join(root, filepath.FromSlash(tt.pattern)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i := range got {
+				rel, _ := filepath.Rel(root, got[i])
+				got[i] = filepath.ToSlash(rel)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("glob = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := glob(filepath.Join(root, "tests", "[")); err == nil {
+		t.Error("glob with a malformed pattern succeeded, want an error")
+	}
+}
+
+func TestDetect_Globs(t *testing.T) {
+	root := makeProject(t)
+	for _, f := range []string{"tests/unit/test_a.gd", "tests/unit/test_b.gd", "tests/e2e/test_c.gd"} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(root)
+
+	result, err := Detect([]string{"tests/**/test_[ab].gd", "res://tests/e2e/*.gd"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"res://tests/unit/test_a.gd", "res://tests/unit/test_b.gd", "res://tests/e2e/test_c.gd"}
+	if strings.Join(result.ResPaths, " ") != strings.Join(want, " ") {
+		t.Errorf("ResPaths = %q, want %q", result.ResPaths, want)
+	}
+
+	for _, p := range []string{"tests/**/none_*.gd", "res://tests/**/none_*.gd"} {
+		if _, err := Detect([]string{p}); err == nil || !strings.Contains(err.Error(), "matches nothing") {
+			t.Errorf("Detect(%q) err = %v, want a no-match error", p, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package detector
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// absolute is filepath.Abs followed by normalizePath, so that spellings of one
+// directory compare equal and convert to the same res:// path.
+func absolute(p string) (string, error) {
+	abs, err := filepath.Abs(normalizePath(p))
+	if err != nil {
+		return "", err
+	}
+	return normalizePath(abs), nil
+}
+
+// normalizePath applies normalizeWindowsPath on Windows and returns p unchanged
+// elsewhere.
+func normalizePath(p string) string {
+	if runtime.GOOS != "windows" {
+		return p
+	}
+	return normalizeWindowsPath(p)
+}
+
+// normalizeWindowsPath strips the \\?\ long path prefix, turning \\?\UNC\server\share
+// back into \\server\share, and upper-cases the drive letter. Go adds the prefix
+// again where a path exceeds MAX_PATH, while Godot and filepath.Rel expect paths
+// without it, and "c:\proj" and "C:\proj" must name the same project.
+func normalizeWindowsPath(p string) string {
+	switch {
+	case hasPrefixFold(p, `\\?\UNC\`):
+		p = `\\` + p[len(`\\?\UNC\`):]
+	case strings.HasPrefix(p, `\\?\`):
+		p = p[len(`\\?\`):]
+	}
+	if len(p) >= 2 && p[1] == ':' && 'a' <= p[0] && p[0] <= 'z' {
+		p = string(p[0]-'a'+'A') + p[1:]
+	}
+	return p
+}
+
+// samePath reports whether a and b name the same directory; Windows paths are
+// compared case-insensitively.
+func samePath(a, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// pathKey returns a map key under which samePath paths collide.
+func pathKey(p string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToLower(p)
+	}
+	return p
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
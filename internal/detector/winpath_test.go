@@ -0,0 +1,23 @@
+package detector
+
+import "testing"
+
+func TestNormalizeWindowsPath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`c:\games\proj`, `C:\games\proj`},
+		{`C:\games\proj`, `C:\games\proj`},
+		{`\\?\c:\very\long\path`, `C:\very\long\path`},
+		{`\\?\UNC\server\share\proj`, `\\server\share\proj`},
+		{`\\?\unc\server\share\proj`, `\\server\share\proj`},
+		{`\\server\share\proj`, `\\server\share\proj`},
+		{`tests\unit`, `tests\unit`},
+		{`/home/me/proj`, `/home/me/proj`},
+	}
+	for _, tt := range tests {
+		if got := normalizeWindowsPath(tt.in); got != tt.want {
+			t.Errorf("normalizeWindowsPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package discovery
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Suite describes a gdUnit4 test suite script found on disk.
+type Suite struct {
+	ResPath  string              `json:"res_path"`            // res:// path of the script
+	Class    string              `json:"class"`               // class_name if declared, otherwise the file base name
+	Tests    []string            `json:"tests"`               // test function names in declaration order
+	Tags     []string            `json:"tags,omitempty"`      // tags applying to every test of the suite
+	TestTags map[string][]string `json:"test_tags,omitempty"` // tags of individual tests
+	Lines    map[string]int      `json:"lines,omitempty"`     // 1-based line of each test function's declaration
+}
+
+// TagsOf returns the tags of test: its own and those of the suite.
+func (s Suite) TagsOf(test string) []string {
+	return append(append([]string{}, s.Tags...), s.TestTags[test]...)
+}
+
+// extendsRe matches the extends line of a gdUnit4 test suite, either by class name
+// or by path to the addon's GdUnitTestSuite.gd.
+var extendsRe = regexp.MustCompile(`^extends\s+(GdUnitTestSuite\b|"res://addons/gdUnit4/src/GdUnitTestSuite\.gd")`)
+
+// classNameRe matches a class_name declaration.
+var classNameRe = regexp.MustCompile(`^class_name\s+(\w+)`)
+
+// testFuncRe matches a top-level test function declaration.
+var testFuncRe = regexp.MustCompile(`^func\s+(test_\w+)\s*\(`)
+
+// tagRe matches a tag annotation comment such as "# @tag: slow, network".
+var tagRe = regexp.MustCompile(`^#\s*@tags?\s*:\s*(.*)$`)
+
+// Discover finds gdUnit4 test suites under each of resPaths in projectDir.
+// resPaths may point at directories or individual .gd files.
+// Suites are returned sorted by res:// path with duplicates removed.
+func Discover(projectDir string, resPaths []string) ([]Suite, error) {
+	seen := map[string]bool{}
+	var suites []Suite
+
+	for _, rp := range resPaths {
+		root := filepath.Join(projectDir, filepath.FromSlash(strings.TrimPrefix(rp, "res://")))
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(p) != ".gd" || seen[p] {
+				return nil
+			}
+			seen[p] = true
+
+			suite, ok, err := parseSuite(p)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(projectDir, p)
+			if err != nil {
+				return err
+			}
+			suite.ResPath = "res://" + filepath.ToSlash(rel)
+			suites = append(suites, suite)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover tests under %s: %w", rp, err)
+		}
+	}
+
+	sort.Slice(suites, func(i, j int) bool { return suites[i].ResPath < suites[j].ResPath })
+	return suites, nil
+}
+
+// Classes maps the class of every script under resPaths in projectDir (its
+// class_name, otherwise its file name) to the res:// paths of the scripts
+// declaring it. Unlike Discover it sees all scripts, so also the suites that
+// extend GdUnitTestSuite through a base class of their own.
+func Classes(projectDir string, resPaths []string) (map[string][]string, error) {
+	classes := map[string][]string{}
+	for _, rp := range resPaths {
+		root := filepath.Join(projectDir, filepath.FromSlash(strings.TrimPrefix(rp, "res://")))
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(p) != ".gd" {
+				return nil
+			}
+			suite, _, err := parseSuite(p)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(projectDir, p)
+			if err != nil {
+				return err
+			}
+			if resPath := "res://" + filepath.ToSlash(rel); !slices.Contains(classes[suite.Class], resPath) {
+				classes[suite.Class] = append(classes[suite.Class], resPath)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to index scripts under %s: %w", rp, err)
+		}
+	}
+	return classes, nil
+}
+
+// HasCandidates reports whether resPath in projectDir is, or contains, a .gd
+// file that may hold tests: a test suite as Discover sees it, or any script
+// declaring test functions, since a suite can extend GdUnitTestSuite through a
+// base class of its own.
+func HasCandidates(projectDir, resPath string) (bool, error) {
+	root := filepath.Join(projectDir, filepath.FromSlash(strings.TrimPrefix(resPath, "res://")))
+	found := false
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(p) != ".gd" {
+			return nil
+		}
+		suite, ok, err := parseSuite(p)
+		if err != nil {
+			return err
+		}
+		if ok || len(suite.Tests) > 0 {
+			found = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to discover tests under %s: %w", resPath, err)
+	}
+	return found, nil
+}
+
+// parseSuite reads a .gd file and reports whether it is a gdUnit4 test suite.
+func parseSuite(path string) (Suite, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Suite{}, false, err
+	}
+	defer f.Close()
+
+	suite := Suite{
+		Class: strings.TrimSuffix(filepath.Base(path), ".gd"),
+		Tests: []string{},
+	}
+	isSuite := false
+
+	// Tag comments directly above a test function (other comments and annotations may
+	// sit in between) tag that test; any other tag comment tags the whole suite.
+	var pending []string
+	flush := func() {
+		suite.Tags = appendTags(suite.Tags, pending)
+		pending = nil
+	}
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case tagRe.MatchString(trimmed):
+			pending = append(pending, strings.Split(tagRe.FindStringSubmatch(trimmed)[1], ",")...)
+			continue
+		case testFuncRe.MatchString(line):
+			name := testFuncRe.FindStringSubmatch(line)[1]
+			suite.Tests = append(suite.Tests, name)
+			if suite.Lines == nil {
+				suite.Lines = map[string]int{}
+			}
+			suite.Lines[name] = n
+			if tags := appendTags(nil, pending); len(tags) > 0 {
+				if suite.TestTags == nil {
+					suite.TestTags = map[string][]string{}
+				}
+				suite.TestTags[name] = tags
+			}
+			pending = nil
+			continue
+		case strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "@"):
+			continue
+		}
+
+		flush()
+		switch {
+		case extendsRe.MatchString(line):
+			isSuite = true
+		case classNameRe.MatchString(line):
+			suite.Class = classNameRe.FindStringSubmatch(line)[1]
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return Suite{}, false, err
+	}
+	return suite, isSuite, nil
+}
+
+// appendTags appends the trimmed, non-empty, not yet present tags to dst.
+func appendTags(dst, tags []string) []string {
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "" && !slices.Contains(dst, t) {
+			dst = append(dst, t)
+		}
+	}
+	return dst
+}
+
+// Criteria selects test cases. Every non-empty part must match.
+type Criteria struct {
+	Patterns []string // test name patterns as accepted by MatchTest
+	Tags     []string // the test must carry at least one of these tags
+	SkipTags []string // the test must carry none of these tags
+}
+
+// SelectCriteria returns the gdUnit4 selectors for the test cases of suites matching c,
+// in suite order. When only tags are used and every test of a suite matches, the
+// suite is selected as a whole ("res://path/suite.gd") instead of test by test.
+func SelectCriteria(suites []Suite, c Criteria) ([]string, error) {
+	var selected []string
+	for _, s := range suites {
+		var tests []string
+		for _, test := range s.Tests {
+			ok, err := c.matches(s, test)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				tests = append(tests, s.ResPath+":"+test)
+			}
+		}
+		if len(c.Patterns) == 0 && len(tests) > 0 && len(tests) == len(s.Tests) {
+			selected = append(selected, s.ResPath)
+			continue
+		}
+		selected = append(selected, tests...)
+	}
+	return selected, nil
+}
+
+// matches reports whether test of suite s satisfies c.
+func (c Criteria) matches(s Suite, test string) (bool, error) {
+	tags := s.TagsOf(test)
+	for _, t := range c.SkipTags {
+		if slices.Contains(tags, t) {
+			return false, nil
+		}
+	}
+	if len(c.Tags) > 0 && !slices.ContainsFunc(c.Tags, func(t string) bool { return slices.Contains(tags, t) }) {
+		return false, nil
+	}
+	if len(c.Patterns) == 0 {
+		return true, nil
+	}
+	for _, p := range c.Patterns {
+		ok, err := MatchTest(p, s.Class, test)
+		if err != nil {
+			return false, fmt.Errorf("invalid filter %q: %w", p, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Select returns the test cases of suites matching any of patterns as gdUnit4
+// "res://path/suite.gd:test_name" selectors, in suite order.
+// A pattern is a test name ("test_add") or a class-qualified name ("TestMath.test_add");
+// both parts may use path.Match wildcards ("test_*", "Player*.test_jump").
+func Select(suites []Suite, patterns []string) ([]string, error) {
+	return SelectCriteria(suites, Criteria{Patterns: patterns})
+}
+
+// MatchTest reports whether a --filter pattern ("test_add", "Player*.test_jump") matches the test in class.
+func MatchTest(pattern, class, test string) (bool, error) {
+	if i := strings.LastIndex(pattern, "."); i >= 0 {
+		ok, err := path.Match(pattern[:i], class)
+		if err != nil || !ok {
+			return false, err
+		}
+		pattern = pattern[i+1:]
+	}
+	return path.Match(pattern, test)
+}
+
+// Find returns the suite and name of the one test of suites matching pattern,
+// as accepted by MatchTest. It fails if none or several match, naming them.
+func Find(suites []Suite, pattern string) (Suite, string, error) {
+	var found []Suite
+	var names, matches []string
+	for _, s := range suites {
+		for _, test := range s.Tests {
+			ok, err := MatchTest(pattern, s.Class, test)
+			if err != nil {
+				return Suite{}, "", fmt.Errorf("invalid test name %q: %w", pattern, err)
+			}
+			if ok {
+				found = append(found, s)
+				names = append(names, test)
+				matches = append(matches, s.Class+"."+test+" ("+s.ResPath+")")
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Suite{}, "", fmt.Errorf("no test matches %q", pattern)
+	case 1:
+		return found[0], names[0], nil
+	}
+	return Suite{}, "", fmt.Errorf("%q matches %d tests, name one of them:\n  %s", pattern, len(found), strings.Join(matches, "\n  "))
+}
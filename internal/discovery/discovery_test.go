@@ -0,0 +1,274 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile creates path (and its parent dirs) under root with content.
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	full := filepath.Join(root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscover_FindsSuitesAndTests(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "tests/test_math.gd", "extends GdUnitTestSuite\n\nfunc test_add() -> void:\n\tpass\n\nfunc helper() -> void:\n\tpass\n\nfunc test_sub():\n\tpass\n")
+	writeFile(t, root, "tests/helper.gd", "extends Node\n\nfunc test_not_a_test() -> void:\n\tpass\n")
+	writeFile(t, root, "tests/unit/player_test.gd", "class_name PlayerTest\nextends GdUnitTestSuite\n\nfunc test_jump() -> void:\n\tpass\n")
+
+	suites, err := Discover(root, []string{"res://tests"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(suites) != 2 {
+		t.Fatalf("len(suites) = %d, want 2: %+v", len(suites), suites)
+	}
+
+	if suites[0].ResPath != "res://tests/test_math.gd" {
+		t.Errorf("suites[0].ResPath = %q, want res://tests/test_math.gd", suites[0].ResPath)
+	}
+	if suites[0].Class != "test_math" {
+		t.Errorf("suites[0].Class = %q, want test_math", suites[0].Class)
+	}
+	if len(suites[0].Tests) != 2 || suites[0].Tests[0] != "test_add" || suites[0].Tests[1] != "test_sub" {
+		t.Errorf("suites[0].Tests = %v, want [test_add test_sub]", suites[0].Tests)
+	}
+	if l := suites[0].Lines; l["test_add"] != 3 || l["test_sub"] != 9 {
+		t.Errorf("suites[0].Lines = %v, want test_add:3 test_sub:9", l)
+	}
+
+	if suites[1].Class != "PlayerTest" {
+		t.Errorf("suites[1].Class = %q, want PlayerTest", suites[1].Class)
+	}
+}
+
+func TestDiscover_SingleFileAndDedup(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "tests/test_a.gd", "extends GdUnitTestSuite\nfunc test_one():\n\tpass\n")
+
+	suites, err := Discover(root, []string{"res://tests/test_a.gd", "res://tests"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(suites) != 1 {
+		t.Fatalf("len(suites) = %d, want 1", len(suites))
+	}
+}
+
+func TestDiscover_SkipsHiddenDirs(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, ".godot/cache/test_x.gd", "extends GdUnitTestSuite\n")
+
+	suites, err := Discover(root, []string{"res://."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(suites) != 0 {
+		t.Errorf("expected no suites from hidden dirs, got %+v", suites)
+	}
+}
+
+func TestDiscover_MissingPath(t *testing.T) {
+	_, err := Discover(t.TempDir(), []string{"res://missing"})
+	if err == nil {
+		t.Fatal("expected error for missing path, got nil")
+	}
+}
+
+func TestClasses(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "tests/base_test.gd", "class_name BaseTest\nextends GdUnitTestSuite\n")
+	writeFile(t, root, "tests/player_test.gd", "class_name PlayerTest\nextends BaseTest\n")
+	writeFile(t, root, "tests/helper.gd", "extends RefCounted\n")
+	writeFile(t, root, ".godot/cache.gd", "class_name Hidden\n")
+
+	classes, err := Classes(root, []string{"res://"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"BaseTest":   "res://tests/base_test.gd",
+		"PlayerTest": "res://tests/player_test.gd",
+		"helper":     "res://tests/helper.gd",
+	}
+	if len(classes) != len(want) {
+		t.Errorf("classes = %v, want %v", classes, want)
+	}
+	for class, path := range want {
+		if got := classes[class]; len(got) != 1 || got[0] != path {
+			t.Errorf("classes[%q] = %v, want [%s]", class, got, path)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	suites := []Suite{
+		{ResPath: "res://tests/test_math.gd", Class: "test_math", Tests: []string{"test_add", "test_sub"}},
+		{ResPath: "res://tests/player_test.gd", Class: "PlayerTest", Tests: []string{"test_add", "test_jump"}},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"plain name matches every suite", []string{"test_add"}, []string{"res://tests/test_math.gd:test_add", "res://tests/player_test.gd:test_add"}},
+		{"class qualified", []string{"PlayerTest.test_add"}, []string{"res://tests/player_test.gd:test_add"}},
+		{"wildcard", []string{"test_math.test_*"}, []string{"res://tests/test_math.gd:test_add", "res://tests/test_math.gd:test_sub"}},
+		{"multiple patterns", []string{"test_sub", "test_jump"}, []string{"res://tests/test_math.gd:test_sub", "res://tests/player_test.gd:test_jump"}},
+		{"no match", []string{"test_missing"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Select(suites, tt.patterns)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Select(%v) = %v, want %v", tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelect_InvalidPattern(t *testing.T) {
+	suites := []Suite{{ResPath: "res://a.gd", Class: "a", Tests: []string{"test_x"}}}
+	if _, err := Select(suites, []string{"test_["}); err == nil {
+		t.Fatal("expected error for malformed pattern, got nil")
+	}
+}
+
+func TestDiscover_Tags(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "tests/test_net.gd", "# @tag: network\nextends GdUnitTestSuite\n\n"+
+		"# @tag: slow\n# a helpful comment\n@warning_ignore('unused_parameter')\nfunc test_download() -> void:\n\tpass\n\n"+
+		"func test_ping() -> void:\n\tpass\n")
+
+	suites, err := Discover(root, []string{"res://tests"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := suites[0]
+	if strings.Join(s.Tags, ",") != "network" {
+		t.Errorf("Tags = %v, want [network]", s.Tags)
+	}
+	if strings.Join(s.TestTags["test_download"], ",") != "slow" {
+		t.Errorf("TestTags[test_download] = %v, want [slow]", s.TestTags["test_download"])
+	}
+	if _, ok := s.TestTags["test_ping"]; ok {
+		t.Errorf("test_ping has tags %v, want none", s.TestTags["test_ping"])
+	}
+	if strings.Join(s.TagsOf("test_download"), ",") != "network,slow" {
+		t.Errorf("TagsOf(test_download) = %v, want [network slow]", s.TagsOf("test_download"))
+	}
+}
+
+func TestSelectCriteria_Tags(t *testing.T) {
+	suites := []Suite{
+		{ResPath: "res://tests/test_net.gd", Class: "test_net", Tests: []string{"test_download", "test_ping"},
+			Tags: []string{"network"}, TestTags: map[string][]string{"test_download": {"slow"}}},
+		{ResPath: "res://tests/test_math.gd", Class: "test_math", Tests: []string{"test_add", "test_big"},
+			TestTags: map[string][]string{"test_big": {"slow"}}},
+	}
+
+	tests := []struct {
+		name     string
+		criteria Criteria
+		want     []string
+	}{
+		{"tag selects whole suite", Criteria{Tags: []string{"network"}}, []string{"res://tests/test_net.gd"}},
+		{"tag selects single tests", Criteria{Tags: []string{"slow"}}, []string{"res://tests/test_net.gd:test_download", "res://tests/test_math.gd:test_big"}},
+		{"skip tags", Criteria{SkipTags: []string{"slow"}}, []string{"res://tests/test_net.gd:test_ping", "res://tests/test_math.gd:test_add"}},
+		{"tags and skip tags", Criteria{Tags: []string{"network"}, SkipTags: []string{"slow"}}, []string{"res://tests/test_net.gd:test_ping"}},
+		{"tags and filter", Criteria{Patterns: []string{"test_*"}, Tags: []string{"network"}}, []string{"res://tests/test_net.gd:test_download", "res://tests/test_net.gd:test_ping"}},
+		{"no match", Criteria{Tags: []string{"gpu"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectCriteria(suites, tt.criteria)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("SelectCriteria(%+v) = %v, want %v", tt.criteria, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasCandidates(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "tests/unit/test_math.gd", "extends GdUnitTestSuite\n")
+	writeFile(t, root, "tests/inherited/player_test.gd", "extends BaseSuite\n\nfunc test_jump() -> void:\n\tpass\n")
+	writeFile(t, root, "tests/helpers/util.gd", "extends Node\n\nfunc helper() -> void:\n\tpass\n")
+	writeFile(t, root, "tests/hidden/.cache/test_x.gd", "extends GdUnitTestSuite\n")
+	writeFile(t, root, "assets/readme.txt", "")
+
+	tests := []struct {
+		resPath string
+		want    bool
+	}{
+		{"res://tests", true},
+		{"res://tests/unit", true},
+		{"res://tests/unit/test_math.gd", true},
+		{"res://tests/inherited", true},
+		{"res://tests/helpers", false},
+		{"res://tests/hidden", false},
+		{"res://assets", false},
+	}
+	for _, tt := range tests {
+		got, err := HasCandidates(root, tt.resPath)
+		if err != nil {
+			t.Fatalf("HasCandidates(%s): %v", tt.resPath, err)
+		}
+		if got != tt.want {
+			t.Errorf("HasCandidates(%s) = %v, want %v", tt.resPath, got, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	suites := []Suite{
+		{ResPath: "res://tests/test_math.gd", Class: "test_math", Tests: []string{"test_add", "test_sub"}},
+		{ResPath: "res://tests/player_test.gd", Class: "PlayerTest", Tests: []string{"test_add", "test_jump"}},
+	}
+
+	tests := []struct {
+		pattern   string
+		wantSuite string
+		wantTest  string
+		wantErr   string
+	}{
+		{"PlayerTest.test_add", "res://tests/player_test.gd", "test_add", ""},
+		{"test_jump", "res://tests/player_test.gd", "test_jump", ""},
+		{"test_add", "", "", "matches 2 tests"},
+		{"test_missing", "", "", "no test matches"},
+		{"test_[", "", "", "invalid test name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			s, test, err := Find(suites, tt.pattern)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.ResPath != tt.wantSuite || test != tt.wantTest {
+				t.Errorf("Find(%q) = %s, %s; want %s, %s", tt.pattern, s.ResPath, test, tt.wantSuite, tt.wantTest)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// maxAnnotations is the number of annotations the Checks API accepts per request;
+// more are sent in further updates, which append to the existing ones.
+const maxAnnotations = 50
+
+// maxSummary is the Checks API limit on the summary length.
+const maxSummary = 65535
+
+// Annotation marks a line of a file in the check run's diff view.
+type Annotation struct {
+	Path      string `json:"path"` // relative to the repository root
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Level     string `json:"annotation_level"` // "notice", "warning" or "failure"
+	Title     string `json:"title,omitempty"`
+	Message   string `json:"message"`
+}
+
+type checkOutput struct {
+	Title       string       `json:"title"`
+	Summary     string       `json:"summary"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+type checkRun struct {
+	Name       string       `json:"name,omitempty"`
+	HeadSHA    string       `json:"head_sha,omitempty"`
+	Status     string       `json:"status,omitempty"`
+	Conclusion string       `json:"conclusion,omitempty"`
+	Output     *checkOutput `json:"output,omitempty"`
+	ID         int64        `json:"id,omitempty"`
+}
+
+// CreateCheckRun starts an in-progress check run named name on commit sha and returns its ID.
+func (c *Client) CreateCheckRun(ctx context.Context, name, sha string) (int64, error) {
+	var created checkRun
+	err := c.do(ctx, http.MethodPost, "/check-runs", checkRun{Name: name, HeadSHA: sha, Status: "in_progress"}, &created)
+	if err != nil {
+		return 0, err
+	}
+	return created.ID, nil
+}
+
+// CompleteCheckRun concludes check run id from out. pathPrefix is the project
+// directory relative to the repository root ("" when they are the same) and is
+// used to turn res:// paths into annotation paths. A nil out (the runner failed
+// before producing a result) concludes the run as failed with errMsg as summary.
+func (c *Client) CompleteCheckRun(ctx context.Context, id int64, out *report.Output, pathPrefix, errMsg string) error {
+	endpoint := fmt.Sprintf("/check-runs/%d", id)
+	if out == nil {
+		return c.do(ctx, http.MethodPatch, endpoint, checkRun{
+			Status:     "completed",
+			Conclusion: "failure",
+			Output:     &checkOutput{Title: "gdUnit4 run failed", Summary: "The test runner failed: " + errMsg},
+		}, nil)
+	}
+
+	annotations := Annotations(out, pathPrefix)
+	first := annotations
+	if len(first) > maxAnnotations {
+		first = first[:maxAnnotations]
+	}
+	run := checkRun{
+		Status:     "completed",
+		Conclusion: Conclusion(out),
+		Output:     &checkOutput{Title: Title(out), Summary: Summary(out), Annotations: first},
+	}
+	if err := c.do(ctx, http.MethodPatch, endpoint, run, nil); err != nil {
+		return err
+	}
+	for i := maxAnnotations; i < len(annotations); i += maxAnnotations {
+		batch := annotations[i:min(i+maxAnnotations, len(annotations))]
+		run.Status, run.Conclusion = "", ""
+		run.Output.Annotations = batch
+		if err := c.do(ctx, http.MethodPatch, endpoint, run, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Conclusion maps the run status to a check run conclusion.
+func Conclusion(out *report.Output) string {
+	if out.Summary.Status == "passed" {
+		return "success"
+	}
+	return "failure"
+}
+
+// Title summarizes the run in one line, e.g. "2 of 10 tests failed".
+func Title(out *report.Output) string {
+	s := out.Summary
+	switch s.Status {
+	case "passed":
+		return fmt.Sprintf("%d tests passed", s.Total)
+	case "crashed":
+		return "Godot crashed"
+	case "aborted_max_failures":
+		return fmt.Sprintf("Stopped after %d of %d tests failed", s.Failed+s.Errors, s.Total)
+	}
+	switch {
+	case s.Failed+s.Errors == 0:
+		return "Coverage threshold missed"
+	case s.Errors == 0:
+		return fmt.Sprintf("%d of %d tests failed", s.Failed, s.Total)
+	case s.Failed == 0:
+		return fmt.Sprintf("%d of %d tests errored", s.Errors, s.Total)
+	}
+	return fmt.Sprintf("%d of %d tests failed, %d errored", s.Failed, s.Total, s.Errors)
+}
+
+// Annotations returns a failure annotation per failed test. Failures without a
+// res:// file are skipped as they cannot be placed in the diff.
+func Annotations(out *report.Output, pathPrefix string) []Annotation {
+	var list []Annotation
+	for _, f := range out.Failures {
+		if !strings.HasPrefix(f.File, "res://") {
+			continue
+		}
+		line := max(f.Line, 1)
+		msg := f.Message
+		if f.Expected != "" || f.Actual != "" {
+			msg += fmt.Sprintf("\nexpected: %s\nactual:   %s", f.Expected, f.Actual)
+		}
+		list = append(list, Annotation{
+			Path:      path.Join(pathPrefix, f.RelPath()),
+			StartLine: line,
+			EndLine:   line,
+			Level:     "failure",
+			Title:     f.Class + "." + f.Method,
+			Message:   strings.TrimSpace(msg),
+		})
+	}
+	return list
+}
+
+// Summary renders the markdown summary of a check run: the counts, crash
+// details and a table of failures.
+func Summary(out *report.Output) string {
+	var b strings.Builder
+	s := out.Summary
+	fmt.Fprintf(&b, "| Total | Passed | Failed | Errors | Status |\n|---|---|---|---|---|\n| %d | %d | %d | %d | %s |\n", s.Total, s.Passed, s.Failed, s.Errors, s.Status)
+
+	if cd := out.CrashDetails; cd != nil {
+		b.WriteString("\n### Crash\n\n```\n")
+		b.WriteString(strings.TrimSpace(cd.CrashInfo + "\n" + cd.ScriptErrors))
+		b.WriteString("\n```\n")
+	}
+	if c := out.Coverage; c != nil {
+		fmt.Fprintf(&b, "\nLine coverage: %.1f%% (%d/%d)", c.Percent, c.LinesCovered, c.LinesValid)
+		if c.Min > 0 {
+			fmt.Fprintf(&b, ", minimum %.1f%%", c.Min)
+		}
+		b.WriteString("\n")
+	}
+	if len(out.Failures) > 0 {
+		b.WriteString("\n### Failures\n\n| Test | Location | Message |\n|---|---|---|\n")
+		for _, f := range out.Failures {
+			fmt.Fprintf(&b, "| `%s.%s` | `%s:%d` | %s |\n", f.Class, f.Method, f.File, f.Line, markdownCell(f.Message))
+		}
+	}
+
+	if b.Len() > maxSummary {
+		const cut = "\n\n_Summary truncated._\n"
+		return strings.ToValidUTF8(b.String()[:maxSummary-len(cut)], "") + cut
+	}
+	return b.String()
+}
+
+// markdownCell makes s safe to place in a single markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
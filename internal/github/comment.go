@@ -0,0 +1,84 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+	"github.com/minami110/gdunit4-test-runner/internal/snapshot"
+)
+
+// maxComment is the GitHub limit on the length of an issue comment.
+const maxComment = 65536
+
+type issueComment struct {
+	ID   int64  `json:"id,omitempty"`
+	Body string `json:"body"`
+}
+
+// UpsertComment posts body on pull request pr, or edits the comment that already
+// contains marker so that repeated runs keep a single comment up to date.
+// It reports whether a new comment was created.
+func (c *Client) UpsertComment(ctx context.Context, pr int, marker, body string) (bool, error) {
+	for page := 1; ; page++ {
+		var comments []issueComment
+		path := fmt.Sprintf("/issues/%d/comments?per_page=100&page=%d", pr, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &comments); err != nil {
+			return false, err
+		}
+		for _, cm := range comments {
+			if strings.Contains(cm.Body, marker) {
+				return false, c.do(ctx, http.MethodPatch, fmt.Sprintf("/issues/comments/%d", cm.ID), issueComment{Body: body}, nil)
+			}
+		}
+		if len(comments) < 100 {
+			break
+		}
+	}
+	return true, c.do(ctx, http.MethodPost, fmt.Sprintf("/issues/%d/comments", pr), issueComment{Body: body}, nil)
+}
+
+// CommentBody renders the pull request comment for out: marker (an HTML comment
+// identifying the runner's comment), a headline, the check run summary and a
+// collapsible section with a diff per failure.
+func CommentBody(out *report.Output, marker string) string {
+	var b strings.Builder
+	icon := ":x:"
+	if out.Summary.Status == "passed" {
+		icon = ":white_check_mark:"
+	}
+	fmt.Fprintf(&b, "%s\n## %s gdUnit4: %s\n\n", marker, icon, Title(out))
+	b.WriteString(Summary(out))
+
+	if diffs := failureDiffs(out); diffs != "" {
+		fmt.Fprintf(&b, "\n<details><summary>Failure diffs</summary>\n\n%s</details>\n", diffs)
+	}
+
+	if b.Len() > maxComment {
+		const cut = "\n\n_Comment truncated._\n"
+		return strings.ToValidUTF8(b.String()[:maxComment-len(cut)], "") + cut
+	}
+	return b.String()
+}
+
+// failureDiffs renders a diff block for every failure with a snapshot diff or
+// an expected/actual pair.
+func failureDiffs(out *report.Output) string {
+	var b strings.Builder
+	for _, f := range out.Failures {
+		var diff string
+		switch {
+		case f.Snapshot != nil:
+			diff = f.Snapshot.Diff
+		case f.Expected != "" || f.Actual != "":
+			diff = snapshot.Diff(f.Expected+"\n", f.Actual+"\n", "expected", "actual")
+		}
+		if diff == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "**`%s.%s`** (`%s:%d`)\n\n```diff\n%s```\n\n", f.Class, f.Method, f.File, f.Line, diff)
+	}
+	return b.String()
+}
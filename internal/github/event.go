@@ -0,0 +1,35 @@
+package github
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Event holds the parts of the GitHub Actions event payload (GITHUB_EVENT_PATH) the runner uses.
+type Event struct {
+	PullRequest *struct {
+		Number int `json:"number"`
+		Head   struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+}
+
+// LoadEvent reads the event payload of the current workflow run.
+// It returns an empty event when GITHUB_EVENT_PATH is unset or unreadable.
+func LoadEvent() *Event {
+	var ev Event
+	if data, err := os.ReadFile(os.Getenv("GITHUB_EVENT_PATH")); err == nil {
+		json.Unmarshal(data, &ev)
+	}
+	return &ev
+}
+
+// HeadSHA returns the commit checks should be attached to. For pull requests this is
+// the head of the branch rather than GITHUB_SHA, which is a temporary merge commit.
+func (ev *Event) HeadSHA() string {
+	if ev.PullRequest != nil && ev.PullRequest.Head.SHA != "" {
+		return ev.PullRequest.Head.SHA
+	}
+	return os.Getenv("GITHUB_SHA")
+}
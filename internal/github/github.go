@@ -0,0 +1,97 @@
+// Package github reports results to GitHub through its REST API.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is used when GITHUB_API_URL is not set (it is on GitHub Enterprise).
+const DefaultAPIURL = "https://api.github.com"
+
+// Client calls the GitHub REST API for one repository.
+type Client struct {
+	http    *http.Client
+	baseURL string
+	token   string
+	Repo    string // "owner/name"
+}
+
+// NewClientFromEnv configures a client from the environment GitHub Actions provides:
+// GITHUB_TOKEN (or GH_TOKEN), GITHUB_REPOSITORY and optionally GITHUB_API_URL.
+func NewClientFromEnv() (*Client, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	if token == "" {
+		return nil, errors.New("GITHUB_TOKEN is not set")
+	}
+	repo := os.Getenv("GITHUB_REPOSITORY")
+	if repo == "" {
+		return nil, errors.New("GITHUB_REPOSITORY is not set")
+	}
+	baseURL := os.Getenv("GITHUB_API_URL")
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return NewClient(baseURL, token, repo), nil
+}
+
+// NewClient returns a client for repo ("owner/name") at the API base URL.
+func NewClient(baseURL, token, repo string) *Client {
+	return &Client{
+		http:    &http.Client{Timeout: 30 * time.Second},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		Repo:    repo,
+	}
+}
+
+// do sends a JSON request to path under the repository and decodes the response into out, if non-nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/repos/"+c.Repo+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: failed to decode response: %w", method, path, err)
+	}
+	return nil
+}
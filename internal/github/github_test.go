@@ -0,0 +1,214 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+type recordedRequest struct {
+	Method string
+	Path   string
+	Body   map[string]any
+}
+
+// fakeAPI records requests and answers POSTs with {"id": 42}.
+func fakeAPI(t *testing.T) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var reqs []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		rec := recordedRequest{Method: r.Method, Path: r.URL.Path}
+		json.Unmarshal(data, &rec.Body)
+		reqs = append(reqs, rec)
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"id": 42}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+func failures(n int) []report.Failure {
+	var list []report.Failure
+	for i := range n {
+		list = append(list, report.Failure{
+			Class: "MathTest", Method: "test_" + strconv.Itoa(i), File: "res://tests/MathTest.gd", Line: i,
+			Message: "FAILED: res://tests/MathTest.gd:" + strconv.Itoa(i), Expected: "1", Actual: "2",
+		})
+	}
+	return list
+}
+
+func TestCheckRun(t *testing.T) {
+	srv, reqs := fakeAPI(t)
+	c := NewClient(srv.URL+"/", "tok", "octo/game")
+
+	id, err := c.CreateCheckRun(context.Background(), "gdUnit4", "abc123")
+	if err != nil || id != 42 {
+		t.Fatalf("CreateCheckRun = %d, %v; want 42", id, err)
+	}
+	out := &report.Output{Summary: report.Summary{Total: 80, Passed: 10, Failed: 70, Status: "failed"}, Failures: failures(70)}
+	if err := c.CompleteCheckRun(context.Background(), id, out, "game", ""); err != nil {
+		t.Fatalf("CompleteCheckRun: %v", err)
+	}
+
+	if len(*reqs) != 3 {
+		t.Fatalf("got %d requests, want create + 2 updates", len(*reqs))
+	}
+	create := (*reqs)[0]
+	if create.Method != "POST" || create.Path != "/repos/octo/game/check-runs" || create.Body["head_sha"] != "abc123" || create.Body["status"] != "in_progress" {
+		t.Errorf("create = %+v", create)
+	}
+
+	update := (*reqs)[1]
+	if update.Method != "PATCH" || update.Path != "/repos/octo/game/check-runs/42" || update.Body["conclusion"] != "failure" {
+		t.Errorf("update = %+v", update)
+	}
+	output := update.Body["output"].(map[string]any)
+	if output["title"] != "70 of 80 tests failed" {
+		t.Errorf("title = %v", output["title"])
+	}
+	anns := output["annotations"].([]any)
+	if len(anns) != maxAnnotations {
+		t.Fatalf("first update has %d annotations, want %d", len(anns), maxAnnotations)
+	}
+	first := anns[0].(map[string]any)
+	if first["path"] != "game/tests/MathTest.gd" || first["start_line"] != float64(1) || first["title"] != "MathTest.test_0" {
+		t.Errorf("annotation = %v", first)
+	}
+	if !strings.Contains(first["message"].(string), "expected: 1\nactual:   2") {
+		t.Errorf("annotation message = %q", first["message"])
+	}
+
+	rest := (*reqs)[2]
+	if _, ok := rest.Body["conclusion"]; ok {
+		t.Errorf("follow-up update should not repeat the conclusion: %v", rest.Body)
+	}
+	if n := len(rest.Body["output"].(map[string]any)["annotations"].([]any)); n != 20 {
+		t.Errorf("second update has %d annotations, want 20", n)
+	}
+}
+
+func TestCompleteCheckRun_NoOutput(t *testing.T) {
+	srv, reqs := fakeAPI(t)
+	c := NewClient(srv.URL, "tok", "octo/game")
+	if err := c.CompleteCheckRun(context.Background(), 7, nil, "", "project.godot not found"); err != nil {
+		t.Fatal(err)
+	}
+	output := (*reqs)[0].Body["output"].(map[string]any)
+	if (*reqs)[0].Body["conclusion"] != "failure" || !strings.Contains(output["summary"].(string), "project.godot not found") {
+		t.Errorf("update = %+v", (*reqs)[0])
+	}
+}
+
+func TestClient_ErrorStatus(t *testing.T) {
+	srv, _ := fakeAPI(t)
+	c := NewClient(srv.URL, "wrong", "octo/game")
+	if _, err := c.CreateCheckRun(context.Background(), "gdUnit4", "abc"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("err = %v, want a 401 error", err)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	out := &report.Output{
+		Summary:  report.Summary{Total: 2, Passed: 1, Failed: 1, Status: "failed"},
+		Failures: []report.Failure{{Class: "A", Method: "test_x", File: "res://a.gd", Line: 3, Message: "line 1 | pipe\nline 2"}},
+	}
+	got := Summary(out)
+	for _, want := range []string{"| 2 | 1 | 1 | 0 | failed |", "| `A.test_x` | `res://a.gd:3` | line 1 \\| pipe<br>line 2 |"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Summary missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestEvent_HeadSHA(t *testing.T) {
+	t.Setenv("GITHUB_SHA", "merge")
+	path := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(path, []byte(`{"pull_request": {"number": 5, "head": {"sha": "head"}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GITHUB_EVENT_PATH", path)
+	if got := LoadEvent().HeadSHA(); got != "head" {
+		t.Errorf("HeadSHA = %q, want head", got)
+	}
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	if got := LoadEvent().HeadSHA(); got != "merge" {
+		t.Errorf("HeadSHA without event = %q, want merge", got)
+	}
+}
+
+func TestUpsertComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    string
+		wantCreated bool
+		wantReq     string
+	}{
+		{"new", `[{"id": 1, "body": "LGTM"}]`, true, "POST /repos/octo/game/issues/5/comments"},
+		{"update", `[{"id": 1, "body": "LGTM"}, {"id": 9, "body": "<!-- marker -->\nold"}]`, false, "PATCH /repos/octo/game/issues/comments/9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var write, body string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					w.Write([]byte(tt.existing))
+					return
+				}
+				data, _ := io.ReadAll(r.Body)
+				write, body = r.Method+" "+r.URL.Path, string(data)
+				w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			created, err := NewClient(srv.URL, "tok", "octo/game").UpsertComment(context.Background(), 5, "<!-- marker -->", "<!-- marker -->\nnew")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created != tt.wantCreated || write != tt.wantReq {
+				t.Errorf("created = %v, request = %q; want %v, %q", created, write, tt.wantCreated, tt.wantReq)
+			}
+			if !strings.Contains(body, `new`) {
+				t.Errorf("body = %s", body)
+			}
+		})
+	}
+}
+
+func TestCommentBody(t *testing.T) {
+	out := &report.Output{
+		Summary: report.Summary{Total: 3, Passed: 1, Failed: 2, Status: "failed"},
+		Failures: []report.Failure{
+			{Class: "MathTest", Method: "test_add", File: "res://tests/MathTest.gd", Line: 4, Expected: "3", Actual: "4"},
+			{Class: "UiTest", Method: "test_menu", File: "res://tests/UiTest.gd", Line: 9,
+				Snapshot: &report.SnapshotDiff{Diff: "--- a\n+++ b\n@@ -1 +1 @@\n-x\n+y\n"}},
+		},
+	}
+	got := CommentBody(out, "<!-- marker -->")
+	for _, want := range []string{
+		"<!-- marker -->\n## :x: gdUnit4: 2 of 3 tests failed",
+		"<details><summary>Failure diffs</summary>",
+		"**`MathTest.test_add`** (`res://tests/MathTest.gd:4`)\n\n```diff\n--- expected\n+++ actual\n@@ -1,1 +1,1 @@\n-3\n+4\n```",
+		"```diff\n--- a\n+++ b\n@@ -1 +1 @@\n-x\n+y\n```",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("comment missing %q:\n%s", want, got)
+		}
+	}
+}
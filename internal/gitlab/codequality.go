@@ -0,0 +1,83 @@
+// Package gitlab reports results to GitLab: code quality reports and merge request notes.
+package gitlab
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Issue is one entry of a GitLab code quality report (a subset of the Code Climate format).
+// See https://docs.gitlab.com/ee/ci/testing/code_quality.html#code-quality-report-format.
+type Issue struct {
+	Description string   `json:"description"`
+	CheckName   string   `json:"check_name"`
+	Fingerprint string   `json:"fingerprint"`
+	Severity    string   `json:"severity"` // "info", "minor", "major", "critical" or "blocker"
+	Location    Location `json:"location"`
+}
+
+// Location places an issue on a line of a file relative to the repository root.
+type Location struct {
+	Path  string `json:"path"`
+	Lines struct {
+		Begin int `json:"begin"`
+	} `json:"lines"`
+}
+
+// Issues returns a code quality issue per failed test. Failures without a res://
+// file are skipped. pathPrefix is the project directory relative to the repository
+// root ("" when they are the same).
+func Issues(out *report.Output, pathPrefix string) []Issue {
+	issues := []Issue{}
+	for _, f := range out.Failures {
+		if !strings.HasPrefix(f.File, "res://") {
+			continue
+		}
+		test := f.Class + "." + f.Method
+		desc := test + ": " + strings.TrimSpace(f.Message)
+		if f.Expected != "" || f.Actual != "" {
+			desc += fmt.Sprintf(" (expected %s, actual %s)", f.Expected, f.Actual)
+		}
+		// The fingerprint must be stable across runs so GitLab can tell new failures from old ones.
+		sum := md5.Sum([]byte(f.File + ":" + test))
+
+		is := Issue{
+			Description: desc,
+			CheckName:   "gdunit4",
+			Fingerprint: hex.EncodeToString(sum[:]),
+			Severity:    "major",
+		}
+		is.Location.Path = path.Join(pathPrefix, f.RelPath())
+		is.Location.Lines.Begin = max(f.Line, 1)
+		issues = append(issues, is)
+	}
+	return issues
+}
+
+// WriteCodeQuality writes the code quality report for out to w.
+func WriteCodeQuality(w io.Writer, out *report.Output, pathPrefix string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Issues(out, pathPrefix))
+}
+
+// WriteCodeQualityFile writes the code quality report for out to path.
+func WriteCodeQualityFile(path string, out *report.Output, pathPrefix string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create code quality report: %w", err)
+	}
+	writeErr := WriteCodeQuality(f, out, pathPrefix)
+	if closeErr := f.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	return writeErr
+}
@@ -0,0 +1,87 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+func TestWriteCodeQuality(t *testing.T) {
+	out := &report.Output{Failures: []report.Failure{
+		{Class: "MathTest", Method: "test_add", File: "res://tests/MathTest.gd", Line: 7, Message: "FAILED: res://tests/MathTest.gd:7", Expected: "3", Actual: "4"},
+		{Class: "Crash", Method: "test_x", File: ""},
+	}}
+	var buf bytes.Buffer
+	if err := WriteCodeQuality(&buf, out, "game"); err != nil {
+		t.Fatal(err)
+	}
+
+	var issues []Issue
+	if err := json.Unmarshal(buf.Bytes(), &issues); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want 1", len(issues))
+	}
+	is := issues[0]
+	if is.Location.Path != "game/tests/MathTest.gd" || is.Location.Lines.Begin != 7 || is.Severity != "major" {
+		t.Errorf("issue = %+v", is)
+	}
+	if is.Description != "MathTest.test_add: FAILED: res://tests/MathTest.gd:7 (expected 3, actual 4)" {
+		t.Errorf("Description = %q", is.Description)
+	}
+	if again := Issues(out, "game"); again[0].Fingerprint != is.Fingerprint || len(is.Fingerprint) != 32 {
+		t.Errorf("Fingerprint %q is not a stable md5", is.Fingerprint)
+	}
+
+	buf.Reset()
+	if err := WriteCodeQuality(&buf, &report.Output{}, ""); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty report = %q, %v; want []", buf.String(), err)
+	}
+}
+
+func TestUpsertNote(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    string
+		wantCreated bool
+		wantReq     string
+	}{
+		{"new", `[{"id": 1, "body": "LGTM"}]`, true, "POST /api/v4/projects/group%2Fgame/merge_requests/3/notes"},
+		{"update", `[{"id": 8, "body": "<!-- marker -->\nold"}]`, false, "PUT /api/v4/projects/group%2Fgame/merge_requests/3/notes/8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var write string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("PRIVATE-TOKEN") != "tok" {
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+				if r.Method == http.MethodGet {
+					w.Write([]byte(tt.existing))
+					return
+				}
+				io.Copy(io.Discard, r.Body)
+				write = r.Method + " " + r.URL.EscapedPath()
+				w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			created, err := NewClient(srv.URL+"/api/v4", "tok", "group/game").UpsertNote(context.Background(), 3, "<!-- marker -->", "body")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created != tt.wantCreated || write != tt.wantReq {
+				t.Errorf("created = %v, request = %q; want %v, %q", created, write, tt.wantCreated, tt.wantReq)
+			}
+		})
+	}
+}
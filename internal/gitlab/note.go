@@ -0,0 +1,113 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Client calls the GitLab REST API (v4) for one project.
+type Client struct {
+	http    *http.Client
+	baseURL string // e.g. https://gitlab.com/api/v4
+	token   string
+	project string // numeric ID or "group/name"
+}
+
+// NewClientFromEnv configures a client from the GitLab CI environment: CI_API_V4_URL,
+// CI_PROJECT_ID and a token in GITLAB_TOKEN. CI_JOB_TOKEN cannot write notes, so a
+// project or personal access token with the api scope is required.
+func NewClientFromEnv() (*Client, error) {
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		return nil, errors.New("GITLAB_TOKEN is not set")
+	}
+	baseURL, project := os.Getenv("CI_API_V4_URL"), os.Getenv("CI_PROJECT_ID")
+	if baseURL == "" || project == "" {
+		return nil, errors.New("CI_API_V4_URL and CI_PROJECT_ID must be set")
+	}
+	return NewClient(baseURL, token, project), nil
+}
+
+// NewClient returns a client for project at the API base URL.
+func NewClient(baseURL, token, project string) *Client {
+	return &Client{
+		http:    &http.Client{Timeout: 30 * time.Second},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		project: project,
+	}
+}
+
+type note struct {
+	ID   int64  `json:"id,omitempty"`
+	Body string `json:"body"`
+}
+
+// UpsertNote posts body on merge request iid, or edits the note that already contains
+// marker. It reports whether a new note was created.
+func (c *Client) UpsertNote(ctx context.Context, iid int, marker, body string) (bool, error) {
+	base := fmt.Sprintf("/merge_requests/%d/notes", iid)
+	for page := 1; ; page++ {
+		var notes []note
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", base, page), nil, &notes); err != nil {
+			return false, err
+		}
+		for _, n := range notes {
+			if strings.Contains(n.Body, marker) {
+				return false, c.do(ctx, http.MethodPut, fmt.Sprintf("%s/%d", base, n.ID), note{Body: body}, nil)
+			}
+		}
+		if len(notes) < 100 {
+			break
+		}
+	}
+	return true, c.do(ctx, http.MethodPost, base, note{Body: body}, nil)
+}
+
+// do sends a JSON request to path under the project and decodes the response into out, if non-nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	endpoint := c.baseURL + "/projects/" + url.PathEscape(c.project) + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: failed to decode response: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package history
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FlakyTest is a test that both passed and failed in the runs looked at.
+type FlakyTest struct {
+	Project     string    `json:"project"` // project directory of the runs
+	Test        string    `json:"test"`    // Class.test_name
+	Runs        int       `json:"runs"`    // runs in which it passed or failed
+	Failures    int       `json:"failures"`
+	Rate        float64   `json:"flake_rate"` // Failures/Runs, 0-1
+	LastFailure time.Time `json:"last_failure"`
+	LastRunID   string    `json:"last_failure_run_id"`
+	Owners      []string  `json:"owners,omitempty"` // owners as of its last failure
+}
+
+// Flaky returns the tests of runs that passed in some and failed in others,
+// highest flake rate first. Tests that failed in every run are broken rather
+// than flaky and are left out. Tests of different projects are told apart,
+// even when their names are the same.
+func Flaky(runs []Run) []FlakyTest {
+	type key struct{ project, test string }
+	byTest := map[key]*FlakyTest{}
+	passed := map[key]bool{}
+	get := func(k key) *FlakyTest {
+		ft := byTest[k]
+		if ft == nil {
+			ft = &FlakyTest{Project: k.project, Test: k.test}
+			byTest[k] = ft
+		}
+		return ft
+	}
+	for _, r := range runs {
+		for _, test := range r.Passed {
+			k := key{r.Project, test}
+			get(k).Runs++
+			passed[k] = true
+		}
+		for _, test := range r.Failed {
+			ft := get(key{r.Project, test})
+			ft.Runs++
+			ft.Failures++
+			if !r.Time.Before(ft.LastFailure) {
+				ft.LastFailure, ft.LastRunID = r.Time, r.ID
+				ft.Owners = r.Owners[test]
+			}
+		}
+	}
+
+	var flaky []FlakyTest
+	for k, ft := range byTest {
+		if ft.Failures > 0 && passed[k] {
+			ft.Rate = float64(ft.Failures) / float64(ft.Runs)
+			flaky = append(flaky, *ft)
+		}
+	}
+	slices.SortFunc(flaky, func(a, b FlakyTest) int {
+		return cmp.Or(
+			cmp.Compare(b.Rate, a.Rate),
+			cmp.Compare(b.Failures, a.Failures),
+			strings.Compare(a.Test, b.Test),
+			strings.Compare(a.Project, b.Project),
+		)
+	})
+	return flaky
+}
+
+// WriteFlakyText writes tests as an aligned table for the terminal, with a
+// project column if they come from more than one project.
+func WriteFlakyText(w io.Writer, tests []FlakyTest, runs int, since time.Duration) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s in %s over the last %s\n", count(len(tests), "flaky test"), count(runs, "run"), FormatSince(since))
+	if len(tests) > 0 {
+		width, projectWidth := len("TEST"), len("PROJECT")
+		for _, t := range tests {
+			width = max(width, len(t.Test))
+			projectWidth = max(projectWidth, len(t.Project))
+		}
+		project := func(s string) {
+			if multiProject(tests) {
+				fmt.Fprintf(&sb, "%-*s  ", projectWidth, s)
+			}
+		}
+		project("PROJECT")
+		fmt.Fprintf(&sb, "%-*s  %10s  %-20s  %s\n", width, "TEST", "FLAKE RATE", "LAST FAILURE", "OWNER")
+		for _, t := range tests {
+			project(t.Project)
+			fmt.Fprintf(&sb, "%-*s  %10s  %-20s  %s\n", width, t.Test, flakeRate(t), t.LastFailure.Format(time.RFC3339), owners(t))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteFlakyMarkdown writes tests as a Markdown report ready to be posted to
+// an issue or a chat channel for triage, with a project column if they come
+// from more than one project.
+func WriteFlakyMarkdown(w io.Writer, tests []FlakyTest, runs int, since time.Duration) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Flaky tests: last %s\n\n", FormatSince(since))
+	if len(tests) == 0 {
+		fmt.Fprintf(&sb, "No flaky tests in %s.\n", count(runs, "run"))
+	} else {
+		fmt.Fprintf(&sb, "%s in %s, highest flake rate first.\n\n", count(len(tests), "flaky test"), count(runs, "run"))
+		multi := multiProject(tests)
+		if multi {
+			sb.WriteString("| Project ")
+		}
+		sb.WriteString("| Test | Flake rate | Last failure | Owner |\n")
+		if multi {
+			sb.WriteString("|---------")
+		}
+		sb.WriteString("|------|-----------:|--------------|-------|\n")
+		for _, t := range tests {
+			if multi {
+				fmt.Fprintf(&sb, "| `%s` ", markdownCell(t.Project))
+			}
+			fmt.Fprintf(&sb, "| `%s` | %s | %s | %s |\n", t.Test, flakeRate(t), t.LastFailure.Format("2006-01-02 15:04 MST"), markdownCell(owners(t)))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// multiProject reports whether tests come from more than one project.
+func multiProject(tests []FlakyTest) bool {
+	return slices.ContainsFunc(tests, func(t FlakyTest) bool { return t.Project != tests[0].Project })
+}
+
+// flakeRate renders the flake rate of t with its counts, e.g. "25% (1/4)".
+func flakeRate(t FlakyTest) string {
+	return fmt.Sprintf("%.0f%% (%d/%d)", t.Rate*100, t.Failures, t.Runs)
+}
+
+// owners renders the owners of t, or "-" if it has none.
+func owners(t FlakyTest) string {
+	if len(t.Owners) == 0 {
+		return "-"
+	}
+	return strings.Join(t.Owners, ", ")
+}
+
+// markdownCell escapes s for a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// count renders n of noun, e.g. "1 run" or "3 runs".
+func count(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// FormatSince renders d in days or hours when it is a whole number of them,
+// e.g. "7d" or "36h", and as time.Duration does otherwise.
+func FormatSince(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return d.String()
+}
@@ -0,0 +1,122 @@
+// Package history keeps the results of past runs in the history category of
+// the runner cache, one JSON line per run, and derives statistics such as
+// flaky tests from them.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// FileName is the file in the history cache directory holding the runs.
+const FileName = "runs.jsonl"
+
+// Run is the record of one run: which tests passed and which failed.
+type Run struct {
+	ID      string              `json:"run_id"`
+	Time    time.Time           `json:"time"`
+	Project string              `json:"project"`          // project directory, or the common parent of all projects
+	Passed  []string            `json:"passed,omitempty"` // tests as Class.test_name
+	Failed  []string            `json:"failed,omitempty"` // failed and errored tests
+	Owners  map[string][]string `json:"owners,omitempty"` // owners of failed tests, by test
+}
+
+// NewRun builds the record of a run from its report and output. Skipped tests
+// are left out; tests that failed without being in the report, e.g. the one
+// running when Godot crashed, count as failed.
+func NewRun(id, projectDir string, t time.Time, suites *report.JUnitTestSuites, out *report.Output) Run {
+	r := Run{ID: id, Time: t.UTC(), Project: projectDir}
+	failed := map[string]bool{}
+	for _, f := range out.Failures {
+		test := f.Class + "." + f.Method
+		if !failed[test] {
+			failed[test] = true
+			r.Failed = append(r.Failed, test)
+		}
+		if len(f.Owners) > 0 {
+			if r.Owners == nil {
+				r.Owners = map[string][]string{}
+			}
+			r.Owners[test] = f.Owners
+		}
+	}
+	if suites != nil {
+		for _, s := range suites.Suites {
+			for _, tc := range s.TestCases {
+				test := tc.Classname + "." + tc.Name
+				if tc.Failure == nil && tc.Error == nil && tc.Skipped == nil && !failed[test] {
+					r.Passed = append(r.Passed, test)
+				}
+			}
+		}
+	}
+	slices.Sort(r.Passed)
+	r.Passed = slices.Compact(r.Passed)
+	slices.Sort(r.Failed)
+	return r
+}
+
+// Append adds r to the history in dir, creating it.
+func Append(dir string, r Run) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to record run history: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, FileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to record run history: %w", err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record run history: %w", err)
+	}
+	return nil
+}
+
+// Read returns the runs of project in the history in dir that started at or
+// after since, oldest first. An empty project returns the runs of every
+// project. A missing history has no runs; lines that are not runs, such as
+// one cut short by a crash, are skipped.
+func Read(dir, project string, since time.Time) ([]Run, error) {
+	f, err := os.Open(filepath.Join(dir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var r Run
+		if json.Unmarshal(scanner.Bytes(), &r) != nil {
+			continue
+		}
+		if r.Time.Before(since) || (project != "" && r.Project != project) {
+			continue
+		}
+		runs = append(runs, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	slices.SortStableFunc(runs, func(a, b Run) int { return a.Time.Compare(b.Time) })
+	return runs, nil
+}
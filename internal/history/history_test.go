@@ -0,0 +1,159 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+func TestNewRun(t *testing.T) {
+	suites := &report.JUnitTestSuites{Suites: []report.JUnitTestSuite{{TestCases: []report.JUnitTestCase{
+		{Classname: "A", Name: "test_ok"},
+		{Classname: "A", Name: "test_bad", Failure: &report.JUnitFailure{}},
+		{Classname: "A", Name: "test_skip", Skipped: &report.JUnitFailure{}},
+	}}}}
+	out := &report.Output{Failures: []report.Failure{
+		{Class: "A", Method: "test_bad", Owners: []string{"@a"}},
+		{Class: "B", Method: "test_crash"},
+	}}
+
+	r := NewRun("run-1", "/p", time.Unix(0, 0), suites, out)
+	if !slices.Equal(r.Passed, []string{"A.test_ok"}) {
+		t.Errorf("Passed = %q, want [A.test_ok]", r.Passed)
+	}
+	if !slices.Equal(r.Failed, []string{"A.test_bad", "B.test_crash"}) {
+		t.Errorf("Failed = %q, want [A.test_bad B.test_crash]", r.Failed)
+	}
+	if !slices.Equal(r.Owners["A.test_bad"], []string{"@a"}) || len(r.Owners) != 1 {
+		t.Errorf("Owners = %v, want A.test_bad: [@a]", r.Owners)
+	}
+}
+
+func TestAppendRead(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if runs, err := Read(dir, "", time.Time{}); err != nil || runs != nil {
+		t.Fatalf("Read of a missing history = %v, %v, want no runs", runs, err)
+	}
+	for _, r := range []Run{
+		{ID: "old", Time: now.Add(-10 * 24 * time.Hour), Project: "/p"},
+		{ID: "b", Time: now, Project: "/p"},
+		{ID: "a", Time: now.Add(-time.Hour), Project: "/p"},
+		{ID: "other", Time: now, Project: "/q"},
+	} {
+		if err := Append(dir, r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// A line cut short is skipped.
+	f, err := os.OpenFile(filepath.Join(dir, FileName), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"run_id": "cut`)
+	f.Close()
+
+	runs, err := Read(dir, "/p", now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []string
+	for _, r := range runs {
+		ids = append(ids, r.ID)
+	}
+	if !slices.Equal(ids, []string{"a", "b"}) {
+		t.Errorf("runs = %q, want [a b]", ids)
+	}
+	if all, _ := Read(dir, "", time.Time{}); len(all) != 4 {
+		t.Errorf("len(Read of every project) = %d, want 4", len(all))
+	}
+}
+
+func TestFlaky(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 12, 0, 0, 0, time.UTC) }
+	runs := []Run{
+		{ID: "1", Time: day(1), Passed: []string{"A.flaky", "A.rare", "A.ok"}, Failed: []string{"A.broken"}},
+		{ID: "2", Time: day(2), Passed: []string{"A.rare", "A.ok"}, Failed: []string{"A.flaky", "A.broken"}, Owners: map[string][]string{"A.flaky": {"@old"}}},
+		{ID: "3", Time: day(3), Passed: []string{"A.ok"}, Failed: []string{"A.flaky", "A.rare", "A.broken"}, Owners: map[string][]string{"A.flaky": {"@a", "@b"}}},
+		{ID: "4", Time: day(4), Passed: []string{"A.rare", "A.ok"}, Failed: []string{"A.broken"}},
+	}
+
+	got := Flaky(runs)
+	if len(got) != 2 {
+		t.Fatalf("Flaky = %+v, want A.flaky and A.rare", got)
+	}
+	f := got[0]
+	if f.Test != "A.flaky" || f.Runs != 3 || f.Failures != 2 || f.LastRunID != "3" || !f.LastFailure.Equal(day(3)) || !slices.Equal(f.Owners, []string{"@a", "@b"}) {
+		t.Errorf("got[0] = %+v", f)
+	}
+	if got[1].Test != "A.rare" || got[1].Rate != 0.25 {
+		t.Errorf("got[1] = %+v, want A.rare at 0.25", got[1])
+	}
+}
+
+func TestFlaky_ByProject(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 12, 0, 0, 0, time.UTC) }
+	runs := []Run{
+		{ID: "1", Time: day(1), Project: "/game", Passed: []string{"A.test_x"}},
+		{ID: "2", Time: day(2), Project: "/tools", Failed: []string{"A.test_x"}},
+		{ID: "3", Time: day(3), Project: "/tools", Failed: []string{"A.test_x"}},
+		{ID: "4", Time: day(4), Project: "/game", Passed: []string{"A.test_y"}, Failed: []string{"A.test_x"}},
+	}
+
+	// A.test_x always fails in /tools: broken there, flaky only in /game.
+	got := Flaky(runs)
+	if len(got) != 1 || got[0].Project != "/game" || got[0].Test != "A.test_x" || got[0].Runs != 2 {
+		t.Fatalf("Flaky = %+v, want A.test_x of /game only", got)
+	}
+
+	var sb strings.Builder
+	tests := []FlakyTest{
+		{Project: "/game", Test: "A.test_x", Runs: 2, Failures: 1, Rate: 0.5, LastFailure: day(4)},
+		{Project: "/tools", Test: "A.test_x", Runs: 4, Failures: 1, Rate: 0.25, LastFailure: day(3)},
+	}
+	if err := WriteFlakyMarkdown(&sb, tests, 6, 7*24*time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"| Project | Test | Flake rate | Last failure | Owner |\n",
+		"| `/tools` | `A.test_x` | 25% (1/4) |",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("output =\n%s\nwant it to contain %q", sb.String(), want)
+		}
+	}
+}
+
+func TestWriteFlakyMarkdown(t *testing.T) {
+	tests := []FlakyTest{{
+		Test: "A.test_x", Runs: 4, Failures: 1, Rate: 0.25,
+		LastFailure: time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC), Owners: []string{"@a|b"},
+	}}
+
+	var sb strings.Builder
+	if err := WriteFlakyMarkdown(&sb, tests, 12, 7*24*time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "## Flaky tests: last 7d\n\n" +
+		"1 flaky test in 12 runs, highest flake rate first.\n\n" +
+		"| Test | Flake rate | Last failure | Owner |\n" +
+		"|------|-----------:|--------------|-------|\n" +
+		"| `A.test_x` | 25% (1/4) | 2026-10-14 09:30 UTC | @a\\|b |\n"
+	if sb.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	sb.Reset()
+	if err := WriteFlakyMarkdown(&sb, nil, 3, 36*time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "## Flaky tests: last 36h\n\nNo flaky tests in 3 runs.\n"; sb.String() != want {
+		t.Errorf("output = %q, want %q", sb.String(), want)
+	}
+}
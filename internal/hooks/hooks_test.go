@@ -0,0 +1,37 @@
+package hooks
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRun_PassesEnvAndDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on Windows")
+	}
+
+	dir := t.TempDir()
+	var sb strings.Builder
+	err := Run(`echo "$GDUNIT4_RUNNER_STATUS"; pwd`, dir, []string{"GDUNIT4_RUNNER_STATUS=failed"}, &sb)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(sb.String(), "failed") {
+		t.Errorf("output should contain env value, got: %q", sb.String())
+	}
+	if !strings.Contains(sb.String(), dir) {
+		t.Errorf("output should contain working dir %q, got: %q", dir, sb.String())
+	}
+}
+
+func TestRun_NonZeroExit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on Windows")
+	}
+
+	var sb strings.Builder
+	if err := Run("exit 3", t.TempDir(), nil, &sb); err == nil {
+		t.Fatal("expected error for failing hook, got nil")
+	}
+}
@@ -0,0 +1,71 @@
+// Package i18n translates the human-readable output of the runner, such as the
+// plain-text summary and progress lines. Machine-readable output (JSON, XML,
+// CI commands) is never translated.
+//
+// Messages are identified by their English format string, which is also the
+// English translation; a catalog maps it to the format of another language.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Lang is a supported output language.
+type Lang string
+
+// Supported languages.
+const (
+	English  Lang = "en"
+	Japanese Lang = "ja"
+)
+
+// Langs lists the supported languages.
+var Langs = []Lang{English, Japanese}
+
+// catalogs maps a language to its translations of the English formats.
+var catalogs = map[Lang]map[string]string{
+	Japanese: japanese,
+}
+
+// Parse returns the language named by s, a language code ("ja") or a locale
+// name as in LANG ("ja_JP.UTF-8").
+func Parse(s string) (Lang, error) {
+	code, _, _ := strings.Cut(s, ".")
+	code, _, _ = strings.Cut(code, "_")
+	code, _, _ = strings.Cut(code, "-")
+	for _, l := range Langs {
+		if strings.EqualFold(code, string(l)) {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported language %q", s)
+}
+
+// FromEnv returns the language of the locale set in the environment (LC_ALL,
+// LC_MESSAGES or LANG, the first one set), or English if it is unsupported.
+func FromEnv() Lang {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			if l, err := Parse(v); err == nil {
+				return l
+			}
+			return English
+		}
+	}
+	return English
+}
+
+// T returns msg in l, or msg itself if l has no translation for it.
+func (l Lang) T(msg string) string {
+	if t, ok := catalogs[l][msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// Sprintf formats args with the format in l of the English format.
+func (l Lang) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(l.T(format), args...)
+}
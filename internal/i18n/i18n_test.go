@@ -0,0 +1,100 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Lang
+	}{
+		{"ja", Japanese},
+		{"ja_JP.UTF-8", Japanese},
+		{"JA-jp", Japanese},
+		{"en_US.UTF-8", English},
+		{"en", English},
+	}
+	for _, tt := range tests {
+		if got, err := Parse(tt.in); err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := Parse("fr_FR"); err == nil {
+		t.Error("Parse(fr_FR): expected error, got nil")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		all, messages, lang string
+		want                Lang
+	}{
+		{"", "", "ja_JP.UTF-8", Japanese},
+		{"", "en_US.UTF-8", "ja_JP.UTF-8", English},
+		{"ja_JP.UTF-8", "en_US.UTF-8", "", Japanese},
+		{"", "", "C.UTF-8", English},
+		{"", "", "", English},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.all)
+		t.Setenv("LC_MESSAGES", tt.messages)
+		t.Setenv("LANG", tt.lang)
+		if got := FromEnv(); got != tt.want {
+			t.Errorf("FromEnv(LC_ALL=%q LC_MESSAGES=%q LANG=%q) = %q, want %q", tt.all, tt.messages, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	if got := English.Sprintf("%s of %d", "8 passed", 10); got != "8 passed of 10" {
+		t.Errorf("English = %q", got)
+	}
+	if got := Japanese.Sprintf("%s of %d", "成功 8 件", 10); got != "全 10 件中 成功 8 件" {
+		t.Errorf("Japanese = %q", got)
+	}
+	if got := Japanese.T("not in the catalog"); got != "not in the catalog" {
+		t.Errorf("untranslated = %q", got)
+	}
+}
+
+// TestCatalogs checks that every translation takes the same arguments as its
+// English format.
+func TestCatalogs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for format, translated := range catalog {
+			var args []any
+			for _, verb := range verbs(format) {
+				switch verb {
+				case 's':
+					args = append(args, "x")
+				case 'f':
+					args = append(args, 1.0)
+				default:
+					args = append(args, 1)
+				}
+			}
+			if got := fmt.Sprintf(translated, args...); strings.Contains(got, "%!") {
+				t.Errorf("%s: %q formats as %q", lang, translated, got)
+			}
+		}
+	}
+}
+
+// verbs returns the verbs of the directives in format, in order.
+func verbs(format string) []byte {
+	var vs []byte
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		for i++; i < len(format) && strings.IndexByte("0123456789.", format[i]) >= 0; i++ {
+		}
+		if i < len(format) && format[i] != '%' {
+			vs = append(vs, format[i])
+		}
+	}
+	return vs
+}
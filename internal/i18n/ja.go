@@ -0,0 +1,36 @@
+package i18n
+
+// japanese is the Japanese catalog.
+var japanese = map[string]string{
+	// Run status (report.Status).
+	"passed":  "成功",
+	"failed":  "失敗",
+	"error":   "エラー",
+	"crashed": "クラッシュ",
+
+	// Summary counts (report.Summary.CountsIn).
+	"%d passed, %d failed": "成功 %d 件、失敗 %d 件",
+	", %d errors":          "、エラー %d 件",
+	", %d skipped":         "、スキップ %d 件",
+	", %d xfail":           "、想定内の失敗 %d 件",
+	", %d xpass":           "、想定外の成功 %d 件",
+	"%s of %d":             "全 %[2]d 件中 %[1]s",
+
+	// Plain-text summary (report.WriteText).
+	"  in suite %s\n":                    "  スイート %s で実行\n",
+	"  failed %d times (attempts %s)\n":  "  %d 回失敗 (試行 %s)\n",
+	"  expected: %s\n  actual:   %s\n":   "  期待値: %s\n  実際値: %s\n",
+	"  case %d (%s) failed at line %d\n": "  ケース %d (%s) が %d 行目で失敗\n",
+	"\nline coverage: %.1f%% (%d/%d)\n":  "\n行カバレッジ: %.1f%% (%d/%d)\n",
+
+	// Summary line on stderr (report.SummaryLine).
+	"%d/%d tests":         "%d/%d 件のテスト",
+	"%d tests":            "%d 件のテスト",
+	", 1 suite crashed":   "、1 スイートがクラッシュ",
+	", %d suites crashed": "、%d スイートがクラッシュ",
+	", incomplete":        "、未完了",
+	", %s":                "、%s",
+
+	// Progress (pipeline heartbeat).
+	"still running: %d suites done, elapsed %s": "実行中: %d スイート完了、経過時間 %s",
+}
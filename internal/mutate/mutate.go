@@ -0,0 +1,202 @@
+// Package mutate generates simple GDScript mutants and measures how many of them the
+// test suite detects.
+package mutate
+
+import (
+	"strings"
+)
+
+// Mutant is a single-token change to a source file.
+type Mutant struct {
+	Line        int    `json:"line"`   // 1-based
+	Column      int    `json:"column"` // 1-based byte column
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+	offset      int    // byte offset of Original in the file
+}
+
+// operators maps each mutated operator to its replacement. Longer operators come
+// first so that "<=" is not read as "<".
+var operators = []struct{ from, to string }{
+	{"==", "!="},
+	{"!=", "=="},
+	{"<=", ">"},
+	{">=", "<"},
+	{"+=", "-="},
+	{"-=", "+="},
+	{"*=", "/="},
+	{"/=", "*="},
+	{"<", ">="},
+	{">", "<="},
+	{"+", "-"},
+	{"-", "+"},
+	{"*", "/"},
+	{"/", "*"},
+}
+
+// skipped are multi-character operators that contain mutated characters but must not be mutated.
+var skipped = []string{"->", "**", "<<", ">>", ":="}
+
+// keywords maps mutated keywords to their replacements.
+var keywords = map[string]string{
+	"true":  "false",
+	"false": "true",
+	"and":   "or",
+	"or":    "and",
+	"&&":    "||",
+	"||":    "&&",
+}
+
+// declPrefixes mark lines that are declarations rather than logic; they are not mutated.
+var declPrefixes = []string{"func ", "static func ", "extends ", "class_name ", "signal ", "@", "enum ", "class "}
+
+// Generate returns the mutants of a GDScript source in file order. Comments, string
+// literals, declarations and unary signs are left alone.
+func Generate(src []byte) []Mutant {
+	var mutants []Mutant
+	offset := 0
+	for i, line := range strings.SplitAfter(string(src), "\n") {
+		mutants = append(mutants, lineMutants(line, i+1, offset)...)
+		offset += len(line)
+	}
+	return mutants
+}
+
+// lineMutants returns the mutants of one line starting at byte offset base.
+func lineMutants(line string, lineNo, base int) []Mutant {
+	trimmed := strings.TrimSpace(line)
+	for _, p := range declPrefixes {
+		if strings.HasPrefix(trimmed, p) {
+			return nil
+		}
+	}
+
+	var mutants []Mutant
+	add := func(j int, from, to string) {
+		mutants = append(mutants, Mutant{Line: lineNo, Column: j + 1, Original: from, Replacement: to, offset: base + j})
+	}
+
+	prev := byte(0) // last significant character before j, 0 at line start
+	for j := 0; j < len(line); {
+		c := line[j]
+		switch {
+		case c == '#':
+			return mutants
+		case c == '"' || c == '\'':
+			j = skipString(line, j)
+			prev = c
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			j++
+			continue
+		case isWordStart(c):
+			k := j
+			for k < len(line) && isWordChar(line[k]) {
+				k++
+			}
+			if to, ok := keywords[line[j:k]]; ok && prev != '.' {
+				add(j, line[j:k], to)
+			}
+			prev = 'a'
+			j = k
+			continue
+		case c == '$' || (c == '%' && !isOperand(prev) && j+1 < len(line) && isWordStart(line[j+1])):
+			// Node paths ($Player/Sprite, %UniqueName) contain "/" that is not division.
+			j++
+			for j < len(line) && (isWordChar(line[j]) || line[j] == '/') {
+				j++
+			}
+			prev = 'a'
+			continue
+		case isDigit(c):
+			for j < len(line) && (isWordChar(line[j]) || line[j] == '.') {
+				j++
+			}
+			prev = '0'
+			continue
+		}
+
+		if op, ok := matchAny(line[j:], skipped); ok {
+			prev = op[len(op)-1]
+			j += len(op)
+			continue
+		}
+		if op := line[j:min(j+2, len(line))]; op == "&&" || op == "||" {
+			add(j, op, keywords[op])
+			prev = op[1]
+			j += 2
+			continue
+		}
+
+		matched := false
+		for _, o := range operators {
+			if !strings.HasPrefix(line[j:], o.from) {
+				continue
+			}
+			// A + or - after an operator, an opening bracket or at line start is a sign.
+			unary := (o.from == "+" || o.from == "-") && !isOperand(prev)
+			if !unary {
+				add(j, o.from, o.to)
+			}
+			prev = o.from[len(o.from)-1]
+			j += len(o.from)
+			matched = true
+			break
+		}
+		if !matched {
+			prev = c
+			j++
+		}
+	}
+	return mutants
+}
+
+// Apply returns src with m applied.
+func Apply(src []byte, m Mutant) []byte {
+	out := make([]byte, 0, len(src)-len(m.Original)+len(m.Replacement))
+	out = append(out, src[:m.offset]...)
+	out = append(out, m.Replacement...)
+	return append(out, src[m.offset+len(m.Original):]...)
+}
+
+// skipString returns the index just past the string literal starting at line[j].
+// Triple-quoted strings spanning lines are not tracked.
+func skipString(line string, j int) int {
+	quote := line[j]
+	for k := j + 1; k < len(line); k++ {
+		switch line[k] {
+		case '\\':
+			k++
+		case quote:
+			return k + 1
+		}
+	}
+	return len(line)
+}
+
+// matchAny returns the first of ops that s starts with.
+func matchAny(s string, ops []string) (string, bool) {
+	for _, op := range ops {
+		if strings.HasPrefix(s, op) {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// isOperand reports whether c can end an operand, making a following + or - binary.
+func isOperand(c byte) bool {
+	return c == 'a' || c == '0' || c == ')' || c == ']' || c == '"' || c == '\''
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
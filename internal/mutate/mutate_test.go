@@ -0,0 +1,158 @@
+package mutate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/testutil"
+)
+
+// describe renders mutants as "line:col from => to" for comparison.
+func describe(mutants []Mutant) string {
+	var parts []string
+	for _, m := range mutants {
+		parts = append(parts, fmt.Sprintf("%d:%d %s => %s", m.Line, m.Column, m.Original, m.Replacement))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"comparison", "\treturn a < b\n", "1:11 < => >="},
+		{"two char operators", "\tif a <= b and c != d:\n", "1:7 <= => >, 1:12 and => or, 1:18 != => =="},
+		{"arithmetic", "\tx = (a + b) * 2\n", "1:9 + => -, 1:14 * => /"},
+		{"compound assignment", "\tx += 1\n", "1:4 += => -="},
+		{"booleans", "\tvar done = false\n", "1:13 false => true"},
+		{"unary minus is a sign", "\tx = -1\n\ty = foo(-a)\n", ""},
+		{"comment and string", "\tprint(\"a < b\") # x > y\n", ""},
+		{"declaration lines", "func add(a: int, b: int = 1 + 2) -> int:\n@export var speed = 1 + 2\n", ""},
+		{"return arrow and power", "\tvar s := a ** b\n", ""},
+		{"node paths", "\t$Player/Sprite.visible = true\n", "1:27 true => false"},
+		{"identifiers containing keywords", "\tvar origin = android\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describe(Generate([]byte(tt.src))); got != tt.want {
+				t.Errorf("Generate(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	src := []byte("extends Node\n\nfunc f(a, b):\n\treturn a <= b\n")
+	mutants := Generate(src)
+	if len(mutants) != 1 {
+		t.Fatalf("len(mutants) = %d, want 1", len(mutants))
+	}
+	got := string(Apply(src, mutants[0]))
+	want := "extends Node\n\nfunc f(a, b):\n\treturn a > b\n"
+	if got != want {
+		t.Errorf("Apply = %q, want %q", got, want)
+	}
+}
+
+// makeProject creates a Godot project with scripts/calc.gd and a fake godot whose
+// tests pass only while calc.gd still contains "a + b".
+func makeProject(t *testing.T) (root, godot string) {
+	t.Helper()
+	godot = testutil.FakeGodot(t, `echo "$@" >> args.log
+mkdir -p reports/report_1
+if grep -q 'a + b' scripts/calc.gd; then f=0; else f=1; fi
+echo "<testsuites tests=\"1\" failures=\"$f\" errors=\"0\"><testsuite name=\"test_calc\"><testcase name=\"test_add\" classname=\"test_calc\"/></testsuite></testsuites>" > reports/report_1/results.xml
+`)
+	root = testutil.Project(t, map[string]string{
+		"scripts/calc.gd":     "extends Node\n\nfunc add(a, b):\n\treturn a + b\n\nfunc is_big(a):\n\treturn a > 100\n",
+		"tests/test_calc.gd":  "extends GdUnitTestSuite\n\nfunc test_add():\n\tpass\n",
+		"tests/test_other.gd": "extends GdUnitTestSuite\n\nfunc test_other():\n\tpass\n",
+	})
+	return root, godot
+}
+
+func TestRun(t *testing.T) {
+	root, godot := makeProject(t)
+	cfg := &config.MutateConfig{
+		Sources: []string{filepath.Join(root, "scripts", "calc.gd")},
+		Base:    &config.Config{TestPaths: []string{filepath.Join(root, "tests")}, GodotPath: godot},
+	}
+
+	res, err := Run(context.Background(), cfg, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Total != 2 || res.Killed != 1 || res.Survived != 1 || res.Score != 50 {
+		t.Errorf("result = %d total, %d killed, %d survived, score %g; want 2, 1, 1, 50", res.Total, res.Killed, res.Survived, res.Score)
+	}
+	if res.Mutants[0].File != "res://scripts/calc.gd" || res.Mutants[0].Status != StatusKilled {
+		t.Errorf("Mutants[0] = %+v, want killed in res://scripts/calc.gd", res.Mutants[0])
+	}
+
+	src, _ := os.ReadFile(filepath.Join(root, "scripts", "calc.gd"))
+	if !strings.Contains(string(src), "a + b") || !strings.Contains(string(src), "a > 100") {
+		t.Errorf("source was not restored:\n%s", src)
+	}
+
+	// Only the matching suite is run.
+	args, _ := os.ReadFile(filepath.Join(root, "args.log"))
+	if strings.Contains(string(args), "test_other") || !strings.Contains(string(args), "res://tests/test_calc.gd") {
+		t.Errorf("godot args = %q, want only test_calc.gd", args)
+	}
+}
+
+func TestRun_MaxMutants(t *testing.T) {
+	root, godot := makeProject(t)
+	cfg := &config.MutateConfig{
+		Sources:    []string{filepath.Join(root, "scripts", "calc.gd")},
+		MaxMutants: 1,
+		Base:       &config.Config{TestPaths: []string{filepath.Join(root, "tests")}, GodotPath: godot},
+	}
+	res, err := Run(context.Background(), cfg, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Total != 1 {
+		t.Errorf("Total = %d, want 1", res.Total)
+	}
+}
+
+func TestRun_RestoreFailureAborts(t *testing.T) {
+	root, godot := makeProject(t)
+	// The first mutant's run replaces the source with a directory, so that it
+	// cannot be restored.
+	wrapper := testutil.FakeGodot(t, godot+" \"$@\"\nif ! grep -q 'a + b' scripts/calc.gd; then rm scripts/calc.gd && mkdir scripts/calc.gd; fi\n")
+	cfg := &config.MutateConfig{
+		Sources: []string{filepath.Join(root, "scripts", "calc.gd")},
+		Base:    &config.Config{TestPaths: []string{filepath.Join(root, "tests")}, GodotPath: wrapper},
+	}
+	_, err := Run(context.Background(), cfg, io.Discard)
+	if !errors.Is(err, errRestore) {
+		t.Fatalf("Run() error = %v, want a restore error", err)
+	}
+	if args, _ := os.ReadFile(filepath.Join(root, "args.log")); strings.Count(string(args), "\n") != 2 {
+		t.Errorf("godot ran %d times, want the baseline and the first mutant only", strings.Count(string(args), "\n"))
+	}
+}
+
+func TestRun_SourceOutsideProject(t *testing.T) {
+	root, godot := makeProject(t)
+	outside := filepath.Join(t.TempDir(), "x.gd")
+	os.WriteFile(outside, []byte("extends Node\n"), 0o644)
+	cfg := &config.MutateConfig{
+		Sources: []string{outside},
+		Base:    &config.Config{TestPaths: []string{filepath.Join(root, "tests")}, GodotPath: godot},
+	}
+	if _, err := Run(context.Background(), cfg, io.Discard); err == nil {
+		t.Fatal("expected error for source outside the project, got nil")
+	}
+}
@@ -0,0 +1,185 @@
+package mutate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+)
+
+// Mutant statuses.
+const (
+	StatusKilled   = "killed"   // the tests failed or crashed
+	StatusSurvived = "survived" // the tests still passed
+	StatusError    = "error"    // the run did not complete (e.g. timed out); counted as killed
+)
+
+// Result is the outcome of a mutation testing session.
+type Result struct {
+	Score    float64        `json:"mutation_score"` // percentage of mutants killed
+	Total    int            `json:"total"`
+	Killed   int            `json:"killed"`
+	Survived int            `json:"survived"`
+	Mutants  []MutantResult `json:"mutants"`
+}
+
+// MutantResult is the outcome of running the tests against one mutant.
+type MutantResult struct {
+	File string `json:"file"` // res:// path of the mutated script
+	Mutant
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Run mutates each source file of cfg in turn and runs the relevant tests against
+// every mutant. Source files are restored after each run, including when ctx is
+// cancelled. Progress is written to stderr.
+func Run(ctx context.Context, cfg *config.MutateConfig, stderr io.Writer) (*Result, error) {
+	detected, err := detector.DetectIn(cfg.Base.ProjectDir, cfg.Base.TestPaths)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := discovery.Discover(detected.ProjectDir, detected.ResPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Mutants: []MutantResult{}}
+	for _, source := range cfg.Sources {
+		if err := mutateFile(ctx, cfg, detected.ProjectDir, suites, source, res, stderr); err != nil {
+			return nil, err
+		}
+		if cfg.MaxMutants > 0 && res.Total >= cfg.MaxMutants {
+			break
+		}
+	}
+
+	res.Score = 100
+	if res.Total > 0 {
+		res.Score = float64(res.Killed) * 100 / float64(res.Total)
+	}
+	return res, nil
+}
+
+// mutateFile runs the tests against every mutant of source and appends the outcomes to res.
+func mutateFile(ctx context.Context, cfg *config.MutateConfig, projectDir string, suites []discovery.Suite, source string, res *Result, stderr io.Writer) error {
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(projectDir, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("source %s is outside the project %s", source, projectDir)
+	}
+	resPath := "res://" + filepath.ToSlash(rel)
+
+	src, err := os.ReadFile(abs)
+	if err != nil {
+		return fmt.Errorf("failed to read source: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return err
+	}
+
+	run := *cfg.Base
+	run.TestPaths = relevantTests(abs, projectDir, suites, cfg.Base.TestPaths)
+
+	// The tests must pass on the unmodified source for the score to mean anything.
+	start := time.Now()
+	baseline, err := pipeline.Execute(ctx, &run, pipeline.Options{Stderr: stderr})
+	if err != nil {
+		return fmt.Errorf("baseline run for %s: %w", resPath, err)
+	}
+	if baseline.ExitCode != 0 {
+		return fmt.Errorf("tests for %s do not pass without mutations (status %s)", resPath, baseline.Output.Summary.Status)
+	}
+	if run.Timeout == 0 {
+		// Mutants can loop forever; give each run generous headroom over the baseline.
+		run.Timeout = 3*time.Since(start) + 30*time.Second
+	}
+
+	mutants := Generate(src)
+	for i, m := range mutants {
+		if cfg.MaxMutants > 0 && res.Total >= cfg.MaxMutants {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		mr := MutantResult{File: resPath, Mutant: m}
+		code, runErr := runMutant(ctx, &run, abs, src, info.Mode(), m, stderr)
+		switch {
+		case errors.Is(runErr, errRestore):
+			return runErr
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case runErr != nil:
+			mr.Status = StatusError
+			mr.Error = runErr.Error()
+		case code == 0:
+			mr.Status = StatusSurvived
+		default:
+			mr.Status = StatusKilled
+		}
+
+		res.Total++
+		if mr.Status == StatusSurvived {
+			res.Survived++
+		} else {
+			res.Killed++
+		}
+		res.Mutants = append(res.Mutants, mr)
+		fmt.Fprintf(stderr, "mutant %d/%d %s:%d:%d %s -> %s: %s\n", i+1, len(mutants), resPath, m.Line, m.Column, m.Original, m.Replacement, mr.Status)
+	}
+	return nil
+}
+
+// errRestore reports that a mutated source file could not be restored, which
+// aborts the session rather than test further mutants against a changed file.
+var errRestore = errors.New("failed to restore the source")
+
+// runMutant writes the mutated source, runs the tests and restores the original.
+func runMutant(ctx context.Context, cfg *config.Config, path string, src []byte, mode os.FileMode, m Mutant, stderr io.Writer) (int, error) {
+	if err := os.WriteFile(path, Apply(src, m), mode); err != nil {
+		return 0, fmt.Errorf("failed to write mutant: %w", err)
+	}
+
+	out, err := pipeline.Execute(ctx, cfg, pipeline.Options{Stderr: io.Discard})
+	if rerr := os.WriteFile(path, src, mode); rerr != nil {
+		return 0, fmt.Errorf("%w %s: %v", errRestore, path, rerr)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return out.ExitCode, nil
+}
+
+// relevantTests returns the suites whose file name matches the source's
+// (player.gd -> test_player.gd, player_test.gd, PlayerTest.gd), falling back to all of fallback.
+func relevantTests(source, projectDir string, suites []discovery.Suite, fallback []string) []string {
+	want := strings.ToLower(strings.TrimSuffix(filepath.Base(source), ".gd"))
+	var paths []string
+	for _, s := range suites {
+		name := strings.ToLower(strings.TrimSuffix(filepath.Base(s.ResPath), ".gd"))
+		name = strings.TrimPrefix(name, "test_")
+		name = strings.TrimSuffix(strings.TrimSuffix(name, "_test"), "test")
+		if name == want {
+			paths = append(paths, filepath.Join(projectDir, filepath.FromSlash(strings.TrimPrefix(s.ResPath, "res://"))))
+		}
+	}
+	if len(paths) == 0 {
+		return fallback
+	}
+	return paths
+}
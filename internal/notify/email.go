@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+)
+
+// emailNotifier sends a failure digest over SMTP.
+type emailNotifier struct {
+	addr     string
+	host     string
+	from     string
+	to       []string
+	username string
+	password string
+	now      func() time.Time
+}
+
+func newEmailNotifier(nc config.Notifier) (*emailNotifier, error) {
+	host, _, err := net.SplitHostPort(nc.SMTP)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp address %q: want host:port", nc.SMTP)
+	}
+	return &emailNotifier{
+		addr:     nc.SMTP,
+		host:     host,
+		from:     nc.From,
+		to:       nc.To,
+		username: os.ExpandEnv(nc.Username),
+		password: os.ExpandEnv(nc.Password),
+		now:      time.Now,
+	}, nil
+}
+
+func (n *emailNotifier) Send(ctx context.Context, msg *Message) error {
+	data, err := n.compose(msg)
+	if err != nil {
+		return err
+	}
+
+	d := net.Dialer{Timeout: 30 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	conn.SetDeadline(deadline)
+
+	implicitTLS := strings.HasSuffix(n.addr, ":465")
+	if implicitTLS {
+		conn = tls.Client(conn, &tls.Config{ServerName: n.host})
+	}
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && !implicitTLS {
+		if err := c.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection to a remote host.
+		if err := c.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.from); err != nil {
+		return err
+	}
+	for _, to := range n.to {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose builds a multipart/alternative email with a plain text and an HTML body.
+func (n *emailNotifier) compose(msg *Message) ([]byte, error) {
+	var htmlBody bytes.Buffer
+	if err := digestTemplate.Execute(&htmlBody, msg); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", n.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", Subject(msg)))
+	fmt.Fprintf(&buf, "Date: %s\r\n", n.now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", Text(msg)},
+		{"text/html; charset=utf-8", htmlBody.String()},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// digestTemplate renders the HTML body: the summary and a table of failures.
+var digestTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+<h2>{{.Project}}: {{.Status}}</h2>
+<p>{{.Summary.Counts}} tests.</p>
+{{- with .CrashDetails}}{{if .CrashInfo}}
+<h3>Godot crashed</h3>
+<pre>{{.CrashInfo}}</pre>
+{{- end}}{{end}}
+{{- if .Failures}}
+<table border="1" cellpadding="4" cellspacing="0" style="border-collapse: collapse">
+<tr><th>Test</th><th>Location</th><th>Owners</th><th>Message</th></tr>
+{{- range .Failures}}
+<tr><td>{{.Class}}.{{.Method}}</td><td>{{.File}}:{{.Line}}</td><td>{{range $i, $o := .Owners}}{{if $i}}, {{end}}{{$o}}{{end}}</td><td><pre>{{.Message}}{{if .Expected}}
+expected: {{.Expected}}
+actual:   {{.Actual}}{{end}}</pre></td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body></html>
+`))
@@ -0,0 +1,143 @@
+// Package notify sends run results to chat and HTTP endpoints according to the
+// routing rules in the config file.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// maxListed caps the failures listed in a text message; the rest are summarized.
+const maxListed = 20
+
+// Message is what a notifier receives: the run summary and the failures routed to it.
+type Message struct {
+	Project      string               `json:"project"` // base name of the project directory
+	Status       string               `json:"status"`
+	Summary      report.Summary       `json:"summary"`
+	CrashDetails *report.CrashDetails `json:"crash_details,omitempty"`
+	Failures     []report.Failure     `json:"failures"`
+	Rules        []string             `json:"rules"` // names of the rules that routed this message
+}
+
+// Notifier delivers messages to one destination.
+type Notifier interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New creates the notifier described by nc.
+func New(nc config.Notifier) (Notifier, error) {
+	if nc.Type == config.NotifierEmail {
+		return newEmailNotifier(nc)
+	}
+
+	url := os.ExpandEnv(nc.URL)
+	if url == "" {
+		return nil, fmt.Errorf("%s notifier url %q is empty after expanding environment variables", nc.Type, nc.URL)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	switch nc.Type {
+	case config.NotifierSlack:
+		return &slackNotifier{client: client, url: url, channel: nc.Channel}, nil
+	case config.NotifierWebhook:
+		return &webhookNotifier{client: client, url: url}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier type %q", nc.Type)
+}
+
+// Subject renders a one-line summary of msg, e.g. "game: failed (3 of 10 tests failed)".
+func Subject(msg *Message) string {
+	s := msg.Summary
+	if msg.Status == "crashed" && s.Total == 0 {
+		return msg.Project + ": crashed"
+	}
+	return fmt.Sprintf("%s: %s (%d of %d tests failed)", msg.Project, msg.Status, s.Failed+s.Errors, s.Total)
+}
+
+// Text renders msg as plain text: a headline followed by one line per failure.
+func Text(msg *Message) string {
+	var b strings.Builder
+	s := msg.Summary
+	fmt.Fprintf(&b, "%s: %s (%s)\n", msg.Project, msg.Status, s.Counts())
+	if msg.CrashDetails != nil && msg.CrashDetails.CrashInfo != "" {
+		fmt.Fprintf(&b, "Godot crashed: %s\n", firstLine(msg.CrashDetails.CrashInfo))
+	}
+	for i, f := range msg.Failures {
+		if i == maxListed {
+			fmt.Fprintf(&b, "... and %d more\n", len(msg.Failures)-maxListed)
+			break
+		}
+		fmt.Fprintf(&b, "- %s.%s (%s:%d)", f.Class, f.Method, f.File, f.Line)
+		if len(f.Owners) > 0 {
+			fmt.Fprintf(&b, " %s", strings.Join(f.Owners, " "))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// slackNotifier posts to a Slack incoming webhook.
+type slackNotifier struct {
+	client  *http.Client
+	url     string
+	channel string
+}
+
+func (n *slackNotifier) Send(ctx context.Context, msg *Message) error {
+	payload := struct {
+		Text    string `json:"text"`
+		Channel string `json:"channel,omitempty"`
+	}{Text: Text(msg), Channel: n.channel}
+	return postJSON(ctx, n.client, n.url, payload)
+}
+
+// webhookNotifier posts the message as JSON to an arbitrary endpoint.
+type webhookNotifier struct {
+	client *http.Client
+	url    string
+}
+
+func (n *webhookNotifier) Send(ctx context.Context, msg *Message) error {
+	return postJSON(ctx, n.client, n.url, msg)
+}
+
+// postJSON POSTs v as JSON to url and fails on a non-2xx response.
+func postJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
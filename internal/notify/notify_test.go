@@ -0,0 +1,239 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+func failedOutput() *report.Output {
+	return &report.Output{
+		Summary: report.Summary{Total: 5, Passed: 2, Failed: 3, Status: "failed"},
+		Failures: []report.Failure{
+			{Class: "HttpTest", Method: "test_get", File: "res://tests/net/HttpTest.gd", Line: 7, Owners: []string{"@net-team"}},
+			{Class: "MenuTest", Method: "test_open", File: "res://tests/ui/MenuTest.gd", Line: 12, Owners: []string{"@ui"}},
+			{Class: "MathTest", Method: "test_big", File: "res://tests/MathTest.gd", Line: 3},
+		},
+	}
+}
+
+func methods(msg *Message) string {
+	var names []string
+	for _, f := range msg.Failures {
+		names = append(names, f.Method)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRoute(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "tests"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	suite := "extends GdUnitTestSuite\n\n# @tag: slow\nfunc test_big():\n\tpass\n"
+	if err := os.WriteFile(filepath.Join(root, "tests", "MathTest.gd"), []byte(suite), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		out    *report.Output
+		rules  []config.NotifyRule
+		want   map[string]string // notifier → routed test methods
+		wantBy string            // rule names of the "a" message
+	}{
+		{
+			name:  "catch-all",
+			out:   failedOutput(),
+			rules: []config.NotifyRule{{Notify: []string{"a"}}},
+			want:  map[string]string{"a": "test_get,test_open,test_big"},
+		},
+		{
+			name: "path, owner and tag",
+			out:  failedOutput(),
+			rules: []config.NotifyRule{
+				{Name: "net", Paths: []string{"tests/net/"}, Notify: []string{"a"}},
+				{Name: "ui", Owners: []string{"@ui"}, Notify: []string{"b"}},
+				{Name: "slow", Tags: []string{"slow"}, Notify: []string{"a"}},
+			},
+			want:   map[string]string{"a": "test_get,test_big", "b": "test_open"},
+			wantBy: "net,slow",
+		},
+		{
+			name:  "all criteria must match",
+			out:   failedOutput(),
+			rules: []config.NotifyRule{{Paths: []string{"tests/net/"}, Owners: []string{"@ui"}, Notify: []string{"a"}}},
+			want:  map[string]string{},
+		},
+		{
+			name:  "passed runs are not sent by default",
+			out:   &report.Output{Summary: report.Summary{Total: 1, Passed: 1, Status: "passed"}},
+			rules: []config.NotifyRule{{Notify: []string{"a"}}, {Status: []string{"passed"}, Notify: []string{"b"}}},
+			want:  map[string]string{"b": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs, err := Route(tt.out, root, tt.rules)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(msgs) != len(tt.want) {
+				t.Fatalf("got messages for %d notifiers, want %d", len(msgs), len(tt.want))
+			}
+			for target, want := range tt.want {
+				msg, ok := msgs[target]
+				if !ok {
+					t.Fatalf("no message for %s", target)
+				}
+				if got := methods(msg); got != want {
+					t.Errorf("%s got %q, want %q", target, got, want)
+				}
+			}
+			if tt.wantBy != "" && strings.Join(msgs["a"].Rules, ",") != tt.wantBy {
+				t.Errorf("Rules = %v, want %s", msgs["a"].Rules, tt.wantBy)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	var slack, hook []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/slack":
+			slack = body
+		case "/hook":
+			hook = body
+		default:
+			http.Error(w, "no such hook", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("TEST_NOTIFY_URL", srv.URL)
+
+	n := config.Notify{
+		Notifiers: map[string]config.Notifier{
+			"qa":     {Type: config.NotifierSlack, URL: "${TEST_NOTIFY_URL}/slack", Channel: "#qa"},
+			"hook":   {Type: config.NotifierWebhook, URL: "$TEST_NOTIFY_URL/hook"},
+			"broken": {Type: config.NotifierWebhook, URL: "$TEST_NOTIFY_URL/missing"},
+		},
+		Rules: []config.NotifyRule{
+			{Owners: []string{"@ui"}, Notify: []string{"qa"}},
+			{Notify: []string{"hook", "broken"}},
+		},
+	}
+	sent, err := Send(context.Background(), n, failedOutput(), "/work/game")
+	if err == nil || !strings.Contains(err.Error(), "broken: 404") {
+		t.Errorf("err = %v, want the broken notifier's 404", err)
+	}
+	if strings.Join(sent, ",") != "hook,qa" {
+		t.Errorf("sent = %v, want [hook qa]", sent)
+	}
+
+	var sp struct{ Text, Channel string }
+	if err := json.Unmarshal(slack, &sp); err != nil {
+		t.Fatalf("slack payload %q: %v", slack, err)
+	}
+	if sp.Channel != "#qa" || !strings.HasPrefix(sp.Text, "game: failed (2 passed, 3 failed of 5)\n") ||
+		!strings.Contains(sp.Text, "- MenuTest.test_open (res://tests/ui/MenuTest.gd:12) @ui") || strings.Contains(sp.Text, "HttpTest") {
+		t.Errorf("slack payload = %+v", sp)
+	}
+
+	var msg Message
+	if err := json.Unmarshal(hook, &msg); err != nil {
+		t.Fatalf("webhook payload %q: %v", hook, err)
+	}
+	if msg.Project != "game" || len(msg.Failures) != 3 {
+		t.Errorf("webhook message = %+v", msg)
+	}
+}
+
+// fakeSMTP accepts one SMTP session on a local listener and sends the received
+// envelope and data on the returned channel.
+func fakeSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var session strings.Builder
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.Fields(line + " ")[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL", "RCPT":
+				session.WriteString(line + "\n")
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, _ := tp.ReadDotBytes()
+				session.Write(data)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				got <- session.String()
+				return
+			default:
+				tp.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+	return ln.Addr().String(), got
+}
+
+func TestEmailNotifier(t *testing.T) {
+	addr, got := fakeSMTP(t)
+	n, err := New(config.Notifier{Type: config.NotifierEmail, SMTP: addr, From: "ci@example.com", To: []string{"qa@example.com", "net@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &Message{Project: "game", Status: "failed", Summary: report.Summary{Total: 5, Passed: 2, Failed: 3}, Failures: failedOutput().Failures}
+	msg.Failures[0].Message = "expected <1> but was <2>"
+	if err := n.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	session := <-got
+	for _, want := range []string{
+		"MAIL FROM:<ci@example.com>",
+		"RCPT TO:<net@example.com>",
+		"Subject: game: failed (3 of 5 tests failed)",
+		"Content-Type: multipart/alternative",
+		"Content-Type: text/html; charset=utf-8",
+		"<td>HttpTest.test_get</td><td>res://tests/net/HttpTest.gd:7</td><td>@net-team</td>",
+		"expected &lt;1&gt; but was &lt;2&gt;",
+	} {
+		if !strings.Contains(strings.ReplaceAll(session, "=\n", ""), want) {
+			t.Errorf("email does not contain %q:\n%s", want, session)
+		}
+	}
+}
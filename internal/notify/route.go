@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+	"github.com/minami110/gdunit4-test-runner/internal/owners"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Route applies rules to out and returns the message for each notifier that
+// receives one, keyed by notifier name. A rule fires when the run status matches
+// and, if it has path, tag or owner criteria, at least one failure matches all of
+// them; the notifier then gets the matching failures. Failures routed to the same
+// notifier by several rules are sent once.
+func Route(out *report.Output, projectDir string, rules []config.NotifyRule) (map[string]*Message, error) {
+	tags, err := failureTags(out, projectDir, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	routed := map[string][]bool{} // notifier → which failures it receives
+	ruleNames := map[string][]string{}
+	for i, r := range rules {
+		if !matchStatus(r.Status, out.Summary.Status) {
+			continue
+		}
+		filtered := len(r.Paths)+len(r.Tags)+len(r.Owners) > 0
+		var picked []bool
+		matched := false
+		for j, f := range out.Failures {
+			ok := !filtered || matchFailure(r, f, tags[j])
+			picked = append(picked, ok)
+			matched = matched || ok
+		}
+		if filtered && !matched {
+			continue
+		}
+
+		name := r.Name
+		if name == "" {
+			name = "#" + strconv.Itoa(i+1)
+		}
+		for _, target := range r.Notify {
+			sel, ok := routed[target]
+			if !ok {
+				sel = make([]bool, len(out.Failures))
+				routed[target] = sel
+			}
+			for j := range sel {
+				sel[j] = sel[j] || picked[j]
+			}
+			ruleNames[target] = append(ruleNames[target], name)
+		}
+	}
+
+	msgs := map[string]*Message{}
+	for target, sel := range routed {
+		msg := &Message{
+			Project:      filepath.Base(projectDir),
+			Status:       out.Summary.Status,
+			Summary:      out.Summary,
+			CrashDetails: out.CrashDetails,
+			Failures:     []report.Failure{},
+			Rules:        ruleNames[target],
+		}
+		for j, ok := range sel {
+			if ok {
+				msg.Failures = append(msg.Failures, out.Failures[j])
+			}
+		}
+		msgs[target] = msg
+	}
+	return msgs, nil
+}
+
+// Send routes out with n.Rules and delivers the messages. It returns the names of
+// the notifiers that were sent to; delivery errors are joined and do not stop the
+// remaining notifiers.
+func Send(ctx context.Context, n config.Notify, out *report.Output, projectDir string) ([]string, error) {
+	msgs, err := Route(out, projectDir, n.Rules)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]string, 0, len(msgs))
+	for target := range msgs {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	var sent []string
+	var errs []error
+	for _, target := range targets {
+		notifier, err := New(n.Notifiers[target])
+		if err == nil {
+			err = notifier.Send(ctx, msgs[target])
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+			continue
+		}
+		sent = append(sent, target)
+	}
+	return sent, errors.Join(errs...)
+}
+
+// matchStatus reports whether status is one of want, which defaults to every
+// status of an unsuccessful run.
+func matchStatus(want []string, status string) bool {
+	if len(want) == 0 {
+		return status != "passed"
+	}
+	return slices.Contains(want, status)
+}
+
+// matchFailure reports whether f satisfies every criterion of r. tags are the tags of f's test.
+func matchFailure(r config.NotifyRule, f report.Failure, tags []string) bool {
+	if len(r.Paths) > 0 {
+		rel := strings.TrimPrefix(f.File, "res://")
+		if !slices.ContainsFunc(r.Paths, func(p string) bool { return owners.Match(p, rel) }) {
+			return false
+		}
+	}
+	if len(r.Tags) > 0 && !overlaps(r.Tags, tags) {
+		return false
+	}
+	if len(r.Owners) > 0 && !overlaps(r.Owners, f.Owners) {
+		return false
+	}
+	return true
+}
+
+func overlaps(a, b []string) bool {
+	return slices.ContainsFunc(a, func(s string) bool { return slices.Contains(b, s) })
+}
+
+// failureTags returns the tags of each failure's test, indexed like out.Failures.
+// Suites are only read when a rule matches on tags; failures whose file is gone have none.
+func failureTags(out *report.Output, projectDir string, rules []config.NotifyRule) ([][]string, error) {
+	tags := make([][]string, len(out.Failures))
+	if !slices.ContainsFunc(rules, func(r config.NotifyRule) bool { return len(r.Tags) > 0 }) {
+		return tags, nil
+	}
+
+	var files []string
+	for _, f := range out.Failures {
+		if !strings.HasPrefix(f.File, "res://") || slices.Contains(files, f.File) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(projectDir, filepath.FromSlash(strings.TrimPrefix(f.File, "res://")))); err == nil {
+			files = append(files, f.File)
+		}
+	}
+	suites, err := discovery.Discover(projectDir, files)
+	if err != nil {
+		return nil, err
+	}
+	byPath := map[string]discovery.Suite{}
+	for _, s := range suites {
+		byPath[s.ResPath] = s
+	}
+	for i, f := range out.Failures {
+		if s, ok := byPath[f.File]; ok {
+			tags[i] = s.TagsOf(f.Method)
+		}
+	}
+	return tags, nil
+}
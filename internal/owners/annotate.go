@@ -0,0 +1,54 @@
+package owners
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Annotate sets the owners of every failure in out from rules and adds the
+// ownership summary. Failure files are res:// paths inside projectDir.
+func Annotate(out *report.Output, projectDir string, rules *Rules) {
+	if rules == nil || len(out.Failures) == 0 {
+		return
+	}
+
+	own := &report.Ownership{}
+	byOwner := map[string]*report.OwnerFailures{}
+	for i := range out.Failures {
+		f := &out.Failures[i]
+		test := f.Class + "." + f.Method
+		if strings.HasPrefix(f.File, "res://") {
+			f.Owners = rules.Owners(filepath.Join(projectDir, filepath.FromSlash(strings.TrimPrefix(f.File, "res://"))))
+		}
+		if len(f.Owners) == 0 {
+			own.Unowned = append(own.Unowned, test)
+			continue
+		}
+		for _, o := range f.Owners {
+			of, ok := byOwner[o]
+			if !ok {
+				of = &report.OwnerFailures{Owner: o}
+				byOwner[o] = of
+			}
+			of.Failures++
+			of.Tests = append(of.Tests, test)
+		}
+	}
+
+	own.Owners = make([]report.OwnerFailures, 0, len(byOwner))
+	for _, of := range byOwner {
+		own.Owners = append(own.Owners, *of)
+	}
+	// Most failures first so the owner with the reddest build is at the top.
+	sort.Slice(own.Owners, func(i, j int) bool {
+		a, b := own.Owners[i], own.Owners[j]
+		if a.Failures != b.Failures {
+			return a.Failures > b.Failures
+		}
+		return a.Owner < b.Owner
+	})
+	out.Ownership = own
+}
@@ -0,0 +1,205 @@
+// Package owners assigns failing tests to their owners from a CODEOWNERS file or a
+// custom owners map.
+package owners
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Locations searched for a CODEOWNERS file, relative to the repository root,
+// in the order GitHub uses.
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rules maps file paths to owners. Patterns follow GitHub's CODEOWNERS syntax
+// and are relative to Root; when several rules match a path, the last one wins.
+type Rules struct {
+	Root  string
+	rules []rule
+}
+
+type rule struct {
+	pattern string
+	owners  []string
+}
+
+// Parse reads CODEOWNERS rules from r. Patterns are relative to root.
+func Parse(r io.Reader, root string) (*Rules, error) {
+	rs := &Rules{Root: root}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// A pattern without owners is valid and removes ownership for matching paths.
+		rs.rules = append(rs.rules, rule{pattern: fields[0], owners: fields[1:]})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CODEOWNERS: %w", err)
+	}
+	return rs, nil
+}
+
+// FromMap builds rules from a pattern → owners map such as the "owners.map" config key.
+// Patterns are relative to root; longer patterns take precedence over shorter ones.
+func FromMap(m map[string][]string, root string) *Rules {
+	patterns := make([]string, 0, len(m))
+	for p := range m {
+		patterns = append(patterns, p)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) < len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	rs := &Rules{Root: root}
+	for _, p := range patterns {
+		rs.rules = append(rs.rules, rule{pattern: p, owners: m[p]})
+	}
+	return rs
+}
+
+// Load returns the ownership rules for a project. A non-empty custom map is used as is,
+// relative to projectDir. Otherwise file is read if set, or else the first CODEOWNERS
+// in Locations of the repository containing projectDir; its patterns are relative to
+// the repository root. It returns nil rules when no CODEOWNERS file exists.
+func Load(projectDir, file string, custom map[string][]string) (*Rules, error) {
+	if len(custom) > 0 {
+		return FromMap(custom, projectDir), nil
+	}
+
+	root := RepoRoot(projectDir)
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open CODEOWNERS: %w", err)
+		}
+		defer f.Close()
+		return Parse(f, root)
+	}
+
+	for _, loc := range Locations {
+		f, err := os.Open(filepath.Join(root, filepath.FromSlash(loc)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open CODEOWNERS: %w", err)
+		}
+		defer f.Close()
+		return Parse(f, root)
+	}
+	return nil, nil
+}
+
+// RepoRoot returns the closest directory at or above dir that contains .git,
+// or dir itself when it is not inside a git repository.
+func RepoRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// Owners returns the owners of the file at path (absolute, or relative to Root),
+// or nil when no rule assigns any.
+func (rs *Rules) Owners(file string) []string {
+	if rs == nil {
+		return nil
+	}
+	rel := file
+	if filepath.IsAbs(file) {
+		var err error
+		if rel, err = filepath.Rel(rs.Root, file); err != nil || strings.HasPrefix(rel, "..") {
+			return nil
+		}
+	}
+	rel = filepath.ToSlash(rel)
+
+	var owners []string
+	for _, r := range rs.rules {
+		if Match(r.pattern, rel) {
+			owners = r.owners
+		}
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+	return owners
+}
+
+// Match reports whether the CODEOWNERS pattern matches the slash-separated path rel.
+// As on GitHub, a pattern containing a non-trailing "/" is anchored to the root, others
+// match at any depth; a pattern matching a directory matches everything below it,
+// except that a trailing "/*" matches direct children only.
+func Match(pattern, rel string) bool {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	p := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return false
+	}
+
+	pat := strings.Split(p, "/")
+	if !anchored {
+		pat = append([]string{"**"}, pat...)
+	}
+	segs := strings.Split(rel, "/")
+
+	if !dirOnly && matchSegments(pat, segs) {
+		return true
+	}
+	if pat[len(pat)-1] == "*" {
+		return false
+	}
+	for i := len(segs) - 1; i >= 1; i-- {
+		if matchSegments(pat, segs[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against pattern segments, where "**" spans
+// zero or more segments and other segments use path.Match.
+func matchSegments(pat, segs []string) bool {
+	if len(pat) == 0 {
+		return len(segs) == 0
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pat[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pat[0], segs[0]); !ok {
+		return false
+	}
+	return matchSegments(pat[1:], segs[1:])
+}
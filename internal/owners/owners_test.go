@@ -0,0 +1,133 @@
+package owners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*", "game/tests/test_a.gd", true},
+		{"*.gd", "game/tests/test_a.gd", true},
+		{"*.gd", "README.md", false},
+		{"tests/", "game/tests/test_a.gd", true},
+		{"tests/", "tests", false},
+		{"/tests/", "game/tests/test_a.gd", false},
+		{"/game/tests/", "game/tests/test_a.gd", true},
+		{"game/tests", "game/tests/net/test_a.gd", true},
+		{"docs/*", "docs/a.md", true},
+		{"docs/*", "docs/build/a.md", false},
+		{"**/net", "game/tests/net/test_a.gd", true},
+		{"game/**/test_*.gd", "game/tests/net/test_a.gd", true},
+		{"game/**/test_*.gd", "other/tests/test_a.gd", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParse_LastMatchWins(t *testing.T) {
+	src := "# default owners\n*  @core\n\ngame/tests/net/ @net-team @ops # networking\ngame/tests/net/legacy/\n"
+	rules, err := Parse(strings.NewReader(src), "/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"/repo/game/tests/test_math.gd", "@core"},
+		{"/repo/game/tests/net/test_http.gd", "@net-team,@ops"},
+		{"/repo/game/tests/net/legacy/test_old.gd", ""},
+		{"game/tests/test_math.gd", "@core"},
+		{"/elsewhere/test_math.gd", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(rules.Owners(tt.file), ","); got != tt.want {
+			t.Errorf("Owners(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestFromMap_LongerPatternWins(t *testing.T) {
+	rules := FromMap(map[string][]string{
+		"tests/":     {"@qa"},
+		"tests/net/": {"@net-team"},
+	}, "/project")
+	if got := strings.Join(rules.Owners("/project/tests/net/test_http.gd"), ","); got != "@net-team" {
+		t.Errorf("Owners = %q, want @net-team", got)
+	}
+	if got := strings.Join(rules.Owners("/project/tests/test_math.gd"), ","); got != "@qa" {
+		t.Errorf("Owners = %q, want @qa", got)
+	}
+}
+
+func TestLoad_FindsRepositoryCODEOWNERS(t *testing.T) {
+	repo := t.TempDir()
+	project := filepath.Join(repo, "game")
+	for _, dir := range []string{".git", ".github", "game"} {
+		if err := os.MkdirAll(filepath.Join(repo, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".github", "CODEOWNERS"), []byte("/game/tests/ @qa\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := Load(project, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(rules.Owners(filepath.Join(project, "tests", "test_a.gd")), ","); got != "@qa" {
+		t.Errorf("Owners = %q, want @qa", got)
+	}
+
+	none, err := Load(t.TempDir(), "", nil)
+	if err != nil || none != nil {
+		t.Errorf("Load without CODEOWNERS = %v, %v; want nil, nil", none, err)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	rules := FromMap(map[string][]string{"tests/net/": {"@net-team", "@ops"}, "tests/ui/": {"@ui"}}, "/project")
+	out := &report.Output{Failures: []report.Failure{
+		{Class: "HttpTest", Method: "test_get", File: "res://tests/net/HttpTest.gd"},
+		{Class: "HttpTest", Method: "test_post", File: "res://tests/net/HttpTest.gd"},
+		{Class: "MenuTest", Method: "test_open", File: "res://tests/ui/MenuTest.gd"},
+		{Class: "MathTest", Method: "test_add", File: "res://tests/MathTest.gd"},
+	}}
+	Annotate(out, "/project", rules)
+
+	if got := strings.Join(out.Failures[0].Owners, ","); got != "@net-team,@ops" {
+		t.Errorf("Failures[0].Owners = %q, want @net-team,@ops", got)
+	}
+	if out.Failures[3].Owners != nil {
+		t.Errorf("Failures[3].Owners = %v, want none", out.Failures[3].Owners)
+	}
+	own := out.Ownership
+	if own == nil {
+		t.Fatal("Ownership = nil")
+	}
+	var got []string
+	for _, o := range own.Owners {
+		got = append(got, o.Owner+":"+strings.Join(o.Tests, "+"))
+	}
+	want := "@net-team:HttpTest.test_get+HttpTest.test_post,@ops:HttpTest.test_get+HttpTest.test_post,@ui:MenuTest.test_open"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Owners = %v, want %s", got, want)
+	}
+	if strings.Join(own.Unowned, ",") != "MathTest.test_add" {
+		t.Errorf("Unowned = %v, want [MathTest.test_add]", own.Unowned)
+	}
+}
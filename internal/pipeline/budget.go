@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// budgetGrace is how long a run over its --budget waits for the running suite
+// to finish before Godot is stopped in the middle of it.
+const budgetGrace = time.Minute
+
+// budget stops a run that used up its wall-clock budget at the next suite
+// boundary, so that no suite is cut short, or budgetGrace later at the latest.
+type budget struct {
+	cancel context.CancelFunc
+	soft   *time.Timer
+	hard   *time.Timer
+
+	mu      sync.Mutex
+	expired bool
+	hit     bool
+}
+
+// startBudget starts the clock of a budget of d.
+func startBudget(d time.Duration, cancel context.CancelFunc) *budget {
+	b := &budget{cancel: cancel}
+	b.soft = time.AfterFunc(d, func() {
+		b.mu.Lock()
+		b.expired = true
+		b.mu.Unlock()
+	})
+	b.hard = time.AfterFunc(d+budgetGrace, b.stop)
+	return b
+}
+
+// observe records a line of Godot output; a suite starting after the budget
+// expired stops the run.
+func (b *budget) observe(line string) {
+	if _, ok := report.SuiteStart(line); !ok {
+		return
+	}
+	b.mu.Lock()
+	expired := b.expired
+	b.mu.Unlock()
+	if expired {
+		b.stop()
+	}
+}
+
+func (b *budget) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.hit {
+		b.hit = true
+		b.cancel()
+	}
+}
+
+// reached reports whether the budget stopped the run.
+func (b *budget) reached() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.hit
+}
+
+// halt stops the clock.
+func (b *budget) halt() {
+	b.soft.Stop()
+	b.hard.Stop()
+}
@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// debuggerPoll is how often waitForDebugger checks for the debugger.
+var debuggerPoll = time.Second
+
+// waitForDebugger returns once something listens at uri, the tcp://host:port
+// of --debug-server. If nothing does yet, it tells how to start the Godot
+// editor's debugger there and waits for it until ctx is done.
+func waitForDebugger(ctx context.Context, uri string, stderr io.Writer) error {
+	addr := strings.TrimPrefix(uri, "tcp://")
+	dial := func() bool {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+	if dial() {
+		return nil
+	}
+
+	_, port, _ := net.SplitHostPort(addr)
+	fmt.Fprintf(stderr, "waiting for a debugger at %s (Ctrl-C to stop waiting):\n", uri)
+	fmt.Fprintf(stderr, "  open the project in the Godot editor, whose debugger listens on the Remote Port of\n")
+	fmt.Fprintf(stderr, "  Editor Settings > Network > Debug (%s here), and set breakpoints in the tests\n", port)
+	ticker := time.NewTicker(debuggerPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no debugger at %s: %w", uri, ctx.Err())
+		case <-ticker.C:
+			if dial() {
+				fmt.Fprintf(stderr, "debugger found at %s; starting Godot\n", uri)
+				return nil
+			}
+		}
+	}
+}
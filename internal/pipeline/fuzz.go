@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// fuzzerSeedParamRe matches a fixed seed in a fuzz test signature, e.g. "fuzzer_seed = 123".
+var fuzzerSeedParamRe = regexp.MustCompile(`fuzzer_seed\s*(?::\s*int\s*)?:?=\s*(-?\d+)`)
+
+// annotateFuzz completes the fuzz details of failures in out: the seed fixed in the
+// test's signature when gdUnit4 did not print one, and a command reproducing the test.
+func annotateFuzz(out *report.Output, projectDir string) {
+	for i := range out.Failures {
+		f := &out.Failures[i]
+		if f.Fuzz == nil {
+			continue
+		}
+		rel := strings.TrimPrefix(f.File, "res://")
+		if f.Fuzz.Seed == nil && rel != "" {
+			if src, err := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(rel))); err == nil {
+				f.Fuzz.Seed = signatureSeed(string(src), f.Method)
+			}
+		}
+		if rel != "" {
+			// An inherited test is run through the suite, not the base class.
+			f.Fuzz.Reproduce = "gdunit4-test-runner --filter " + f.Class + "." + f.Method + " " + strings.TrimPrefix(cmp.Or(f.Suite, f.File), "res://")
+		}
+	}
+}
+
+// signatureSeed returns the fuzzer_seed default of the test function named method in src.
+func signatureSeed(src, method string) *int64 {
+	re := regexp.MustCompile(`(?m)^func\s+` + regexp.QuoteMeta(method) + `\s*\(`)
+	loc := re.FindStringIndex(src)
+	if loc == nil {
+		return nil
+	}
+	// The signature ends at the colon that opens the body; parameters may span lines.
+	sig := src[loc[1]:]
+	if end := strings.Index(sig, "):"); end >= 0 {
+		sig = sig[:end]
+	} else if end := strings.Index(sig, ") ->"); end >= 0 {
+		sig = sig[:end]
+	}
+	m := fuzzerSeedParamRe.FindStringSubmatch(sig)
+	if m == nil {
+		return nil
+	}
+	seed, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &seed
+}
@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// hardwareInfo describes the machine of a run whose log names the GPU Godot
+// rendered on; it returns nil for headless runs.
+func hardwareInfo(logFile string, stderr io.Writer) *report.Hardware {
+	gpu, err := report.ScanGPU(logFile)
+	if err != nil {
+		fmt.Fprintln(stderr, "warning: GPU info:", err)
+		return nil
+	}
+	if len(gpu) == 0 {
+		return nil
+	}
+	return &report.Hardware{
+		GPU:  gpu,
+		CPU:  cpuModel(),
+		CPUs: runtime.NumCPU(),
+		OS:   runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+// cpuModel returns the CPU model name, or "" if the OS does not tell it.
+func cpuModel() string {
+	switch runtime.GOOS {
+	case "linux":
+		data, err := os.ReadFile("/proc/cpuinfo")
+		if err != nil {
+			return ""
+		}
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			if key, value, ok := strings.Cut(sc.Text(), ":"); ok && strings.TrimSpace(key) == "model name" {
+				return strings.TrimSpace(value)
+			}
+		}
+	case "darwin":
+		out, err := exec.Command("sysctl", "-n", "machdep.cpu.brand_string").Output()
+		if err == nil {
+			return strings.TrimSpace(string(out))
+		}
+	case "windows":
+		return os.Getenv("PROCESSOR_IDENTIFIER")
+	}
+	return ""
+}
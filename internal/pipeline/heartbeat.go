@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/i18n"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// heartbeat periodically prints a progress line so that CI systems with
+// no-output timeouts do not kill a long, quiet Godot run.
+type heartbeat struct {
+	mu      sync.Mutex
+	started int // suites started so far
+	start   time.Time
+	lang    i18n.Lang
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// startHeartbeat prints "still running: N suites done, elapsed 3m10s" in lang to w every
+// interval until stop is called. Lines passed to observe are used to count suites.
+func startHeartbeat(w io.Writer, interval time.Duration, lang i18n.Lang) *heartbeat {
+	h := &heartbeat{start: time.Now(), lang: lang, stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(h.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Fprintln(w, h.status())
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+	return h
+}
+
+// observe records a line of Godot output.
+func (h *heartbeat) observe(line string) {
+	if _, ok := report.SuiteStart(line); ok {
+		h.mu.Lock()
+		h.started++
+		h.mu.Unlock()
+	}
+}
+
+// status renders the progress line. The suite that is running is not counted as done.
+func (h *heartbeat) status() string {
+	h.mu.Lock()
+	done := max(h.started-1, 0)
+	h.mu.Unlock()
+	return h.lang.Sprintf("still running: %d suites done, elapsed %s", done, time.Since(h.start).Round(time.Second))
+}
+
+// halt stops the heartbeat and waits for the printing goroutine to exit.
+func (h *heartbeat) halt() {
+	close(h.stop)
+	<-h.done
+}
@@ -0,0 +1,22 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/history"
+)
+
+// recordHistory adds the run res, started at started, to the run history in
+// dir, which stats flaky and the dashboard read. Failing to write it is a
+// warning: the history is a convenience.
+func recordHistory(dir string, started time.Time, res *Result, stderr io.Writer) {
+	if res.Output == nil || (res.Suites == nil && len(res.Output.Failures) == 0) {
+		return // nothing ran
+	}
+	r := history.NewRun(res.RunID, res.ProjectDir, started, res.Suites, res.Output)
+	if err := history.Append(dir, r); err != nil {
+		fmt.Fprintln(stderr, "warning:", err)
+	}
+}
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// maxSlowLoads is how many resources --profile-startup reports.
+const maxSlowLoads = 10
+
+// loadRe matches the line Godot prints with --verbose when it loads a
+// resource, e.g. "Loading resource: res://levels/world.tscn".
+var loadRe = regexp.MustCompile(`^\s*Loading resource:\s+(res://\S+)`)
+
+// loadProfile times the resource loads Godot logs with --verbose. Godot does
+// not log how long a load took, so a load is timed until the next line of
+// output; a scene's time thus excludes the dependencies it logs loading.
+type loadProfile struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	suite   string // suite running
+	pending string // resource whose load is being timed
+	since   time.Time
+	loads   map[string]*report.ResourceLoad
+}
+
+func newLoadProfile() *loadProfile {
+	return &loadProfile{now: time.Now, loads: map[string]*report.ResourceLoad{}}
+}
+
+// observe records a line of Godot output.
+func (p *loadProfile) observe(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if p.pending != "" {
+		p.record(p.pending, now.Sub(p.since))
+		p.pending = ""
+	}
+	if suite, ok := report.SuiteStart(line); ok {
+		p.suite = suite
+	}
+	if m := loadRe.FindStringSubmatch(line); m != nil {
+		p.pending, p.since = m[1], now
+	}
+}
+
+func (p *loadProfile) record(path string, d time.Duration) {
+	l := p.loads[path]
+	if l == nil {
+		l = &report.ResourceLoad{Path: path, Suite: p.suite}
+		p.loads[path] = l
+	}
+	l.Count++
+	l.Seconds += d.Seconds()
+	l.Max = max(l.Max, d.Seconds())
+}
+
+// slowest returns the resources that took longest to load in total, slowest first.
+func (p *loadProfile) slowest() []report.ResourceLoad {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	loads := make([]report.ResourceLoad, 0, len(p.loads))
+	for _, l := range p.loads {
+		r := *l
+		r.Seconds, r.Max = round3(r.Seconds), round3(r.Max)
+		loads = append(loads, r)
+	}
+	slices.SortFunc(loads, func(a, b report.ResourceLoad) int {
+		return cmp.Or(cmp.Compare(b.Seconds, a.Seconds), cmp.Compare(a.Path, b.Path))
+	})
+	return loads[:min(len(loads), maxSlowLoads)]
+}
+
+// writeSlowLoads prints loads as a table to w.
+func writeSlowLoads(w io.Writer, loads []report.ResourceLoad) {
+	if len(loads) == 0 {
+		fmt.Fprintln(w, "profile-startup: Godot logged no resource loads")
+		return
+	}
+	fmt.Fprintln(w, "slowest resource loads:")
+	for _, l := range loads {
+		fmt.Fprintf(w, "  %8.3fs  %3dx  %s", l.Seconds, l.Count, l.Path)
+		if l.Suite != "" {
+			fmt.Fprintf(w, "  (%s)", l.Suite)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,213 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// ManifestFileName is the file in a run's report directory that records how
+// the run was made.
+const ManifestFileName = "run-manifest.json"
+
+// manifestVersion is the version of the run manifest format.
+const manifestVersion = 1
+
+// redacted replaces the values of secrets in the manifest.
+const redacted = "<redacted>"
+
+// manifest records everything needed to reproduce a run: the command, the
+// resolved configuration, the environment, the paths used, when it ran and
+// what came of it. It is written locally and never sent anywhere.
+type manifest struct {
+	Version     int            `json:"version"`
+	RunID       string         `json:"run_id"`
+	Runner      string         `json:"runner_version,omitempty"`
+	Command     []string       `json:"command"`
+	WorkDir     string         `json:"work_dir"`
+	Config      config.Config  `json:"config"`
+	Environment manifestEnv    `json:"environment"`
+	Paths       manifestPaths  `json:"paths"`
+	Timing      manifestTiming `json:"timing"`
+	Result      manifestResult `json:"result"`
+}
+
+type manifestEnv struct {
+	OS           string            `json:"os"`
+	Arch         string            `json:"arch"`
+	Hostname     string            `json:"hostname,omitempty"`
+	GodotVersion string            `json:"godot_version,omitempty"`
+	Vars         map[string]string `json:"vars"` // the variables that affect a run; secrets redacted
+}
+
+type manifestPaths struct {
+	Godot      string   `json:"godot"`
+	Project    string   `json:"project"`
+	Tests      []string `json:"tests"` // res:// paths Godot was given
+	ReportDir  string   `json:"report_dir,omitempty"`
+	LogFile    string   `json:"log_file,omitempty"`
+	TimingFile string   `json:"timing_file"`
+}
+
+type manifestTiming struct {
+	Started  time.Time      `json:"started"`
+	Finished time.Time      `json:"finished"`
+	Seconds  float64        `json:"seconds"`
+	Godot    *report.Timing `json:"godot,omitempty"`
+}
+
+type manifestResult struct {
+	ExitCode      int             `json:"exit_code"`
+	GodotExitCode int             `json:"godot_exit_code"`
+	Error         string          `json:"error,omitempty"`
+	Summary       *report.Summary `json:"summary,omitempty"`
+	Reports       []manifestFile  `json:"reports,omitempty"` // the results files read, with their digests
+}
+
+type manifestFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// manifestVars are the environment variables recorded in the manifest, by
+// name or, ending in "*", by prefix.
+var manifestVars = []string{"GDUNIT4_*", "GODOT*", "CI", "LANG", "LC_ALL", "LC_MESSAGES", "TZ"}
+
+// writeManifest writes the manifest of the run res made of cfg, started at
+// started and ending with runErr, to its report directory under reportsDir,
+// and records its path in res.Output. Failing to write it is a warning.
+func writeManifest(cfg *config.Config, detected *detector.Result, reportsDir, runner string, started time.Time, res *Result, runErr error, stderr io.Writer) {
+	finished := time.Now()
+	m := manifest{
+		Version: manifestVersion,
+		RunID:   res.RunID,
+		Runner:  runner,
+		Command: os.Args,
+		Config:  redactConfig(cfg),
+		Environment: manifestEnv{
+			OS:   runtime.GOOS,
+			Arch: runtime.GOARCH,
+			Vars: environment(),
+		},
+		Paths: manifestPaths{
+			Godot:      cfg.GodotPath,
+			Project:    detected.ProjectDir,
+			Tests:      detected.ResPaths,
+			ReportDir:  res.ReportDir,
+			TimingFile: timingPath(cfg, detected.ProjectDir),
+		},
+		Timing: manifestTiming{
+			Started:  started,
+			Finished: finished,
+			Seconds:  finished.Sub(started).Seconds(),
+		},
+		Result: manifestResult{ExitCode: res.ExitCode, GodotExitCode: res.GodotExitCode},
+	}
+	m.WorkDir, _ = os.Getwd()
+	m.Environment.Hostname, _ = os.Hostname()
+	if runErr != nil {
+		m.Result.Error = runErr.Error()
+	}
+	if out := res.Output; out != nil {
+		m.Paths.LogFile = out.LogFile
+		m.Timing.Godot = out.Timing
+		m.Result.Summary = &out.Summary
+		if out.Project != nil {
+			m.Environment.GodotVersion = out.Project.GodotVersion
+		}
+	}
+	for _, p := range res.reports {
+		sum, err := fileDigest(p)
+		if err != nil {
+			continue
+		}
+		m.Result.Reports = append(m.Result.Reports, manifestFile{Path: p, SHA256: sum})
+	}
+
+	dir := filepath.Join(reportsDir, res.RunID)
+	path := filepath.Join(dir, ManifestFileName)
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(dir, 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0o644)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "warning: run manifest:", err)
+		return
+	}
+	if res.Output != nil {
+		res.Output.Manifest = path
+	}
+}
+
+// redactConfig returns a copy of cfg without the credentials of its notifiers
+// and the query of its upload URL, which may hold a signature.
+func redactConfig(cfg *config.Config) config.Config {
+	c := *cfg
+	if u, _, ok := strings.Cut(c.Upload, "?"); ok {
+		c.Upload = u + "?" + redacted
+	}
+	if len(cfg.Notify.Notifiers) > 0 {
+		c.Notify.Notifiers = make(map[string]config.Notifier, len(cfg.Notify.Notifiers))
+		for name, n := range cfg.Notify.Notifiers {
+			for _, s := range []*string{&n.URL, &n.Username, &n.Password} {
+				if *s != "" {
+					*s = redacted
+				}
+			}
+			c.Notify.Notifiers[name] = n
+		}
+	}
+	return c
+}
+
+// environment returns the environment variables listed in manifestVars, with
+// the values of those whose names suggest a secret redacted.
+func environment() map[string]string {
+	vars := map[string]string{}
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if !slices.ContainsFunc(manifestVars, func(p string) bool {
+			prefix, ok := strings.CutSuffix(p, "*")
+			return name == p || ok && strings.HasPrefix(name, prefix)
+		}) {
+			continue
+		}
+		upper := strings.ToUpper(name)
+		for _, s := range []string{"TOKEN", "SECRET", "PASSWORD", "KEY"} {
+			if strings.Contains(upper, s) {
+				value = redacted
+			}
+		}
+		vars[name] = value
+	}
+	return vars
+}
+
+// fileDigest returns the hex SHA-256 of the file at path.
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
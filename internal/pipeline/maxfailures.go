@@ -0,0 +1,35 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// StatusAbortedMaxFailures is the status of a run stopped by --max-failures.
+const StatusAbortedMaxFailures = "aborted_max_failures"
+
+// failureLimit cancels the run once max tests have failed or errored.
+type failureLimit struct {
+	max    int
+	cancel context.CancelFunc
+
+	mu  sync.Mutex
+	hit bool
+}
+
+// observe records the number of failed and errored tests so far.
+func (l *failureLimit) observe(failures int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if failures >= l.max && !l.hit {
+		l.hit = true
+		l.cancel()
+	}
+}
+
+// reached reports whether the limit stopped the run.
+func (l *failureLimit) reached() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.hit
+}
@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"cmp"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// liveResults tallies the test results Godot prints while it runs. gdUnit4
+// writes its report only at the end, so for a run stopped early by
+// --max-failures or --budget they are all that is left.
+type liveResults struct {
+	mu       sync.Mutex
+	passed   int
+	failures []report.Failure
+}
+
+// observe records a line of Godot output and returns the number of failed and
+// errored tests so far.
+func (r *liveResults) observe(line string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	suite, test, status, ok := report.TestResult(line)
+	if !ok {
+		return len(r.failures)
+	}
+	switch status {
+	case "passed":
+		r.passed++
+	case "failed", "error":
+		kind := report.KindFailure
+		if status == "error" {
+			kind = report.KindError
+		}
+		r.failures = append(r.failures, report.Failure{
+			Kind:   kind,
+			Class:  strings.TrimSuffix(path.Base(suite), path.Ext(suite)),
+			Method: test,
+			File:   suite,
+		})
+	}
+	return len(r.failures)
+}
+
+// output builds the incomplete result of a stopped run from the tests seen
+// before it stopped. An empty status is derived from the counts.
+func (r *liveResults) output(status string) *report.Output {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := report.Summary{Passed: r.passed}
+	for _, f := range r.failures {
+		if f.Kind == report.KindError {
+			s.Errors++
+		} else {
+			s.Failed++
+		}
+	}
+	s.Total = s.Passed + s.Failed + s.Errors
+	s.Status = cmp.Or(status, report.Status(false, s.Failed, s.Errors))
+	return &report.Output{Summary: s, Failures: append([]report.Failure{}, r.failures...), Incomplete: true}
+}
@@ -0,0 +1,826 @@
+package pipeline
+
+import (
+	"cmp"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/coverage"
+	"github.com/minami110/gdunit4-test-runner/internal/crashdump"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+	"github.com/minami110/gdunit4-test-runner/internal/hooks"
+	"github.com/minami110/gdunit4-test-runner/internal/owners"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+	"github.com/minami110/gdunit4-test-runner/internal/runner"
+	"github.com/minami110/gdunit4-test-runner/internal/snapshot"
+)
+
+// EnvRunID is the environment variable holding the run ID for Godot and hooks.
+const EnvRunID = "GDUNIT4_RUNNER_RUN_ID"
+
+// NewRunID returns a sortable, unique run ID such as "20260102T150405Z-1a2b3c4d".
+func NewRunID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+// Options controls a single pipeline execution.
+type Options struct {
+	OnLine func(line string) // called for each line of Godot output, if set
+	Stderr io.Writer         // destination for --verbose output, hook output and warnings; defaults to os.Stderr
+
+	Version string // version of the runner, recorded in the run manifest
+
+	// HistoryDir is the run history to record the run in (see
+	// internal/history); the run is not recorded if empty. The runs of a
+	// workspace are recorded per project.
+	HistoryDir string
+}
+
+// Result holds the outcome of a pipeline execution.
+type Result struct {
+	RunID      string // unique ID of the run; see NewRunID
+	ProjectDir string
+	Output     *report.Output          // nil when the run failed before a result was produced
+	Suites     *report.JUnitTestSuites // parsed report; nil when no report was produced
+	ReportDir  string                  // gdUnit4 report directory containing results.xml; empty when none
+	Coverage   coverage.Profile        // merged coverage data; nil unless cfg.CoverageOut is set
+	ExitCode   int
+
+	// GodotExitCode is the exit code of Godot itself, gdUnit4's 0 (passed), 100
+	// (failed) or 101 (warnings) unless Godot crashed; -1 if Godot did not run
+	// or did not exit on its own.
+	GodotExitCode int
+
+	transient string   // the known-transient error the run failed with only, if any
+	reports   []string // the results files read
+}
+
+// Stages of the pipeline a StageError comes from.
+const (
+	StageDetection = "detection" // finding the project and the tests to run
+	StageHook      = "hook"      // the pre_run hook
+)
+
+// StageError is a tool-level failure of the pipeline before Godot ran, so
+// that callers can tell what went wrong without matching messages.
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string { return e.Err.Error() }
+
+func (e *StageError) Unwrap() error { return e.Err }
+
+// Execute runs detection, hooks, Godot and report parsing for cfg.
+// A non-nil error means a tool-level failure; Result is still returned with
+// ExitCode 2 so callers can report it uniformly.
+func Execute(ctx context.Context, cfg *config.Config, opts Options) (*Result, error) {
+	stderr := opts.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	started := time.Now()
+
+	if cfg.ProjectDir == "" {
+		groups, err := detector.Group(cfg.TestPaths)
+		if err != nil {
+			return &Result{ExitCode: 2, GodotExitCode: -1}, &StageError{StageDetection, err}
+		}
+		if len(groups) > 1 {
+			return executeWorkspace(ctx, cfg, groups, opts, stderr)
+		}
+	}
+
+	detected, err := detector.DetectIn(cfg.ProjectDir, cfg.TestPaths)
+	if err != nil {
+		return &Result{ExitCode: 2, GodotExitCode: -1}, &StageError{StageDetection, err}
+	}
+	res := &Result{RunID: NewRunID(), ProjectDir: detected.ProjectDir, ExitCode: 2, GodotExitCode: -1}
+
+	// Starting Godot costs seconds; don't pay it for paths without tests.
+	if detected.ResPaths, err = validatePaths(detected, stderr); err != nil {
+		return res, &StageError{StageDetection, err}
+	}
+
+	if cfg.CmdTool != "" {
+		rel := filepath.FromSlash(strings.TrimPrefix(cfg.CmdTool, "res://"))
+		if _, err := os.Stat(filepath.Join(detected.ProjectDir, rel)); err != nil {
+			return res, &StageError{StageDetection, fmt.Errorf("--cmd-tool %s not found in project %s", cfg.CmdTool, detected.ProjectDir)}
+		}
+	}
+
+	settings, projectInfo := checkProject(ctx, cfg.GodotPath, detected.ProjectDir, stderr)
+	// A run stopped at a breakpoint or a debugger prompt must not time out.
+	if cfg.Timeout == 0 && settings.GdUnit4.TestTimeout > 0 && cfg.DebugServer == "" && !cfg.Interactive {
+		run := *cfg
+		run.Timeout = settings.GdUnit4.TestTimeout
+		cfg = &run
+	}
+
+	criteria := discovery.Criteria{Patterns: cfg.Filter, Tags: cfg.Tags, SkipTags: cfg.SkipTags}
+	if len(criteria.Patterns)+len(criteria.Tags)+len(criteria.SkipTags) > 0 {
+		if detected.ResPaths, err = selectTests(detected, criteria); err != nil {
+			return res, &StageError{StageDetection, err}
+		}
+	}
+	if len(cfg.Schedule.Order) > 0 {
+		if detected.ResPaths, err = orderSuites(detected, cfg.Schedule.Order); err != nil {
+			return res, &StageError{StageDetection, err}
+		}
+	}
+
+	if cfg.Hooks.PreRun != "" {
+		env := []string{"GDUNIT4_RUNNER_PROJECT_DIR=" + detected.ProjectDir, EnvRunID + "=" + res.RunID}
+		if err := hooks.Run(cfg.Hooks.PreRun, detected.ProjectDir, env, stderr); err != nil {
+			return res, &StageError{StageHook, fmt.Errorf("pre_run %w", err)}
+		}
+	}
+
+	temps := newRunTemps(cfg)
+	defer temps.sweep(stderr)
+	err = execute(ctx, cfg, detected, settings.ReportsDir(detected.ProjectDir), temps, opts.OnLine, stderr, res)
+	if sig := res.transient; sig != "" && ctx.Err() == nil {
+		fmt.Fprintf(stderr, "warning: Godot failed with a known transient error, retrying once: %s\n", sig)
+		res = &Result{RunID: NewRunID(), ProjectDir: detected.ProjectDir, ExitCode: 2, GodotExitCode: -1}
+		err = execute(ctx, cfg, detected, settings.ReportsDir(detected.ProjectDir), temps, opts.OnLine, stderr, res)
+		if res.Output != nil {
+			res.Output.TransientRetry = sig
+		}
+	}
+	if res.Output != nil {
+		res.Output.RunID = res.RunID
+		res.Output.Project = projectInfo
+	}
+	if res.Coverage != nil && res.Output != nil {
+		applyCoverage(res, cfg.Coverage)
+	}
+	res.sort()
+	writeManifest(cfg, detected, settings.ReportsDir(detected.ProjectDir), opts.Version, started, res, err, stderr)
+	if opts.HistoryDir != "" {
+		recordHistory(opts.HistoryDir, started, res, stderr)
+	}
+
+	if cfg.Hooks.PostRun != "" {
+		runPostHook(cfg.Hooks.PostRun, detected.ProjectDir, temps, res.RunID, res.Output, res.ExitCode, stderr)
+	}
+	return res, err
+}
+
+// sort puts the suites and the output of r into their canonical order (see
+// report.Output.Sort).
+func (r *Result) sort() {
+	if r.Suites != nil {
+		r.Suites.Sort()
+	}
+	if r.Output != nil {
+		r.Output.Sort()
+	}
+}
+
+// execute runs Godot and fills res from its log and report. Temp files of the
+// run live in a directory of temps named after the run ID, and gdUnit4 writes
+// its report to <reportsDir>/<run id>/, so concurrent runs against one project
+// do not collide.
+func execute(ctx context.Context, cfg *config.Config, detected *detector.Result, reportsDir string, temps *runTemps, onLine func(string), stderr io.Writer, res *Result) error {
+	tempDir, err := temps.dir(res.RunID)
+	if err != nil {
+		return err
+	}
+	reportDir := filepath.Join(reportsDir, res.RunID)
+
+	env := []string{EnvRunID + "=" + res.RunID}
+	if cfg.CollectCoverage() {
+		dir, err := os.MkdirTemp(tempDir, "gdunit4-coverage-*")
+		if err != nil {
+			return fmt.Errorf("failed to create coverage dir: %w", err)
+		}
+		env = append(env, coverage.EnvDir+"="+dir)
+		defer func() { res.Coverage = collectCoverage(dir, detected.ProjectDir, stderr) }()
+	}
+	if cfg.UpdateSnapshots {
+		env = append(env, snapshot.EnvUpdate+"=1")
+	}
+	if cfg.IsolateUserData {
+		sandbox := filepath.Join(tempDir, "user_data")
+		if err := os.Mkdir(sandbox, 0o700); err != nil {
+			return fmt.Errorf("failed to create user data dir: %w", err)
+		}
+		env = append(env, userDataEnv(sandbox)...)
+		// Runs before the run temp dir is removed, once res.Output is set.
+		defer func() {
+			dst := filepath.Join(reportDir, "user_data")
+			captured, err := captureUserData(sandbox, dst)
+			if err != nil {
+				fmt.Fprintln(stderr, "warning: user data:", err)
+			}
+			if captured && res.Output != nil {
+				res.Output.UserDataDir = dst
+			}
+		}()
+	}
+
+	// Verbose runs print Godot's own output, which is enough to keep CI alive.
+	var hb *heartbeat
+	if cfg.Heartbeat > 0 && !cfg.Verbose && !cfg.Interactive {
+		hb = startHeartbeat(stderr, cfg.Heartbeat, cfg.Lang)
+		next := onLine
+		onLine = func(line string) {
+			hb.observe(line)
+			if next != nil {
+				next(line)
+			}
+		}
+	}
+
+	// --max-failures and --budget stop Godot early and keep what it printed.
+	var (
+		live  *liveResults
+		limit *failureLimit
+		bud   *budget
+	)
+	if cfg.MaxFailures > 0 || cfg.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		live = &liveResults{}
+		if cfg.MaxFailures > 0 {
+			limit = &failureLimit{max: cfg.MaxFailures, cancel: cancel}
+		}
+		if cfg.Budget > 0 {
+			bud = startBudget(cfg.Budget, cancel)
+			defer bud.halt()
+		}
+		next := onLine
+		onLine = func(line string) {
+			failures := live.observe(line)
+			if limit != nil {
+				limit.observe(failures)
+			}
+			if bud != nil {
+				bud.observe(line)
+			}
+			if next != nil {
+				next(line)
+			}
+		}
+	}
+
+	tm := newTiming()
+	var loads *loadProfile
+	if cfg.ProfileStartup {
+		loads = newLoadProfile()
+	}
+	next := onLine
+	onLine = func(line string) {
+		tm.observe(line)
+		if loads != nil {
+			loads.observe(line)
+		}
+		if next != nil {
+			next(line)
+		}
+	}
+
+	// Single tests cannot be selected with -a, only through a runner config.
+	var configFile string
+	if runner.SelectsTests(detected.ResPaths) {
+		configFile = filepath.Join(tempDir, "GdUnitRunner.cfg")
+	}
+	if cfg.DebugServer != "" {
+		if err := waitForDebugger(ctx, cfg.DebugServer, stderr); err != nil {
+			return err
+		}
+	}
+	if cfg.CrashDumps {
+		if err := crashdump.Enable(); err != nil {
+			fmt.Fprintln(stderr, "warning: crash dumps:", err)
+		}
+	}
+	started := time.Now()
+	result, err := runner.RunContext(ctx, cfg.GodotPath, detected.ProjectDir, detected.ResPaths, runner.Options{
+		Verbose: cfg.Verbose,
+		Streams: cfg.VerboseStreams,
+		Timeout: cfg.Timeout,
+		OnLine:  onLine,
+		TempDir: tempDir,
+		Env:     env,
+
+		VerboseOut: stderr,
+		MaxLogSize: cfg.MaxLogSize,
+		KillGrace:  cfg.KillGrace,
+		ReportDir:  reportDir,
+		ConfigFile: configFile,
+		CmdTool:    cmp.Or(cfg.CmdTool, detected.CmdTool),
+
+		GodotVerbose: cfg.ProfileStartup,
+		RemoteDebug:  cfg.DebugServer,
+		Interactive:  cfg.Interactive,
+		Render:       cfg.Render,
+	})
+	if hb != nil {
+		hb.halt()
+	}
+	if err != nil && limit != nil && limit.reached() {
+		fmt.Fprintf(stderr, "warning: stopped Godot after %d failed tests (--max-failures)\n", cfg.MaxFailures)
+		res.Output = live.output(StatusAbortedMaxFailures)
+		res.ExitCode = ExitCode(res.Output)
+		return nil
+	}
+	if err != nil && bud != nil && bud.reached() {
+		fmt.Fprintf(stderr, "warning: stopped Godot after its %s budget (--budget); results are incomplete\n", cfg.Budget)
+		res.Output = live.output("")
+		res.ExitCode = ExitCode(res.Output)
+		return nil
+	}
+	if err != nil && result != nil {
+		salvage(cfg, result.LogFile, reportDir, reportsDir, started, stderr, res)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	res.GodotExitCode = result.ExitCode
+	if err := saveTimings(timingPath(cfg, detected.ProjectDir), tm.suiteDurations()); err != nil {
+		fmt.Fprintln(stderr, "warning: timing file:", err)
+	}
+	defer func() {
+		if res.Output == nil {
+			return
+		}
+		res.Output.Timing = tm.result(result)
+		if loads != nil {
+			res.Output.SlowLoads = loads.slowest()
+			writeSlowLoads(stderr, res.Output.SlowLoads)
+		}
+	}()
+	// logFile is the plain log read below: the kept one, unless it is compressed.
+	logFile, kept := result.LogFile, ""
+	if dst := keptLog(cfg, reportDir); dst != "" {
+		if err := keepLog(result.LogFile, dst); err != nil {
+			fmt.Fprintln(stderr, "warning: log file:", err)
+		} else {
+			kept = dst
+			if !report.Compressed(kept) {
+				logFile = kept
+			}
+			defer func() {
+				if res.Output != nil {
+					res.Output.LogFile = kept
+				}
+			}()
+		}
+	}
+
+	// Detect crashes in the Godot output log.
+	crash, err := report.DetectCrash(logFile)
+	if err != nil {
+		return err
+	}
+	if crash != nil && cfg.CrashDumps {
+		dumps := collectDumps(cfg, detected.ProjectDir, result, started, filepath.Join(reportDir, "crash_dumps"), stderr)
+		defer func() {
+			if res.Output != nil && res.Output.CrashDetails != nil {
+				res.Output.CrashDetails.Dumps = dumps
+			}
+		}()
+	}
+	hardware := hardwareInfo(logFile, stderr)
+	defer func() {
+		if res.Output != nil {
+			res.Output.Hardware = hardware
+		}
+	}()
+
+	// If the process crashed (non-zero exit without a parseable report), emit crash-only output.
+	xmlPaths, xmlErr := findReports(cfg, reportDir, reportsDir, started)
+	if xmlErr != nil {
+		res.Output = report.BuildOutput(nil, crash)
+		res.transient = findTransient(cfg, logFile, stderr)
+		attachSuiteLogs(res.Output, logFile, kept, stderr)
+		if crash == nil {
+			// Godot ran but produced no report (unexpected).
+			fmt.Fprintln(stderr, "warning: Godot produced no test report")
+		}
+		return nil
+	}
+
+	// The reports are decoded whole rather than streamed (see
+	// report.StreamXML): res.Suites feeds the report formats, xfail and the
+	// run history, which all need every test case of the run.
+	suites, err := report.ParseXMLFiles(xmlPaths)
+	if err != nil {
+		return err
+	}
+
+	res.Suites = suites
+	res.ReportDir = reportDirOf(xmlPaths)
+	res.reports = xmlPaths
+	res.Output = report.BuildOutput(suites, crash)
+	applyXFail(cfg, detected, suites, res.Output, stderr)
+	res.ExitCode = ExitCode(res.Output)
+	if res.Output.Summary.Crashed && len(res.Output.Failures) == 0 {
+		res.transient = findTransient(cfg, logFile, stderr)
+	}
+	resolveSuites(res.Output, detected.ProjectDir, stderr)
+	annotateFuzz(res.Output, detected.ProjectDir)
+	attachSuiteLogs(res.Output, logFile, kept, stderr)
+
+	if err := snapshot.Attach(res.Output, detected.ProjectDir); err != nil {
+		fmt.Fprintln(stderr, "warning: snapshots:", err)
+	}
+	if len(res.Output.Failures) > 0 {
+		rules, err := owners.Load(detected.ProjectDir, cfg.Owners.File, cfg.Owners.Map)
+		if err != nil {
+			fmt.Fprintln(stderr, "warning: owners:", err)
+		}
+		owners.Annotate(res.Output, detected.ProjectDir, rules)
+	}
+	if cfg.UpdateSnapshots {
+		approved, err := snapshot.ApproveAll(detected.ProjectDir)
+		if err != nil {
+			fmt.Fprintln(stderr, "warning: snapshots:", err)
+		}
+		if len(approved) > 0 {
+			fmt.Fprintf(stderr, "approved %d snapshots\n", len(approved))
+		}
+	}
+	return nil
+}
+
+// salvage keeps what a Godot stopped by a timeout or an interrupt wrote on its
+// way out: the log, with --log-file, and the report, if gdUnit4 got to write it
+// in the --kill-grace period. The run stays a tool error.
+func salvage(cfg *config.Config, logFile, reportDir, reportsDir string, started time.Time, stderr io.Writer, res *Result) {
+	kept := keptLog(cfg, reportDir)
+	if kept != "" {
+		if err := keepLog(logFile, kept); err != nil {
+			fmt.Fprintln(stderr, "warning: log file:", err)
+			kept = ""
+		}
+	}
+	xmlPaths, err := findReports(cfg, reportDir, reportsDir, started)
+	if err != nil {
+		return
+	}
+	suites, err := report.ParseXMLFiles(xmlPaths)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(stderr, "warning: Godot was stopped; reporting the results it wrote before it quit")
+	res.Suites = suites
+	res.ReportDir = reportDirOf(xmlPaths)
+	res.reports = xmlPaths
+	res.Output = report.BuildOutput(suites, nil)
+	res.Output.Incomplete = true
+	res.Output.LogFile = kept
+}
+
+// BundleLogName is the file in a run's report directory that keeps the log
+// for --bundle when --log-file does not.
+const BundleLogName = "godot.log"
+
+// keptLog returns the path the log of a run reporting into reportDir is kept
+// at: --log-file or, for --bundle, BundleLogName in reportDir. It is empty if
+// the log is not kept.
+func keptLog(cfg *config.Config, reportDir string) string {
+	if cfg.LogFile == "" && cfg.Bundle != "" {
+		return filepath.Join(reportDir, BundleLogName)
+	}
+	return cfg.LogFile
+}
+
+// findReports returns the results files of a run matching --report-glob, all
+// of which are merged: those in reportDir, the run's report directory, or for
+// gdUnit4 versions without -rd, those written to reportsDir since the run
+// started, or else the newest one there.
+func findReports(cfg *config.Config, reportDir, reportsDir string, started time.Time) ([]string, error) {
+	glob := cmp.Or(cfg.ReportGlob, report.DefaultReportGlob)
+	paths, err := report.FindReportXMLs(reportDir, glob, time.Time{})
+	if err != nil || len(paths) > 0 {
+		return paths, err
+	}
+	if paths, err = report.FindReportXMLs(reportsDir, glob, started); err != nil || len(paths) > 0 {
+		return paths, err
+	}
+	if paths, err = report.FindReportXMLs(reportsDir, glob, time.Time{}); err != nil {
+		return nil, err
+	}
+	var newest string
+	var newestTime time.Time
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil && info.ModTime().After(newestTime) {
+			newest, newestTime = p, info.ModTime()
+		}
+	}
+	if newest == "" {
+		return nil, fmt.Errorf("no report file found matching: %s", filepath.Join(reportDir, filepath.FromSlash(glob)))
+	}
+	return []string{newest}, nil
+}
+
+// reportDirOf returns the gdUnit4 report directory of the results files paths:
+// the directory holding them all.
+func reportDirOf(paths []string) string {
+	dirs := make([]string, len(paths))
+	for i, p := range paths {
+		dirs[i] = filepath.Dir(p)
+	}
+	return commonDir(dirs)
+}
+
+// collectDumps moves the crash dumps the Godot process of result left into dst.
+func collectDumps(cfg *config.Config, projectDir string, result *runner.RunResult, started time.Time, dst string, stderr io.Writer) []string {
+	dumps, err := crashdump.Collect(crashdump.Process{PID: result.PID, Exe: cfg.GodotPath, Dir: projectDir, Start: started}, dst)
+	if err != nil {
+		fmt.Fprintln(stderr, "warning: crash dumps:", err)
+	}
+	if err == nil && len(dumps) == 0 {
+		fmt.Fprintln(stderr, "warning: crash dumps: Godot crashed but left no dump")
+	}
+	return dumps
+}
+
+// findTransient returns the known-transient error in the log of a run that
+// failed without test results, if the log holds no other error.
+func findTransient(cfg *config.Config, logFile string, stderr io.Writer) string {
+	if len(cfg.TransientErrors) == 0 {
+		return ""
+	}
+	sig, err := report.FindTransient(logFile, cfg.TransientErrors)
+	if err != nil {
+		fmt.Fprintln(stderr, "warning: transient errors:", err)
+	}
+	return sig
+}
+
+// applyXFail reports the tests tagged report.TagXFail among the suites of the
+// run, and those matching the xfail patterns of the config file, as expected
+// failures, warning about those that passed.
+func applyXFail(cfg *config.Config, detected *detector.Result, suites *report.JUnitTestSuites, out *report.Output, stderr io.Writer) {
+	var resPaths []string
+	for _, rp := range detected.ResPaths {
+		if suite, _ := runner.SplitTest(rp); !slices.Contains(resPaths, suite) {
+			resPaths = append(resPaths, suite)
+		}
+	}
+	found, err := discovery.Discover(detected.ProjectDir, resPaths)
+	if err != nil {
+		fmt.Fprintln(stderr, "warning: xfail:", err)
+	}
+	tagged := map[string]bool{}
+	for _, s := range found {
+		for _, test := range s.Tests {
+			if slices.Contains(s.TagsOf(test), report.TagXFail) {
+				tagged[s.Class+"."+test] = true
+			}
+		}
+	}
+	if len(tagged) == 0 && len(cfg.XFail) == 0 {
+		return
+	}
+	report.ApplyXFail(out, suites, func(class, method string) bool {
+		if tagged[class+"."+method] {
+			return true
+		}
+		for _, p := range cfg.XFail {
+			if ok, _ := discovery.MatchTest(p, class, method); ok {
+				return true
+			}
+		}
+		return false
+	})
+	if len(out.XPassed) > 0 {
+		fmt.Fprintf(stderr, "warning: tests expected to fail passed, remove their xfail mark: %s\n", strings.Join(out.XPassed, ", "))
+	}
+}
+
+// resolveSuites sets the suite of failures reported in a script that does not
+// declare the test's class: a base class the suite inherits the test from. The
+// suite is looked up by class among the project's scripts, and left unset if
+// the class is not declared exactly once.
+func resolveSuites(out *report.Output, projectDir string, stderr io.Writer) {
+	var classes map[string][]string
+	for i := range out.Failures {
+		f := &out.Failures[i]
+		if !strings.HasPrefix(f.File, "res://") {
+			continue
+		}
+		if classes == nil {
+			var err error
+			if classes, err = discovery.Classes(projectDir, []string{"res://"}); err != nil {
+				fmt.Fprintln(stderr, "warning: suites:", err)
+				return
+			}
+		}
+		if files := classes[f.Class]; len(files) == 1 && files[0] != f.File {
+			f.Suite = files[0]
+		}
+	}
+}
+
+// attachSuiteLogs adds the log segments of suites with failures and of the suite
+// running when Godot crashed to out. When the log is kept (--log-file), the full
+// segments are also written next to the kept log, into "<kept without
+// extension>-suites/".
+func attachSuiteLogs(out *report.Output, logFile, kept string, stderr io.Writer) {
+	opts := report.SliceOptions{Suites: map[string]bool{}, Unfinished: out.CrashDetails != nil}
+	for _, f := range out.Failures {
+		opts.Suites[cmp.Or(f.Suite, f.File)] = true
+	}
+	if len(opts.Suites) == 0 && !opts.Unfinished {
+		return
+	}
+	if kept != "" {
+		kept = strings.TrimSuffix(kept, report.GzipExt)
+		opts.Dir = strings.TrimSuffix(kept, filepath.Ext(kept)) + "-suites"
+	}
+	slices, err := report.SliceLog(logFile, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "warning: suite logs:", err)
+		return
+	}
+	out.SuiteLogs = slices
+}
+
+// keepLog moves the temp log at tmp to dst, creating its directory. It falls
+// back to copying when the two are on different file systems. A dst ending in
+// report.GzipExt is written compressed, and tmp is left for the run to read.
+func keepLog(tmp, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	compress := report.Compressed(dst)
+	if !compress && os.Rename(tmp, dst) == nil {
+		return nil
+	}
+	src, err := os.Open(tmp)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	var copyErr error
+	if compress {
+		gz := gzip.NewWriter(out)
+		_, copyErr = io.Copy(gz, src)
+		if closeErr := gz.Close(); copyErr == nil {
+			copyErr = closeErr
+		}
+	} else {
+		_, copyErr = io.Copy(out, src)
+	}
+	if closeErr := out.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil || compress {
+		return copyErr
+	}
+	src.Close()
+	return os.Remove(tmp)
+}
+
+// applyCoverage adds the coverage summary to res.Output. A run whose tests passed
+// is marked failed when a coverage threshold is missed.
+func applyCoverage(res *Result, th config.CoverageThresholds) {
+	res.Output.Coverage = coverage.Summarize(res.Coverage, th.Min, th.Packages)
+	if !res.Output.Coverage.Passed && res.ExitCode == 0 {
+		res.Output.Summary.Status = "failed"
+		res.ExitCode = ExitCode(res.Output)
+	}
+}
+
+// collectCoverage reads the coverage data the coverage addon wrote to dir.
+// Missing or unreadable data is reported as a warning and yields an empty profile.
+func collectCoverage(dir, projectDir string, stderr io.Writer) coverage.Profile {
+	profile, err := coverage.ReadDir(dir, projectDir)
+	if err != nil {
+		fmt.Fprintln(stderr, "warning: coverage:", err)
+		return coverage.Profile{}
+	}
+	if len(profile) == 0 {
+		fmt.Fprintf(stderr, "warning: coverage: no data was written to %s; is a coverage addon installed?\n", coverage.EnvDir)
+	}
+	return profile
+}
+
+// validatePaths returns the paths of detected that may hold tests (see
+// discovery.HasCandidates), warning about the others. It fails if none do.
+func validatePaths(detected *detector.Result, stderr io.Writer) ([]string, error) {
+	var kept []string
+	for _, rp := range detected.ResPaths {
+		ok, err := discovery.HasCandidates(detected.ProjectDir, rp)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			fmt.Fprintf(stderr, "warning: no test suites found under %s; skipping it\n", rp)
+			continue
+		}
+		kept = append(kept, rp)
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("no test suites found under %s", strings.Join(detected.ResPaths, ", "))
+	}
+	return kept, nil
+}
+
+// selectTests narrows the detected paths to the suites and test cases matching c.
+func selectTests(detected *detector.Result, c discovery.Criteria) ([]string, error) {
+	suites, err := discovery.Discover(detected.ProjectDir, detected.ResPaths)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := discovery.SelectCriteria(suites, c)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no tests match %s", describeCriteria(c))
+	}
+	return selected, nil
+}
+
+// describeCriteria renders c for error messages, e.g. "filter test_add, tags slow".
+func describeCriteria(c discovery.Criteria) string {
+	var parts []string
+	if len(c.Patterns) > 0 {
+		parts = append(parts, "filter "+strings.Join(c.Patterns, ","))
+	}
+	if len(c.Tags) > 0 {
+		parts = append(parts, "tags "+strings.Join(c.Tags, ","))
+	}
+	if len(c.SkipTags) > 0 {
+		parts = append(parts, "skip-tags "+strings.Join(c.SkipTags, ","))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ExitCode maps the output status to the process exit code.
+func ExitCode(out *report.Output) int {
+	switch out.Summary.Status {
+	case "crashed":
+		return 2
+	case "failed", "error", StatusAbortedMaxFailures:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// runPostHook runs the post_run hook with the result exposed via environment variables.
+// The JSON output is written to a temp file whose path is passed as GDUNIT4_RUNNER_OUTPUT.
+// Hook failures are reported as warnings and do not change the exit code.
+func runPostHook(command, projectDir string, temps *runTemps, runID string, out *report.Output, code int, stderr io.Writer) {
+	status := "error"
+	env := []string{
+		"GDUNIT4_RUNNER_PROJECT_DIR=" + projectDir,
+		EnvRunID + "=" + runID,
+		"GDUNIT4_RUNNER_EXIT_CODE=" + strconv.Itoa(code),
+	}
+	if out != nil {
+		status = out.Summary.Status
+
+		dir, err := temps.dir(runID)
+		if err != nil {
+			fmt.Fprintln(stderr, "warning: post_run:", err)
+			return
+		}
+		f, err := os.Create(filepath.Join(dir, "output.json"))
+		if err != nil {
+			fmt.Fprintln(stderr, "warning: post_run: failed to create output file:", err)
+			return
+		}
+		writeErr := report.WriteJSON(f, out)
+		if closeErr := f.Close(); writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr != nil {
+			fmt.Fprintln(stderr, "warning: post_run:", writeErr)
+			return
+		}
+		env = append(env, "GDUNIT4_RUNNER_OUTPUT="+f.Name())
+	}
+	env = append(env, "GDUNIT4_RUNNER_STATUS="+status)
+
+	if err := hooks.Run(command, projectDir, env, stderr); err != nil {
+		fmt.Fprintln(stderr, "warning: post_run:", err)
+	}
+}
@@ -0,0 +1,1015 @@
+package pipeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+	"github.com/minami110/gdunit4-test-runner/internal/runner"
+	"github.com/minami110/gdunit4-test-runner/internal/testutil"
+)
+
+const failingXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="2" failures="1" errors="0">
+  <testsuite name="test_math" package="res://tests/test_math.gd" tests="2" failures="1" errors="0">
+    <testcase name="test_add" classname="test_math"/>
+    <testcase name="test_sub" classname="test_math">
+      <failure message="FAILED: res://tests/test_math.gd:7"><![CDATA[Expected '1' but was '2']]></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+
+// suiteSource is a test suite with a single passing test.
+const suiteSource = "extends GdUnitTestSuite\n\nfunc test_add():\n\tpass\n"
+
+// makeProject creates a Godot project with a fake godot script that prints a log line
+// and writes xml as the gdUnit4 report. It returns the project root and script path.
+func makeProject(t *testing.T, xml string) (string, string) {
+	t.Helper()
+	script := testutil.FakeGodot(t, "echo 'Run Test Suite: res://tests/test_math.gd'\n"+
+		"mkdir -p reports/report_1 && cp results.xml.src reports/report_1/results.xml\nexit 100\n")
+	root := testutil.Project(t, map[string]string{
+		"results.xml.src":    xml,
+		"tests/test_math.gd": suiteSource,
+	})
+	return root, script
+}
+
+func TestExecute_Failing(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+	}
+
+	var lines []string
+	res, err := Execute(context.Background(), cfg, Options{OnLine: func(l string) { lines = append(lines, l) }})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ExitCode != 1 {
+		t.Errorf("ExitCode = %d, want 1", res.ExitCode)
+	}
+	if res.GodotExitCode != 100 {
+		t.Errorf("GodotExitCode = %d, want gdUnit4's 100", res.GodotExitCode)
+	}
+	if res.Output == nil || res.Output.Summary.Status != "failed" {
+		t.Fatalf("Output = %+v, want status failed", res.Output)
+	}
+	if len(res.Output.Failures) != 1 {
+		t.Errorf("len(Failures) = %d, want 1", len(res.Output.Failures))
+	}
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "Run Test Suite:") {
+		t.Errorf("OnLine lines = %q, want the log line", lines)
+	}
+	if res.Output.Timing == nil || res.Output.Timing.Total <= 0 {
+		t.Errorf("Timing = %+v, want the time of the Godot run", res.Output.Timing)
+	}
+}
+
+func TestExecute_ValidatesPaths(t *testing.T) {
+	root, _ := makeProject(t, failingXML)
+	if err := os.MkdirAll(filepath.Join(root, "assets"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// The fake Godot leaves a marker, so a run that should not start is visible.
+	marker := filepath.Join(root, "started")
+	script := testutil.FakeGodot(t, "touch started\nmkdir -p reports/report_1 && cp results.xml.src reports/report_1/results.xml\n")
+
+	cfg := &config.Config{TestPaths: []string{filepath.Join(root, "assets")}, GodotPath: script}
+	_, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+	if err == nil || !strings.Contains(err.Error(), "no test suites found under res://assets") {
+		t.Errorf("err = %v, want a no test suites error", err)
+	}
+	var se *StageError
+	if !errors.As(err, &se) || se.Stage != StageDetection {
+		t.Errorf("err = %#v, want a %s StageError", err, StageDetection)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("Godot was started for a path without tests")
+	}
+
+	var stderr bytes.Buffer
+	cfg.TestPaths = []string{filepath.Join(root, "assets"), filepath.Join(root, "tests")}
+	if _, err := Execute(context.Background(), cfg, Options{Stderr: &stderr}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "warning: no test suites found under res://assets") {
+		t.Errorf("stderr = %q, want a warning for res://assets", stderr.String())
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Error("Godot was not started for the remaining path")
+	}
+}
+
+func TestExecute_ChecksProject(t *testing.T) {
+	root, _ := makeProject(t, failingXML)
+	settings := "config_version=5\n\n[application]\n\nconfig/name=\"Game\"\nconfig/features=PackedStringArray(\"4.3\", \"Forward Plus\")\n"
+	if err := os.WriteFile(filepath.Join(root, "project.godot"), []byte(settings), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	content := "[ \"$1\" = --version ] && { echo 4.2.2.stable.official.15073afe3; exit 0; }\n" +
+		"mkdir -p reports/report_1 && cp results.xml.src reports/report_1/results.xml\n"
+	script := testutil.FakeGodot(t, content)
+
+	var stderr bytes.Buffer
+	cfg := &config.Config{TestPaths: []string{filepath.Join(root, "tests")}, GodotPath: script}
+	res, err := Execute(context.Background(), cfg, Options{Stderr: &stderr})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "warning: project targets Godot 4.3 but the Godot binary is 4.2.2.stable.official.15073afe3") {
+		t.Errorf("stderr = %q, want a version mismatch warning", stderr.String())
+	}
+	p := res.Output.Project
+	if p == nil || p.Name != "Game" || strings.Join(p.Features, ",") != "4.3,Forward Plus" || p.GodotVersion != "4.2.2.stable.official.15073afe3" {
+		t.Errorf("Output.Project = %+v", p)
+	}
+}
+
+func TestExecute_GdUnit4Settings(t *testing.T) {
+	root, _ := makeProject(t, failingXML)
+	settings := "config_version=5\n\n[application]\n\nconfig/name=\"Game\"\n\n" +
+		"[gdunit4]\n\nreport/directory=\"res://build/test-reports\"\nsettings/test/test_timeout_seconds=1\n"
+	if err := os.WriteFile(filepath.Join(root, "project.godot"), []byte(settings), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Ignores -rd like old gdUnit4 versions, then hangs past the test timeout.
+	content := "mkdir -p build/test-reports/report_1 && cp results.xml.src build/test-reports/report_1/results.xml\n" +
+		"[ -e hang ] && sleep 5\nexit 0\n"
+	script := testutil.FakeGodot(t, content)
+
+	cfg := &config.Config{TestPaths: []string{filepath.Join(root, "tests")}, GodotPath: script}
+	res, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Output.Summary.Total != 2 {
+		t.Errorf("Summary = %+v, want the report from the configured report directory", res.Output.Summary)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "hang"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	res, _ = Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("run took %v, want Godot killed after the 1s test timeout", elapsed)
+	}
+	if res.ExitCode != 2 {
+		t.Errorf("ExitCode = %d, want 2 after a timeout", res.ExitCode)
+	}
+	if cfg.Timeout != 0 {
+		t.Errorf("cfg.Timeout = %v, the caller's config should be left alone", cfg.Timeout)
+	}
+}
+
+func TestExecute_PostRunHook(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	marker := filepath.Join(t.TempDir(), "hook.txt")
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		Hooks: config.Hooks{
+			PostRun: `echo "$GDUNIT4_RUNNER_STATUS $GDUNIT4_RUNNER_EXIT_CODE" > ` + marker + ` && test -s "$GDUNIT4_RUNNER_OUTPUT"`,
+		},
+	}
+
+	var stderr strings.Builder
+	if _, err := Execute(context.Background(), cfg, Options{Stderr: &stderr}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("post_run hook did not run: %v (stderr: %s)", err, stderr.String())
+	}
+	if strings.TrimSpace(string(data)) != "failed 1" {
+		t.Errorf("hook saw %q, want %q", strings.TrimSpace(string(data)), "failed 1")
+	}
+}
+
+func TestExecute_PreRunHookFailureAborts(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		Hooks:     config.Hooks{PreRun: "exit 1"},
+	}
+
+	var stderr strings.Builder
+	res, err := Execute(context.Background(), cfg, Options{Stderr: &stderr})
+	if err == nil {
+		t.Fatal("expected error when pre_run hook fails, got nil")
+	}
+	var se *StageError
+	if !errors.As(err, &se) || se.Stage != StageHook {
+		t.Errorf("err = %#v, want a %s StageError", err, StageHook)
+	}
+	if res.ExitCode != 2 {
+		t.Errorf("ExitCode = %d, want 2", res.ExitCode)
+	}
+	if _, statErr := os.Stat(filepath.Join(root, "reports")); statErr == nil {
+		t.Error("Godot should not run when pre_run fails")
+	}
+}
+
+func TestExecute_FilterSelectsTestCases(t *testing.T) {
+	root, _ := makeProject(t, failingXML)
+	suite := "extends GdUnitTestSuite\n\nfunc test_add():\n\tpass\n\nfunc test_sub():\n\tpass\n"
+	if err := os.WriteFile(filepath.Join(root, "tests", "test_math.gd"), []byte(suite), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The runner config lives in the run temp dir, so the script keeps a copy.
+	confFile := filepath.Join(t.TempDir(), "GdUnitRunner.cfg")
+	script := testutil.FakeGodot(t, "while [ $# -gt 0 ]; do\n\tif [ \"$1\" = -conf ]; then cp \"$2\" "+confFile+"; fi\n\tshift\ndone\n")
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		Filter:    []string{"test_sub"},
+	}
+
+	var stderr strings.Builder
+	if _, err := Execute(context.Background(), cfg, Options{Stderr: &stderr}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(confFile)
+	if err != nil {
+		t.Fatalf("Godot was not passed a runner config: %v", err)
+	}
+	var conf runner.RunnerConfig
+	if err := json.Unmarshal(data, &conf); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"res://tests/test_math.gd": {"test_sub"}}
+	if !reflect.DeepEqual(conf.Included, want) {
+		t.Errorf("included = %v, want %v", conf.Included, want)
+	}
+}
+
+func TestExecute_MergesReports(t *testing.T) {
+	root, _ := makeProject(t, failingXML)
+	content := "mkdir -p reports/report_1 reports/split/report_2\n" +
+		"cp results.xml.src reports/report_1/results.xml\ncp results.xml.src reports/split/report_2/results.xml\nexit 100\n"
+	script := testutil.FakeGodot(t, content)
+
+	tests := []struct {
+		glob  string
+		tests int
+	}{
+		{"", 2},
+		{"**/results.xml", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob, func(t *testing.T) {
+			cfg := &config.Config{
+				TestPaths:  []string{filepath.Join(root, "tests")},
+				GodotPath:  script,
+				ReportGlob: tt.glob,
+			}
+			res, err := Execute(context.Background(), cfg, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Output.Summary.Total != tt.tests {
+				t.Errorf("Total = %d, want %d", res.Output.Summary.Total, tt.tests)
+			}
+		})
+	}
+}
+
+func TestExecute_XFail(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		xfail  []string
+	}{
+		{"tag", "extends GdUnitTestSuite\n\nfunc test_add():\n\tpass\n\n# @tag: xfail\nfunc test_sub():\n\tpass\n", nil},
+		{"config pattern", suiteSource, []string{"test_math.test_sub"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, script := makeProject(t, failingXML)
+			if err := os.WriteFile(filepath.Join(root, "tests", "test_math.gd"), []byte(tt.source), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg := &config.Config{
+				TestPaths: []string{filepath.Join(root, "tests")},
+				GodotPath: script,
+				XFail:     tt.xfail,
+			}
+
+			res, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.ExitCode != 0 {
+				t.Errorf("ExitCode = %d, want 0 for an expected failure", res.ExitCode)
+			}
+			s := res.Output.Summary
+			if s.Failed != 0 || s.XFail != 1 || s.Status != "passed" {
+				t.Errorf("Summary = %+v, want the failure counted as xfail", s)
+			}
+			if len(res.Output.XFailures) != 1 || len(res.Output.Failures) != 0 {
+				t.Errorf("Failures = %+v, XFailures = %+v", res.Output.Failures, res.Output.XFailures)
+			}
+		})
+	}
+}
+
+func TestExecute_FilterNoMatch(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		Filter:    []string{"test_missing"},
+	}
+
+	if _, err := Execute(context.Background(), cfg, Options{}); err == nil {
+		t.Fatal("expected error when no tests match the filter, got nil")
+	}
+}
+
+// makeCoverageProject is like makeProject, but its fake godot also writes coverage
+// data for scripts/player.gd (1 of 2 lines covered) and an addon script.
+func makeCoverageProject(t *testing.T, xml string) (string, string) {
+	t.Helper()
+	root, script := makeProject(t, xml)
+	content := "echo '{\"files\": {\"res://scripts/player.gd\": {\"1\": 2, \"2\": 0}, \"res://addons/gdUnit4/x.gd\": {\"1\": 1}}}' > \"$GDUNIT4_RUNNER_COVERAGE_DIR/cov.json\"\n" +
+		"exec " + script + "\n"
+	wrapper := testutil.FakeGodot(t, content)
+	return root, wrapper
+}
+
+func TestExecute_CollectsCoverage(t *testing.T) {
+	root, godot := makeCoverageProject(t, failingXML)
+	cfg := &config.Config{
+		TestPaths:   []string{filepath.Join(root, "tests")},
+		GodotPath:   godot,
+		CoverageOut: filepath.Join(t.TempDir(), "coverage.xml"),
+	}
+	res, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Coverage) != 1 {
+		t.Fatalf("Coverage = %v, want only scripts/player.gd", res.Coverage)
+	}
+	if valid, covered := res.Coverage.Lines(""); valid != 2 || covered != 1 {
+		t.Errorf("Lines = %d/%d, want 1/2", covered, valid)
+	}
+	if res.Output.Coverage == nil || res.Output.Coverage.Percent != 50 {
+		t.Errorf("Output.Coverage = %+v, want 50%%", res.Output.Coverage)
+	}
+}
+
+func TestExecute_CoverageThreshold(t *testing.T) {
+	passingXML := `<testsuites tests="1" failures="0" errors="0"><testsuite name="s"><testcase name="test_a" classname="s"/></testsuite></testsuites>`
+
+	tests := []struct {
+		name       string
+		min        float64
+		wantCode   int
+		wantStatus string
+	}{
+		{"met", 50, 0, "passed"},
+		{"missed", 80, 1, "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, godot := makeCoverageProject(t, passingXML)
+			cfg := &config.Config{
+				TestPaths: []string{filepath.Join(root, "tests")},
+				GodotPath: godot,
+				Coverage:  config.CoverageThresholds{Min: tt.min},
+			}
+			res, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.ExitCode != tt.wantCode || res.Output.Summary.Status != tt.wantStatus {
+				t.Errorf("ExitCode = %d, Status = %q, want %d, %q", res.ExitCode, res.Output.Summary.Status, tt.wantCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAnnotateFuzz(t *testing.T) {
+	root := t.TempDir()
+	src := "extends GdUnitTestSuite\n\n" +
+		"func test_fixed(fuzzer := Fuzzers.rangei(0, 9),\n\t\tfuzzer_iterations = 100,\n\t\tfuzzer_seed: int = -42) -> void:\n\tpass\n\n" +
+		"func test_random(fuzzer := Fuzzers.rangei(0, 9)) -> void:\n\tvar fuzzer_seed = 7\n"
+	if err := os.MkdirAll(filepath.Join(root, "tests"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "tests", "FuzzTest.gd"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &report.Output{Failures: []report.Failure{
+		{Class: "FuzzTest", Method: "test_fixed", File: "res://tests/FuzzTest.gd", Fuzz: &report.FuzzDetails{Iteration: 5}},
+		{Class: "FuzzTest", Method: "test_random", File: "res://tests/FuzzTest.gd", Fuzz: &report.FuzzDetails{Iteration: 2}},
+		{Class: "FuzzTest", Method: "test_plain", File: "res://tests/FuzzTest.gd"},
+	}}
+	annotateFuzz(out, root)
+
+	fixed := out.Failures[0].Fuzz
+	if fixed.Seed == nil || *fixed.Seed != -42 {
+		t.Errorf("test_fixed seed = %v, want -42", fixed.Seed)
+	}
+	if fixed.Reproduce != "gdunit4-test-runner --filter FuzzTest.test_fixed tests/FuzzTest.gd" {
+		t.Errorf("Reproduce = %q", fixed.Reproduce)
+	}
+	if s := out.Failures[1].Fuzz.Seed; s != nil {
+		t.Errorf("test_random seed = %d, want none (the body is not part of the signature)", *s)
+	}
+	if out.Failures[2].Fuzz != nil {
+		t.Errorf("non-fuzz failure got Fuzz = %+v", out.Failures[2].Fuzz)
+	}
+}
+
+func TestResolveSuites(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"tests/base_test.gd":   "class_name BaseTest\nextends GdUnitTestSuite\n\nfunc test_shared() -> void:\n\tpass\n",
+		"tests/player_test.gd": "class_name PlayerTest\nextends BaseTest\n",
+		"tests/math_test.gd":   "extends GdUnitTestSuite\n\nfunc test_add() -> void:\n\tpass\n",
+		"a/dup_test.gd":        "extends BaseTest\n",
+		"b/dup_test.gd":        "extends BaseTest\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &report.Output{Failures: []report.Failure{
+		{Class: "PlayerTest", Method: "test_shared", File: "res://tests/base_test.gd"},
+		{Class: "math_test", Method: "test_add", File: "res://tests/math_test.gd"},
+		{Class: "dup_test", Method: "test_shared", File: "res://tests/base_test.gd"},
+		{Class: "Gone", Method: "test_x"},
+	}}
+	resolveSuites(out, root, io.Discard)
+
+	want := []string{"res://tests/player_test.gd", "", "", ""}
+	for i, f := range out.Failures {
+		if f.Suite != want[i] {
+			t.Errorf("%s: Suite = %q, want %q", f.Class, f.Suite, want[i])
+		}
+	}
+}
+
+func TestExecute_Heartbeat(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	slow := testutil.FakeGodot(t, "echo 'Run Test Suite: res://tests/a.gd'\necho 'Run Test Suite: res://tests/b.gd'\nsleep 1\nexec "+script+"\n")
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: slow,
+		Heartbeat: 200 * time.Millisecond,
+	}
+
+	var stderr strings.Builder
+	if _, err := Execute(context.Background(), cfg, Options{Stderr: &stderr}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "still running: 1 suites done, elapsed ") {
+		t.Errorf("stderr = %q, want a heartbeat line", stderr.String())
+	}
+}
+
+func TestExecute_MaxFailures(t *testing.T) {
+	root, _ := makeProject(t, failingXML)
+	content := "echo 'Run Test: res://tests/test_math.gd > test_add :PASSED 1ms'\n" +
+		"echo 'Run Test: res://tests/test_math.gd > test_sub :FAILED 1ms'\n" +
+		"echo 'Run Test: res://tests/test_math.gd > test_mul :FAILED 1ms'\nexec sleep 10\n"
+	script := testutil.FakeGodot(t, content)
+	cfg := &config.Config{
+		TestPaths:   []string{filepath.Join(root, "tests")},
+		GodotPath:   script,
+		MaxFailures: 2,
+	}
+
+	start := time.Now()
+	var stderr strings.Builder
+	res, err := Execute(context.Background(), cfg, Options{Stderr: &stderr})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("run took %v, want Godot stopped after the second failure", elapsed)
+	}
+	if res.ExitCode != 1 {
+		t.Errorf("ExitCode = %d, want 1", res.ExitCode)
+	}
+	s := res.Output.Summary
+	if s.Status != StatusAbortedMaxFailures || s.Total != 3 || s.Passed != 1 || s.Failed != 2 {
+		t.Errorf("Summary = %+v, want 1 passed and 2 failed, aborted", s)
+	}
+	// In canonical order: without lines, by method.
+	if len(res.Output.Failures) != 2 || res.Output.Failures[1].Method != "test_sub" || res.Output.Failures[1].Class != "test_math" {
+		t.Errorf("Failures = %+v", res.Output.Failures)
+	}
+	if !strings.Contains(stderr.String(), "--max-failures") {
+		t.Errorf("stderr = %q, want a warning", stderr.String())
+	}
+}
+
+func TestExecute_Budget(t *testing.T) {
+	root, _ := makeProject(t, failingXML)
+	content := "echo 'Run Test Suite: res://tests/test_math.gd'\n" +
+		"echo 'Run Test: res://tests/test_math.gd > test_add :PASSED 1ms'\nsleep 1\n" +
+		"echo 'Run Test: res://tests/test_math.gd > test_sub :FAILED 1ms'\n" +
+		"echo 'Run Test Suite: res://tests/test_io.gd'\nexec sleep 10\n"
+	script := testutil.FakeGodot(t, content)
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		Budget:    200 * time.Millisecond,
+	}
+
+	start := time.Now()
+	var stderr strings.Builder
+	res, err := Execute(context.Background(), cfg, Options{Stderr: &stderr})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("run took %v, want Godot stopped at the next suite", elapsed)
+	}
+	if !res.Output.Incomplete {
+		t.Error("Incomplete = false, want true")
+	}
+	// The suite running when the budget ran out is finished.
+	if s := res.Output.Summary; s.Status != "failed" || s.Passed != 1 || s.Failed != 1 {
+		t.Errorf("Summary = %+v, want 1 passed and 1 failed", s)
+	}
+	if res.ExitCode != 1 {
+		t.Errorf("ExitCode = %d, want 1", res.ExitCode)
+	}
+	if !strings.Contains(stderr.String(), "--budget") {
+		t.Errorf("stderr = %q, want a warning", stderr.String())
+	}
+}
+
+func TestExecute_TimeoutSalvagesReport(t *testing.T) {
+	root, _ := makeProject(t, failingXML)
+	content := "trap 'mkdir -p reports/report_1 && cp results.xml.src reports/report_1/results.xml; exit 1' TERM\n" +
+		"sleep 10 &\nwait\n"
+	script := testutil.FakeGodot(t, content)
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		Timeout:   300 * time.Millisecond,
+		KillGrace: 5 * time.Second,
+	}
+
+	res, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+	if res.ExitCode != 2 {
+		t.Errorf("ExitCode = %d, want 2", res.ExitCode)
+	}
+	if res.Output == nil || !res.Output.Incomplete || res.Output.Summary.Failed != 1 {
+		t.Fatalf("Output = %+v, want the incomplete report written after SIGTERM", res.Output)
+	}
+}
+
+func TestExecute_RetriesTransientError(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	marker := filepath.Join(t.TempDir(), "attempted")
+	content := "if [ ! -e " + marker + " ]; then\n  touch " + marker + "\n" +
+		"  echo 'ERROR: Vulkan: VK_ERROR_DEVICE_LOST'\n  echo 'handle_crash: signal 11'\n  exit 1\nfi\nexec " + script + "\n"
+	flaky := testutil.FakeGodot(t, content)
+
+	for _, retry := range []bool{false, true} {
+		os.Remove(marker)
+		cfg := &config.Config{
+			TestPaths: []string{filepath.Join(root, "tests")},
+			GodotPath: flaky,
+		}
+		if retry {
+			cfg.TransientErrors = []*regexp.Regexp{regexp.MustCompile(`VK_ERROR_DEVICE_LOST`)}
+		}
+		res, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !retry {
+			if res.Output.Summary.Status != "crashed" {
+				t.Errorf("without patterns: status = %q, want crashed", res.Output.Summary.Status)
+			}
+			continue
+		}
+		if res.Output.Summary.Status != "failed" || res.ExitCode != 1 {
+			t.Errorf("status = %q, exit code %d; want the failed second attempt", res.Output.Summary.Status, res.ExitCode)
+		}
+		if res.Output.TransientRetry != "ERROR: Vulkan: VK_ERROR_DEVICE_LOST" {
+			t.Errorf("TransientRetry = %q", res.Output.TransientRetry)
+		}
+	}
+}
+
+func TestTiming(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	clock := start
+	tm := &timing{now: func() time.Time { return clock }, start: start}
+	for _, step := range []struct {
+		after time.Duration
+		line  string
+	}{
+		{time.Second, "Godot Engine v4.3.stable"},
+		{time.Second, "[   0% ] first_scan_filesystem | Started Project initialization"},
+		{3 * time.Second, "[  DONE ] reimport"},
+		{time.Second, "GdUnit4 Comandline Tool"},
+		{time.Second, "Run Test Suite: res://tests/test_math.gd"},
+		{10 * time.Second, "Statistics: | 2 tests cases | 0 error | 1 failed |"},
+	} {
+		clock = clock.Add(step.after)
+		tm.observe(step.line)
+	}
+
+	got := tm.result(&runner.RunResult{Duration: 20 * time.Second, CPUTime: 1500 * time.Millisecond})
+	want := &report.Timing{Total: 20, Startup: 6, Import: 3, Tests: 10, Report: 3, CPU: 1.5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("timing = %+v, want %+v", got, want)
+	}
+	if got := tm.suiteDurations(); !reflect.DeepEqual(got, map[string]time.Duration{"res://tests/test_math.gd": 10 * time.Second}) {
+		t.Errorf("suiteDurations() = %v", got)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	clock := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	p := newLoadProfile()
+	p.now = func() time.Time { return clock }
+	for _, step := range []struct {
+		after time.Duration
+		line  string
+	}{
+		{0, "Loading resource: res://icon.svg"},
+		{100 * time.Millisecond, "Run Test Suite: res://tests/test_level.gd"},
+		{0, "Loading resource: res://levels/world.tscn"},
+		{2 * time.Second, "Loading resource: res://levels/tiles.png"},
+		{500 * time.Millisecond, "Run Test: res://tests/test_level.gd > test_load :PASSED 3s"},
+		{0, "Loading resource: res://levels/world.tscn"},
+		{time.Second, "Statistics: | 1 tests cases | 0 error | 0 failed |"},
+	} {
+		clock = clock.Add(step.after)
+		p.observe(step.line)
+	}
+
+	want := []report.ResourceLoad{
+		{Path: "res://levels/world.tscn", Suite: "res://tests/test_level.gd", Count: 2, Seconds: 3, Max: 2},
+		{Path: "res://levels/tiles.png", Suite: "res://tests/test_level.gd", Count: 1, Seconds: 0.5, Max: 0.5},
+		{Path: "res://icon.svg", Count: 1, Seconds: 0.1, Max: 0.1},
+	}
+	if got := p.slowest(); !reflect.DeepEqual(got, want) {
+		t.Errorf("slowest() = %+v, want %+v", got, want)
+	}
+}
+
+func TestExecute_Hardware(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	device := "Vulkan 1.3.277 - Forward+ - Using Device #0: NVIDIA - NVIDIA GeForce RTX 4070"
+	gpu := testutil.FakeGodot(t, "echo '"+device+"'\nexec "+script+"\n")
+
+	for _, godot := range []string{script, gpu} {
+		cfg := &config.Config{TestPaths: []string{filepath.Join(root, "tests")}, GodotPath: godot}
+		res, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		hw := res.Output.Hardware
+		if godot == script {
+			if hw != nil {
+				t.Errorf("headless run: Hardware = %+v, want nil", hw)
+			}
+			continue
+		}
+		if hw == nil || !reflect.DeepEqual(hw.GPU, []string{device}) || hw.CPUs == 0 {
+			t.Errorf("Hardware = %+v, want the device line", hw)
+		}
+	}
+}
+
+func TestExecute_KeepsLogFile(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	logFile := filepath.Join(t.TempDir(), "logs", "godot.log")
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		LogFile:   logFile,
+	}
+
+	res, err := Execute(context.Background(), cfg, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Output.LogFile != logFile {
+		t.Errorf("Output.LogFile = %q, want %q", res.Output.LogFile, logFile)
+	}
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("log file was not kept: %v", err)
+	}
+	if !strings.Contains(string(data), "Run Test Suite: res://tests/test_math.gd") {
+		t.Errorf("log file = %q, want the Godot output", data)
+	}
+	if len(res.Output.SuiteLogs) != 1 {
+		t.Fatalf("SuiteLogs = %+v, want the failed suite", res.Output.SuiteLogs)
+	}
+	want := filepath.Join(filepath.Dir(logFile), "godot-suites", "tests_test_math.log")
+	if sl := res.Output.SuiteLogs[0]; sl.Suite != "res://tests/test_math.gd" || sl.LogFile != want {
+		t.Errorf("SuiteLogs[0] = %+v, want res://tests/test_math.gd written to %s", sl, want)
+	}
+}
+
+func TestExecute_CompressesLogFile(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	logFile := filepath.Join(t.TempDir(), "godot.log.gz")
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		LogFile:   logFile,
+	}
+
+	res, err := Execute(context.Background(), cfg, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Output.LogFile != logFile {
+		t.Errorf("Output.LogFile = %q, want %q", res.Output.LogFile, logFile)
+	}
+	f, err := os.Open(logFile)
+	if err != nil {
+		t.Fatalf("log file was not kept: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("log file is not gzip: %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Run Test Suite: res://tests/test_math.gd") {
+		t.Errorf("log file = %q, want the Godot output", data)
+	}
+	// The suite logs are sliced from the plain log and written uncompressed.
+	want := filepath.Join(filepath.Dir(logFile), "godot-suites", "tests_test_math.log")
+	if len(res.Output.SuiteLogs) != 1 || res.Output.SuiteLogs[0].LogFile != want {
+		t.Errorf("SuiteLogs = %+v, want the failed suite written to %s", res.Output.SuiteLogs, want)
+	}
+}
+
+func TestExecute_BundleKeepsLog(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		Bundle:    filepath.Join(t.TempDir(), "bundle.zip"),
+	}
+
+	res, err := Execute(context.Background(), cfg, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Without --log-file, the log is kept in the run's report directory.
+	logFile := res.Output.LogFile
+	if filepath.Base(logFile) != BundleLogName || filepath.Base(filepath.Dir(logFile)) != res.RunID {
+		t.Errorf("Output.LogFile = %q, want %s in the report directory of run %s", logFile, BundleLogName, res.RunID)
+	}
+	if _, err := os.Stat(logFile); err != nil {
+		t.Errorf("log file was not kept: %v", err)
+	}
+}
+
+func TestWaitForDebugger(t *testing.T) {
+	debuggerPoll = 10 * time.Millisecond
+	defer func() { debuggerPoll = time.Second }()
+
+	// Reserve a free port, then listen on it only after the wait has begun.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	var stderr strings.Builder
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := waitForDebugger(ctx, "tcp://"+addr, &stderr); err == nil {
+		t.Fatal("expected an error without a debugger, got nil")
+	}
+	if !strings.Contains(stderr.String(), "waiting for a debugger at tcp://"+addr) {
+		t.Errorf("stderr = %q, want the connection instructions", stderr.String())
+	}
+
+	time.AfterFunc(30*time.Millisecond, func() {
+		if l, err := net.Listen("tcp", addr); err == nil {
+			t.Cleanup(func() { l.Close() })
+		}
+	})
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := waitForDebugger(ctx, "tcp://"+addr, io.Discard); err != nil {
+		t.Errorf("unexpected error once the debugger listens: %v", err)
+	}
+}
+
+func TestNewRunID(t *testing.T) {
+	a, b := NewRunID(), NewRunID()
+	if a == b {
+		t.Errorf("NewRunID returned %q twice", a)
+	}
+	if !regexp.MustCompile(`^\d{8}T\d{6}Z-[0-9a-f]{8}$`).MatchString(a) {
+		t.Errorf("NewRunID = %q", a)
+	}
+}
+
+func TestExecute_TempDir(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	for _, keep := range []bool{false, true} {
+		tmp := t.TempDir()
+		cfg := &config.Config{
+			TestPaths: []string{filepath.Join(root, "tests")},
+			GodotPath: script,
+			TempDir:   tmp,
+			KeepTemp:  keep,
+			Hooks:     config.Hooks{PostRun: "true"},
+		}
+		var stderr strings.Builder
+		res, err := Execute(context.Background(), cfg, Options{Stderr: &stderr})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		runDir := filepath.Join(tmp, config.TempDirName, res.RunID)
+		entries, _ := os.ReadDir(tmp)
+		if !keep && len(entries) != 0 {
+			t.Errorf("temp files left behind: %v", entries)
+		}
+		if keep {
+			logs, _ := filepath.Glob(filepath.Join(runDir, "*.log"))
+			if len(logs) != 1 {
+				t.Errorf("--keep-temp: logs in %s = %q, want the Godot log", runDir, logs)
+			}
+			if !strings.Contains(stderr.String(), "kept temp files: "+runDir) {
+				t.Errorf("--keep-temp: stderr = %q, want the kept directory", stderr.String())
+			}
+		}
+	}
+}
+
+func TestExecute_ConcurrentRunsUseOwnReportDir(t *testing.T) {
+	root, _ := makeProject(t, failingXML)
+	passing := `<testsuites tests="1" failures="0" errors="0"><testsuite name="s"><testcase name="test_a" classname="s"/></testsuite></testsuites>`
+	for _, dir := range []string{"tests/failing", "tests/passing"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "test_a.gd"), []byte(suiteSource), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "passing.xml.src"), []byte(passing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Writes the report matching the tested directory into the -rd directory,
+	// after a pause that makes both runs overlap.
+	content := "src=results.xml.src\n" +
+		"while [ $# -gt 0 ]; do case \"$1\" in -rd) dir=$2;; res://tests/passing) src=passing.xml.src;; esac; shift; done\n" +
+		"[ -n \"$GDUNIT4_RUNNER_RUN_ID\" ] || exit 3\n" +
+		"sleep 0.3\nmkdir -p \"$dir/report_1\" && cp $src \"$dir/report_1/results.xml\"\n"
+	script := testutil.FakeGodot(t, content)
+	run := func(dir string) *Result {
+		cfg := &config.Config{TestPaths: []string{filepath.Join(root, dir)}, GodotPath: script}
+		res, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+		if err != nil {
+			t.Errorf("Execute(%s): %v", dir, err)
+		}
+		return res
+	}
+
+	var failed *Result
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		failed = run("tests/failing")
+	}()
+	passed := run("tests/passing")
+	<-done
+	if failed.Output == nil || passed.Output == nil {
+		t.Fatal("a run produced no output")
+	}
+
+	if failed.Output.Summary.Status != "failed" || passed.Output.Summary.Status != "passed" {
+		t.Errorf("statuses = %s, %s; want failed, passed", failed.Output.Summary.Status, passed.Output.Summary.Status)
+	}
+	if failed.RunID == passed.RunID || failed.Output.RunID != failed.RunID {
+		t.Errorf("run IDs = %q, %q (output %q)", failed.RunID, passed.RunID, failed.Output.RunID)
+	}
+	if want := filepath.Join(root, "reports", passed.RunID, "report_1"); passed.ReportDir != want {
+		t.Errorf("ReportDir = %q, want %q", passed.ReportDir, want)
+	}
+}
+
+func TestExecute_IsolateUserData(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	home := "$XDG_DATA_HOME"
+	if runtime.GOOS == "darwin" {
+		home = "$HOME"
+	}
+	content := "mkdir -p " + home + "/godot/app_userdata/Game && echo saved > " + home + "/godot/app_userdata/Game/save.dat\nexec " + script + "\n"
+	wrapper := testutil.FakeGodot(t, content)
+	cfg := &config.Config{
+		TestPaths:       []string{filepath.Join(root, "tests")},
+		GodotPath:       wrapper,
+		IsolateUserData: true,
+	}
+
+	res, err := Execute(context.Background(), cfg, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := filepath.Join(root, "reports", res.RunID, "user_data")
+	if res.Output.UserDataDir != want {
+		t.Errorf("UserDataDir = %q, want %q", res.Output.UserDataDir, want)
+	}
+	data, err := os.ReadFile(filepath.Join(want, "godot", "app_userdata", "Game", "save.dat"))
+	if err != nil || string(data) != "saved\n" {
+		t.Errorf("captured save file = %q, %v", data, err)
+	}
+}
+
+func TestExecute_WritesManifest(t *testing.T) {
+	root, script := makeProject(t, failingXML)
+	t.Setenv("GDUNIT4_RUNNER_TIMEOUT", "5m")
+	t.Setenv("GDUNIT4_RUNNER_UPLOAD_TOKEN", "s3cret")
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		Notify: config.Notify{Notifiers: map[string]config.Notifier{
+			"team": {Type: config.NotifierSlack, URL: "https://hooks.slack.com/services/T0/B0/x"},
+		}},
+	}
+
+	res, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard, Version: "1.2.3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := filepath.Join(root, "reports", res.RunID, ManifestFileName)
+	if res.Output.Manifest != want {
+		t.Errorf("Manifest = %q, want %q", res.Output.Manifest, want)
+	}
+	data, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+	if m.RunID != res.RunID || m.Runner != "1.2.3" || m.Result.ExitCode != 1 || m.Result.GodotExitCode != 100 {
+		t.Errorf("manifest = %+v, want the run's ID, version and exit codes", m)
+	}
+	if m.Paths.Godot != script || m.Paths.Project != root || !reflect.DeepEqual(m.Paths.Tests, []string{"res://tests"}) {
+		t.Errorf("Paths = %+v", m.Paths)
+	}
+	if m.Result.Summary == nil || m.Result.Summary.Failed != 1 {
+		t.Errorf("Summary = %+v, want 1 failed", m.Result.Summary)
+	}
+	if len(m.Result.Reports) != 1 || len(m.Result.Reports[0].SHA256) != 64 {
+		t.Errorf("Reports = %+v, want the digest of results.xml", m.Result.Reports)
+	}
+	if m.Environment.Vars["GDUNIT4_RUNNER_TIMEOUT"] != "5m" || m.Environment.Vars["GDUNIT4_RUNNER_UPLOAD_TOKEN"] != redacted {
+		t.Errorf("Vars = %v, want the timeout and the token redacted", m.Environment.Vars)
+	}
+	if strings.Contains(string(data), "hooks.slack.com") {
+		t.Error("manifest contains the notifier's webhook URL")
+	}
+	if m.Timing.Finished.Before(m.Timing.Started) {
+		t.Errorf("Timing = %+v", m.Timing)
+	}
+}
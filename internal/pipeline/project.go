@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minami110/gdunit4-test-runner/internal/project"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+	"github.com/minami110/gdunit4-test-runner/internal/runner"
+)
+
+// checkProject reads project.godot and, when the project names an engine version
+// or uses C#, warns if the Godot binary does not match it. Problems are warnings
+// only; if project.godot cannot be read, the settings are empty and the
+// ProjectInfo is nil.
+func checkProject(ctx context.Context, godotPath, projectDir string, stderr io.Writer) (*project.Info, *report.ProjectInfo) {
+	info, err := project.Load(projectDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "warning: failed to read %s: %v\n", project.FileName, err)
+		return &project.Info{}, nil
+	}
+	out := &report.ProjectInfo{Name: info.Name, Features: info.Features}
+	if info.Version() == "" && !info.UsesCSharp() {
+		return info, out
+	}
+	version, err := runner.Version(ctx, godotPath)
+	if err != nil {
+		fmt.Fprintln(stderr, "warning:", err)
+		return info, out
+	}
+	out.GodotVersion = version
+	for _, w := range info.Check(version) {
+		fmt.Fprintln(stderr, "warning:", w)
+	}
+	return info, out
+}
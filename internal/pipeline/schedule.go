@@ -0,0 +1,182 @@
+package pipeline
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+	"github.com/minami110/gdunit4-test-runner/internal/owners"
+	"github.com/minami110/gdunit4-test-runner/internal/runner"
+)
+
+// stageOf returns the stage of the suite at resPath under order: one more than
+// the index of the first pattern matching it, or 0 if none does.
+func stageOf(order []string, resPath string) int {
+	rel := strings.TrimPrefix(resPath, "res://")
+	for i, p := range order {
+		if owners.Match(p, rel) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// orderSuites returns the res:// paths of detected reordered so that Godot
+// runs their suites in the stages of order. A directory whose suites fall in
+// different stages is replaced by its suites; other paths are kept as they
+// are, so that gdUnit4 still finds the suites under a directory that
+// discovery does not see. Paths of the same stage keep their order.
+func orderSuites(detected *detector.Result, order []string) ([]string, error) {
+	type entry struct {
+		path  string
+		stage int
+	}
+	var entries []entry
+	for _, p := range detected.ResPaths {
+		suite, test := runner.SplitTest(p)
+		if test != "" || strings.HasSuffix(suite, ".gd") {
+			entries = append(entries, entry{p, stageOf(order, suite)})
+			continue
+		}
+		suites, err := discovery.Discover(detected.ProjectDir, []string{p})
+		if err != nil {
+			return nil, err
+		}
+		stages := make([]int, len(suites))
+		for i, s := range suites {
+			stages[i] = stageOf(order, s.ResPath)
+		}
+		if len(slices.Compact(slices.Clone(stages))) <= 1 {
+			stage := 0
+			if len(stages) > 0 {
+				stage = stages[0]
+			}
+			entries = append(entries, entry{p, stage})
+			continue
+		}
+		for i, s := range suites {
+			entries = append(entries, entry{s.ResPath, stages[i]})
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b entry) int { return cmp.Compare(a.stage, b.stage) })
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.path
+	}
+	return paths, nil
+}
+
+// needs is what a project's run takes from the slots of a workspace run
+// besides one of its jobs.
+type needs struct {
+	serial bool     // runs alone
+	tags   []string // tags with a limit of concurrent projects, sorted
+}
+
+// projectNeeds returns the needs of the run of g under s: serial if one of its
+// suites matches s.Serial or is tagged config.TagSerial, and the tags of
+// s.Limits its suites or tests carry.
+func projectNeeds(s config.Schedule, g *detector.Result) (needs, error) {
+	var n needs
+	suites, err := discovery.Discover(g.ProjectDir, g.ResPaths)
+	if err != nil {
+		return n, err
+	}
+	for _, suite := range suites {
+		rel := strings.TrimPrefix(suite.ResPath, "res://")
+		if slices.ContainsFunc(s.Serial, func(p string) bool { return owners.Match(p, rel) }) {
+			n.serial = true
+		}
+		tags := slices.Clone(suite.Tags)
+		for _, t := range suite.TestTags {
+			tags = append(tags, t...)
+		}
+		for _, tag := range tags {
+			if tag == config.TagSerial {
+				n.serial = true
+			}
+			if _, ok := s.Limits[tag]; ok && !slices.Contains(n.tags, tag) {
+				n.tags = append(n.tags, tag)
+			}
+		}
+	}
+	slices.Sort(n.tags)
+	return n, nil
+}
+
+// slots hands out the slots of a workspace run: jobs projects at a time, at
+// most limits[tag] of them needing tag, and a serial project only while no
+// other project runs.
+type slots struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	jobs    int
+	limits  map[string]int
+	running int
+	serial  bool           // a serial project is running
+	inUse   map[string]int // running projects by tag
+}
+
+func newSlots(jobs int, limits map[string]int) *slots {
+	s := &slots{jobs: jobs, limits: limits, inUse: map[string]int{}}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// take waits until a project of pending can start and takes its slots. It
+// returns the index into pending of the first project, in order, whose
+// needs are met; a serial project waiting for the others to finish is not
+// overtaken, so that it is not starved.
+func (s *slots) take(pending []int, projects []needs) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		for k, i := range pending {
+			n := projects[i]
+			if s.fits(n) {
+				s.running++
+				s.serial = n.serial
+				for _, tag := range n.tags {
+					s.inUse[tag]++
+				}
+				return k
+			}
+			if n.serial {
+				break
+			}
+		}
+		s.cond.Wait()
+	}
+}
+
+// fits reports whether a project with needs n can start now.
+func (s *slots) fits(n needs) bool {
+	if s.running >= s.jobs || s.serial || (n.serial && s.running > 0) {
+		return false
+	}
+	for _, tag := range n.tags {
+		if s.inUse[tag] >= s.limits[tag] {
+			return false
+		}
+	}
+	return true
+}
+
+// release returns the slots taken for a project with needs n.
+func (s *slots) release(n needs) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	if n.serial {
+		s.serial = false
+	}
+	for _, tag := range n.tags {
+		s.inUse[tag]--
+	}
+	s.cond.Broadcast()
+}
@@ -0,0 +1,201 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/testutil"
+)
+
+// writeSuites creates a test suite at each of the project-relative paths under root.
+func writeSuites(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		file := filepath.Join(root, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(suiteSource), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOrderSuites(t *testing.T) {
+	root := t.TempDir()
+	writeSuites(t, root,
+		"tests/integration/test_save.gd",
+		"tests/unit/test_math.gd",
+		"tests/test_misc.gd",
+		"other/test_other.gd",
+	)
+	order := []string{"tests/unit/", "tests/integration/"}
+
+	tests := []struct {
+		name     string
+		resPaths []string
+		want     []string
+	}{
+		{
+			"directory split into its suites",
+			[]string{"res://tests"},
+			[]string{"res://tests/test_misc.gd", "res://tests/unit/test_math.gd", "res://tests/integration/test_save.gd"},
+		},
+		{
+			"directory of one stage kept",
+			[]string{"res://tests/integration", "res://other"},
+			[]string{"res://other", "res://tests/integration"},
+		},
+		{
+			"selectors and files",
+			[]string{"res://tests/integration/test_save.gd:test_a", "res://tests/unit/test_math.gd", "res://tests/integration/test_save.gd:test_b"},
+			[]string{"res://tests/unit/test_math.gd", "res://tests/integration/test_save.gd:test_a", "res://tests/integration/test_save.gd:test_b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := orderSuites(&detector.Result{ProjectDir: root, ResPaths: tt.resPaths}, order)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("orderSuites() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectNeeds(t *testing.T) {
+	root := t.TempDir()
+	writeSuites(t, root, "tests/test_math.gd", "tests/save/test_save.gd")
+	tagged := map[string]string{
+		"tests/render/test_shader.gd": "# @tag: gpu\nextends GdUnitTestSuite\n\nfunc test_a():\n\tpass\n",
+		"tests/net/test_lobby.gd":     "extends GdUnitTestSuite\n\n# @tag: network, serial\nfunc test_a():\n\tpass\n",
+	}
+	for p, src := range tagged {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, p)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, p), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	limits := map[string]int{"gpu": 1, "network": 2}
+
+	tests := []struct {
+		name     string
+		schedule config.Schedule
+		resPaths []string
+		want     needs
+	}{
+		{"no schedule", config.Schedule{}, []string{"res://tests/test_math.gd"}, needs{}},
+		{"serial suite under the paths", config.Schedule{Serial: []string{"save/"}}, []string{"res://tests"}, needs{serial: true}},
+		{"serial suite not run", config.Schedule{Serial: []string{"save/"}}, []string{"res://tests/test_math.gd"}, needs{}},
+		{"limited suite tag", config.Schedule{Limits: limits}, []string{"res://tests/render"}, needs{tags: []string{"gpu"}}},
+		{"serial and limited test tags", config.Schedule{Limits: limits}, []string{"res://tests"}, needs{serial: true, tags: []string{"gpu", "network"}}},
+		{"tag without a limit", config.Schedule{}, []string{"res://tests/render"}, needs{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &detector.Result{ProjectDir: root, ResPaths: tt.resPaths}
+			got, err := projectNeeds(tt.schedule, g)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("projectNeeds() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlots(t *testing.T) {
+	projects := []needs{
+		{tags: []string{"gpu"}},
+		{tags: []string{"gpu"}},
+		{},
+		{serial: true},
+		{},
+	}
+	s := newSlots(3, map[string]int{"gpu": 1})
+	pending := []int{0, 1, 2, 3, 4}
+	take := func() int {
+		k := s.take(pending, projects)
+		i := pending[k]
+		pending = slices.Delete(pending, k, k+1)
+		return i
+	}
+
+	// The second gpu project is passed while the first runs.
+	if got := take(); got != 0 {
+		t.Fatalf("first take() = %d, want 0", got)
+	}
+	if got := take(); got != 2 {
+		t.Fatalf("second take() = %d, want 2: project 1 waits for the gpu", got)
+	}
+	// The serial project waits for the others and is not passed by 4.
+	done := make(chan int)
+	go func() { done <- take() }()
+	select {
+	case i := <-done:
+		t.Fatalf("take() = %d while the gpu is in use and a serial project waits", i)
+	case <-time.After(50 * time.Millisecond):
+	}
+	s.release(projects[0])
+	if got := <-done; got != 1 {
+		t.Fatalf("take() after the gpu is released = %d, want 1", got)
+	}
+	go func() { done <- take() }()
+	s.release(projects[1])
+	select {
+	case i := <-done:
+		t.Fatalf("take() = %d while project 2 runs, want the serial project to wait", i)
+	case <-time.After(50 * time.Millisecond):
+	}
+	s.release(projects[2])
+	if got := <-done; got != 3 {
+		t.Fatalf("take() once all are released = %d, want 3", got)
+	}
+	go func() { done <- take() }()
+	select {
+	case i := <-done:
+		t.Fatalf("take() = %d while the serial project runs", i)
+	case <-time.After(50 * time.Millisecond):
+	}
+	s.release(projects[3])
+	if got := <-done; got != 4 {
+		t.Fatalf("last take() = %d, want 4", got)
+	}
+}
+
+func TestExecute_ScheduleOrder(t *testing.T) {
+	root, _ := makeProject(t, failingXML)
+	writeSuites(t, root, "tests/integration/test_save.gd", "tests/unit/test_add.gd")
+	argsFile := filepath.Join(t.TempDir(), "args")
+	script := testutil.FakeGodot(t, "echo \"$@\" > "+argsFile+"\n")
+	cfg := &config.Config{
+		TestPaths: []string{filepath.Join(root, "tests")},
+		GodotPath: script,
+		Schedule:  config.Schedule{Order: []string{"unit/", "integration/"}},
+	}
+	if _, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-a res://tests/test_math.gd -a res://tests/unit/test_add.gd -a res://tests/integration/test_save.gd"
+	if !strings.Contains(string(data), want) {
+		t.Errorf("args = %q, want the suites in schedule order: %s", data, want)
+	}
+}
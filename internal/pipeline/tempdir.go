@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+)
+
+// runTemps tracks the temp directories of the attempts of one run, each at
+// <temp>/gdunit4-runner/<run id>/, so that a single deferred sweep removes
+// them all, also when the run panics or is stopped by a signal.
+type runTemps struct {
+	root string // <temp>/gdunit4-runner
+	keep bool   // --keep-temp
+	dirs []string
+}
+
+func newRunTemps(cfg *config.Config) *runTemps {
+	return &runTemps{
+		root: filepath.Join(cmp.Or(cfg.TempDir, os.TempDir()), config.TempDirName),
+		keep: cfg.KeepTemp,
+	}
+}
+
+// dir returns the temp directory of the attempt runID, creating it if needed.
+func (t *runTemps) dir(runID string) (string, error) {
+	dir := filepath.Join(t.root, runID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create run temp dir: %w", err)
+	}
+	for _, d := range t.dirs {
+		if d == dir {
+			return dir, nil
+		}
+	}
+	t.dirs = append(t.dirs, dir)
+	return dir, nil
+}
+
+// sweep removes the temp directories, or with --keep-temp prints where they are.
+func (t *runTemps) sweep(stderr io.Writer) {
+	for _, dir := range t.dirs {
+		if t.keep {
+			fmt.Fprintln(stderr, "kept temp files:", dir)
+			continue
+		}
+		os.RemoveAll(dir)
+	}
+	// Only succeeds once no other run uses it.
+	os.Remove(t.root)
+}
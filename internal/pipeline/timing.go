@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"maps"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+	"github.com/minami110/gdunit4-test-runner/internal/runner"
+)
+
+// importRe matches the progress lines Godot prints while it scans and imports
+// the project's resources, e.g. "[  50% ] reimport | ..." or "first_scan_filesystem".
+var importRe = regexp.MustCompile(`(?i)first_scan_filesystem|\breimport|update_scripts_classes|\bimporting\b`)
+
+// timing follows the phases of a Godot run through its output.
+type timing struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	start time.Time
+
+	importStart, importEnd time.Time
+	gdunit                 time.Time // first line of gdUnit4
+	testsStart, testsEnd   time.Time
+
+	suite      string // the suite running, if any
+	suiteStart time.Time
+	suites     map[string]time.Duration // duration of each finished suite
+}
+
+func newTiming() *timing {
+	return &timing{now: time.Now, start: time.Now()}
+}
+
+// observe records a line of Godot output.
+func (t *timing) observe(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	if t.gdunit.IsZero() {
+		switch {
+		case importRe.MatchString(line):
+			if t.importStart.IsZero() {
+				t.importStart = now
+			}
+			t.importEnd = now
+			return
+		case strings.Contains(strings.ToLower(line), "gdunit"):
+			t.gdunit = now
+		}
+	}
+	if suite, ok := report.SuiteStart(line); ok {
+		if t.testsStart.IsZero() {
+			t.testsStart = now
+		}
+		t.suite, t.suiteStart = suite, now
+	}
+	if report.SuiteEnd(line) {
+		t.testsEnd = now
+		if t.suite != "" {
+			if t.suites == nil {
+				t.suites = map[string]time.Duration{}
+			}
+			t.suites[t.suite] = now.Sub(t.suiteStart)
+			t.suite = ""
+		}
+	}
+}
+
+// suiteDurations returns how long each suite that finished took.
+func (t *timing) suiteDurations() map[string]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return maps.Clone(t.suites)
+}
+
+// result breaks down the run that ended with result.
+func (t *timing) result(result *runner.RunResult) *report.Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	exited := t.start.Add(result.Duration)
+	out := &report.Timing{
+		Total: seconds(result.Duration),
+		CPU:   seconds(result.CPUTime),
+	}
+	if !t.gdunit.IsZero() {
+		out.Startup = seconds(t.gdunit.Sub(t.start))
+	}
+	if !t.importStart.IsZero() {
+		out.Import = seconds(t.importEnd.Sub(t.importStart))
+	}
+	if !t.testsStart.IsZero() && !t.testsEnd.IsZero() {
+		out.Tests = seconds(t.testsEnd.Sub(t.testsStart))
+		out.Report = seconds(max(exited.Sub(t.testsEnd), 0))
+	}
+	return out
+}
+
+// seconds returns d in seconds, rounded to milliseconds.
+func seconds(d time.Duration) float64 {
+	return round3(d.Seconds())
+}
+
+// round3 rounds s to milliseconds.
+func round3(s float64) float64 {
+	return math.Round(s*1000) / 1000
+}
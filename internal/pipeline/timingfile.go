@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/runner"
+)
+
+// timingFileVersion is the version of the timing file format.
+const timingFileVersion = 1
+
+// timingFile is the content of a timing file: the duration of each suite in
+// seconds, by res:// path, as last measured.
+type timingFile struct {
+	Version int                `json:"version"`
+	Suites  map[string]float64 `json:"suites"`
+}
+
+// timingPath returns the timing file of a run in projectDir: --timing-file,
+// or timings.json in the project's runner state directory.
+func timingPath(cfg *config.Config, projectDir string) string {
+	return cmp.Or(cfg.TimingFile, filepath.Join(projectDir, config.StateDir, config.TimingFileName))
+}
+
+// readTimings returns the suite durations recorded at path, or none if it
+// does not exist.
+func readTimings(path string) (map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]float64{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f timingFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if f.Suites == nil {
+		f.Suites = map[string]float64{}
+	}
+	return f.Suites, nil
+}
+
+// saveTimings records durations at path, keeping the suites the run did not
+// measure. The file is replaced at once so that concurrent runs reading it
+// never see half of it.
+func saveTimings(path string, durations map[string]time.Duration) error {
+	if len(durations) == 0 {
+		return nil
+	}
+	suites, err := readTimings(path)
+	if err != nil {
+		suites = map[string]float64{} // start over from a corrupt file
+	}
+	for suite, d := range durations {
+		suites[suite] = seconds(d)
+	}
+	data, err := json.MarshalIndent(timingFile{Version: timingFileVersion, Suites: suites}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// estimate returns the recorded duration of the suites under resPaths, in
+// seconds, and whether any was recorded.
+func estimate(timings map[string]float64, resPaths []string) (float64, bool) {
+	var total float64
+	found := false
+	for suite, s := range timings {
+		if slices.ContainsFunc(resPaths, func(p string) bool { return underResPath(suite, p) }) {
+			total += s
+			found = true
+		}
+	}
+	return total, found
+}
+
+// underResPath reports whether suite is p or inside the directory p. A
+// selection of single tests in p counts as p.
+func underResPath(suite, p string) bool {
+	p, _ = runner.SplitTest(p)
+	p = strings.TrimSuffix(p, "/")
+	return suite == p || strings.HasPrefix(suite, p+"/")
+}
+
+// longestFirst returns the indexes of groups in the order to start them for
+// LPT (longest processing time first) scheduling by est, the estimated
+// duration of each and whether there is one. Groups without an estimate
+// start first, since nothing says they are short.
+func longestFirst(est []float64, known []bool) []int {
+	order := make([]int, len(est))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if known[a] != known[b] {
+			if known[a] {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(est[b], est[a])
+	})
+	return order
+}
@@ -0,0 +1,34 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// userDataEnv returns the environment that makes Godot resolve user:// (and its
+// other per-user data) inside dir. Godot derives the user data directory from
+// XDG_DATA_HOME on Linux and the BSDs, from HOME on macOS
+// (~/Library/Application Support) and from APPDATA on Windows.
+func userDataEnv(dir string) []string {
+	switch runtime.GOOS {
+	case "windows":
+		return []string{"APPDATA=" + dir}
+	case "darwin":
+		return []string{"HOME=" + dir}
+	}
+	return []string{"XDG_DATA_HOME=" + dir}
+}
+
+// captureUserData copies the user data sandbox to dst if the run wrote anything
+// to it, and reports whether it did.
+func captureUserData(sandbox, dst string) (bool, error) {
+	entries, err := os.ReadDir(sandbox)
+	if err != nil || len(entries) == 0 {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return false, err
+	}
+	return true, os.CopyFS(dst, os.DirFS(sandbox))
+}
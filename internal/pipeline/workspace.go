@@ -0,0 +1,339 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// executeWorkspace runs each project of groups as a run of its own, cfg.ProjectJobs
+// at a time, and merges the results into one. Failures are tagged with their
+// project relative to the common parent of all projects, which becomes the
+// ProjectDir of the merged result. When projects run at once, their stderr
+// lines are tagged with the project and, with OutputGrouped, held back until
+// the project's run finishes. A project with serial suites runs while no other
+// project does, and projects with suites of a tag in cfg.Schedule.Limits run at
+// most that many at once.
+func executeWorkspace(ctx context.Context, cfg *config.Config, groups []*detector.Result, opts Options, stderr io.Writer) (*Result, error) {
+	dirs := make([]string, len(groups))
+	for i, g := range groups {
+		dirs[i] = g.ProjectDir
+	}
+	root := commonDir(dirs)
+	res := &Result{RunID: NewRunID(), ProjectDir: root, ExitCode: 2, GodotExitCode: -1}
+
+	jobs := max(cfg.ProjectJobs, 1)
+	sub := Options{OnLine: opts.OnLine, Stderr: stderr, HistoryDir: opts.HistoryDir}
+	if jobs > 1 {
+		var mu sync.Mutex
+		if opts.OnLine != nil {
+			sub.OnLine = func(line string) {
+				mu.Lock()
+				defer mu.Unlock()
+				opts.OnLine(line)
+			}
+		}
+		sub.Stderr = &lockedWriter{w: stderr}
+	}
+	grouped := jobs > 1 && cfg.OutputMode == config.OutputGrouped
+
+	results := make([]*Result, len(groups))
+	errs := make([]error, len(groups))
+	// The budget is for the whole run, not for each project.
+	deadline := time.Now().Add(cfg.Budget)
+	projects := make([]needs, len(groups))
+	if jobs > 1 {
+		for i, g := range groups {
+			var err error
+			if projects[i], err = projectNeeds(cfg.Schedule, g); err != nil {
+				fmt.Fprintf(stderr, "warning: schedule of %s: %v\n", relDir(root, g.ProjectDir), err)
+			}
+		}
+	}
+	slots := newSlots(jobs, cfg.Schedule.Limits)
+	var wg sync.WaitGroup
+	for pending := scheduleProjects(cfg, root, groups, jobs, stderr); len(pending) > 0; {
+		// Slots are taken before starting the run, in schedule order; a
+		// project waiting for a limited tag is passed by those that can start.
+		k := slots.take(pending, projects)
+		i := pending[k]
+		pending = slices.Delete(pending, k, k+1)
+		g := groups[i]
+		run := *cfg
+		run.ProjectDir = g.ProjectDir
+		run.TestPaths = g.ResPaths
+		if cfg.LogFile != "" {
+			run.LogFile = projectLogFile(cfg.LogFile, relDir(root, g.ProjectDir))
+		}
+		if cfg.TimingFile != "" {
+			run.TimingFile = projectLogFile(cfg.TimingFile, relDir(root, g.ProjectDir))
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer slots.release(projects[i])
+			projOpts := sub
+			if jobs > 1 {
+				w := &prefixWriter{prefix: "[" + relDir(root, g.ProjectDir) + "] ", w: sub.Stderr}
+				var group bytes.Buffer
+				if grouped {
+					w.w = &group
+				}
+				defer func() {
+					w.flush()
+					if grouped {
+						sub.Stderr.Write(group.Bytes())
+					}
+				}()
+				projOpts.Stderr = w
+			}
+			if cfg.Budget > 0 {
+				if run.Budget = time.Until(deadline); run.Budget <= 0 {
+					fmt.Fprintf(projOpts.Stderr, "warning: %s skipped, the --budget is used up\n", relDir(root, g.ProjectDir))
+					results[i] = &Result{GodotExitCode: -1, Output: &report.Output{
+						Summary:    report.Summary{Status: "passed"},
+						Failures:   []report.Failure{},
+						Incomplete: true,
+					}}
+					return
+				}
+			}
+			results[i], errs[i] = Execute(ctx, &run, projOpts)
+		}()
+	}
+	wg.Wait()
+
+	if cfg.CoverageOut != "" {
+		fmt.Fprintln(stderr, "warning: coverage is not merged across projects; see the coverage of each entry in projects")
+	}
+
+	var joined []error
+	for i, err := range errs {
+		if err != nil {
+			joined = append(joined, fmt.Errorf("%s: %w", relDir(root, groups[i].ProjectDir), err))
+		}
+	}
+	mergeResults(res, root, dirs, results, errs)
+	res.Output.RunID = res.RunID
+	res.sort()
+	return res, errors.Join(joined...)
+}
+
+// scheduleProjects returns the order to start the projects of groups in: as
+// given when they run one at a time, otherwise longest first by the suite
+// durations of their timing files, so that a long project does not start last
+// while the other slots are idle.
+func scheduleProjects(cfg *config.Config, root string, groups []*detector.Result, jobs int, stderr io.Writer) []int {
+	est := make([]float64, len(groups))
+	known := make([]bool, len(groups))
+	if jobs == 1 {
+		return longestFirst(est, known)
+	}
+	for i, g := range groups {
+		path := filepath.Join(g.ProjectDir, config.StateDir, config.TimingFileName)
+		if cfg.TimingFile != "" {
+			path = projectLogFile(cfg.TimingFile, relDir(root, g.ProjectDir))
+		}
+		timings, err := readTimings(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "warning: timing file of %s: %v\n", relDir(root, g.ProjectDir), err)
+			continue
+		}
+		est[i], known[i] = estimate(timings, g.ResPaths)
+	}
+	return longestFirst(est, known)
+}
+
+// mergeResults fills res from the results of each project's run in dirs.
+func mergeResults(res *Result, root string, dirs []string, results []*Result, errs []error) {
+	out := &report.Output{Failures: []report.Failure{}}
+	res.ExitCode = 0
+	res.GodotExitCode = 0
+	for i, r := range results {
+		res.ExitCode = max(res.ExitCode, r.ExitCode)
+		if res.GodotExitCode >= 0 {
+			res.GodotExitCode = max(res.GodotExitCode, r.GodotExitCode)
+			if r.GodotExitCode < 0 {
+				res.GodotExitCode = -1
+			}
+		}
+		p := report.ProjectResult{Dir: relDir(root, dirs[i]), RunID: r.RunID}
+		if errs[i] != nil {
+			p.Error = errs[i].Error()
+			res.ExitCode = 2
+		}
+		if r.Output != nil {
+			summary := r.Output.Summary
+			p.Summary = &summary
+			p.Coverage = r.Output.Coverage
+			p.LogFile = r.Output.LogFile
+
+			out.Summary.Total += summary.Total
+			out.Summary.Passed += summary.Passed
+			out.Summary.Failed += summary.Failed
+			out.Summary.Errors += summary.Errors
+			out.Summary.Skipped += summary.Skipped
+			out.Summary.XFail += summary.XFail
+			out.Summary.XPass += summary.XPass
+			out.Summary.Crashed = out.Summary.Crashed || summary.Crashed
+			for _, f := range r.Output.Failures {
+				f.Project = p.Dir
+				out.Failures = append(out.Failures, f)
+			}
+			for _, f := range r.Output.XFailures {
+				f.Project = p.Dir
+				out.XFailures = append(out.XFailures, f)
+			}
+			out.Skipped = append(out.Skipped, r.Output.Skipped...)
+			out.XPassed = append(out.XPassed, r.Output.XPassed...)
+			out.SuiteLogs = append(out.SuiteLogs, r.Output.SuiteLogs...)
+			if d := r.Output.CrashDetails; d != nil {
+				if out.CrashDetails == nil {
+					out.CrashDetails = &report.CrashDetails{}
+				}
+				out.CrashDetails.CrashInfo = appendSection(out.CrashDetails.CrashInfo, p.Dir, d.CrashInfo)
+				out.CrashDetails.ScriptErrors = appendSection(out.CrashDetails.ScriptErrors, p.Dir, d.ScriptErrors)
+				out.CrashDetails.Dumps = append(out.CrashDetails.Dumps, d.Dumps...)
+			}
+		}
+		if r.Suites != nil {
+			if res.Suites == nil {
+				res.Suites = &report.JUnitTestSuites{}
+			}
+			res.Suites.Tests += r.Suites.Tests
+			res.Suites.Failures += r.Suites.Failures
+			res.Suites.Errors += r.Suites.Errors
+			res.Suites.Time += r.Suites.Time
+			res.Suites.Suites = append(res.Suites.Suites, r.Suites.Suites...)
+		}
+		out.Projects = append(out.Projects, p)
+	}
+
+	out.Summary.Status = report.Status(out.Summary.Crashed, out.Summary.Failed, out.Summary.Errors)
+	if out.Summary.Status == "passed" && res.ExitCode != 0 {
+		out.Summary.Status = "failed"
+	}
+	for _, r := range results {
+		if r.Output == nil {
+			continue
+		}
+		out.Incomplete = out.Incomplete || r.Output.Incomplete
+		if r.Output.Summary.Status == StatusAbortedMaxFailures && !out.Summary.Crashed {
+			out.Summary.Status = StatusAbortedMaxFailures
+		}
+	}
+	res.Output = out
+}
+
+// appendSection appends text under a header naming project, if text is not empty.
+func appendSection(dst, project, text string) string {
+	if text == "" {
+		return dst
+	}
+	if dst != "" {
+		dst += "\n"
+	}
+	return dst + "[" + project + "]\n" + text
+}
+
+// commonDir returns the deepest directory containing all of dirs.
+func commonDir(dirs []string) string {
+	root := dirs[0]
+	for _, d := range dirs[1:] {
+		for relDir(root, d) == "" {
+			parent := filepath.Dir(root)
+			if parent == root {
+				return root
+			}
+			root = parent
+		}
+	}
+	return root
+}
+
+// relDir returns dir relative to root with slashes, "." for root itself, or ""
+// if dir is not inside root.
+func relDir(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// projectLogFile names the --log-file of one project: game.log becomes
+// game-<project>.log, with the project's slashes turned into dashes, and
+// game.log.gz becomes game-<project>.log.gz.
+func projectLogFile(logFile, project string) string {
+	logFile, gz := strings.CutSuffix(logFile, report.GzipExt)
+	ext := filepath.Ext(logFile)
+	name := strings.TrimSuffix(logFile, ext) + "-" + strings.ReplaceAll(project, "/", "-") + ext
+	if gz {
+		name += report.GzipExt
+	}
+	return name
+}
+
+// lockedWriter serializes writes of concurrent project runs.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// prefixWriter writes each complete line with a prefix, so that the lines of
+// concurrent project runs can be told apart. An unfinished line is held back
+// until its newline or flush, so that it is not split by another run's output.
+type prefixWriter struct {
+	mu     sync.Mutex
+	prefix string
+	w      io.Writer
+	buf    []byte // the unfinished last line
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.buf = append(p.buf, b...)
+	var out []byte
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		out = append(append(out, p.prefix...), p.buf[:i+1]...)
+		p.buf = p.buf[i+1:]
+	}
+	if len(out) > 0 {
+		if _, err := p.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// flush writes an unfinished last line, ending it with a newline.
+func (p *prefixWriter) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.buf) > 0 {
+		p.w.Write(append(append([]byte(p.prefix), p.buf...), '\n'))
+		p.buf = nil
+	}
+}
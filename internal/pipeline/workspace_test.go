@@ -0,0 +1,220 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/testutil"
+)
+
+func TestExecute_Workspace(t *testing.T) {
+	passing := `<testsuites tests="1" failures="0" errors="0"><testsuite name="s"><testcase name="test_a" classname="s"/></testsuite></testsuites>`
+	failingRoot, script := makeProject(t, failingXML)
+	passingRoot, _ := makeProject(t, passing)
+
+	for _, jobs := range []int{1, 2} {
+		cfg := &config.Config{
+			TestPaths:   []string{filepath.Join(failingRoot, "tests"), filepath.Join(passingRoot, "tests")},
+			GodotPath:   script,
+			ProjectJobs: jobs,
+		}
+		res, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard})
+		if err != nil {
+			t.Fatalf("jobs=%d: unexpected error: %v", jobs, err)
+		}
+		if res.ExitCode != 1 || res.Output.Summary.Status != "failed" {
+			t.Errorf("jobs=%d: ExitCode = %d, Status = %q, want 1, failed", jobs, res.ExitCode, res.Output.Summary.Status)
+		}
+		if res.ProjectDir != filepath.Dir(failingRoot) {
+			t.Errorf("jobs=%d: ProjectDir = %q, want the common parent %q", jobs, res.ProjectDir, filepath.Dir(failingRoot))
+		}
+
+		projects := res.Output.Projects
+		if len(projects) != 2 || projects[0].Dir != filepath.Base(failingRoot) || projects[1].Dir != filepath.Base(passingRoot) {
+			t.Fatalf("jobs=%d: Projects = %+v", jobs, projects)
+		}
+		if projects[0].Summary.Status != "failed" || projects[1].Summary.Status != "passed" {
+			t.Errorf("jobs=%d: project statuses = %q, %q", jobs, projects[0].Summary.Status, projects[1].Summary.Status)
+		}
+		if projects[0].RunID == "" || projects[0].RunID == projects[1].RunID || res.Output.RunID != res.RunID {
+			t.Errorf("jobs=%d: run IDs = %q, %q, %q", jobs, res.Output.RunID, projects[0].RunID, projects[1].RunID)
+		}
+		if want := projects[0].Summary.Total + projects[1].Summary.Total; res.Output.Summary.Total != want {
+			t.Errorf("jobs=%d: Total = %d, want %d", jobs, res.Output.Summary.Total, want)
+		}
+		if len(res.Output.Failures) != 1 || res.Output.Failures[0].Project != filepath.Base(failingRoot) {
+			t.Errorf("jobs=%d: Failures = %+v, want one tagged with its project", jobs, res.Output.Failures)
+		}
+	}
+}
+
+func TestExecute_WorkspaceOutputMode(t *testing.T) {
+	rootA, script := makeProject(t, failingXML)
+	rootB, _ := makeProject(t, failingXML)
+	tags := []string{"[" + filepath.Base(rootA) + "] ", "[" + filepath.Base(rootB) + "] "}
+
+	for _, mode := range []string{config.OutputInterleaved, config.OutputGrouped} {
+		var stderr bytes.Buffer
+		cfg := &config.Config{
+			TestPaths:   []string{filepath.Join(rootA, "tests"), filepath.Join(rootB, "tests")},
+			GodotPath:   script,
+			ProjectJobs: 2,
+			OutputMode:  mode,
+			Verbose:     true,
+		}
+		if _, err := Execute(context.Background(), cfg, Options{Stderr: &stderr}); err != nil {
+			t.Fatalf("%s: unexpected error: %v", mode, err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(stderr.String(), "\n"), "\n")
+		var order []string // the tag of each run of lines from one project
+		for _, line := range lines {
+			i := slices.IndexFunc(tags, func(tag string) bool { return strings.HasPrefix(line, tag) })
+			if i < 0 {
+				t.Fatalf("%s: line %q is not tagged with its project", mode, line)
+			}
+			if len(order) == 0 || order[len(order)-1] != tags[i] {
+				order = append(order, tags[i])
+			}
+		}
+		if !strings.Contains(stderr.String(), "Run Test Suite: res://tests/test_math.gd") {
+			t.Errorf("%s: stderr lacks the Godot output:\n%s", mode, stderr.String())
+		}
+		if mode == config.OutputGrouped && len(order) != 2 {
+			t.Errorf("%s: output of the projects is interleaved:\n%s", mode, stderr.String())
+		}
+	}
+}
+
+func TestExecute_WorkspaceSchedule(t *testing.T) {
+	const gpuSuite = "# @tag: gpu\nextends GdUnitTestSuite\n\nfunc test_a():\n\tpass\n"
+	const serialSuite = "extends GdUnitTestSuite\n\n# @tag: serial\nfunc test_a():\n\tpass\n"
+
+	tests := []struct {
+		name     string
+		schedule config.Schedule
+		suiteB   string // source of tests/save/test_save.gd in project B
+		want     string
+	}{
+		{"unconstrained", config.Schedule{}, suiteSource, "start\nstart\nend\nend\n"},
+		{"serial pattern", config.Schedule{Serial: []string{"tests/save/"}}, suiteSource, "start\nend\nstart\nend\n"},
+		{"serial tag", config.Schedule{}, serialSuite, "start\nend\nstart\nend\n"},
+		{"tag limit", config.Schedule{Limits: map[string]int{"gpu": 1}}, gpuSuite, "start\nend\nstart\nend\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootA, _ := makeProject(t, failingXML)
+			rootB, _ := makeProject(t, failingXML)
+			// Both projects need the gpu; only project B has the serial suite.
+			for _, f := range []string{filepath.Join(rootA, "tests", "test_render.gd"), filepath.Join(rootB, "tests", "save", "test_save.gd")} {
+				src := tt.suiteB
+				if filepath.Base(f) == "test_render.gd" {
+					src = gpuSuite
+				}
+				if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(f, []byte(src), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Each run logs its start and end, so that overlapping runs show
+			// up as two starts in a row.
+			events := filepath.Join(t.TempDir(), "events")
+			content := "echo start >> " + events + "\nsleep 0.3\necho end >> " + events + "\n" +
+				"mkdir -p reports/report_1 && cp results.xml.src reports/report_1/results.xml\nexit 100\n"
+			script := testutil.FakeGodot(t, content)
+
+			cfg := &config.Config{
+				TestPaths:   []string{filepath.Join(rootA, "tests"), filepath.Join(rootB, "tests")},
+				GodotPath:   script,
+				ProjectJobs: 2,
+				Schedule:    tt.schedule,
+			}
+			if _, err := Execute(context.Background(), cfg, Options{Stderr: io.Discard}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data, err := os.ReadFile(events)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(data); got != tt.want {
+				t.Errorf("runs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleProjects(t *testing.T) {
+	root := t.TempDir()
+	timingFile := filepath.Join(t.TempDir(), "timings.json")
+	durations := map[string]map[string]time.Duration{
+		"short": {"res://tests/test_a.gd": time.Second},
+		"long":  {"res://tests/test_a.gd": time.Second, "res://tests/slow/test_b.gd": time.Minute},
+		"other": {"res://other/test_c.gd": time.Hour}, // not under the paths run
+	}
+	var groups []*detector.Result
+	for _, name := range []string{"new", "short", "other", "long"} {
+		dir := filepath.Join(root, name)
+		if err := saveTimings(projectLogFile(timingFile, name), durations[name]); err != nil {
+			t.Fatal(err)
+		}
+		groups = append(groups, &detector.Result{ProjectDir: dir, ResPaths: []string{"res://tests"}})
+	}
+
+	cfg := &config.Config{TimingFile: timingFile}
+	// Projects without timings first, then the longest.
+	if got, want := scheduleProjects(cfg, root, groups, 2, io.Discard), []int{0, 2, 3, 1}; !slices.Equal(got, want) {
+		t.Errorf("scheduleProjects() = %v, want %v", got, want)
+	}
+	if got, want := scheduleProjects(cfg, root, groups, 1, io.Discard), []int{0, 1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("scheduleProjects() with one job = %v, want %v", got, want)
+	}
+
+	// A later run keeps the suites it did not measure.
+	longFile := projectLogFile(timingFile, "long")
+	if err := saveTimings(longFile, map[string]time.Duration{"res://tests/test_a.gd": 2500 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	timings, err := readTimings(longFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]float64{"res://tests/test_a.gd": 2.5, "res://tests/slow/test_b.gd": 60}; !reflect.DeepEqual(timings, want) {
+		t.Errorf("timings = %v, want %v", timings, want)
+	}
+	if est, ok := estimate(timings, []string{"res://tests/slow/test_b.gd:test_x"}); !ok || est != 60 {
+		t.Errorf("estimate() of a single test = %v, %v, want 60, true", est, ok)
+	}
+}
+
+func TestCommonDir(t *testing.T) {
+	tests := []struct {
+		dirs []string
+		want string
+	}{
+		{[]string{"/repo/games/a", "/repo/games/b"}, "/repo/games"},
+		{[]string{"/repo/a", "/repo/games/b"}, "/repo"},
+		{[]string{"/repo", "/repo/games/b"}, "/repo"},
+	}
+	for _, tt := range tests {
+		dirs := make([]string, len(tt.dirs))
+		for i, d := range tt.dirs {
+			dirs[i] = filepath.FromSlash(d)
+		}
+		if got := commonDir(dirs); got != filepath.FromSlash(tt.want) {
+			t.Errorf("commonDir(%q) = %q, want %q", dirs, got, tt.want)
+		}
+	}
+}
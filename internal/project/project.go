@@ -0,0 +1,156 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileName is the name of a Godot project's settings file.
+const FileName = "project.godot"
+
+// Info holds the settings of project.godot the runner reports and checks.
+type Info struct {
+	Name     string   // application/config/name
+	Features []string // application/config/features, e.g. "4.3", "C#", "Forward Plus"
+	GdUnit4  GdUnit4  // the [gdunit4] section
+}
+
+// GdUnit4 holds the gdUnit4 settings the runner takes as defaults. Godot only
+// writes settings that differ from their defaults, so zero values mean unset.
+type GdUnit4 struct {
+	ReportDir   string        // gdunit4/report/directory, a res:// path
+	TestTimeout time.Duration // gdunit4/settings/test/test_timeout_seconds
+}
+
+// quotedRe matches a double-quoted Godot string with backslash escapes.
+var quotedRe = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+
+// featureVersionRe matches the engine version among the features, e.g. "4.3".
+var featureVersionRe = regexp.MustCompile(`^\d+\.\d+$`)
+
+// versionRe matches the major.minor version at the start of the output of
+// godot --version, e.g. "4.2.1.stable.official.b09f793f5".
+var versionRe = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// Load reads projectDir/project.godot.
+func Load(projectDir string) (*Info, error) {
+	f, err := os.Open(filepath.Join(projectDir, FileName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads the application name and features and the gdUnit4 settings from
+// a project.godot file. Other sections and keys, including values spanning
+// several lines, are skipped.
+func Parse(r io.Reader) (*Info, error) {
+	info := &Info{}
+	section := ""
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch section + "/" + strings.TrimSpace(key) {
+		case "gdunit4/report/directory":
+			info.GdUnit4.ReportDir = unquote(value)
+		case "gdunit4/settings/test/test_timeout_seconds":
+			if n, err := strconv.ParseFloat(value, 64); err == nil && n > 0 {
+				info.GdUnit4.TestTimeout = time.Duration(n * float64(time.Second))
+			}
+		case "application/config/name":
+			info.Name = unquote(value)
+		case "application/config/features":
+			info.Features = nil
+			for _, m := range quotedRe.FindAllStringSubmatch(value, -1) {
+				info.Features = append(info.Features, unquote(`"`+m[1]+`"`))
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+	return info, nil
+}
+
+func unquote(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return strings.Trim(s, `"`)
+}
+
+// ReportsDir returns the directory gdUnit4 writes its reports to in the project
+// at projectDir: the configured report directory, or reports/ by default. Only
+// res:// directories are honored; a user:// one would lie outside the project.
+func (i *Info) ReportsDir(projectDir string) string {
+	dir, ok := strings.CutPrefix(i.GdUnit4.ReportDir, "res://")
+	if !ok {
+		return filepath.Join(projectDir, "reports")
+	}
+	return filepath.Join(projectDir, filepath.FromSlash(dir))
+}
+
+// Version returns the engine version the project was last saved with, the
+// "major.minor" entry of its features, or "" if it has none (Godot 3 projects).
+func (i *Info) Version() string {
+	for _, f := range i.Features {
+		if featureVersionRe.MatchString(f) {
+			return f
+		}
+	}
+	return ""
+}
+
+// UsesCSharp reports whether the project needs a .NET build of Godot.
+func (i *Info) UsesCSharp() bool {
+	for _, f := range i.Features {
+		if f == "C#" {
+			return true
+		}
+	}
+	return false
+}
+
+// Check compares the project with godotVersion, the output of godot --version
+// (e.g. "4.3.stable.mono.official.77dcf97d8"), and returns a warning per
+// mismatch: a different major.minor version, or a C# project run by a build
+// without .NET support.
+func (i *Info) Check(godotVersion string) []string {
+	var warnings []string
+	if want := i.Version(); want != "" {
+		if got := majorMinor(godotVersion); got != "" && got != want {
+			warnings = append(warnings, fmt.Sprintf("project targets Godot %s but the Godot binary is %s", want, godotVersion))
+		}
+	}
+	if i.UsesCSharp() && godotVersion != "" && !strings.Contains(godotVersion, "mono") {
+		warnings = append(warnings, fmt.Sprintf("project uses C# but the Godot binary (%s) is not a .NET build", godotVersion))
+	}
+	return warnings
+}
+
+// majorMinor returns the "major.minor" prefix of a Godot version string.
+func majorMinor(version string) string {
+	m := versionRe.FindStringSubmatch(strings.TrimSpace(version))
+	if m == nil {
+		return ""
+	}
+	return m[1] + "." + m[2]
+}
@@ -0,0 +1,97 @@
+package project
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const projectGodot = `; Engine configuration file.
+
+config_version=5
+
+[application]
+
+config/name="Space \"Shooter\""
+config/features=PackedStringArray("4.3", "C#", "Forward Plus")
+run/main_scene="res://main.tscn"
+
+[autoload]
+
+Global="*res://global.gd"
+
+[input]
+
+jump={
+"deadzone": 0.5,
+"events": []
+}
+
+[gdunit4]
+
+report/directory="res://build/reports"
+settings/test/test_timeout_seconds=90
+`
+
+func TestParse(t *testing.T) {
+	info, err := Parse(strings.NewReader(projectGodot))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Name != `Space "Shooter"` {
+		t.Errorf("Name = %q", info.Name)
+	}
+	if !slices.Equal(info.Features, []string{"4.3", "C#", "Forward Plus"}) {
+		t.Errorf("Features = %q", info.Features)
+	}
+	if info.GdUnit4.ReportDir != "res://build/reports" || info.GdUnit4.TestTimeout != 90*time.Second {
+		t.Errorf("GdUnit4 = %+v", info.GdUnit4)
+	}
+	if got, want := info.ReportsDir("/game"), filepath.Join("/game", "build", "reports"); got != want {
+		t.Errorf("ReportsDir = %q, want %q", got, want)
+	}
+	if info.Version() != "4.3" || !info.UsesCSharp() {
+		t.Errorf("Version = %q, UsesCSharp = %v", info.Version(), info.UsesCSharp())
+	}
+
+	godot3, err := Parse(strings.NewReader("config_version=4\n\n[application]\n\nconfig/name=\"Old\"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if godot3.Name != "Old" || godot3.Version() != "" || godot3.UsesCSharp() {
+		t.Errorf("Godot 3 project = %+v", godot3)
+	}
+	if got, want := godot3.ReportsDir("/game"), filepath.Join("/game", "reports"); got != want {
+		t.Errorf("default ReportsDir = %q, want %q", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		features []string
+		version  string
+		want     []string
+	}{
+		{[]string{"4.3", "Forward Plus"}, "4.3.stable.official.77dcf97d8", nil},
+		{[]string{"4.3"}, "4.2.2.stable.official.15073afe3", []string{"targets Godot 4.3"}},
+		{[]string{"4.3", "C#"}, "4.3.stable.mono.official.77dcf97d8", nil},
+		{[]string{"4.3", "C#"}, "4.3.stable.official.77dcf97d8", []string{"not a .NET build"}},
+		{[]string{"4.3"}, "v4.4.1.stable", []string{"targets Godot 4.3"}},
+		{nil, "3.5.stable", nil},
+	}
+	for _, tt := range tests {
+		info := &Info{Features: tt.features}
+		got := info.Check(tt.version)
+		if len(got) != len(tt.want) {
+			t.Errorf("Check(%q) with %q = %q, want %d warnings", tt.version, tt.features, got, len(tt.want))
+			continue
+		}
+		for i := range got {
+			if !strings.Contains(got[i], tt.want[i]) {
+				t.Errorf("Check(%q) with %q = %q, want %q", tt.version, tt.features, got[i], tt.want[i])
+			}
+		}
+	}
+}
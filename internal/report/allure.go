@@ -0,0 +1,287 @@
+package report
+
+import (
+	"cmp"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// allureResult is an Allure 2 test result, written as <uuid>-result.json.
+type allureResult struct {
+	UUID          string             `json:"uuid"`
+	HistoryID     string             `json:"historyId"`
+	TestCaseID    string             `json:"testCaseId"`
+	FullName      string             `json:"fullName"`
+	Name          string             `json:"name"`
+	Status        string             `json:"status"`
+	StatusDetails *allureDetails     `json:"statusDetails,omitempty"`
+	Stage         string             `json:"stage"`
+	Start         int64              `json:"start"`
+	Stop          int64              `json:"stop"`
+	Labels        []allureLabel      `json:"labels"`
+	Parameters    []allureLabel      `json:"parameters,omitempty"`
+	Attachments   []allureAttachment `json:"attachments,omitempty"`
+}
+
+// allureContainer groups the results of a suite, written as <uuid>-container.json.
+type allureContainer struct {
+	UUID     string   `json:"uuid"`
+	Name     string   `json:"name"`
+	Children []string `json:"children"`
+	Start    int64    `json:"start"`
+	Stop     int64    `json:"stop"`
+}
+
+type allureDetails struct {
+	Known   bool   `json:"known,omitempty"`
+	Message string `json:"message,omitempty"`
+	Trace   string `json:"trace,omitempty"`
+}
+
+// allureLabel is a label or a parameter of a result.
+type allureLabel struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type allureAttachment struct {
+	Name   string `json:"name"`
+	Source string `json:"source"` // file name in the results directory
+	Type   string `json:"type"`
+}
+
+// WriteAllure writes the test cases of suites as Allure 2 results into dir:
+// a result file per test case, a container per suite, and as attachments the
+// log segment of the suite of a failed test and its received snapshot. out
+// supplies the expected failures, suite logs and snapshots, with res:// paths
+// resolved under projectDir; stop is when the run ended, from which the tests'
+// times are laid out backwards as gdUnit4 reports durations only. If suites is
+// nil, a single broken result describes the crash.
+func WriteAllure(dir string, suites *JUnitTestSuites, out *Output, projectDir string, stop time.Time) error {
+	if suites == nil {
+		suites = crashSuites(out.CrashDetails)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create Allure results directory: %w", err)
+	}
+	w := allureWriter{dir: dir, out: out, projectDir: projectDir}
+
+	at := runStart(suites, stop)
+
+	for _, s := range suites.Suites {
+		c := allureContainer{UUID: newUUID(), Name: cmp.Or(s.Name, s.Package), Start: at.UnixMilli()}
+		for i := range s.TestCases {
+			tc := &s.TestCases[i]
+			end := at.Add(time.Duration(tc.Time * float64(time.Second)))
+			r, err := w.result(&s, tc, at, end)
+			if err != nil {
+				return err
+			}
+			c.Children = append(c.Children, r.UUID)
+			at = end
+		}
+		c.Stop = at.UnixMilli()
+		if err := w.writeJSON(c.UUID+"-container.json", c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allureWriter writes the files of one set of Allure results.
+type allureWriter struct {
+	dir        string
+	out        *Output
+	projectDir string
+}
+
+// result writes the result of tc, a test case of suite run from start to end.
+func (w *allureWriter) result(suite *JUnitTestSuite, tc *JUnitTestCase, start, end time.Time) (*allureResult, error) {
+	method, index, args := tc.Name, "", ""
+	if m := paramCaseRe.FindStringSubmatch(tc.Name); m != nil {
+		method, index, args = m[1], m[2], m[3]
+	}
+	fullName := tc.Classname + "." + method
+	r := &allureResult{
+		UUID:       newUUID(),
+		TestCaseID: md5Hex(fullName),
+		HistoryID:  md5Hex(tc.Classname + "." + tc.Name),
+		FullName:   fullName,
+		Name:       method,
+		Status:     "passed",
+		Stage:      "finished",
+		Start:      start.UnixMilli(),
+		Stop:       end.UnixMilli(),
+		Labels: []allureLabel{
+			{"framework", "gdUnit4"},
+			{"language", "gdscript"},
+			{"suite", cmp.Or(suite.Name, tc.Classname)},
+			{"testClass", tc.Classname},
+			{"testMethod", method},
+		},
+	}
+	if suite.Package != "" {
+		r.Labels = append(r.Labels, allureLabel{"package", suite.Package})
+	}
+	if index != "" {
+		r.Parameters = []allureLabel{{"index", index}}
+		if args != "" {
+			r.Parameters = append(r.Parameters, allureLabel{"args", args})
+		}
+	}
+
+	xfail := w.expected(tc.Classname, method)
+	switch {
+	case tc.Skipped != nil:
+		r.Status = "skipped"
+		r.StatusDetails = &allureDetails{Message: cmp.Or(tc.Skipped.Message, tc.Skipped.Text)}
+	case tc.Failure != nil || tc.Error != nil:
+		f, status := tc.Failure, "failed"
+		if f == nil {
+			f, status = tc.Error, "broken"
+		}
+		r.Status = status
+		r.StatusDetails = &allureDetails{Message: f.Message, Trace: strings.TrimSpace(f.Text)}
+		if xfail {
+			r.Status = "skipped"
+			r.StatusDetails.Known = true
+			r.StatusDetails.Message = "XFAIL: " + f.Message
+		}
+		if err := w.attach(r, suite.Package, tc.Classname, method); err != nil {
+			return nil, err
+		}
+	case xfail:
+		r.StatusDetails = &allureDetails{Message: "XPASS: expected to fail"}
+	}
+
+	if err := w.writeJSON(r.UUID+"-result.json", r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// expected reports whether out lists the test as expected to fail.
+func (w *allureWriter) expected(class, method string) bool {
+	name := class + "." + method
+	for _, f := range w.out.XFailures {
+		if f.Class+"."+f.Method == name {
+			return true
+		}
+	}
+	for _, x := range w.out.XPassed {
+		if x == name {
+			return true
+		}
+	}
+	return false
+}
+
+// attach adds the log segment of the suite at resPath and the received
+// snapshot of the test to r.
+func (w *allureWriter) attach(r *allureResult, resPath, class, method string) error {
+	for _, l := range w.out.SuiteLogs {
+		if l.Suite != resPath {
+			continue
+		}
+		var data []byte
+		if l.LogFile != "" {
+			data, _ = os.ReadFile(l.LogFile)
+		}
+		if data == nil {
+			data = []byte(l.Log)
+		}
+		if len(data) == 0 {
+			continue
+		}
+		if err := w.attachment(r, "log", ".txt", data); err != nil {
+			return err
+		}
+	}
+	for _, f := range slices.Concat(w.out.Failures, w.out.XFailures) {
+		if f.Class != class || f.Method != method || f.Snapshot == nil || !strings.HasPrefix(f.Snapshot.Received, "res://") {
+			continue
+		}
+		rel := path.Join(f.Project, strings.TrimPrefix(f.Snapshot.Received, "res://"))
+		data, err := os.ReadFile(filepath.Join(w.projectDir, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		if err := w.attachment(r, "received snapshot", path.Ext(rel), data); err != nil {
+			return err
+		}
+		break
+	}
+	return nil
+}
+
+// attachment writes data as an attachment of r with the file extension ext.
+func (w *allureWriter) attachment(r *allureResult, name, ext string, data []byte) error {
+	source := newUUID() + "-attachment" + ext
+	if err := os.WriteFile(filepath.Join(w.dir, source), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write Allure attachment: %w", err)
+	}
+	r.Attachments = append(r.Attachments, allureAttachment{Name: name, Source: source, Type: mimeType(ext)})
+	return nil
+}
+
+func (w *allureWriter) writeJSON(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode Allure result: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(w.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write Allure result: %w", err)
+	}
+	return nil
+}
+
+// mimeType returns the MIME type of an attachment with the file extension ext.
+func mimeType(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".png":
+		return "image/png"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".txt", ".log", "":
+		return "text/plain"
+	case ".json":
+		return "application/json"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return guid(hex.EncodeToString(b[:]))
+}
+
+// runStart returns when the tests of suites started if they ran back to back
+// until stop.
+func runStart(suites *JUnitTestSuites, stop time.Time) time.Time {
+	var total float64
+	for _, s := range suites.Suites {
+		for _, tc := range s.TestCases {
+			total += tc.Time
+		}
+	}
+	return stop.Add(-time.Duration(total * float64(time.Second)))
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,141 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteAllure(t *testing.T) {
+	project := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(project, "tests", "snapshots"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "tests", "snapshots", "test_render.received.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	suites := &JUnitTestSuites{Suites: []JUnitTestSuite{{
+		Name:    "RenderTest",
+		Package: "res://tests/RenderTest.gd",
+		TestCases: []JUnitTestCase{
+			{Name: "test_render", Classname: "RenderTest", Time: 1.5, Failure: &JUnitFailure{Message: "FAILED: res://tests/RenderTest.gd:3", Text: "snapshot mismatch"}},
+			{Name: "test_add:1 (1, 2)", Classname: "RenderTest", Time: 0.5},
+			{Name: "test_crash", Classname: "RenderTest", Error: &JUnitFailure{Message: "boom"}},
+			{Name: "test_later", Classname: "RenderTest", Skipped: &JUnitFailure{Message: "not yet"}},
+		},
+	}}}
+	out := &Output{
+		Failures:  []Failure{{Class: "RenderTest", Method: "test_render", Snapshot: &SnapshotDiff{Received: "res://tests/snapshots/test_render.received.png"}}},
+		SuiteLogs: []SuiteLog{{Suite: "res://tests/RenderTest.gd", Log: "Run Test Suite: res://tests/RenderTest.gd\n"}},
+	}
+	dir := filepath.Join(t.TempDir(), "allure-results")
+	stop := time.UnixMilli(10_000)
+	if err := WriteAllure(dir, suites, out, project, stop); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string]allureResult{}
+	var containers []allureContainer
+	attachments := 0
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case strings.HasSuffix(e.Name(), "-result.json"):
+			var r allureResult
+			if err := json.Unmarshal(data, &r); err != nil {
+				t.Fatal(err)
+			}
+			results[r.Name] = r
+		case strings.HasSuffix(e.Name(), "-container.json"):
+			var c allureContainer
+			if err := json.Unmarshal(data, &c); err != nil {
+				t.Fatal(err)
+			}
+			containers = append(containers, c)
+		default:
+			attachments++
+		}
+	}
+
+	want := map[string]string{"test_render": "failed", "test_add": "passed", "test_crash": "broken", "test_later": "skipped"}
+	for name, status := range want {
+		if results[name].Status != status {
+			t.Errorf("%s status = %q, want %q", name, results[name].Status, status)
+		}
+	}
+	render := results["test_render"]
+	if render.Start != 8_000 || render.Stop != 9_500 {
+		t.Errorf("test_render ran %d..%d, want 8000..9500", render.Start, render.Stop)
+	}
+	if len(render.Attachments) != 2 || render.Attachments[1].Type != "image/png" {
+		t.Errorf("test_render attachments = %+v, want the log and the png snapshot", render.Attachments)
+	}
+	// The suite log is attached to test_crash too.
+	if attachments != 3 {
+		t.Errorf("wrote %d attachment files, want 3", attachments)
+	}
+	if p := results["test_add"].Parameters; len(p) != 2 || p[0].Value != "1" || p[1].Value != "1, 2" {
+		t.Errorf("test_add parameters = %+v", p)
+	}
+	if len(containers) != 1 || len(containers[0].Children) != 4 {
+		t.Errorf("containers = %+v, want one with 4 children", containers)
+	}
+}
+
+func TestWriteAllure_XFailAndCrash(t *testing.T) {
+	suites := &JUnitTestSuites{Suites: []JUnitTestSuite{{
+		Name: "NetTest",
+		TestCases: []JUnitTestCase{
+			{Name: "test_flaky", Classname: "NetTest", Failure: &JUnitFailure{Message: "timeout"}},
+		},
+	}}}
+	out := &Output{XFailures: []Failure{{Class: "NetTest", Method: "test_flaky"}}}
+	dir := t.TempDir()
+	if err := WriteAllure(dir, suites, out, t.TempDir(), time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := readAllureResults(t, dir)
+	if len(r) != 1 || r[0].Status != "skipped" || r[0].StatusDetails == nil || !r[0].StatusDetails.Known {
+		t.Errorf("results = %+v, want a known skipped result", r)
+	}
+
+	dir = t.TempDir()
+	if err := WriteAllure(dir, nil, &Output{CrashDetails: &CrashDetails{CrashInfo: "SIGSEGV"}}, t.TempDir(), time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r = readAllureResults(t, dir)
+	if len(r) != 1 || r[0].Status != "broken" || !strings.Contains(r[0].StatusDetails.Trace, "SIGSEGV") {
+		t.Errorf("results = %+v, want a broken result describing the crash", r)
+	}
+}
+
+func readAllureResults(t *testing.T, dir string) []allureResult {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*-result.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []allureResult
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r allureResult
+		if err := json.Unmarshal(data, &r); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r)
+	}
+	return results
+}
@@ -0,0 +1,122 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Badge colors, as shields.io names them.
+const (
+	badgeLabel       = "#555"
+	badgeBrightGreen = "#4c1"
+	badgeGreen       = "#97ca00"
+	badgeYellowGreen = "#a4a61d"
+	badgeYellow      = "#dfb317"
+	badgeOrange      = "#fe7d37"
+	badgeRed         = "#e05d44"
+)
+
+// badgeSegment is one colored part of a badge.
+type badgeSegment struct {
+	text  string
+	color string
+}
+
+// WriteBadge writes a shields.io-style SVG badge of out: the counts of passed
+// and failed tests, green if the run passed and red otherwise, followed by the
+// line coverage when out has a coverage summary.
+func WriteBadge(w io.Writer, out *Output) error {
+	s := out.Summary
+	tests := badgeSegment{color: badgeBrightGreen}
+	switch failed := s.Failed + s.Errors; {
+	case s.Crashed:
+		tests = badgeSegment{"crashed", badgeRed}
+	case failed > 0 || s.Status != "passed":
+		tests = badgeSegment{fmt.Sprintf("%d passed, %d failed", s.Passed, failed), badgeRed}
+	default:
+		tests.text = fmt.Sprintf("%d passed", s.Passed)
+		if s.Skipped > 0 {
+			tests.text += fmt.Sprintf(", %d skipped", s.Skipped)
+		}
+	}
+	segments := []badgeSegment{{"tests", badgeLabel}, tests}
+	if c := out.Coverage; c != nil {
+		segments = append(segments, badgeSegment{"coverage", badgeLabel}, badgeSegment{fmt.Sprintf("%g%%", c.Percent), coverageColor(c.Percent)})
+	}
+
+	if _, err := io.WriteString(w, badgeSVG(segments)); err != nil {
+		return fmt.Errorf("failed to write badge: %w", err)
+	}
+	return nil
+}
+
+// coverageColor returns the badge color of a coverage percentage, on the
+// scale shields.io uses for coverage badges.
+func coverageColor(percent float64) string {
+	switch {
+	case percent >= 90:
+		return badgeBrightGreen
+	case percent >= 80:
+		return badgeGreen
+	case percent >= 70:
+		return badgeYellowGreen
+	case percent >= 60:
+		return badgeYellow
+	case percent >= 50:
+		return badgeOrange
+	}
+	return badgeRed
+}
+
+// badgeSVG renders segments in shields.io's flat style.
+func badgeSVG(segments []badgeSegment) string {
+	const height, padding = 20, 10
+	var body, texts strings.Builder
+	var title []string
+	x := 0
+	for _, seg := range segments {
+		width := textWidth(seg.text) + padding
+		text := html.EscapeString(seg.text)
+		fmt.Fprintf(&body, `<rect x="%d" width="%d" height="%d" fill="%s"/>`, x, width, height, seg.color)
+		// Text is drawn at 10 times the size and scaled down, as shields.io does, for sharper kerning.
+		mid := (x*2 + width) * 5
+		fmt.Fprintf(&texts, `<text x="%d" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="%d">%s</text>`, mid, (width-padding)*10, text)
+		fmt.Fprintf(&texts, `<text x="%d" y="140" transform="scale(.1)" fill="#fff" textLength="%d">%s</text>`, mid, (width-padding)*10, text)
+		title = append(title, text)
+		x += width
+	}
+	label := strings.Join(title, " ")
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" aria-label="%s">`, x, height, label)
+	fmt.Fprintf(&sb, `<title>%s</title>`, label)
+	sb.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&sb, `<clipPath id="r"><rect width="%d" height="%d" rx="3" fill="#fff"/></clipPath>`, x, height)
+	fmt.Fprintf(&sb, `<g clip-path="url(#r)">%s<rect width="%d" height="%d" fill="url(#s)"/></g>`, body.String(), x, height)
+	fmt.Fprintf(&sb, `<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="110">%s</g>`, texts.String())
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// textWidth estimates the width in pixels of s in 11px Verdana, the badge
+// font; textLength in the SVG makes the text fit it exactly.
+func textWidth(s string) int {
+	width := 0.0
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("ijl.,:;!|' ", r):
+			width += 3.9
+		case strings.ContainsRune("frt()", r):
+			width += 4.9
+		case strings.ContainsRune("mwMW%", r):
+			width += 10.5
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 6.9
+		}
+	}
+	return int(width + 0.5)
+}
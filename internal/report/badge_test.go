@@ -0,0 +1,66 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteBadge(t *testing.T) {
+	tests := []struct {
+		name string
+		out  *Output
+		want []string // substrings of the SVG
+		not  []string
+	}{
+		{
+			name: "passed",
+			out:  &Output{Summary: Summary{Total: 13, Passed: 12, Skipped: 1, Status: "passed"}},
+			want: []string{"<title>tests 12 passed, 1 skipped</title>", `fill="` + badgeBrightGreen + `"`},
+			not:  []string{"coverage", badgeRed},
+		},
+		{
+			name: "failed",
+			out:  &Output{Summary: Summary{Total: 4, Passed: 2, Failed: 1, Errors: 1, Status: "failed"}},
+			want: []string{"<title>tests 2 passed, 2 failed</title>", `fill="` + badgeRed + `"`},
+		},
+		{
+			name: "crashed",
+			out:  &Output{Summary: Summary{Crashed: true, Status: "crashed"}},
+			want: []string{"<title>tests crashed</title>"},
+		},
+		{
+			name: "coverage",
+			out:  &Output{Summary: Summary{Total: 1, Passed: 1, Status: "passed"}, Coverage: &Coverage{Percent: 72.5}},
+			want: []string{"<title>tests 1 passed coverage 72.5%</title>", `fill="` + badgeYellowGreen + `"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := WriteBadge(&sb, tt.out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			svg := sb.String()
+			for _, s := range tt.want {
+				if !strings.Contains(svg, s) {
+					t.Errorf("badge lacks %q:\n%s", s, svg)
+				}
+			}
+			for _, s := range tt.not {
+				if strings.Contains(svg, s) {
+					t.Errorf("badge has %q:\n%s", s, svg)
+				}
+			}
+			dec := xml.NewDecoder(strings.NewReader(svg))
+			for {
+				if _, err := dec.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("badge is not well-formed XML: %v\n%s", err, svg)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"cmp"
+	"slices"
+)
+
+// The canonical order of a result, so that the output of two runs of the same
+// tests differs only where their results do, whatever order Godot ran or
+// reported them in. Tools diffing results against a baseline rely on it:
+//
+//   - suites by res:// path, then name; test cases keep gdUnit4's order within
+//     their suite, which is the order of the suite's source
+//   - failures and expected failures by project, file, line, class, method
+//     and kind; skipped tests by class and method
+//   - suite logs by suite, projects by directory, and lists of test names
+//     alphabetically
+//
+// Lists ordered by what they measure, such as the owners with the most
+// failures or the slowest resource loads, keep that order.
+
+// Sort puts the suites of s into their canonical order.
+func (s *JUnitTestSuites) Sort() {
+	slices.SortStableFunc(s.Suites, func(a, b JUnitTestSuite) int {
+		return cmp.Or(cmp.Compare(a.Package, b.Package), cmp.Compare(a.Name, b.Name))
+	})
+}
+
+// Sort puts the lists of o into their canonical order.
+func (o *Output) Sort() {
+	slices.SortStableFunc(o.Failures, compareFailures)
+	slices.SortStableFunc(o.XFailures, compareFailures)
+	slices.SortStableFunc(o.Skipped, func(a, b Skip) int {
+		return cmp.Or(cmp.Compare(a.Class, b.Class), cmp.Compare(a.Method, b.Method))
+	})
+	slices.Sort(o.XPassed)
+	slices.SortStableFunc(o.SuiteLogs, func(a, b SuiteLog) int { return cmp.Compare(a.Suite, b.Suite) })
+	slices.SortStableFunc(o.Projects, func(a, b ProjectResult) int { return cmp.Compare(a.Dir, b.Dir) })
+	if o.Ownership != nil {
+		for i := range o.Ownership.Owners {
+			slices.Sort(o.Ownership.Owners[i].Tests)
+		}
+		slices.Sort(o.Ownership.Unowned)
+	}
+}
+
+// compareFailures orders failures by where they happened.
+func compareFailures(a, b Failure) int {
+	return cmp.Or(
+		cmp.Compare(a.Project, b.Project),
+		cmp.Compare(a.File, b.File),
+		cmp.Compare(a.Line, b.Line),
+		cmp.Compare(a.Class, b.Class),
+		cmp.Compare(a.Method, b.Method),
+		cmp.Compare(a.Kind, b.Kind),
+	)
+}
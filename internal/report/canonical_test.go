@@ -0,0 +1,101 @@
+package report
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/i18n"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+func TestOutputSort(t *testing.T) {
+	out := &Output{
+		Failures: []Failure{
+			{Kind: KindError, Class: "B", Method: "test_b", File: "res://b.gd", Line: 3},
+			{Kind: KindFailure, Class: "A", Method: "test_z", File: "res://a.gd", Line: 20},
+			{Kind: KindFailure, Class: "A", Method: "test_a", File: "res://a.gd", Line: 20},
+			{Kind: KindFailure, Class: "A", Method: "test_y", File: "res://a.gd", Line: 9},
+			{Kind: KindFailure, Class: "C", Method: "test_c", File: "res://c.gd", Line: 1, Project: "a"},
+		},
+		Skipped:   []Skip{{Class: "B", Method: "test_b"}, {Class: "A", Method: "test_b"}, {Class: "A", Method: "test_a"}},
+		XPassed:   []string{"B.test_b", "A.test_a"},
+		SuiteLogs: []SuiteLog{{Suite: "res://b.gd"}, {Suite: "res://a.gd"}},
+		Ownership: &Ownership{
+			Owners:  []OwnerFailures{{Owner: "@b", Failures: 2, Tests: []string{"B.test_b", "A.test_a"}}, {Owner: "@a", Failures: 1}},
+			Unowned: []string{"Z.test_z", "C.test_c"},
+		},
+	}
+	out.Sort()
+
+	var got []string
+	for _, f := range out.Failures {
+		got = append(got, f.Class+"."+f.Method)
+	}
+	if want := []string{"A.test_y", "A.test_a", "A.test_z", "B.test_b", "C.test_c"}; !slices.Equal(got, want) {
+		t.Errorf("Failures = %q, want %q", got, want)
+	}
+	if want := []Skip{{Class: "A", Method: "test_a"}, {Class: "A", Method: "test_b"}, {Class: "B", Method: "test_b"}}; !slices.Equal(out.Skipped, want) {
+		t.Errorf("Skipped = %v, want %v", out.Skipped, want)
+	}
+	if !slices.IsSorted(out.XPassed) || out.SuiteLogs[0].Suite != "res://a.gd" {
+		t.Errorf("XPassed = %q, SuiteLogs = %v, want both sorted", out.XPassed, out.SuiteLogs)
+	}
+	// Owners stay ordered by failures; their tests are sorted.
+	own := out.Ownership
+	if own.Owners[0].Owner != "@b" || !slices.IsSorted(own.Owners[0].Tests) || !slices.IsSorted(own.Unowned) {
+		t.Errorf("Ownership = %+v", own)
+	}
+}
+
+// TestGolden checks the outputs of the sample results against the files in
+// testdata/golden, so that a change to their content or order shows up as a
+// diff. Run with -update to rewrite them after an intended change.
+func TestGolden(t *testing.T) {
+	suites, err := ParseXML(filepath.Join("..", "..", "testdata", "sample_results.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// gdUnit4 may report the suites in any order; the output must not depend on it.
+	slices.Reverse(suites.Suites)
+	suites.Sort()
+	out := BuildOutput(suites, nil)
+	out.Sort()
+
+	tests := []struct {
+		file  string
+		write func(w io.Writer) error
+	}{
+		{"sample_results.json", func(w io.Writer) error { return WriteJSON(w, out) }},
+		{"sample_results.txt", func(w io.Writer) error { return WriteText(w, out, i18n.English) }},
+		{"sample_results.junit.xml", func(w io.Writer) error { return WriteJUnitXML(w, suites, nil) }},
+		{"sample_results.ctest.txt", func(w io.Writer) error { return WriteCTest(w, out) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			path := filepath.Join("..", "..", "testdata", "golden", tt.file)
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output differs from %s (run go test -update after an intended change):\n%s", path, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// GzipExt ends the path of every gzip-compressed output (--compress).
+const GzipExt = ".gz"
+
+// Compressed reports whether path names a gzip-compressed output.
+func Compressed(path string) bool {
+	return strings.HasSuffix(path, GzipExt)
+}
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Decompress returns a reader of the content of r, decompressing it if it is
+// a gzip stream, so that readers of outputs need not know whether they were
+// written with --compress.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(head, gzipMagic) {
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	data := []byte(`{"summary":{}}`)
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"plain", data},
+		{"gzip", gzipBytes(t, data)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Decompress(bytes.NewReader(tt.in))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("Decompress = %q, want %q", got, data)
+			}
+		})
+	}
+
+	// Shorter than the gzip magic.
+	r, err := Decompress(bytes.NewReader([]byte("x")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != "x" {
+		t.Errorf("Decompress = %q, want %q", got, "x")
+	}
+}
+
+func TestParseXML_Gzip(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "sample_results.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "results.xml.gz")
+	if err := os.WriteFile(path, gzipBytes(t, data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseXML(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, err := ParseXML(filepath.Join("..", "..", "testdata", "sample_results.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Suites) == 0 || len(got.Suites) != len(want.Suites) {
+		t.Errorf("got %d suites, want %d", len(got.Suites), len(want.Suites))
+	}
+}
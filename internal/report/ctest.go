@@ -0,0 +1,57 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteCTest writes out as line-oriented text suited to CTest logs and
+// FAIL_REGULAR_EXPRESSION matching:
+//
+//	GDUNIT4 FAILED <class>.<method>[:<param index>] <file>:<line>: <message>
+//	GDUNIT4 CRASHED <crash or script error line>
+//	GDUNIT4 SUMMARY status=<status> total=<n> passed=<n> failed=<n> errors=<n>
+//
+// Each record is a single line; embedded newlines are replaced by spaces. Errored
+// tests are FAILED records too, so that existing expressions keep matching them.
+func WriteCTest(w io.Writer, out *Output) error {
+	var sb strings.Builder
+	for _, f := range out.Failures {
+		if len(f.Parameters) > 0 {
+			// One record per failed case so that no parameter set is hidden.
+			for _, p := range f.Parameters {
+				if p.Status == "failed" {
+					fmt.Fprintf(&sb, "GDUNIT4 FAILED %s.%s:%d %s:%d: %s\n", f.Class, f.Method, p.Index, f.File, p.Line, oneLine(p.Message))
+				}
+			}
+			continue
+		}
+		detail := f.Message
+		if f.Expected != "" || f.Actual != "" {
+			detail = fmt.Sprintf("expected '%s' but was '%s'", f.Expected, f.Actual)
+		}
+		fmt.Fprintf(&sb, "GDUNIT4 FAILED %s.%s %s:%d: %s\n", f.Class, f.Method, f.File, f.Line, oneLine(detail))
+	}
+	if c := out.CrashDetails; c != nil {
+		for _, block := range []string{c.CrashInfo, c.ScriptErrors} {
+			for _, line := range strings.Split(block, "\n") {
+				if line != "" {
+					fmt.Fprintf(&sb, "GDUNIT4 CRASHED %s\n", line)
+				}
+			}
+		}
+	}
+	s := out.Summary
+	fmt.Fprintf(&sb, "GDUNIT4 SUMMARY status=%s total=%d passed=%d failed=%d errors=%d\n", s.Status, s.Total, s.Passed, s.Failed, s.Errors)
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write CTest output: %w", err)
+	}
+	return nil
+}
+
+// oneLine collapses s onto a single line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteCTest(t *testing.T) {
+	out := &Output{
+		Summary: Summary{Total: 3, Passed: 2, Failed: 1, Status: "failed"},
+		Failures: []Failure{
+			{Class: "TestMath", Method: "test_sub", File: "res://tests/test_math.gd", Line: 7, Expected: "1", Actual: "2"},
+		},
+	}
+
+	var sb strings.Builder
+	if err := WriteCTest(&sb, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "GDUNIT4 FAILED TestMath.test_sub res://tests/test_math.gd:7: expected '1' but was '2'\n" +
+		"GDUNIT4 SUMMARY status=failed total=3 passed=2 failed=1 errors=0\n"
+	if sb.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
+
+func TestWriteCTest_CrashAndMultilineMessage(t *testing.T) {
+	out := &Output{
+		Summary:      Summary{Crashed: true, Status: "crashed"},
+		CrashDetails: &CrashDetails{CrashInfo: "handle_crash: signal 11", ScriptErrors: "SCRIPT ERROR: a\nSCRIPT ERROR: b"},
+		Failures:     []Failure{{Class: "A", Method: "test_x", Message: "line one\nline two"}},
+	}
+
+	var sb strings.Builder
+	if err := WriteCTest(&sb, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 5:\n%s", len(lines), sb.String())
+	}
+	if !strings.HasSuffix(lines[0], ": line one line two") {
+		t.Errorf("multiline message not collapsed: %q", lines[0])
+	}
+	if lines[1] != "GDUNIT4 CRASHED handle_crash: signal 11" {
+		t.Errorf("lines[1] = %q", lines[1])
+	}
+}
+
+func TestWriteCTest_ParameterizedFailure(t *testing.T) {
+	out := &Output{
+		Summary: Summary{Total: 3, Passed: 1, Failed: 2, Status: "failed"},
+		Failures: []Failure{{
+			Class: "MathTest", Method: "test_add", File: "res://tests/MathTest.gd", Line: 12,
+			Parameters: []ParameterResult{
+				{Index: 0, Status: "passed"},
+				{Index: 1, Status: "failed", Line: 12, Message: "boom"},
+				{Index: 2, Status: "failed", Line: 13, Message: "bang"},
+			},
+		}},
+	}
+	var buf strings.Builder
+	if err := WriteCTest(&buf, out); err != nil {
+		t.Fatal(err)
+	}
+	want := "GDUNIT4 FAILED MathTest.test_add:1 res://tests/MathTest.gd:12: boom\n" +
+		"GDUNIT4 FAILED MathTest.test_add:2 res://tests/MathTest.gd:13: bang\n" +
+		"GDUNIT4 SUMMARY status=failed total=3 passed=1 failed=2 errors=0\n"
+	if buf.String() != want {
+		t.Errorf("WriteCTest =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
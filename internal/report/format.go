@@ -0,0 +1,179 @@
+package report
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// xmlFormat reads one layout of gdUnit4's JUnit XML into the JUnitTestSuites
+// the rest of the package works with, so that an addon upgrade changing the
+// layout needs a new format rather than changes to every reader.
+type xmlFormat interface {
+	// name identifies the format in error messages.
+	name() string
+	// sniff reports whether a document with this root element is in the format.
+	sniff(root xml.StartElement) bool
+	// decode reads the rest of the document whose root element is root.
+	decode(dec *xml.Decoder, root xml.StartElement) (*JUnitTestSuites, error)
+	// recounts reports whether the totals of a document with this root
+	// element are summed up from its suites rather than read from the root.
+	recounts(root xml.StartElement) bool
+}
+
+// xmlFormats are the known formats, tried in order.
+var xmlFormats = []xmlFormat{suitesFormat{}, suiteFormat{}}
+
+// suitesFormat is the layout of gdUnit4 4.x: one <testsuites> root holding
+// the <testsuite> of every suite run.
+type suitesFormat struct{}
+
+func (suitesFormat) name() string { return "gdUnit4 4.x" }
+
+func (suitesFormat) sniff(root xml.StartElement) bool {
+	return root.Name.Local == "testsuites" && formatVersion(root) < 5
+}
+
+func (suitesFormat) decode(dec *xml.Decoder, root xml.StartElement) (*JUnitTestSuites, error) {
+	var suites JUnitTestSuites
+	if err := dec.DecodeElement(&suites, &root); err != nil {
+		return nil, err
+	}
+	return &suites, nil
+}
+
+// recounts reports whether root lacks the totals, as hand-written and
+// converted reports sometimes do.
+func (suitesFormat) recounts(root xml.StartElement) bool {
+	return !slices.ContainsFunc(root.Attr, func(a xml.Attr) bool { return a.Name.Local == "tests" })
+}
+
+// suiteFormat is the layout expected from gdUnit4 5.x: a report per suite
+// with a <testsuite> root, or a <testsuites> root marked version 5 or later.
+// The root's totals are recomputed from the suites, as the format may leave
+// them out.
+type suiteFormat struct{}
+
+func (suiteFormat) name() string { return "gdUnit4 5.x" }
+
+func (suiteFormat) sniff(root xml.StartElement) bool {
+	return root.Name.Local == "testsuite" || root.Name.Local == "testsuites" && formatVersion(root) >= 5
+}
+
+func (suiteFormat) decode(dec *xml.Decoder, root xml.StartElement) (*JUnitTestSuites, error) {
+	var suites JUnitTestSuites
+	if root.Name.Local == "testsuite" {
+		var suite JUnitTestSuite
+		if err := dec.DecodeElement(&suite, &root); err != nil {
+			return nil, err
+		}
+		suites.Suites = []JUnitTestSuite{suite}
+	} else {
+		// Decode the suites only: the root's attributes are recomputed.
+		root.Attr = nil
+		if err := dec.DecodeElement(&suites, &root); err != nil {
+			return nil, err
+		}
+	}
+	suites.XMLName = xml.Name{Local: "testsuites"}
+	return &suites, nil
+}
+
+func (suiteFormat) recounts(xml.StartElement) bool { return true }
+
+// caseTotals counts the test cases of a suite.
+type caseTotals struct {
+	tests, failures, errors int
+}
+
+func (c *caseTotals) add(tc *JUnitTestCase) {
+	c.tests++
+	if tc.Failure != nil {
+		c.failures++
+	}
+	if tc.Error != nil {
+		c.errors++
+	}
+}
+
+// addTotals adds the totals of s to suites: those s carries, or those counted
+// from its test cases if it carries none.
+func addTotals(suites *JUnitTestSuites, s *JUnitTestSuite, cases caseTotals) {
+	if s.Tests == 0 {
+		s.Tests, s.Failures, s.Errors = cases.tests, cases.failures, cases.errors
+	}
+	suites.Tests += s.Tests
+	suites.Failures += s.Failures
+	suites.Errors += s.Errors
+	suites.Time += s.Time
+}
+
+// recount sets the totals of suites from its suites.
+func recount(suites *JUnitTestSuites) {
+	suites.Tests, suites.Failures, suites.Errors, suites.Time = 0, 0, 0, 0
+	for i := range suites.Suites {
+		s := &suites.Suites[i]
+		var cases caseTotals
+		for j := range s.TestCases {
+			cases.add(&s.TestCases[j])
+		}
+		addTotals(suites, s, cases)
+	}
+}
+
+// formatVersion returns the major version of the root's version attribute,
+// or 0 if it has none.
+func formatVersion(root xml.StartElement) int {
+	for _, a := range root.Attr {
+		if a.Name.Local == "version" {
+			major, _, _ := strings.Cut(a.Value, ".")
+			return atoi(major)
+		}
+	}
+	return 0
+}
+
+// sniffXML reads the JUnit XML document of dec up to its root element and
+// returns that element with the format of the document.
+func sniffXML(dec *xml.Decoder) (xml.StartElement, xmlFormat, error) {
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return xml.StartElement{}, nil, errors.New("no root element")
+		}
+		if err != nil {
+			return xml.StartElement{}, nil, err
+		}
+		root, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, f := range xmlFormats {
+			if f.sniff(root) {
+				return root, f, nil
+			}
+		}
+		return xml.StartElement{}, nil, fmt.Errorf("unsupported report format: root element <%s>", root.Name.Local)
+	}
+}
+
+// decodeXML sniffs the format of the JUnit XML document in r from its root
+// element and decodes it.
+func decodeXML(r io.Reader) (*JUnitTestSuites, error) {
+	dec := xml.NewDecoder(r)
+	root, f, err := sniffXML(dec)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := f.decode(dec, root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.name(), err)
+	}
+	if f.recounts(root) {
+		recount(suites)
+	}
+	return suites, nil
+}
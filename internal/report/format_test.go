@@ -0,0 +1,51 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeXML_Formats(t *testing.T) {
+	tests := []struct {
+		name   string
+		xml    string
+		tests  int
+		failed int
+		suites int
+	}{
+		{
+			"4.x testsuites",
+			`<?xml version="1.0"?><testsuites tests="2" failures="1"><testsuite name="A" tests="2" failures="1"/></testsuites>`,
+			2, 1, 1,
+		},
+		{
+			"5.x testsuite root",
+			`<testsuite name="A" tests="3" failures="2"><testcase name="test_a" classname="A"/></testsuite>`,
+			3, 2, 1,
+		},
+		{
+			"5.x versioned testsuites without totals",
+			`<testsuites version="5.0"><testsuite name="A" tests="1"/><testsuite name="B" tests="2" failures="1"/></testsuites>`,
+			3, 1, 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suites, err := decodeXML(strings.NewReader(tt.xml))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if suites.Tests != tt.tests || suites.Failures != tt.failed || len(suites.Suites) != tt.suites {
+				t.Errorf("suites = %+v, want %d tests, %d failures, %d suites", suites, tt.tests, tt.failed, tt.suites)
+			}
+		})
+	}
+}
+
+func TestDecodeXML_Unsupported(t *testing.T) {
+	for _, doc := range []string{`<results/>`, ``, `<testsuites><testsuite`} {
+		if _, err := decodeXML(strings.NewReader(doc)); err == nil {
+			t.Errorf("decodeXML(%q): expected error, got nil", doc)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// gpuRe matches the line Godot prints at startup naming the rendering API and
+// device, e.g. "Vulkan 1.3.277 - Forward+ - Using Device #0: NVIDIA - NVIDIA
+// GeForce RTX 4070" or "OpenGL API 3.3.0 NVIDIA 550.54.14 - Compatibility -
+// Using Device: NVIDIA Corporation - NVIDIA GeForce RTX 4070".
+var gpuRe = regexp.MustCompile(`(?i)^\s*(vulkan|opengl|d3d12|metal)\b.*\busing device\b`)
+
+// errStartupDone stops ScanGPU at the first suite.
+var errStartupDone = errors.New("startup done")
+
+// ScanGPU returns the renderer and device lines Godot printed at startup, before
+// the first suite ran; none with --headless, which renders nothing.
+func ScanGPU(logPath string) ([]string, error) {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	var lines []string
+	err = StreamLog(f, 0, func(line string) error {
+		if _, ok := SuiteStart(line); ok {
+			return errStartupDone
+		}
+		if gpuRe.MatchString(line) {
+			lines = append(lines, line)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStartupDone) {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return lines, nil
+}
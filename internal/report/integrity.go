@@ -0,0 +1,163 @@
+package report
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Integrity lets a later job check that a result was not changed after the
+// run: the SHA-256 digest of the result's canonical JSON and, when a key was
+// given, an Ed25519 signature of it.
+//
+// The canonical JSON is the result without "integrity", encoded with object
+// keys sorted, no insignificant whitespace, no escaping of <, > and &, and
+// numbers as they were written; see CanonicalJSON.
+type Integrity struct {
+	Algorithm string `json:"algorithm"`            // "sha256"
+	Digest    string `json:"digest"`               // hex digest of the canonical JSON
+	Signature string `json:"signature,omitempty"`  // base64 Ed25519 signature of the canonical JSON
+	PublicKey string `json:"public_key,omitempty"` // base64 public key of the signature, to identify the key
+}
+
+// Sign sets the Integrity of out, signing it with key unless key is nil.
+func Sign(out *Output, key ed25519.PrivateKey) error {
+	out.Integrity = nil
+	data, err := json.Marshal(out)
+	if err != nil {
+		return fmt.Errorf("failed to sign output: %w", err)
+	}
+	canon, err := CanonicalJSON(data)
+	if err != nil {
+		return fmt.Errorf("failed to sign output: %w", err)
+	}
+	sum := sha256.Sum256(canon)
+	in := &Integrity{Algorithm: "sha256", Digest: hex.EncodeToString(sum[:])}
+	if key != nil {
+		in.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, canon))
+		in.PublicKey = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	}
+	out.Integrity = in
+	return nil
+}
+
+// Verify checks the integrity of the JSON result data and returns it. With a
+// public key, the result must also carry a valid signature made with it.
+func Verify(data []byte, key ed25519.PublicKey) (*Integrity, error) {
+	var doc struct {
+		Integrity *Integrity `json:"integrity"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("not a JSON result: %w", err)
+	}
+	in := doc.Integrity
+	if in == nil {
+		return nil, errors.New("result has no integrity digest; was it written with --sign-output?")
+	}
+	if in.Algorithm != "sha256" {
+		return in, fmt.Errorf("unsupported digest algorithm %q", in.Algorithm)
+	}
+	canon, err := CanonicalJSON(data)
+	if err != nil {
+		return in, err
+	}
+	sum := sha256.Sum256(canon)
+	if hex.EncodeToString(sum[:]) != in.Digest {
+		return in, errors.New("digest mismatch: the result was changed after it was signed")
+	}
+	if key == nil {
+		return in, nil
+	}
+	if in.Signature == "" {
+		return in, errors.New("result is not signed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(in.Signature)
+	if err != nil || !ed25519.Verify(key, canon, sig) {
+		return in, errors.New("signature is not valid for this key")
+	}
+	return in, nil
+}
+
+// CanonicalJSON returns the canonical form of the JSON result data, which
+// Integrity digests and signs: without its "integrity", object keys sorted,
+// no insignificant whitespace and no HTML escaping.
+func CanonicalJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("not a JSON result: %w", err)
+	}
+	delete(doc, "integrity")
+
+	// Maps encode with sorted keys, and json.Number as written.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// LoadSigningKey reads an Ed25519 private key from a PEM file holding a PKCS #8
+// "PRIVATE KEY", as written by openssl genpkey -algorithm ed25519.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	ed, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 key", path)
+	}
+	return ed, nil
+}
+
+// LoadPublicKey reads an Ed25519 public key from a PEM file holding a PKIX
+// "PUBLIC KEY", as written by openssl pkey -pubout.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	ed, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 key", path)
+	}
+	return ed, nil
+}
+
+// readPEM returns the bytes of the first PEM block of type typ in the file at path.
+func readPEM(path, typ string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s: no PEM %q block", path, typ)
+		}
+		if block.Type == typ {
+			return block.Bytes, nil
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package report
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeKeys writes a new Ed25519 key pair as PEM files into dir and returns
+// their paths.
+func writeKeys(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privPath, pubPath := filepath.Join(dir, "key.pem"), filepath.Join(dir, "key.pub.pem")
+	if err := os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return privPath, pubPath
+}
+
+func TestSignVerify(t *testing.T) {
+	dir := t.TempDir()
+	privPath, pubPath := writeKeys(t, dir)
+	priv, err := LoadSigningKey(privPath)
+	if err != nil {
+		t.Fatalf("LoadSigningKey: %v", err)
+	}
+	pub, err := LoadPublicKey(pubPath)
+	if err != nil {
+		t.Fatalf("LoadPublicKey: %v", err)
+	}
+	_, otherPub := writeKeys(t, t.TempDir())
+	other, err := LoadPublicKey(otherPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &Output{
+		Summary:  Summary{Total: 2, Passed: 1, Failed: 1, Status: "failed"},
+		Failures: []Failure{{Kind: KindFailure, Class: "A", Method: "test_a", File: "res://a.gd", Line: 3, Message: "a < b && c"}},
+	}
+	if err := Sign(out, priv); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, out); err != nil {
+		t.Fatal(err)
+	}
+	signed := buf.Bytes()
+
+	// Reformatting the document keeps it valid; changing a result does not.
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, signed); err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(signed), `"failed": 1`, `"failed": 0`, 1)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		key     ed25519.PublicKey
+		wantErr string
+	}{
+		{name: "digest", data: signed},
+		{name: "signature", data: signed, key: pub},
+		{name: "reformatted", data: compact.Bytes(), key: pub},
+		{name: "tampered", data: []byte(tampered), wantErr: "digest mismatch"},
+		{name: "other key", data: signed, key: other, wantErr: "signature is not valid"},
+		{name: "unsigned", data: []byte(`{"summary": {}}`), wantErr: "no integrity digest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Verify(tt.data, tt.key)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Verify error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A digest-only result cannot pass for a signed one.
+	if err := Sign(out, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(out)
+	if _, err := Verify(data, nil); err != nil {
+		t.Errorf("Verify unsigned digest: %v", err)
+	}
+	if _, err := Verify(data, pub); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("Verify with key = %v, want a not signed error", err)
+	}
+}
+
+func TestLoadSigningKey_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	_, pubPath := writeKeys(t, dir)
+	if _, err := LoadSigningKey(pubPath); err == nil {
+		t.Error("LoadSigningKey accepted a public key")
+	}
+	if _, err := LoadSigningKey(filepath.Join(dir, "missing.pem")); err == nil {
+		t.Error("LoadSigningKey accepted a missing file")
+	}
+}
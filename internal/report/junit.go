@@ -0,0 +1,62 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteJUnitXML writes suites as a JUnit XML document to w.
+// If suites is nil (Godot produced no report), a single errored test case describing
+// the crash is written instead so that consumers still see a failing result.
+func WriteJUnitXML(w io.Writer, suites *JUnitTestSuites, crash *CrashDetails) error {
+	if suites == nil {
+		suites = crashSuites(crash)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write JUnit XML: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return fmt.Errorf("failed to write JUnit XML: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write JUnit XML: %w", err)
+	}
+	return nil
+}
+
+// crashSuites builds a one-test report describing a run that produced no results.
+func crashSuites(crash *CrashDetails) *JUnitTestSuites {
+	message := "Godot produced no test report"
+	var details []string
+	if crash != nil {
+		message = "Godot crashed"
+		for _, s := range []string{crash.CrashInfo, crash.ScriptErrors} {
+			if s != "" {
+				details = append(details, s)
+			}
+		}
+	}
+
+	return &JUnitTestSuites{
+		Tests:  1,
+		Errors: 1,
+		Suites: []JUnitTestSuite{{
+			Name:   "gdunit4-test-runner",
+			Tests:  1,
+			Errors: 1,
+			TestCases: []JUnitTestCase{{
+				Name:      "godot",
+				Classname: "gdunit4-test-runner",
+				Error: &JUnitFailure{
+					Message: message,
+					Text:    strings.Join(details, "\n"),
+				},
+			}},
+		}},
+	}
+}
@@ -0,0 +1,51 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteJUnitXML_RoundTrip(t *testing.T) {
+	suites, err := ParseXML(filepath.Join("..", "..", "testdata", "sample_results.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnitXML(&buf, suites, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.xml")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseXML(path)
+	if err != nil {
+		t.Fatalf("written XML does not parse: %v", err)
+	}
+	if parsed.Tests != suites.Tests || parsed.Failures != suites.Failures {
+		t.Errorf("parsed tests/failures = %d/%d, want %d/%d", parsed.Tests, parsed.Failures, suites.Tests, suites.Failures)
+	}
+	if len(ExtractFailures(parsed)) != len(ExtractFailures(suites)) {
+		t.Error("failures were lost in the round trip")
+	}
+}
+
+func TestWriteJUnitXML_Crash(t *testing.T) {
+	var buf bytes.Buffer
+	crash := &CrashDetails{CrashInfo: "handle_crash: signal 11"}
+	if err := WriteJUnitXML(&buf, nil, crash); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `<error message="Godot crashed">`) {
+		t.Errorf("expected an error element for the crash, got:\n%s", out)
+	}
+	if !strings.Contains(out, "handle_crash: signal 11") {
+		t.Errorf("expected crash details in the output, got:\n%s", out)
+	}
+}
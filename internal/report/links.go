@@ -0,0 +1,62 @@
+package report
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Editor link templates (--editor-links) by editor name. In a template,
+// {path} is the absolute, slash-separated file path starting with "/",
+// percent-encoded; {res} is its res:// path and {line} the line number.
+var editorLinks = map[string]string{
+	"vscode":          "vscode://file{path}:{line}",
+	"vscode-insiders": "vscode-insiders://file{path}:{line}",
+	"cursor":          "cursor://file{path}:{line}",
+	"zed":             "zed://file{path}:{line}",
+	"file":            "file://{path}",
+}
+
+// EditorNames returns the editor names EditorLinkTemplate knows, sorted.
+func EditorNames() []string {
+	names := make([]string, 0, len(editorLinks))
+	for name := range editorLinks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// EditorLinkTemplate returns the link template of spec: the template of a
+// known editor name, or spec itself if it is a template using {path} or {res}.
+func EditorLinkTemplate(spec string) (string, error) {
+	if t, ok := editorLinks[spec]; ok {
+		return t, nil
+	}
+	if strings.Contains(spec, "{path}") || strings.Contains(spec, "{res}") {
+		return spec, nil
+	}
+	return "", fmt.Errorf("unknown editor %q: want one of %s or a template using {path} or {res}", spec, strings.Join(EditorNames(), ", "))
+}
+
+// EditorLink returns the link opening the file of f at its line in the editor
+// of the link template tmpl, or "" if f has no res:// file. projectDir is the
+// project directory, or the directory holding all projects.
+func EditorLink(tmpl, projectDir string, f Failure) string {
+	rel := f.RelPath()
+	if rel == "" {
+		return ""
+	}
+	abs := filepath.ToSlash(filepath.Join(projectDir, filepath.FromSlash(rel)))
+	p := (&url.URL{Path: "/" + strings.TrimPrefix(abs, "/")}).EscapedPath()
+	return strings.NewReplacer("{path}", p, "{res}", f.File, "{line}", strconv.Itoa(f.Line)).Replace(tmpl)
+}
+
+// hyperlink marks text as a link to uri with the OSC 8 escape sequence, which
+// terminals supporting hyperlinks make clickable and others ignore.
+func hyperlink(uri, text string) string {
+	return "\x1b]8;;" + uri + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/i18n"
+)
+
+func TestEditorLinkTemplate(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"vscode", "vscode://file{path}:{line}", false},
+		{"zed", "zed://file{path}:{line}", false},
+		{"godot://open?file={res}&line={line}", "godot://open?file={res}&line={line}", false},
+		{"idea://open?file={path}&line={line}", "idea://open?file={path}&line={line}", false},
+		{"emacs", "", true},
+		{"editor://{line}", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := EditorLinkTemplate(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EditorLinkTemplate(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EditorLinkTemplate(%q) = %q, want %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditorLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses Unix paths")
+	}
+	f := Failure{File: "res://tests/test math.gd", Line: 7}
+	tests := []struct {
+		tmpl string
+		f    Failure
+		want string
+	}{
+		{"vscode://file{path}:{line}", f, "vscode://file/work/game/tests/test%20math.gd:7"},
+		{"file://{path}", f, "file:///work/game/tests/test%20math.gd"},
+		{"godot://{res}:{line}", f, "godot://res://tests/test math.gd:7"},
+		{"zed://file{path}:{line}", Failure{File: "res://a.gd", Line: 1, Project: "client"}, "zed://file/work/game/client/a.gd:1"},
+		{"vscode://file{path}:{line}", Failure{File: "", Line: 0}, ""},
+	}
+	for _, tt := range tests {
+		if got := EditorLink(tt.tmpl, filepath.FromSlash("/work/game"), tt.f); got != tt.want {
+			t.Errorf("EditorLink(%q, %+v) = %q, want %q", tt.tmpl, tt.f, got, tt.want)
+		}
+	}
+}
+
+func TestWriteTerminalText(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses Unix paths")
+	}
+	out := &Output{
+		Summary:  Summary{Total: 2, Passed: 1, Failed: 1, Status: "failed"},
+		Failures: []Failure{{Class: "TestMath", Method: "test_sub", File: "res://tests/test_math.gd", Line: 7, Message: "\x1b[31mboom\x1b[0m"}},
+	}
+
+	var sb strings.Builder
+	if err := WriteTerminalText(&sb, out, i18n.English, "vscode://file{path}:{line}", "/work/game"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "gdUnit4: failed (1 passed, 1 failed of 2)\n" +
+		"\nFAILED TestMath.test_sub (\x1b]8;;vscode://file/work/game/tests/test_math.gd:7\x1b\\res://tests/test_math.gd:7\x1b]8;;\x1b\\)\n  boom\n"
+	if sb.String() != want {
+		t.Errorf("WriteTerminalText = %q, want %q", sb.String(), want)
+	}
+
+	// Without a template, it is the plain text.
+	var plain, text strings.Builder
+	WriteTerminalText(&plain, out, i18n.English, "", "/work/game")
+	WriteText(&text, out, i18n.English)
+	if plain.String() != text.String() {
+		t.Errorf("WriteTerminalText without links = %q, want %q", plain.String(), text.String())
+	}
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ansiRe matches ANSI escape sequences: CSI (colors, cursor movement), OSC
+// (window titles, hyperlinks) and the short charset and keypad selections.
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[()][0-9A-Za-z]|\x1b[=>]`)
+
+// stripANSI removes ANSI escape sequences from s.
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	return ansiRe.ReplaceAllString(s, "")
+}
+
+// windows1252 maps the bytes 0x80-0x9F of code page 1252 to Unicode; the other
+// bytes above 0x7F are the same as in Latin-1. Zero entries are undefined bytes.
+var windows1252 = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// normalizeLine turns a raw Godot log line into clean UTF-8 for pattern matching:
+//
+//   - a byte order mark, left at the start of a line by a tool writing the log, is dropped
+//   - NUL bytes are dropped, which recovers ASCII text written as UTF-16 by Windows
+//     console tools
+//   - a line that is not valid UTF-8 comes from a Windows ANSI code page and is
+//     decoded as code page 1252
+//   - ANSI escape sequences and a trailing carriage return are removed
+func normalizeLine(s string) string {
+	s = strings.TrimPrefix(s, "\ufeff")
+	if strings.IndexByte(s, 0) >= 0 {
+		s = strings.ReplaceAll(s, "\x00", "")
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "\xff\xfe"), "\xfe\xff")
+	}
+	if !utf8.ValidString(s) {
+		s = decodeWindows1252(s)
+	}
+	return strings.TrimSuffix(stripANSI(s), "\r")
+}
+
+// decodeWindows1252 decodes s as code page 1252, keeping valid UTF-8 sequences
+// so that a line mixing both comes out readable.
+func decodeWindows1252(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + len(s)/4)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r != utf8.RuneError || size > 1 {
+			b.WriteString(s[i : i+size])
+			i += size
+			continue
+		}
+		c := s[i]
+		switch {
+		case c >= 0x80 && c < 0xA0 && windows1252[c-0x80] != 0:
+			b.WriteRune(windows1252[c-0x80])
+		case c >= 0x80 && c < 0xA0:
+			b.WriteRune(utf8.RuneError)
+		default:
+			b.WriteRune(rune(c))
+		}
+		i++
+	}
+	return b.String()
+}
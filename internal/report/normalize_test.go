@@ -0,0 +1,58 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLine(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "SCRIPT ERROR: x", "SCRIPT ERROR: x"},
+		{"color", "\x1b[1;31mSCRIPT ERROR:\x1b[0m x", "SCRIPT ERROR: x"},
+		{"erase line", "\x1b[2K\x1b[GSCRIPT ERROR: x", "SCRIPT ERROR: x"},
+		{"osc title", "\x1b]0;Godot\x07handle_crash: boom", "handle_crash: boom"},
+		{"utf-8 bom", "\ufeffSCRIPT ERROR: x", "SCRIPT ERROR: x"},
+		{"utf-16le", "\xff\xfeS\x00C\x00R\x00I\x00P\x00T\x00", "SCRIPT"},
+		{"code page 1252", "caf\xe9 \x80 \x93quoted\x94", "café € “quoted”"},
+		{"valid utf-8 kept", "日本語 é", "日本語 é"},
+		{"carriage return", "line\r", "line"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeLine(tt.in); got != tt.want {
+				t.Errorf("normalizeLine(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectCrash_EscapePrefixedLines(t *testing.T) {
+	log := "\ufeffGodot Engine v4.3\n" +
+		"\x1b[1;31mSCRIPT ERROR: Invalid call. Nonexistent function 'foo'.\x1b[0m\n" +
+		"\x1b[0;90m   at: res://tests/test_a.gd:12 (TestA::test_x)\x1b[0m\n" +
+		"\x1b[31mhandle_crash: Program crashed with signal 11\x1b[0m\r\n"
+	path := filepath.Join(t.TempDir(), "godot.log")
+	if err := os.WriteFile(path, []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	crash, err := DetectCrash(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if crash == nil {
+		t.Fatal("expected crash details, got nil")
+	}
+	if crash.CrashInfo != "handle_crash: Program crashed with signal 11" {
+		t.Errorf("CrashInfo = %q", crash.CrashInfo)
+	}
+	if !strings.HasPrefix(crash.ScriptErrors, "SCRIPT ERROR: Invalid call") || !strings.Contains(crash.ScriptErrors, "at: res://tests/test_a.gd:12") {
+		t.Errorf("ScriptErrors = %q", crash.ScriptErrors)
+	}
+}
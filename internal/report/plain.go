@@ -0,0 +1,72 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// WritePlain writes out as single-line records for problem matchers and other
+// regex-based log scanners. The format is stable:
+//
+//	gdunit4: failure: <file>:<line>: <class>.<method>[<case>]: <message>
+//	gdunit4: error: <file>:<line>: <class>.<method>: <message>
+//	gdunit4: script-error: <file>:<line>: <message>
+//	gdunit4: crash: <message>
+//	gdunit4: summary: status=<status> total=<n> passed=<n> failed=<n> errors=<n> skipped=<n>
+//
+// "[<case>]" is the index of the failed case of a parameterized test and is
+// left out for other tests. "<file>:<line>: " is left out of a record without
+// a res:// location, and <line> is 0 if it is not known. pathPrefix is
+// prepended to the project-relative files. Every record is one line of plain
+// text: newlines and runs of white space collapse to a space, and ANSI escape
+// codes are removed.
+func WritePlain(w io.Writer, out *Output, pathPrefix string) error {
+	var sb strings.Builder
+	record := func(kind, rel string, line int, msg string) {
+		loc := ""
+		if rel != "" {
+			loc = fmt.Sprintf("%s:%d: ", path.Join(pathPrefix, rel), line)
+		}
+		fmt.Fprintf(&sb, "gdunit4: %s: %s%s\n", kind, loc, oneLine(stripANSI(msg)))
+	}
+	for _, f := range out.Failures {
+		kind := "failure"
+		if f.Kind == KindError {
+			kind = "error"
+		}
+		if len(f.Parameters) > 0 {
+			// One record per failed case, like WriteCTest, its index in
+			// brackets so that it does not read as a line number.
+			for _, p := range f.Parameters {
+				if p.Status == "failed" {
+					record(kind, f.RelPath(), p.Line, fmt.Sprintf("%s.%s[%d]: %s", f.Class, f.Method, p.Index, p.Message))
+				}
+			}
+			continue
+		}
+		msg := f.Message
+		if f.Expected != "" || f.Actual != "" {
+			msg = fmt.Sprintf("expected '%s' but was '%s'", f.Expected, f.Actual)
+		}
+		record(kind, f.RelPath(), f.Line, f.Class+"."+f.Method+": "+msg)
+	}
+	if c := out.CrashDetails; c != nil {
+		for _, e := range ParseScriptErrors(c.ScriptErrors) {
+			record("script-error", strings.TrimPrefix(e.File, "res://"), e.Line, e.Message)
+		}
+		for _, line := range strings.Split(c.CrashInfo, "\n") {
+			if strings.TrimSpace(line) != "" {
+				record("crash", "", 0, line)
+			}
+		}
+	}
+	s := out.Summary
+	fmt.Fprintf(&sb, "gdunit4: summary: status=%s total=%d passed=%d failed=%d errors=%d skipped=%d\n", s.Status, s.Total, s.Passed, s.Failed, s.Errors, s.Skipped)
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write plain output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWritePlain(t *testing.T) {
+	out := &Output{
+		Summary: Summary{Total: 4, Passed: 1, Failed: 2, Errors: 1, Status: "failed"},
+		Failures: []Failure{
+			{Kind: KindFailure, Class: "TestMath", Method: "test_sub", File: "res://tests/test_math.gd", Line: 7, Expected: "1", Actual: "2"},
+			{Kind: KindError, Class: "TestMath", Method: "test_div", File: "res://tests/test_math.gd", Message: "\x1b[31mdivision\x1b[0m\nby zero"},
+			{Kind: KindFailure, Class: "P", Method: "test_p", File: "res://tests/p.gd", Line: 3, Parameters: []ParameterResult{
+				{Index: 0, Status: "passed"},
+				{Index: 1, Status: "failed", Line: 4, Message: "boom"},
+			}},
+		},
+		CrashDetails: &CrashDetails{
+			CrashInfo:    "handle_crash: signal 11",
+			ScriptErrors: "SCRIPT ERROR: Parse Error: x\n   at: GDScript::reload (res://tests/broken.gd:9)\nSCRIPT ERROR: nowhere",
+		},
+	}
+
+	var sb strings.Builder
+	if err := WritePlain(&sb, out, "game"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "gdunit4: failure: game/tests/test_math.gd:7: TestMath.test_sub: expected '1' but was '2'\n" +
+		"gdunit4: error: game/tests/test_math.gd:0: TestMath.test_div: division by zero\n" +
+		"gdunit4: failure: game/tests/p.gd:4: P.test_p[1]: boom\n" +
+		"gdunit4: script-error: game/tests/broken.gd:9: Parse Error: x\n" +
+		"gdunit4: script-error: nowhere\n" +
+		"gdunit4: crash: handle_crash: signal 11\n" +
+		"gdunit4: summary: status=failed total=4 passed=1 failed=2 errors=1 skipped=0\n"
+	if sb.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
+
+// TestWritePlain_ProblemMatcher checks the records against the GitHub Actions
+// problem matcher shipped in contrib/github.
+func TestWritePlain_ProblemMatcher(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "contrib", "github", "gdunit4-problem-matcher.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var matcher struct {
+		ProblemMatcher []struct {
+			Pattern []struct {
+				Regexp string `json:"regexp"`
+			} `json:"pattern"`
+		} `json:"problemMatcher"`
+	}
+	if err := json.Unmarshal(data, &matcher); err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(matcher.ProblemMatcher[0].Pattern[0].Regexp)
+
+	tests := []struct {
+		line, file, lineNo, message string
+	}{
+		{"gdunit4: failure: game/tests/a b.gd:7: A.test_x: expected '1:2: ' but was '2'", "game/tests/a b.gd", "7", "A.test_x: expected '1:2: ' but was '2'"},
+		{"gdunit4: error: A.test_x: boom", "", "", "A.test_x: boom"},
+		{"gdunit4: script-error: tests/b.gd:9: Parse Error: x", "tests/b.gd", "9", "Parse Error: x"},
+		{"gdunit4: crash: handle_crash: signal 11", "", "", "handle_crash: signal 11"},
+	}
+	for _, tt := range tests {
+		m := re.FindStringSubmatch(tt.line)
+		if m == nil {
+			t.Errorf("%q does not match", tt.line)
+			continue
+		}
+		if m[2] != tt.file || m[3] != tt.lineNo || m[4] != tt.message {
+			t.Errorf("%q: file, line, message = %q, %q, %q, want %q, %q, %q", tt.line, m[2], m[3], m[4], tt.file, tt.lineNo, tt.message)
+		}
+	}
+	if line := "gdunit4: summary: status=passed total=1"; re.MatchString(line) {
+		t.Errorf("%q should not match", line)
+	}
+}
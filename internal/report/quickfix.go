@@ -0,0 +1,56 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// WriteQuickfix writes the failures and script errors of out as lines in the
+// "file:line:col: message" form Vim's and Neovim's default errorformat reads
+// into the quickfix list:
+//
+//	tests/test_math.gd:12:1: TestMath.test_add: expected '3' but was '4'
+//
+// pathPrefix is prepended to the project-relative file of each line. Failures
+// and errors without a res:// location are left out, as a quickfix entry
+// needs a file; a line that is not known is given as 1.
+func WriteQuickfix(w io.Writer, out *Output, pathPrefix string) error {
+	var sb strings.Builder
+	entry := func(rel string, line int, msg string) {
+		fmt.Fprintf(&sb, "%s:%d:1: %s\n", path.Join(pathPrefix, rel), max(line, 1), oneLine(msg))
+	}
+	for _, f := range out.Failures {
+		rel := f.RelPath()
+		if rel == "" {
+			continue
+		}
+		if len(f.Parameters) > 0 {
+			// One entry per failed case, like WriteCTest.
+			for _, p := range f.Parameters {
+				if p.Status == "failed" {
+					entry(rel, p.Line, fmt.Sprintf("%s.%s:%d: %s", f.Class, f.Method, p.Index, p.Message))
+				}
+			}
+			continue
+		}
+		msg := f.Message
+		if f.Expected != "" || f.Actual != "" {
+			msg = fmt.Sprintf("expected '%s' but was '%s'", f.Expected, f.Actual)
+		}
+		entry(rel, f.Line, f.Class+"."+f.Method+": "+msg)
+	}
+	if out.CrashDetails != nil {
+		for _, e := range ParseScriptErrors(out.CrashDetails.ScriptErrors) {
+			if e.File != "" {
+				entry(strings.TrimPrefix(e.File, "res://"), e.Line, "SCRIPT ERROR: "+e.Message)
+			}
+		}
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write quickfix output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteQuickfix(t *testing.T) {
+	out := &Output{
+		Summary: Summary{Total: 4, Passed: 1, Failed: 3, Status: "failed"},
+		Failures: []Failure{
+			{Class: "TestMath", Method: "test_sub", File: "res://tests/test_math.gd", Line: 7, Expected: "1", Actual: "2"},
+			{Class: "TestMath", Method: "test_div", File: "res://tests/test_math.gd", Message: "division\nby zero"},
+			{Class: "A", Method: "test_x", Message: "no location"},
+			{Class: "P", Method: "test_p", File: "res://tests/p.gd", Line: 3, Parameters: []ParameterResult{
+				{Index: 0, Status: "passed"},
+				{Index: 1, Status: "failed", Line: 4, Message: "boom"},
+			}},
+		},
+		CrashDetails: &CrashDetails{ScriptErrors: "SCRIPT ERROR: Parse Error: x\n   at: GDScript::reload (res://tests/broken.gd:9)\nSCRIPT ERROR: nowhere"},
+	}
+
+	var sb strings.Builder
+	if err := WriteQuickfix(&sb, out, "game"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "game/tests/test_math.gd:7:1: TestMath.test_sub: expected '1' but was '2'\n" +
+		"game/tests/test_math.gd:1:1: TestMath.test_div: division by zero\n" +
+		"game/tests/p.gd:4:1: P.test_p:1: boom\n" +
+		"game/tests/broken.gd:9:1: SCRIPT ERROR: Parse Error: x\n"
+	if sb.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
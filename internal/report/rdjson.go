@@ -0,0 +1,118 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// rdResult is a reviewdog Diagnostic Format (rdjson) result.
+// See https://github.com/reviewdog/reviewdog/tree/master/proto/rdf.
+type rdResult struct {
+	Source      rdSource       `json:"source"`
+	Severity    string         `json:"severity"`
+	Diagnostics []rdDiagnostic `json:"diagnostics"`
+}
+
+type rdSource struct {
+	Name string `json:"name"`
+}
+
+type rdDiagnostic struct {
+	Message  string     `json:"message"`
+	Location rdLocation `json:"location"`
+	Severity string     `json:"severity"`
+	Source   *rdSource  `json:"source,omitempty"`
+	Code     rdCode     `json:"code"`
+}
+
+type rdLocation struct {
+	Path  string   `json:"path"`
+	Range *rdRange `json:"range,omitempty"`
+}
+
+type rdRange struct {
+	Start rdPosition `json:"start"`
+}
+
+type rdPosition struct {
+	Line int `json:"line"`
+}
+
+type rdCode struct {
+	Value string `json:"value"`
+}
+
+// Diagnostic codes of the reviewdog output.
+const (
+	rdCodeFailure     = "test-failure"
+	rdCodeError       = "test-error"
+	rdCodeScriptError = "script-error"
+	rdCodeParseError  = "parse-error"
+)
+
+// WriteRDJSON writes the failures and script errors of out in reviewdog's
+// diagnostic format, as one rdjson document or, with lines set, as rdjsonl
+// (a diagnostic per line). pathPrefix is prepended to the project-relative
+// file of each diagnostic; failures and errors without a res:// location are
+// left out, as a review comment needs a file.
+func WriteRDJSON(w io.Writer, out *Output, pathPrefix string, lines bool) error {
+	diags := []rdDiagnostic{}
+	at := func(rel string, line int) rdLocation {
+		loc := rdLocation{Path: path.Join(pathPrefix, rel)}
+		if line > 0 {
+			loc.Range = &rdRange{Start: rdPosition{Line: line}}
+		}
+		return loc
+	}
+	for _, f := range out.Failures {
+		if f.RelPath() == "" {
+			continue
+		}
+		code := rdCodeFailure
+		if f.Kind == KindError {
+			code = rdCodeError
+		}
+		msg := strings.TrimSpace(f.Message)
+		if f.Expected != "" || f.Actual != "" {
+			msg = fmt.Sprintf("expected '%s' but was '%s'", f.Expected, f.Actual)
+		}
+		diags = append(diags, rdDiagnostic{
+			Message:  f.Class + "." + f.Method + ": " + msg,
+			Location: at(f.RelPath(), f.Line),
+			Severity: "ERROR",
+			Code:     rdCode{Value: code},
+		})
+	}
+	if out.CrashDetails != nil {
+		for _, e := range ParseScriptErrors(out.CrashDetails.ScriptErrors) {
+			if e.File == "" {
+				continue
+			}
+			code := rdCodeScriptError
+			if strings.HasPrefix(e.Message, "Parse Error") {
+				code = rdCodeParseError
+			}
+			diags = append(diags, rdDiagnostic{Message: e.Message, Location: at(strings.TrimPrefix(e.File, "res://"), e.Line), Severity: "ERROR", Code: rdCode{Value: code}})
+		}
+	}
+
+	if lines {
+		enc := json.NewEncoder(w)
+		for _, d := range diags {
+			d.Source = &rdSource{Name: "gdunit4"}
+			if err := enc.Encode(d); err != nil {
+				return fmt.Errorf("failed to write rdjsonl: %w", err)
+			}
+		}
+		return nil
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rdResult{Source: rdSource{Name: "gdunit4"}, Severity: "ERROR", Diagnostics: diags}); err != nil {
+		return fmt.Errorf("failed to write rdjson: %w", err)
+	}
+	return nil
+}
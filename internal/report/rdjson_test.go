@@ -0,0 +1,67 @@
+package report
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func rdjsonSample() *Output {
+	return &Output{
+		Failures: []Failure{
+			{Kind: KindFailure, Class: "MathTest", Method: "test_sub", File: "res://tests/MathTest.gd", Line: 7, Expected: "1", Actual: "2"},
+			{Kind: KindError, Class: "NetTest", Method: "test_io", File: "res://tests/NetTest.gd", Message: "timeout"},
+			{Kind: KindFailure, Class: "Orphan", Method: "test_x", Message: "no location"},
+		},
+		CrashDetails: &CrashDetails{ScriptErrors: "SCRIPT ERROR: Parse Error: Unexpected token.\n   at: GDScript::reload (res://src/player.gd:3)"},
+	}
+}
+
+func TestWriteRDJSON(t *testing.T) {
+	var sb strings.Builder
+	if err := WriteRDJSON(&sb, rdjsonSample(), "game", false); err != nil {
+		t.Fatal(err)
+	}
+	var res rdResult
+	if err := json.Unmarshal([]byte(sb.String()), &res); err != nil {
+		t.Fatalf("invalid JSON %s: %v", sb.String(), err)
+	}
+	if res.Source.Name != "gdunit4" || len(res.Diagnostics) != 3 {
+		t.Fatalf("result = %+v, want 3 diagnostics from gdunit4", res)
+	}
+	want := []struct{ path, message, code string }{
+		{"game/tests/MathTest.gd", "MathTest.test_sub: expected '1' but was '2'", rdCodeFailure},
+		{"game/tests/NetTest.gd", "NetTest.test_io: timeout", rdCodeError},
+		{"game/src/player.gd", "Parse Error: Unexpected token.", rdCodeParseError},
+	}
+	for i, w := range want {
+		d := res.Diagnostics[i]
+		if d.Location.Path != w.path || d.Message != w.message || d.Code.Value != w.code {
+			t.Errorf("diagnostic %d = %+v, want %+v", i, d, w)
+		}
+	}
+	if r := res.Diagnostics[0].Location.Range; r == nil || r.Start.Line != 7 {
+		t.Errorf("range = %+v, want line 7", r)
+	}
+	if res.Diagnostics[1].Location.Range != nil {
+		t.Errorf("range = %+v, want none without a line", res.Diagnostics[1].Location.Range)
+	}
+}
+
+func TestWriteRDJSON_Lines(t *testing.T) {
+	var sb strings.Builder
+	if err := WriteRDJSON(&sb, rdjsonSample(), "", true); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), sb.String())
+	}
+	var d rdDiagnostic
+	if err := json.Unmarshal([]byte(lines[2]), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Location.Path != "src/player.gd" || d.Source == nil || d.Source.Name != "gdunit4" {
+		t.Errorf("diagnostic = %+v", d)
+	}
+}
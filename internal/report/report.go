@@ -1,16 +1,24 @@
 package report
 
 import (
-	"bufio"
+	"cmp"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/i18n"
 )
 
 // ---- XML structures (gdUnit4 JUnit XML format) ----
@@ -21,6 +29,7 @@ type JUnitTestSuites struct {
 	Tests    int              `xml:"tests,attr"`
 	Failures int              `xml:"failures,attr"`
 	Errors   int              `xml:"errors,attr"`
+	Time     float64          `xml:"time,attr,omitempty"`
 	Suites   []JUnitTestSuite `xml:"testsuite"`
 }
 
@@ -31,6 +40,7 @@ type JUnitTestSuite struct {
 	Tests     int             `xml:"tests,attr"`
 	Failures  int             `xml:"failures,attr"`
 	Errors    int             `xml:"errors,attr"`
+	Time      float64         `xml:"time,attr,omitempty"`
 	TestCases []JUnitTestCase `xml:"testcase"`
 }
 
@@ -38,11 +48,13 @@ type JUnitTestSuite struct {
 type JUnitTestCase struct {
 	Name      string        `xml:"name,attr"`
 	Classname string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr,omitempty"`
 	Failure   *JUnitFailure `xml:"failure"`
 	Error     *JUnitFailure `xml:"error"`
+	Skipped   *JUnitFailure `xml:"skipped"`
 }
 
-// JUnitFailure represents a <failure> or <error> element.
+// JUnitFailure represents a <failure>, <error> or <skipped> element.
 type JUnitFailure struct {
 	Message string `xml:"message,attr"`
 	Text    string `xml:",chardata"`
@@ -50,30 +62,178 @@ type JUnitFailure struct {
 
 // ---- JSON output structures ----
 
+// SchemaVersion is the version of the JSON output format. It changes when a
+// field is removed or changes meaning, not when one is added.
+const SchemaVersion = "1"
+
+// Schemas are the versions of the report formats the runner writes, by the
+// name of the format, for those that are versioned.
+var Schemas = map[string]string{
+	"json":       SchemaVersion,
+	"trx":        "2010",
+	"xunit":      "2",
+	"allure":     "2",
+	"sonar":      "1",
+	"checkstyle": checkstyleVersion,
+}
+
 // Output is the top-level JSON output.
 type Output struct {
 	Summary      Summary       `json:"summary"`
 	CrashDetails *CrashDetails `json:"crash_details,omitempty"`
 	Failures     []Failure     `json:"failures"`
+	Skipped      []Skip        `json:"skipped,omitempty"`   // tests gdUnit4 skipped
+	XFailures    []Failure     `json:"xfailures,omitempty"` // failures of tests expected to fail, not counted as failed
+	XPassed      []string      `json:"xpassed,omitempty"`   // tests expected to fail that passed, as Class.test_name
+	Coverage     *Coverage     `json:"coverage,omitempty"`
+	Ownership    *Ownership    `json:"ownership,omitempty"`
+	RunID        string        `json:"run_id,omitempty"`        // unique ID of the run, also naming its report directory
+	LogFile      string        `json:"log_file,omitempty"`      // Godot output kept with --log-file
+	UserDataDir  string        `json:"user_data_dir,omitempty"` // user:// data of the run, with --isolate-user-data
+	SuiteLogs    []SuiteLog    `json:"suite_logs,omitempty"`    // log segments of failed and crashed suites
+	Manifest     string        `json:"manifest,omitempty"`      // run-manifest.json recording how the run was made
+
+	// Incomplete marks the result of a run stopped before all tests ran, e.g.
+	// by --budget; it holds only the tests that finished.
+	Incomplete bool `json:"incomplete,omitempty"`
+
+	// TransientRetry is the known-transient error a first attempt of the run
+	// failed with, if the run was retried because of it.
+	TransientRetry string `json:"transient_retry,omitempty"`
+
+	// Projects breaks a run spanning several Godot projects down by project.
+	Projects []ProjectResult `json:"projects,omitempty"`
+
+	Project *ProjectInfo `json:"project,omitempty"` // settings read from project.godot
+
+	Timing *Timing `json:"timing,omitempty"` // where the time of the Godot run went
+
+	Hardware *Hardware `json:"hardware,omitempty"` // set when Godot rendered on a GPU
+
+	// SlowLoads are the resources that took longest to load, with --profile-startup.
+	SlowLoads []ResourceLoad `json:"slow_loads,omitempty"`
+
+	Integrity *Integrity `json:"integrity,omitempty"` // digest and signature of the rest, with --sign-output
+}
+
+// Hardware describes the machine a rendering run used, so that visual test
+// failures can be told apart by driver stack.
+type Hardware struct {
+	GPU  []string `json:"gpu"`           // renderer, driver and device lines Godot printed at startup
+	CPU  string   `json:"cpu,omitempty"` // CPU model, where the OS tells it
+	CPUs int      `json:"cpus"`          // logical CPUs
+	OS   string   `json:"os"`            // GOOS/GOARCH of the runner
+}
+
+// ResourceLoad is the time Godot spent loading one resource during a run.
+type ResourceLoad struct {
+	Path    string  `json:"path"`            // res:// path of the resource
+	Suite   string  `json:"suite,omitempty"` // the suite that first loaded it; empty before the first suite
+	Count   int     `json:"count"`           // times it was loaded
+	Seconds float64 `json:"seconds"`         // total load time
+	Max     float64 `json:"max_seconds"`     // longest single load
+}
+
+// Timing breaks the wall-clock time of a Godot run down by phase, in seconds.
+// A phase whose start or end does not show in the log is 0.
+type Timing struct {
+	Total   float64 `json:"total_seconds"`   // Godot started to Godot exited
+	Startup float64 `json:"startup_seconds"` // Godot started to the first output of gdUnit4
+	Import  float64 `json:"import_seconds"`  // the part of startup spent importing resources
+	Tests   float64 `json:"tests_seconds"`   // the first suite started to the last suite done
+	Report  float64 `json:"report_seconds"`  // the last suite done to Godot exited, mostly writing the report
+	CPU     float64 `json:"cpu_seconds"`     // user and system CPU time of Godot
+}
+
+// ProjectInfo describes the Godot project of a run.
+type ProjectInfo struct {
+	Name         string   `json:"name,omitempty"`
+	Features     []string `json:"features,omitempty"`      // config/features, e.g. "4.3", "C#", "Forward Plus"
+	GodotVersion string   `json:"godot_version,omitempty"` // reported by the Godot binary, when it was checked against the project
+}
+
+// ProjectResult is the outcome of one project of a run spanning several projects.
+type ProjectResult struct {
+	Dir      string    `json:"dir"`               // project directory relative to the common parent of all projects
+	RunID    string    `json:"run_id,omitempty"`  // run ID of the project's own run
+	Summary  *Summary  `json:"summary,omitempty"` // nil when the project produced no result
+	Coverage *Coverage `json:"coverage,omitempty"`
+	LogFile  string    `json:"log_file,omitempty"`
+	Error    string    `json:"error,omitempty"` // tool-level error of the project's run, if any
 }
 
 // Summary holds test result counts and overall status.
 type Summary struct {
 	Total   int    `json:"total"`
 	Passed  int    `json:"passed"`
-	Failed  int    `json:"failed"`
+	Failed  int    `json:"failed"` // tests with a failed assertion (<failure>)
+	Errors  int    `json:"errors"` // tests that errored instead (<error>), e.g. a script error or a timeout
 	Crashed bool   `json:"crashed"`
-	Status  string `json:"status"` // "passed", "failed", or "crashed"
+	Status  string `json:"status"` // "passed", "failed", "error" (errors but no failures), "crashed", or "aborted_max_failures" (see pipeline)
+
+	Skipped int `json:"skipped,omitempty"` // tests gdUnit4 skipped
+	XFail   int `json:"xfail,omitempty"`   // failed or errored tests expected to fail
+	XPass   int `json:"xpass,omitempty"`   // passed tests expected to fail
+}
+
+// Counts renders the test counts, e.g. "8 passed, 2 failed of 10", with the
+// errored tests after the failed ones when there are any.
+// Skipped and expected failures follow when there are any.
+func (s Summary) Counts() string {
+	return s.CountsIn(i18n.English)
 }
 
+// CountsIn is Counts in lang.
+func (s Summary) CountsIn(lang i18n.Lang) string {
+	counts := lang.Sprintf("%d passed, %d failed", s.Passed, s.Failed)
+	for _, c := range []struct {
+		n      int
+		format string
+	}{{s.Errors, ", %d errors"}, {s.Skipped, ", %d skipped"}, {s.XFail, ", %d xfail"}, {s.XPass, ", %d xpass"}} {
+		if c.n > 0 {
+			counts += lang.Sprintf(c.format, c.n)
+		}
+	}
+	return lang.Sprintf("%s of %d", counts, s.Total)
+}
+
+// Failure kinds, telling assertion failures from tests that could not complete.
+const (
+	KindFailure = "failure" // a JUnit <failure>
+	KindError   = "error"   // a JUnit <error>
+)
+
 // CrashDetails holds crash/error information extracted from the Godot log.
 type CrashDetails struct {
-	CrashInfo    string `json:"crash_info,omitempty"`
-	ScriptErrors string `json:"script_errors,omitempty"`
+	CrashInfo    string   `json:"crash_info,omitempty"`
+	ScriptErrors string   `json:"script_errors,omitempty"`
+	Dumps        []string `json:"dumps,omitempty"` // core or minidump files of the crashed Godot (--crash-dumps)
+}
+
+// Coverage summarizes line coverage, overall and per directory.
+type Coverage struct {
+	LinesValid   int           `json:"lines_valid"`
+	LinesCovered int           `json:"lines_covered"`
+	Percent      float64       `json:"percent"`
+	Min          float64       `json:"min,omitempty"` // required overall percentage, if any
+	Passed       bool          `json:"passed"`        // false if any threshold was missed
+	Directories  []CoverageDir `json:"directories"`
+}
+
+// CoverageDir holds the line coverage of the scripts directly in one directory,
+// or of a directory tree when it has a threshold of its own.
+type CoverageDir struct {
+	Path         string  `json:"path"`
+	LinesValid   int     `json:"lines_valid"`
+	LinesCovered int     `json:"lines_covered"`
+	Percent      float64 `json:"percent"`
+	Min          float64 `json:"min,omitempty"`
+	Passed       bool    `json:"passed"`
 }
 
 // Failure represents a single test failure.
 type Failure struct {
+	Kind     string `json:"kind"` // KindFailure or KindError
 	Class    string `json:"class"`
 	Method   string `json:"method"`
 	File     string `json:"file"`
@@ -81,6 +241,82 @@ type Failure struct {
 	Expected string `json:"expected"`
 	Actual   string `json:"actual"`
 	Message  string `json:"message"`
+
+	// Suite is the res:// path of the suite that ran the test, set when File is
+	// a base class the suite inherits the test from.
+	Suite string `json:"suite,omitempty"`
+
+	Snapshot *SnapshotDiff `json:"snapshot,omitempty"` // set when the test left a received snapshot
+
+	Fuzz *FuzzDetails `json:"fuzz,omitempty"` // set when a fuzzer-driven test failed
+
+	// Occurrences is how many times the test was reported failing exactly this
+	// way, e.g. by retries, and Attempts which of its reports (1-based) did; both
+	// are set only when it was more than once.
+	Occurrences int   `json:"occurrences,omitempty"`
+	Attempts    []int `json:"attempts,omitempty"`
+
+	// Parameters holds every case of a parameterized test, passed or failed, in index
+	// order. The other fields then describe the first failed case.
+	Parameters []ParameterResult `json:"parameters,omitempty"`
+
+	Owners []string `json:"owners,omitempty"` // owners of the test file from CODEOWNERS or the owners map
+
+	// Project is the directory of the test's project relative to the common parent
+	// of all projects, set when the run spans several projects (see Output.Projects).
+	Project string `json:"project,omitempty"`
+}
+
+// RelPath returns the path of f's file relative to the directory holding the
+// project (or all projects), or "" if f has no res:// file.
+func (f Failure) RelPath() string {
+	if !strings.HasPrefix(f.File, "res://") {
+		return ""
+	}
+	return path.Join(f.Project, strings.TrimPrefix(f.File, "res://"))
+}
+
+// FuzzDetails describes a failed fuzzer-driven test.
+type FuzzDetails struct {
+	Iteration int    `json:"iteration"`           // 1-based fuzzer iteration that failed
+	Seed      *int64 `json:"seed,omitempty"`      // fuzzer seed, if reported or fixed in the test signature
+	Reproduce string `json:"reproduce,omitempty"` // command re-running just this test, from the project directory
+}
+
+// ParameterResult is the outcome of one case of a parameterized test.
+type ParameterResult struct {
+	Index   int    `json:"index"`
+	Args    string `json:"args,omitempty"` // parameter values as printed by gdUnit4, e.g. "1, 2"
+	Status  string `json:"status"`         // "passed" or "failed"
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Skip is a test gdUnit4 skipped, e.g. by do_skip() or a skip annotation.
+type Skip struct {
+	Class  string `json:"class"`
+	Method string `json:"method"`
+	Reason string `json:"reason,omitempty"` // as gdUnit4 reported it
+}
+
+// Ownership groups the failures of a run by owner.
+type Ownership struct {
+	Owners  []OwnerFailures `json:"owners"`
+	Unowned []string        `json:"unowned,omitempty"` // failing tests ("Class.method") without an owner
+}
+
+// OwnerFailures lists the failing tests an owner is responsible for.
+type OwnerFailures struct {
+	Owner    string   `json:"owner"`
+	Failures int      `json:"failures"`
+	Tests    []string `json:"tests"` // "Class.method"
+}
+
+// SnapshotDiff describes a mismatched approval snapshot.
+type SnapshotDiff struct {
+	Approved string `json:"approved"` // res:// path of the approved snapshot
+	Received string `json:"received"` // res:// path of the received snapshot
+	Diff     string `json:"diff"`     // unified diff from approved to received
 }
 
 // ---- Regex patterns ----
@@ -88,6 +324,15 @@ type Failure struct {
 // failedLocRe matches "FAILED: res://path/to/file.gd:42" in failure messages.
 var failedLocRe = regexp.MustCompile(`FAILED:\s*(res://[^:]+):(\d+)`)
 
+// paramCaseRe matches gdUnit4 parameterized test case names such as "test_add:0 (1, 2)".
+var paramCaseRe = regexp.MustCompile(`^(.+?):(\d+)(?:\s+\((.*)\))?$`)
+
+// fuzzIterationRe matches gdUnit4's "Found an error after '23' test iterations" fuzzer message.
+var fuzzIterationRe = regexp.MustCompile(`Found an error after\s+'?(\d+)'?\s+test iterations`)
+
+// fuzzSeedRe matches a fuzzer seed printed in a failure message, e.g. "fuzzer_seed=42" or "seed: 42".
+var fuzzSeedRe = regexp.MustCompile(`(?i)\b(?:fuzzer_)?seed\s*[:=]\s*'?(-?\d+)`)
+
 // expectedActualRe matches "Expected '<x>' but was '<y>'" patterns in CDATA.
 var expectedActualRe = regexp.MustCompile(`Expected\s+'([^']*)'\s+but was\s+'([^']*)'`)
 
@@ -95,11 +340,30 @@ var expectedActualRe = regexp.MustCompile(`Expected\s+'([^']*)'\s+but was\s+'([^
 
 // FindReportXML finds the most recently modified results.xml under projectDir/reports/report_*/.
 func FindReportXML(projectDir string) (string, error) {
-	pattern := filepath.Join(projectDir, "reports", "report_*", "results.xml")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
+	return FindReportXMLIn(filepath.Join(projectDir, "reports"))
+}
+
+// FindReportXMLIn finds the most recently modified results.xml under reportsDir/report_*/,
+// where reportsDir is the report directory passed to gdUnit4.
+//
+// The directory is listed rather than globbed, so that brackets in the project
+// path or the ? of a Windows \\?\ long path prefix are not taken as patterns.
+func FindReportXMLIn(reportsDir string) (string, error) {
+	pattern := filepath.Join(reportsDir, "report_*", "results.xml")
+	entries, err := os.ReadDir(reportsDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return "", fmt.Errorf("failed to search for report files: %w", err)
 	}
+	var matches []string
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "report_") {
+			continue
+		}
+		m := filepath.Join(reportsDir, e.Name(), "results.xml")
+		if _, err := os.Stat(m); err == nil {
+			matches = append(matches, m)
+		}
+	}
 	if len(matches) == 0 {
 		return "", fmt.Errorf("no report file found matching: %s", pattern)
 	}
@@ -123,7 +387,85 @@ func FindReportXML(projectDir string) (string, error) {
 	return newest, nil
 }
 
-// ParseXML parses a JUnit XML file produced by gdUnit4.
+// DefaultReportGlob matches the results files gdUnit4 writes into its report
+// directory.
+const DefaultReportGlob = "report_*/results.xml"
+
+// FindReportXMLs returns the files under dir matching glob and modified at or
+// after since, in lexical order. glob is a slash-separated path relative to dir
+// with the syntax of path.Match within a path element, where a "**" element
+// matches any number of directories. Like FindReportXMLIn, it walks dir rather
+// than globbing it, so that dir itself is never taken as a pattern.
+func FindReportXMLs(dir, glob string, since time.Time) ([]string, error) {
+	pattern := strings.Split(glob, "/")
+	var found []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || !matchGlob(pattern, strings.Split(filepath.ToSlash(rel), "/")) {
+			return err
+		}
+		if info, err := d.Info(); err == nil && !info.ModTime().Before(since.Truncate(time.Second)) {
+			found = append(found, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for report files: %w", err)
+	}
+	return found, nil
+}
+
+// matchGlob reports whether the path elements name match the elements of
+// pattern (see FindReportXMLs).
+func matchGlob(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchGlob(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], name[0])
+	return ok && matchGlob(pattern[1:], name[1:])
+}
+
+// ParseXMLFiles parses the JUnit XML files at paths, e.g. the reports of a run
+// gdUnit4 split into several, and merges them into one set of suites.
+func ParseXMLFiles(paths []string) (*JUnitTestSuites, error) {
+	merged := &JUnitTestSuites{}
+	for _, p := range paths {
+		suites, err := ParseXML(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		merged.Tests += suites.Tests
+		merged.Failures += suites.Failures
+		merged.Errors += suites.Errors
+		merged.Time += suites.Time
+		merged.Suites = append(merged.Suites, suites.Suites...)
+	}
+	return merged, nil
+}
+
+// ParseXML parses a JUnit XML file produced by gdUnit4, or by the runner,
+// also gzip-compressed. The layout of the file, which differs between gdUnit4
+// versions, is sniffed from its root element.
 func ParseXML(path string) (*JUnitTestSuites, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -131,51 +473,182 @@ func ParseXML(path string) (*JUnitTestSuites, error) {
 	}
 	defer f.Close()
 
-	var suites JUnitTestSuites
-	if err := xml.NewDecoder(f).Decode(&suites); err != nil {
+	r, err := Decompress(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+	suites, err := decodeXML(r)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse XML: %w", err)
 	}
-	return &suites, nil
+	return suites, nil
 }
 
 // ExtractFailures extracts Failure entries from parsed test suites.
+// The cases of a parameterized test are grouped into a single entry, and
+// textually identical failures of a test reported more than once collapse
+// into one.
 func ExtractFailures(suites *JUnitTestSuites) []Failure {
-	var failures []Failure
+	var c failureCollector
 	for _, suite := range suites.Suites {
-		for _, tc := range suite.TestCases {
-			f := tc.Failure
-			if f == nil {
-				f = tc.Error
-			}
-			if f == nil {
-				continue
-			}
-			failure := Failure{
-				Class:   tc.Classname,
-				Method:  tc.Name,
-				Message: f.Message,
-			}
-			// Extract file and line from the message (e.g. "FAILED: res://path.gd:42").
-			if m := failedLocRe.FindStringSubmatch(f.Message); m != nil {
-				failure.File = m[1]
-				if line, err := strconv.Atoi(m[2]); err == nil {
-					failure.Line = line
-				}
-			}
-			// Extract expected/actual from CDATA body (best-effort).
-			body := strings.TrimSpace(f.Text)
-			if m := expectedActualRe.FindStringSubmatch(body); m != nil {
-				failure.Expected = m[1]
-				failure.Actual = m[2]
-			}
-			failures = append(failures, failure)
+		for i := range suite.TestCases {
+			c.add(&suite.TestCases[i])
+		}
+	}
+	return c.failures()
+}
+
+// failureCollector accumulates failures test case by test case, grouping the cases
+// of parameterized tests under their parent test and collapsing repeated failures.
+type failureCollector struct {
+	list    []Failure
+	params  map[string]*paramGroup // keyed by class + "." + parent test name
+	reports map[string]int         // reports of each other test so far, keyed by class + "." + name
+	same    map[string]int         // position in list of each distinct failure, keyed by failureKey
+	skipped []Skip
+}
+
+// paramGroup holds the cases of one parameterized test seen so far.
+type paramGroup struct {
+	cases []ParameterResult
+	index int // position of the parent in list, or -1 while no case has failed
+	first Failure
+}
+
+func (c *failureCollector) add(tc *JUnitTestCase) {
+	if sk := tc.Skipped; sk != nil {
+		c.skipped = append(c.skipped, Skip{Class: tc.Classname, Method: tc.Name, Reason: strings.TrimSpace(cmp.Or(sk.Text, sk.Message))})
+		return
+	}
+	failure, failed := extractFailure(tc)
+
+	m := paramCaseRe.FindStringSubmatch(tc.Name)
+	if m == nil {
+		if c.reports == nil {
+			c.reports, c.same = map[string]int{}, map[string]int{}
+		}
+		name := tc.Classname + "." + tc.Name
+		c.reports[name]++
+		if !failed {
+			return
+		}
+		key := failureKey(failure)
+		if i, ok := c.same[key]; ok {
+			c.list[i].Occurrences++
+			c.list[i].Attempts = append(c.list[i].Attempts, c.reports[name])
+			return
+		}
+		failure.Occurrences, failure.Attempts = 1, []int{c.reports[name]}
+		c.same[key] = len(c.list)
+		c.list = append(c.list, failure)
+		return
+	}
+
+	if c.params == nil {
+		c.params = map[string]*paramGroup{}
+	}
+	key := tc.Classname + "." + m[1]
+	g := c.params[key]
+	if g == nil {
+		g = &paramGroup{index: -1}
+		c.params[key] = g
+	}
+
+	index, _ := strconv.Atoi(m[2])
+	result := ParameterResult{Index: index, Args: m[3], Status: "passed"}
+	if failed {
+		result.Status = "failed"
+		result.Line = failure.Line
+		result.Message = failure.Message
+	}
+	// A case reported again with the same result, e.g. by a retry, is listed once.
+	if slices.Contains(g.cases, result) {
+		return
+	}
+	if failed {
+		if g.index < 0 {
+			failure.Method = m[1]
+			g.index = len(c.list)
+			c.list = append(c.list, failure)
+		}
+	}
+	g.cases = append(g.cases, result)
+}
+
+func (c *failureCollector) failures() []Failure {
+	for i := range c.list {
+		if c.list[i].Occurrences == 1 {
+			c.list[i].Occurrences, c.list[i].Attempts = 0, nil
+		}
+	}
+	for _, g := range c.params {
+		if g.index < 0 {
+			continue
+		}
+		sort.SliceStable(g.cases, func(i, j int) bool { return g.cases[i].Index < g.cases[j].Index })
+		c.list[g.index].Parameters = g.cases
+	}
+	return c.list
+}
+
+// failureKey identifies the text of a failure of a test.
+func failureKey(f Failure) string {
+	return strings.Join([]string{f.Kind, f.Class, f.Method, f.File, strconv.Itoa(f.Line), f.Expected, f.Actual, f.Message}, "\x00")
+}
+
+// extractFailure converts a failed or errored test case to a Failure.
+// It reports false for passing test cases.
+func extractFailure(tc *JUnitTestCase) (Failure, bool) {
+	f, kind := tc.Failure, KindFailure
+	if f == nil {
+		f, kind = tc.Error, KindError
+	}
+	if f == nil {
+		return Failure{}, false
+	}
+	failure := Failure{
+		Kind:    kind,
+		Class:   tc.Classname,
+		Method:  tc.Name,
+		Message: f.Message,
+	}
+	// Extract file and line from the message (e.g. "FAILED: res://path.gd:42").
+	if m := failedLocRe.FindStringSubmatch(f.Message); m != nil {
+		failure.File = m[1]
+		if line, err := strconv.Atoi(m[2]); err == nil {
+			failure.Line = line
 		}
 	}
-	return failures
+	// Extract expected/actual from CDATA body (best-effort).
+	body := strings.TrimSpace(f.Text)
+	if m := expectedActualRe.FindStringSubmatch(body); m != nil {
+		failure.Expected = m[1]
+		failure.Actual = m[2]
+	}
+	failure.Fuzz = extractFuzz(f.Message + "\n" + body)
+	return failure, true
+}
+
+// extractFuzz returns the fuzzer iteration and seed reported in a failure text,
+// or nil if the failure did not come from a fuzzer.
+func extractFuzz(text string) *FuzzDetails {
+	m := fuzzIterationRe.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	fuzz := &FuzzDetails{}
+	fuzz.Iteration, _ = strconv.Atoi(m[1])
+	if s := fuzzSeedRe.FindStringSubmatch(text); s != nil {
+		if seed, err := strconv.ParseInt(s[1], 10, 64); err == nil {
+			fuzz.Seed = &seed
+		}
+	}
+	return fuzz
 }
 
 // DetectCrash scans the Godot log file for crash/error patterns.
 // Returns nil if no crash indicators are found.
+// At most maxCrashLines lines of each kind are kept; the rest are counted.
 func DetectCrash(logPath string) (*CrashDetails, error) {
 	f, err := os.Open(logPath)
 	if err != nil {
@@ -183,73 +656,121 @@ func DetectCrash(logPath string) (*CrashDetails, error) {
 	}
 	defer f.Close()
 
-	var crashLines []string
-	var scriptErrorLines []string
-
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
+	var crashLines, scriptErrorLines lineCollector
+	afterScriptError := false
+	err = StreamLog(f, 0, func(line string) error {
+		kind := ClassifyLine(line)
 		switch {
-		case strings.Contains(line, "handle_crash:"):
-			crashLines = append(crashLines, line)
-		case strings.HasPrefix(line, "SCRIPT ERROR:"):
-			scriptErrorLines = append(scriptErrorLines, line)
+		case kind == LineCrash:
+			crashLines.add(line)
+		case kind == LineScriptError:
+			scriptErrorLines.add(line)
+		case afterScriptError && strings.HasPrefix(strings.TrimSpace(line), "at:"):
+			// Keep the location Godot prints below a script error with it.
+			scriptErrorLines.add(line)
 		}
-	}
-	if err := scanner.Err(); err != nil {
+		afterScriptError = kind == LineScriptError
+		return nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to read log file: %w", err)
 	}
 
-	if len(crashLines) == 0 && len(scriptErrorLines) == 0 {
+	if crashLines.empty() && scriptErrorLines.empty() {
 		return nil, nil
 	}
 
 	return &CrashDetails{
-		CrashInfo:    strings.Join(crashLines, "\n"),
-		ScriptErrors: strings.Join(scriptErrorLines, "\n"),
+		CrashInfo:    crashLines.String(),
+		ScriptErrors: scriptErrorLines.String(),
 	}, nil
 }
 
+// lineCollector keeps the first maxCrashLines lines added and counts the rest.
+type lineCollector struct {
+	lines   []string
+	dropped int
+}
+
+func (c *lineCollector) add(line string) {
+	if len(c.lines) < maxCrashLines {
+		c.lines = append(c.lines, line)
+		return
+	}
+	c.dropped++
+}
+
+func (c *lineCollector) empty() bool {
+	return len(c.lines) == 0
+}
+
+func (c *lineCollector) String() string {
+	s := strings.Join(c.lines, "\n")
+	if c.dropped > 0 {
+		s += fmt.Sprintf("\n... (%d more lines)", c.dropped)
+	}
+	return s
+}
+
 // BuildOutput constructs the Output struct from parsed suites and optional crash details.
 func BuildOutput(suites *JUnitTestSuites, crash *CrashDetails) *Output {
-	failures := []Failure{}
+	var c failureCollector
 	if suites != nil {
-		extracted := ExtractFailures(suites)
-		if extracted != nil {
-			failures = extracted
+		for _, suite := range suites.Suites {
+			for i := range suite.TestCases {
+				c.add(&suite.TestCases[i])
+			}
 		}
 	}
+	return c.output(suites, crash)
+}
+
+// output builds the Output of the test cases collected from suites.
+func (c *failureCollector) output(suites *JUnitTestSuites, crash *CrashDetails) *Output {
+	failures := []Failure{}
+	if extracted := c.failures(); extracted != nil {
+		failures = extracted
+	}
 
 	crashed := crash != nil
-	total := 0
-	failed := 0
+	total, failed, errored := 0, 0, 0
 	if suites != nil {
 		total = suites.Tests
-		failed = suites.Failures + suites.Errors
-	}
-	passed := total - failed
-	if passed < 0 {
-		passed = 0
-	}
-
-	status := "passed"
-	if crashed {
-		status = "crashed"
-	} else if failed > 0 {
-		status = "failed"
+		failed = suites.Failures
+		errored = suites.Errors
 	}
+	passed := max(total-failed-errored-len(c.skipped), 0)
 
 	return &Output{
 		Summary: Summary{
 			Total:   total,
 			Passed:  passed,
 			Failed:  failed,
+			Errors:  errored,
 			Crashed: crashed,
-			Status:  status,
+			Status:  Status(crashed, failed, errored),
+			Skipped: len(c.skipped),
 		},
 		CrashDetails: crash,
 		Failures:     failures,
+		Skipped:      c.skipped,
+	}
+}
+
+// Status returns the run status for a run that crashed or had failed and
+// errored tests. Failures win over errors: an assertion failure is a finding in
+// the code under test, while a run with only errors is more likely broken by
+// its environment.
+func Status(crashed bool, failed, errored int) string {
+	switch {
+	case crashed:
+		return "crashed"
+	case failed > 0:
+		return "failed"
+	case errored > 0:
+		return "error"
 	}
+	return "passed"
 }
 
 // WriteJSON encodes the Output as indented JSON to w.
@@ -261,3 +782,23 @@ func WriteJSON(w io.Writer, out *Output) error {
 	}
 	return nil
 }
+
+// ToolError describes a failure of the runner itself, as opposed to failed
+// tests: the run ended with exit code 2 before any result was produced.
+type ToolError struct {
+	Kind     string `json:"kind"` // what failed: "config", "godot_not_found", "detection", "hook", "daemon" or "run"
+	Message  string `json:"message"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// WriteError encodes e as {"error": {...}} JSON to w.
+func WriteError(w io.Writer, e ToolError) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(struct {
+		Error ToolError `json:"error"`
+	}{e}); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
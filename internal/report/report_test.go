@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseXML_MixedResults(t *testing.T) {
@@ -87,6 +89,83 @@ func TestExtractFailures(t *testing.T) {
 	}
 }
 
+func TestExtractFailures_GroupsParameterizedCases(t *testing.T) {
+	fail := func(line string) *JUnitFailure {
+		return &JUnitFailure{Message: "FAILED: res://tests/MathTest.gd:" + line}
+	}
+	suites := &JUnitTestSuites{
+		Suites: []JUnitTestSuite{
+			{
+				TestCases: []JUnitTestCase{
+					{Name: "test_plain", Classname: "MathTest", Failure: fail("5")},
+					{Name: "test_add:0 (1, 2, 3)", Classname: "MathTest"},
+					{Name: "test_add:2 (2, 2, 5)", Classname: "MathTest", Failure: fail("12")},
+					{Name: "test_add:1 (1, 1, 3)", Classname: "MathTest", Failure: fail("12")},
+					{Name: "test_sub:0 (1, 1, 0)", Classname: "MathTest"},
+				},
+			},
+		},
+	}
+
+	failures := ExtractFailures(suites)
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 failures (plain + grouped test_add), got %d: %+v", len(failures), failures)
+	}
+	f := failures[1]
+	if f.Method != "test_add" || f.Line != 12 {
+		t.Errorf("grouped failure = %s line %d, want test_add line 12", f.Method, f.Line)
+	}
+	want := []ParameterResult{
+		{Index: 0, Args: "1, 2, 3", Status: "passed"},
+		{Index: 1, Args: "1, 1, 3", Status: "failed", Line: 12, Message: "FAILED: res://tests/MathTest.gd:12"},
+		{Index: 2, Args: "2, 2, 5", Status: "failed", Line: 12, Message: "FAILED: res://tests/MathTest.gd:12"},
+	}
+	if !reflect.DeepEqual(f.Parameters, want) {
+		t.Errorf("Parameters = %+v, want %+v", f.Parameters, want)
+	}
+	if failures[0].Parameters != nil {
+		t.Errorf("plain test has Parameters: %+v", failures[0].Parameters)
+	}
+}
+
+func TestExtractFailures_CollapsesRepeats(t *testing.T) {
+	fail := func(line string) *JUnitFailure {
+		return &JUnitFailure{Message: "FAILED: res://tests/MathTest.gd:" + line}
+	}
+	suites := &JUnitTestSuites{
+		Suites: []JUnitTestSuite{
+			{
+				TestCases: []JUnitTestCase{
+					{Name: "test_flaky", Classname: "MathTest", Failure: fail("5")},
+					{Name: "test_flaky", Classname: "MathTest"},
+					{Name: "test_flaky", Classname: "MathTest", Failure: fail("5")},
+					{Name: "test_flaky", Classname: "MathTest", Failure: fail("7")},
+					{Name: "test_once", Classname: "MathTest", Failure: fail("9")},
+					{Name: "test_add:0 (1, 1, 3)", Classname: "MathTest", Failure: fail("12")},
+					{Name: "test_add:0 (1, 1, 3)", Classname: "MathTest", Failure: fail("12")},
+				},
+			},
+		},
+	}
+
+	failures := ExtractFailures(suites)
+	if len(failures) != 4 {
+		t.Fatalf("expected 4 failures, got %d: %+v", len(failures), failures)
+	}
+	if f := failures[0]; f.Line != 5 || f.Occurrences != 2 || !reflect.DeepEqual(f.Attempts, []int{1, 3}) {
+		t.Errorf("repeated failure = line %d, %d occurrences, attempts %v; want line 5, 2, [1 3]", f.Line, f.Occurrences, f.Attempts)
+	}
+	if f := failures[1]; f.Line != 7 || f.Occurrences != 0 || f.Attempts != nil {
+		t.Errorf("distinct failure = line %d, %d occurrences, attempts %v; want line 7 and no counts", f.Line, f.Occurrences, f.Attempts)
+	}
+	if f := failures[2]; f.Occurrences != 0 {
+		t.Errorf("single failure has Occurrences = %d, want 0", f.Occurrences)
+	}
+	if f := failures[3]; len(f.Parameters) != 1 {
+		t.Errorf("repeated case listed %d times, want once: %+v", len(f.Parameters), f.Parameters)
+	}
+}
+
 func TestExtractFailures_ErrorElement(t *testing.T) {
 	suites := &JUnitTestSuites{
 		Suites: []JUnitTestSuite{
@@ -111,6 +190,9 @@ func TestExtractFailures_ErrorElement(t *testing.T) {
 	if failures[0].File != "res://tests/ErrorTest.gd" {
 		t.Errorf("File = %q, want res://tests/ErrorTest.gd", failures[0].File)
 	}
+	if failures[0].Kind != KindError {
+		t.Errorf("Kind = %q, want %q", failures[0].Kind, KindError)
+	}
 }
 
 func TestDetectCrash_NoCrash(t *testing.T) {
@@ -220,6 +302,23 @@ func TestBuildOutput_WithFailures(t *testing.T) {
 	}
 }
 
+func TestBuildOutput_Errors(t *testing.T) {
+	tests := []struct {
+		failures, errors int
+		status           string
+	}{
+		{0, 3, "error"},
+		{1, 3, "failed"},
+	}
+	for _, tt := range tests {
+		out := BuildOutput(&JUnitTestSuites{Tests: 10, Failures: tt.failures, Errors: tt.errors}, nil)
+		s := out.Summary
+		if s.Status != tt.status || s.Failed != tt.failures || s.Errors != tt.errors || s.Passed != 10-tt.failures-tt.errors {
+			t.Errorf("failures=%d errors=%d: Summary = %+v, want status %s", tt.failures, tt.errors, s, tt.status)
+		}
+	}
+}
+
 func TestBuildOutput_Crashed(t *testing.T) {
 	crash := &CrashDetails{CrashInfo: "handle_crash: signal 11"}
 	out := BuildOutput(nil, crash)
@@ -267,7 +366,8 @@ func TestWriteJSON(t *testing.T) {
 }
 
 func TestFindReportXML(t *testing.T) {
-	root := t.TempDir()
+	// Brackets in the project path must not be taken as a pattern.
+	root := filepath.Join(t.TempDir(), "game [v2]")
 	reportDir := filepath.Join(root, "reports", "report_20240101_120000")
 	if err := os.MkdirAll(reportDir, 0o755); err != nil {
 		t.Fatal(err)
@@ -286,6 +386,70 @@ func TestFindReportXML(t *testing.T) {
 	}
 }
 
+func TestFindReportXMLs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run [1]")
+	files := []string{"report_1/results.xml", "report_2/results.xml", "report_2/other.xml", "split/a/report_3/results.xml"}
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("<testsuites/>"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		glob  string
+		since time.Time
+		want  []string
+	}{
+		{DefaultReportGlob, time.Time{}, []string{"report_1/results.xml", "report_2/results.xml"}},
+		{"**/results.xml", time.Time{}, []string{"report_1/results.xml", "report_2/results.xml", "split/a/report_3/results.xml"}},
+		{"report_2/*.xml", time.Time{}, []string{"report_2/other.xml", "report_2/results.xml"}},
+		{DefaultReportGlob, time.Now().Add(time.Hour), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob, func(t *testing.T) {
+			got, err := FindReportXMLs(dir, tt.glob, tt.since)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var rel []string
+			for _, p := range got {
+				r, _ := filepath.Rel(dir, p)
+				rel = append(rel, filepath.ToSlash(r))
+			}
+			if !reflect.DeepEqual(rel, tt.want) {
+				t.Errorf("FindReportXMLs(%q) = %v, want %v", tt.glob, rel, tt.want)
+			}
+		})
+	}
+
+	if got, err := FindReportXMLs(filepath.Join(dir, "missing"), DefaultReportGlob, time.Time{}); err != nil || got != nil {
+		t.Errorf("missing dir: got %v, %v; want nothing", got, err)
+	}
+}
+
+func TestParseXMLFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.xml")
+	b := filepath.Join(dir, "b.xml")
+	if err := os.WriteFile(a, []byte(`<testsuites tests="2" failures="1"><testsuite name="A"/></testsuites>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(`<testsuites tests="3" errors="1"><testsuite name="B"/></testsuites>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	suites, err := ParseXMLFiles([]string{a, b})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if suites.Tests != 5 || suites.Failures != 1 || suites.Errors != 1 || len(suites.Suites) != 2 {
+		t.Errorf("merged = %+v", suites)
+	}
+}
+
 func TestFindReportXML_NotFound(t *testing.T) {
 	root := t.TempDir()
 	_, err := FindReportXML(root)
@@ -293,3 +457,56 @@ func TestFindReportXML_NotFound(t *testing.T) {
 		t.Fatal("expected error when no report found, got nil")
 	}
 }
+
+func TestExtractFailures_Fuzz(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		text      string
+		wantFuzz  bool
+		wantIter  int
+		wantSeed  int64
+		wantSeeds bool
+	}{
+		{"iteration only", "FAILED: res://tests/FuzzTest.gd:9", "Found an error after '24' test iterations\n Expected 'true' but was 'false'", true, 24, 0, false},
+		{"with seed", "FAILED: res://tests/FuzzTest.gd:9", "Found an error after 3 test iterations (fuzzer_seed=1234)", true, 3, 1234, true},
+		{"not a fuzz failure", "FAILED: res://tests/FuzzTest.gd:9", "Expected 'true' but was 'false'", false, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suites := &JUnitTestSuites{Suites: []JUnitTestSuite{{TestCases: []JUnitTestCase{
+				{Name: "test_fuzz", Classname: "FuzzTest", Failure: &JUnitFailure{Message: tt.message, Text: tt.text}},
+			}}}}
+			f := ExtractFailures(suites)[0]
+			if (f.Fuzz != nil) != tt.wantFuzz {
+				t.Fatalf("Fuzz = %+v, want present=%v", f.Fuzz, tt.wantFuzz)
+			}
+			if !tt.wantFuzz {
+				return
+			}
+			if f.Fuzz.Iteration != tt.wantIter {
+				t.Errorf("Iteration = %d, want %d", f.Fuzz.Iteration, tt.wantIter)
+			}
+			if (f.Fuzz.Seed != nil) != tt.wantSeeds || (tt.wantSeeds && *f.Fuzz.Seed != tt.wantSeed) {
+				t.Errorf("Seed = %v, want %d (present=%v)", f.Fuzz.Seed, tt.wantSeed, tt.wantSeeds)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	var sb strings.Builder
+	if err := WriteError(&sb, ToolError{Kind: "detection", Message: "no project.godot found", ExitCode: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var parsed struct {
+		Error ToolError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(sb.String()), &parsed); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	want := ToolError{Kind: "detection", Message: "no project.godot found", ExitCode: 2}
+	if parsed.Error != want {
+		t.Errorf("error = %+v, want %+v", parsed.Error, want)
+	}
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strings"
+)
+
+// sonarExecutions is the root of SonarQube's generic test execution report.
+type sonarExecutions struct {
+	XMLName xml.Name    `xml:"testExecutions"`
+	Version int         `xml:"version,attr"`
+	Files   []sonarFile `xml:"file"`
+}
+
+type sonarFile struct {
+	Path      string          `xml:"path,attr"`
+	TestCases []sonarTestCase `xml:"testCase"`
+}
+
+type sonarTestCase struct {
+	Name     string        `xml:"name,attr"`
+	Duration int64         `xml:"duration,attr"` // milliseconds
+	Failure  *JUnitFailure `xml:"failure"`
+	Error    *JUnitFailure `xml:"error"`
+	Skipped  *JUnitFailure `xml:"skipped"`
+}
+
+// WriteSonar writes suites as a SonarQube generic test execution report to w.
+// pathPrefix is prepended to the project-relative file of each suite so that
+// paths resolve against the SonarQube project's base directory. Sonar ties
+// every test to a file, so test cases of a suite without a res:// file, such
+// as the crash placeholder of a run without results, are left out.
+func WriteSonar(w io.Writer, suites *JUnitTestSuites, pathPrefix string) error {
+	report := sonarExecutions{Version: 1}
+	if suites != nil {
+		files := map[string]int{}
+		for _, s := range suites.Suites {
+			if !strings.HasPrefix(s.Package, "res://") {
+				continue
+			}
+			p := path.Join(pathPrefix, strings.TrimPrefix(s.Package, "res://"))
+			i, ok := files[p]
+			if !ok {
+				i = len(report.Files)
+				files[p] = i
+				report.Files = append(report.Files, sonarFile{Path: p})
+			}
+			for _, tc := range s.TestCases {
+				report.Files[i].TestCases = append(report.Files[i].TestCases, sonarTestCase{
+					Name:     tc.Name,
+					Duration: int64(math.Round(tc.Time * 1000)),
+					Failure:  tc.Failure,
+					Error:    tc.Error,
+					Skipped:  tc.Skipped,
+				})
+			}
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write Sonar report: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write Sonar report: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write Sonar report: %w", err)
+	}
+	return nil
+}
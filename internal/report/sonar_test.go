@@ -0,0 +1,45 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteSonar(t *testing.T) {
+	suites := &JUnitTestSuites{Suites: []JUnitTestSuite{
+		{
+			Package: "res://tests/MathTest.gd",
+			TestCases: []JUnitTestCase{
+				{Name: "test_add", Classname: "MathTest", Time: 0.0125},
+				{Name: "test_sub", Classname: "MathTest", Time: 0.5, Failure: &JUnitFailure{Message: "FAILED: res://tests/MathTest.gd:7", Text: "Expected '1' but was '2'"}},
+			},
+		},
+		{
+			Package:   "res://tests/NetTest.gd",
+			TestCases: []JUnitTestCase{{Name: "test_later", Classname: "NetTest", Skipped: &JUnitFailure{Message: "not yet"}}},
+		},
+		{Name: "gdunit4-test-runner", TestCases: []JUnitTestCase{{Name: "godot", Error: &JUnitFailure{Message: "Godot crashed"}}}},
+	}}
+	var sb strings.Builder
+	if err := WriteSonar(&sb, suites, "game"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testExecutions version="1">
+  <file path="game/tests/MathTest.gd">
+    <testCase name="test_add" duration="13"></testCase>
+    <testCase name="test_sub" duration="500">
+      <failure message="FAILED: res://tests/MathTest.gd:7">Expected &#39;1&#39; but was &#39;2&#39;</failure>
+    </testCase>
+  </file>
+  <file path="game/tests/NetTest.gd">
+    <testCase name="test_later" duration="0">
+      <skipped message="not yet"></skipped>
+    </testCase>
+  </file>
+</testExecutions>
+`
+	if sb.String() != want {
+		t.Errorf("WriteSonar =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
@@ -0,0 +1,215 @@
+package report
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultMaxLineLength is the longest log line StreamLog passes on; longer lines are truncated.
+const DefaultMaxLineLength = 64 * 1024
+
+// maxCrashLines caps the lines DetectCrash collects per category so that a log
+// flooded with errors cannot exhaust memory.
+const maxCrashLines = 200
+
+// Log line kinds reported by ClassifyLine.
+const (
+	LineCrash       = "crash"
+	LineScriptError = "script_error"
+)
+
+// StreamXML decodes a gdUnit4 JUnit XML document from r one test case at a time.
+// fn is called for every <testcase> with its enclosing suite; the suite carries its
+// attributes only (TestCases is always empty), so memory use does not grow with the
+// number of test cases. The layout of the document is sniffed from its root
+// element as ParseXML does. The returned value holds the totals of the document,
+// from the root <testsuites> attributes or, for layouts without them, summed up
+// from the suites, with Suites left empty.
+func StreamXML(r io.Reader, fn func(suite *JUnitTestSuite, tc *JUnitTestCase) error) (*JUnitTestSuites, error) {
+	dec := xml.NewDecoder(r)
+	rootElem, format, err := sniffXML(dec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+	recounts := format.recounts(rootElem)
+	root := &JUnitTestSuites{XMLName: xml.Name{Local: "testsuites"}}
+	if !recounts {
+		root.XMLName = rootElem.Name
+		root.Tests, root.Failures, root.Errors, root.Time = rootTotals(rootElem)
+	}
+
+	var suite *JUnitTestSuite
+	var cases caseTotals
+	// endSuite closes the suite read so far, adding it to the totals when
+	// they are summed up.
+	endSuite := func() {
+		if suite != nil && recounts {
+			addTotals(root, suite, cases)
+		}
+		suite, cases = nil, caseTotals{}
+	}
+	if rootElem.Name.Local == "testsuite" {
+		suite = suiteAttrs(rootElem)
+	}
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "testsuite":
+				endSuite()
+				suite = suiteAttrs(t)
+			case "testcase":
+				var tc JUnitTestCase
+				if err := dec.DecodeElement(&tc, &t); err != nil {
+					return nil, fmt.Errorf("failed to parse XML: %w", err)
+				}
+				if suite == nil {
+					suite = &JUnitTestSuite{}
+				}
+				cases.add(&tc)
+				if err := fn(suite, &tc); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "testsuite" {
+				endSuite()
+			}
+		}
+	}
+	endSuite()
+	return root, nil
+}
+
+// rootTotals returns the totals of a <testsuites> root element's attributes.
+func rootTotals(root xml.StartElement) (tests, failures, errs int, secs float64) {
+	for _, a := range root.Attr {
+		switch a.Name.Local {
+		case "tests":
+			tests = atoi(a.Value)
+		case "failures":
+			failures = atoi(a.Value)
+		case "errors":
+			errs = atoi(a.Value)
+		case "time":
+			secs = atof(a.Value)
+		}
+	}
+	return tests, failures, errs, secs
+}
+
+// suiteAttrs returns the suite of a <testsuite> element's attributes.
+func suiteAttrs(t xml.StartElement) *JUnitTestSuite {
+	suite := &JUnitTestSuite{}
+	for _, a := range t.Attr {
+		switch a.Name.Local {
+		case "name":
+			suite.Name = a.Value
+		case "package":
+			suite.Package = a.Value
+		case "tests":
+			suite.Tests = atoi(a.Value)
+		case "failures":
+			suite.Failures = atoi(a.Value)
+		case "errors":
+			suite.Errors = atoi(a.Value)
+		case "time":
+			suite.Time = atof(a.Value)
+		}
+	}
+	return suite
+}
+
+// BuildOutputFromXML streams the JUnit XML in r and builds the Output without
+// retaining passing test cases (other than the cases of parameterized tests).
+// The Output is the same as BuildOutput makes of the document as ParseXML
+// reads it, in every layout ParseXML knows.
+func BuildOutputFromXML(r io.Reader, crash *CrashDetails) (*Output, error) {
+	var c failureCollector
+	root, err := StreamXML(r, func(_ *JUnitTestSuite, tc *JUnitTestCase) error {
+		c.add(tc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.output(root, crash), nil
+}
+
+// StreamLog reads r line by line and calls fn for each line without its line ending.
+// Lines longer than maxLen bytes (DefaultMaxLineLength if maxLen <= 0) are truncated
+// rather than buffered in full. Lines are normalized to UTF-8 without ANSI escape
+// sequences first, so that patterns match whatever console Godot wrote to.
+func StreamLog(r io.Reader, maxLen int, fn func(line string) error) error {
+	if maxLen <= 0 {
+		maxLen = DefaultMaxLineLength
+	}
+	br := bufio.NewReaderSize(r, 64*1024)
+	var line []byte
+	truncated := false
+
+	for {
+		chunk, isPrefix, err := br.ReadLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+		if !truncated {
+			room := maxLen - len(line)
+			if len(chunk) > room {
+				chunk = chunk[:room]
+				truncated = true
+			}
+			line = append(line, chunk...)
+		}
+		if isPrefix {
+			continue
+		}
+		if err := fn(normalizeLine(string(line))); err != nil {
+			return err
+		}
+		line = line[:0]
+		truncated = false
+	}
+}
+
+// ClassifyLine reports whether a log line signals a crash (LineCrash), a GDScript
+// error (LineScriptError), or neither ("").
+func ClassifyLine(line string) string {
+	switch {
+	case strings.Contains(line, "handle_crash:"):
+		return LineCrash
+	case strings.HasPrefix(line, "SCRIPT ERROR:"):
+		return LineScriptError
+	}
+	return ""
+}
+
+// atoi parses an integer attribute, treating malformed values as 0.
+func atoi(s string) int {
+	var n int
+	fmt.Sscan(s, &n)
+	return n
+}
+
+// atof parses a float attribute, treating malformed values as 0.
+func atof(s string) float64 {
+	var f float64
+	fmt.Sscan(s, &f)
+	return f
+}
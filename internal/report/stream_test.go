@@ -0,0 +1,226 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStreamXML_MatchesParseXML(t *testing.T) {
+	path := filepath.Join("..", "..", "testdata", "sample_results.xml")
+	want, err := ParseXML(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var cases []JUnitTestCase
+	var suiteNames []string
+	root, err := StreamXML(f, func(suite *JUnitTestSuite, tc *JUnitTestCase) error {
+		if len(suite.TestCases) != 0 {
+			t.Errorf("suite %q carries %d test cases, want 0", suite.Name, len(suite.TestCases))
+		}
+		suiteNames = append(suiteNames, suite.Name)
+		cases = append(cases, *tc)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if root.Tests != want.Tests || root.Failures != want.Failures || root.Errors != want.Errors {
+		t.Errorf("root = %d/%d/%d, want %d/%d/%d", root.Tests, root.Failures, root.Errors, want.Tests, want.Failures, want.Errors)
+	}
+	var wantCases []JUnitTestCase
+	var wantNames []string
+	for _, s := range want.Suites {
+		for _, tc := range s.TestCases {
+			wantCases = append(wantCases, tc)
+			wantNames = append(wantNames, s.Name)
+		}
+	}
+	if !reflect.DeepEqual(cases, wantCases) {
+		t.Errorf("streamed test cases differ from ParseXML:\n got %+v\nwant %+v", cases, wantCases)
+	}
+	if !reflect.DeepEqual(suiteNames, wantNames) {
+		t.Errorf("suite names = %v, want %v", suiteNames, wantNames)
+	}
+}
+
+func TestStreamXML_CallbackErrorStops(t *testing.T) {
+	xml := `<testsuites tests="2"><testsuite name="s"><testcase name="a"/><testcase name="b"/></testsuite></testsuites>`
+	stop := errors.New("stop")
+	calls := 0
+	_, err := StreamXML(strings.NewReader(xml), func(*JUnitTestSuite, *JUnitTestCase) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("err = %v, want %v", err, stop)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestStreamXML_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+	}{
+		{"malformed", `<testsuites><testsuite>`},
+		{"no root", `<other/>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := StreamXML(strings.NewReader(tt.xml), func(*JUnitTestSuite, *JUnitTestCase) error { return nil })
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestBuildOutputFromXML_MatchesBuildOutput(t *testing.T) {
+	path := filepath.Join("..", "..", "testdata", "sample_results.xml")
+	suites, err := ParseXML(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := BuildOutput(suites, nil)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := BuildOutputFromXML(f, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildOutputFromXML = %+v, want %+v", got, want)
+	}
+}
+
+func TestStreamXML_Formats(t *testing.T) {
+	const cases = `<testcase name="test_a" classname="A"/><testcase name="test_b" classname="A"><failure message="FAILED: res://tests/a.gd:9">boom</failure></testcase>`
+	tests := []struct {
+		name   string
+		xml    string
+		total  int
+		failed int
+	}{
+		{"4.x testsuites", `<testsuites tests="2" failures="1"><testsuite name="A" tests="2" failures="1">` + cases + `</testsuite></testsuites>`, 2, 1},
+		{"4.x testsuites without totals", `<testsuites><testsuite name="A">` + cases + `</testsuite></testsuites>`, 2, 1},
+		{"5.x testsuite root", `<?xml version="1.0"?><testsuite name="A">` + cases + `</testsuite>`, 2, 1},
+		{"5.x versioned testsuites", `<testsuites version="5.0" tests="9"><testsuite name="A">` + cases + `</testsuite><testsuite name="B" tests="3" failures="1"/></testsuites>`, 5, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := StreamXML(strings.NewReader(tt.xml), func(*JUnitTestSuite, *JUnitTestCase) error { return nil })
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if root.Tests != tt.total || root.Failures != tt.failed {
+				t.Errorf("root = %d tests, %d failures, want %d, %d", root.Tests, root.Failures, tt.total, tt.failed)
+			}
+
+			got, err := BuildOutputFromXML(strings.NewReader(tt.xml), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			suites, err := decodeXML(strings.NewReader(tt.xml))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := BuildOutput(suites, nil); !reflect.DeepEqual(got, want) {
+				t.Errorf("BuildOutputFromXML = %+v, want %+v", got, want)
+			}
+			if got.Summary.Status != "failed" || got.Summary.Total != tt.total {
+				t.Errorf("summary = %+v, want failed with %d tests", got.Summary, tt.total)
+			}
+		})
+	}
+}
+
+func TestStreamLog(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	input := "first\r\n" + long + "\nlast"
+
+	var got []string
+	err := StreamLog(strings.NewReader(input), 10, func(line string) error {
+		got = append(got, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"first", strings.Repeat("x", 10), "last"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestStreamLog_LineLongerThanBuffer(t *testing.T) {
+	long := strings.Repeat("y", 200*1024)
+	var got []string
+	err := StreamLog(strings.NewReader(long+"\nnext\n"), 0, func(line string) error {
+		got = append(got, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || len(got[0]) != DefaultMaxLineLength || got[1] != "next" {
+		t.Errorf("got %d lines (first len %d), want truncated line then %q", len(got), len(got[0]), "next")
+	}
+}
+
+func TestClassifyLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"handle_crash: Program crashed with signal 11", LineCrash},
+		{"SCRIPT ERROR: Invalid call", LineScriptError},
+		{"  SCRIPT ERROR: indented", ""},
+		{"ERROR: something", ""},
+	}
+	for _, tt := range tests {
+		if got := ClassifyLine(tt.line); got != tt.want {
+			t.Errorf("ClassifyLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestDetectCrash_CapsLines(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < maxCrashLines+5; i++ {
+		fmt.Fprintf(&b, "SCRIPT ERROR: error %d\n", i)
+	}
+	path := filepath.Join(t.TempDir(), "godot.log")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	crash, err := DetectCrash(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if crash == nil {
+		t.Fatal("expected crash details, got nil")
+	}
+	if !strings.HasSuffix(crash.ScriptErrors, "... (5 more lines)") {
+		t.Errorf("ScriptErrors does not end with the dropped-line count: %q", crash.ScriptErrors[len(crash.ScriptErrors)-40:])
+	}
+}
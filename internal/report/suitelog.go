@@ -0,0 +1,181 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxSuiteLogLines caps the lines kept per suite slice; the last ones are kept
+// as they lead up to the failure.
+const maxSuiteLogLines = 200
+
+// suiteStartRe matches the line gdUnit4 prints when it starts a test suite,
+// e.g. "Run Test Suite: res://tests/test_math.gd" (older versions print
+// "Running test suite: ...").
+var suiteStartRe = regexp.MustCompile(`(?i)^\s*Run(?:ning)? Test Suite:?\s+(res://\S+)`)
+
+// suiteEndRe matches the statistics line gdUnit4 prints when a suite is done.
+var suiteEndRe = regexp.MustCompile(`^\s*Statistics:`)
+
+// SuiteStart returns the res:// path of the suite a log line starts, if it does.
+func SuiteStart(line string) (string, bool) {
+	m := suiteStartRe.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// SuiteEnd reports whether a log line is the statistics line gdUnit4 prints
+// when a suite is done.
+func SuiteEnd(line string) bool {
+	return suiteEndRe.MatchString(line)
+}
+
+// testResultRe matches the line gdUnit4 prints when a test is done, e.g.
+// "Run Test: res://tests/test_math.gd > test_sub :FAILED 12ms".
+var testResultRe = regexp.MustCompile(`(?i)^\s*Run(?:ning)? Test:?\s+(res://\S+)\s*>\s*(\S+?)\s*:\s*(PASSED|FAILED|ERRORS?|SKIPPED)\b`)
+
+// TestResult returns the suite, the test and its status ("passed", "failed",
+// "error" or "skipped") if a log line reports the end of a test.
+func TestResult(line string) (suite, test, status string, ok bool) {
+	m := testResultRe.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", "", false
+	}
+	status = strings.ToLower(m[3])
+	if status == "errors" {
+		status = "error"
+	}
+	return m[1], m[2], status, true
+}
+
+// SuiteLog is the part of the Godot log written while one suite ran.
+type SuiteLog struct {
+	Suite   string `json:"suite"`              // res:// path of the suite
+	Log     string `json:"log,omitempty"`      // the slice, at most maxSuiteLogLines lines
+	LogFile string `json:"log_file,omitempty"` // the full slice, when written to a file
+}
+
+// SliceOptions selects the segments SliceLog returns.
+type SliceOptions struct {
+	Suites map[string]bool // res:// paths of the suites whose segments are wanted
+
+	// Unfinished also returns the segment of a suite that never printed its
+	// statistics line: the one running when Godot crashed or was killed.
+	Unfinished bool
+
+	// Dir, if set, is where the full segments of Suites are written, one file per suite.
+	Dir string
+}
+
+// SliceLog splits the Godot log at logPath into per-suite segments, from each
+// suite's start marker to its statistics line (or the next suite), and returns
+// the segments selected by opts in log order. Log holds the last maxSuiteLogLines
+// lines of a segment; LogFile is set for segments written in full to opts.Dir.
+func SliceLog(logPath string, opts SliceOptions) ([]SuiteLog, error) {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	var (
+		slices  []SuiteLog
+		current *suiteSlice
+		file    *os.File
+	)
+	// finish closes the current segment, keeping it if it is wanted.
+	finish := func(keep bool) error {
+		if current == nil {
+			return nil
+		}
+		sl := SuiteLog{Suite: current.suite, Log: current.String()}
+		current = nil
+		if file != nil {
+			sl.LogFile = file.Name()
+			err := file.Close()
+			file = nil
+			if err != nil {
+				return err
+			}
+		}
+		if keep {
+			slices = append(slices, sl)
+		}
+		return nil
+	}
+	err = StreamLog(f, 0, func(line string) error {
+		if suite, ok := SuiteStart(line); ok {
+			if err := finish(current != nil && opts.Suites[current.suite]); err != nil {
+				return err
+			}
+			current = &suiteSlice{suite: suite}
+			if opts.Dir != "" && opts.Suites[suite] {
+				if file, err = createSuiteLog(opts.Dir, suite); err != nil {
+					return err
+				}
+			}
+		}
+		if current == nil {
+			return nil
+		}
+		current.add(line)
+		if file != nil {
+			if _, err := fmt.Fprintln(file, line); err != nil {
+				return err
+			}
+		}
+		if suiteEndRe.MatchString(line) {
+			return finish(opts.Suites[current.suite])
+		}
+		return nil
+	})
+	if err == nil && current != nil {
+		err = finish(opts.Unfinished || opts.Suites[current.suite])
+	}
+	if file != nil {
+		file.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to slice log file: %w", err)
+	}
+	return slices, nil
+}
+
+// createSuiteLog creates the file for suite's slice in dir, e.g.
+// "tests_unit_TestSuiteA.log" for res://tests/unit/TestSuiteA.gd.
+func createSuiteLog(dir, suite string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(suite, "res://"), ".gd")
+	name = strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(name)
+	return os.Create(filepath.Join(dir, name+".log"))
+}
+
+// suiteSlice keeps the last maxSuiteLogLines lines of a suite's segment.
+type suiteSlice struct {
+	suite   string
+	lines   []string
+	dropped int
+}
+
+func (s *suiteSlice) add(line string) {
+	if len(s.lines) == maxSuiteLogLines {
+		s.lines = s.lines[1:]
+		s.dropped++
+	}
+	s.lines = append(s.lines, line)
+}
+
+func (s *suiteSlice) String() string {
+	text := strings.Join(s.lines, "\n")
+	if s.dropped > 0 {
+		text = fmt.Sprintf("... (%d earlier lines)\n", s.dropped) + text
+	}
+	return text
+}
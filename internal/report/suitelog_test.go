@@ -0,0 +1,116 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const suiteLog = `Godot Engine v4.3.stable
+Run Test Suite: res://tests/test_a.gd
+  test_one PASSED
+Statistics: | 1 tests cases | 0 error | 0 failed |
+Run Test Suite: res://tests/unit/test_b.gd
+  test_two FAILED
+  expected 1 but was 2
+Statistics: | 1 tests cases | 0 error | 1 failed |
+Running test suite: res://tests/test_c.gd
+SCRIPT ERROR: Invalid call.
+handle_crash: signal 11
+`
+
+func writeLog(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "godot.log")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSliceLog(t *testing.T) {
+	path := writeLog(t, suiteLog)
+
+	slices, err := SliceLog(path, SliceOptions{Suites: map[string]bool{"res://tests/unit/test_b.gd": true}, Unfinished: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(slices) != 2 {
+		t.Fatalf("got %d slices, want 2: %+v", len(slices), slices)
+	}
+	if slices[0].Suite != "res://tests/unit/test_b.gd" || !strings.Contains(slices[0].Log, "expected 1 but was 2") ||
+		strings.Contains(slices[0].Log, "test_one") || !strings.HasSuffix(slices[0].Log, "1 failed |") {
+		t.Errorf("failed suite slice = %+v", slices[0])
+	}
+	if slices[1].Suite != "res://tests/test_c.gd" || !strings.HasSuffix(slices[1].Log, "handle_crash: signal 11") {
+		t.Errorf("unfinished suite slice = %+v", slices[1])
+	}
+}
+
+func TestSliceLog_WritesFiles(t *testing.T) {
+	path := writeLog(t, suiteLog)
+	dir := filepath.Join(t.TempDir(), "suites")
+
+	slices, err := SliceLog(path, SliceOptions{Suites: map[string]bool{"res://tests/unit/test_b.gd": true}, Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(slices) != 1 {
+		t.Fatalf("got %d slices, want 1", len(slices))
+	}
+	want := filepath.Join(dir, "tests_unit_test_b.log")
+	if slices[0].LogFile != want {
+		t.Errorf("LogFile = %q, want %q", slices[0].LogFile, want)
+	}
+	data, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != slices[0].Log+"\n" {
+		t.Errorf("file = %q, want %q", data, slices[0].Log+"\n")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir has %d files, want only the wanted suite", len(entries))
+	}
+}
+
+func TestSliceLog_KeepsLastLines(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Run Test Suite: res://tests/test_a.gd\n")
+	for range maxSuiteLogLines + 10 {
+		b.WriteString("noise\n")
+	}
+	b.WriteString("the failure\n")
+	path := writeLog(t, b.String())
+
+	slices, err := SliceLog(path, SliceOptions{Suites: map[string]bool{"res://tests/test_a.gd": true}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log := slices[0].Log
+	if !strings.HasPrefix(log, "... (12 earlier lines)\n") || !strings.HasSuffix(log, "the failure") {
+		t.Errorf("Log = %q...%q", log[:40], log[len(log)-20:])
+	}
+}
+
+func TestTestResult(t *testing.T) {
+	tests := []struct {
+		line                string
+		suite, test, status string
+		ok                  bool
+	}{
+		{"Run Test: res://tests/test_math.gd > test_sub :FAILED 12ms", "res://tests/test_math.gd", "test_sub", "failed", true},
+		{"  Run Test: res://tests/test_math.gd > test_add :PASSED 3ms", "res://tests/test_math.gd", "test_add", "passed", true},
+		{"Run Test: res://tests/test_io.gd > test_read :ERRORS 5ms", "res://tests/test_io.gd", "test_read", "error", true},
+		{"Run Test: res://tests/test_math.gd > test_add :STARTED", "", "", "", false},
+		{"Run Test Suite: res://tests/test_math.gd", "", "", "", false},
+	}
+	for _, tt := range tests {
+		suite, test, status, ok := TestResult(tt.line)
+		if suite != tt.suite || test != tt.test || status != tt.status || ok != tt.ok {
+			t.Errorf("TestResult(%q) = %q, %q, %q, %v", tt.line, suite, test, status, ok)
+		}
+	}
+}
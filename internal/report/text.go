@@ -0,0 +1,130 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/i18n"
+)
+
+// WriteText writes out as a human-readable plain-text summary without ANSI escape
+// codes, suited to CI consoles that do not render colors:
+//
+//	gdUnit4: failed (8 passed, 2 failed of 10)
+//
+//	FAILED MathTest.test_add (res://tests/MathTest.gd:7)
+//	  expected: 3
+//	  actual:   4
+//
+// Errored tests are counted and listed as ERROR. The text is in lang, except
+// for the gdUnit4:, FAILED, ERROR and CRASHED markers that log matching relies on.
+func WriteText(w io.Writer, out *Output, lang i18n.Lang) error {
+	return writeText(w, out, lang, func(Failure) string { return "" })
+}
+
+// WriteTerminalText is WriteText for a terminal: with the editor link template
+// tmpl (see EditorLinkTemplate), the location of each failure is a hyperlink
+// opening it in the editor. projectDir is as for EditorLink.
+func WriteTerminalText(w io.Writer, out *Output, lang i18n.Lang, tmpl, projectDir string) error {
+	if tmpl == "" {
+		return WriteText(w, out, lang)
+	}
+	return writeText(w, out, lang, func(f Failure) string { return EditorLink(tmpl, projectDir, f) })
+}
+
+// writeText writes the text of WriteText, with the location of each failure
+// a hyperlink to its link, if it has one.
+func writeText(w io.Writer, out *Output, lang i18n.Lang, link func(Failure) string) error {
+	// sb holds text not yet stripped of ANSI codes into text, which the
+	// hyperlinks are written to directly.
+	var sb, text strings.Builder
+	flush := func() {
+		text.WriteString(stripANSI(sb.String()))
+		sb.Reset()
+	}
+	s := out.Summary
+	fmt.Fprintf(&sb, "gdUnit4: %s (%s)\n", lang.T(s.Status), s.CountsIn(lang))
+
+	for _, f := range out.Failures {
+		label := "FAILED"
+		if f.Kind == KindError {
+			label = "ERROR"
+		}
+		fmt.Fprintf(&sb, "\n%s %s.%s (", label, f.Class, f.Method)
+		loc := fmt.Sprintf("%s:%d", f.File, f.Line)
+		if uri := link(f); uri != "" {
+			flush()
+			text.WriteString(hyperlink(uri, stripANSI(loc)))
+		} else {
+			sb.WriteString(loc)
+		}
+		sb.WriteString(")\n")
+		if f.Suite != "" {
+			sb.WriteString(lang.Sprintf("  in suite %s\n", f.Suite))
+		}
+		if f.Occurrences > 1 {
+			sb.WriteString(lang.Sprintf("  failed %d times (attempts %s)\n", f.Occurrences, strings.Trim(fmt.Sprint(f.Attempts), "[]")))
+		}
+		if f.Expected != "" || f.Actual != "" {
+			sb.WriteString(lang.Sprintf("  expected: %s\n  actual:   %s\n", f.Expected, f.Actual))
+		} else if msg := strings.TrimSpace(f.Message); msg != "" {
+			fmt.Fprintf(&sb, "  %s\n", strings.ReplaceAll(msg, "\n", "\n  "))
+		}
+		for _, p := range f.Parameters {
+			if p.Status == "failed" {
+				sb.WriteString(lang.Sprintf("  case %d (%s) failed at line %d\n", p.Index, p.Args, p.Line))
+			}
+		}
+	}
+	if c := out.CrashDetails; c != nil {
+		sb.WriteString("\nCRASHED\n")
+		for _, block := range []string{c.CrashInfo, c.ScriptErrors} {
+			if block != "" {
+				fmt.Fprintf(&sb, "  %s\n", strings.ReplaceAll(block, "\n", "\n  "))
+			}
+		}
+	}
+	if c := out.Coverage; c != nil {
+		sb.WriteString(lang.Sprintf("\nline coverage: %.1f%% (%d/%d)\n", c.Percent, c.LinesCovered, c.LinesValid))
+	}
+
+	flush()
+	if _, err := io.WriteString(w, text.String()); err != nil {
+		return fmt.Errorf("failed to write text output: %w", err)
+	}
+	return nil
+}
+
+// SummaryLine renders the verdict of a run as one line for the end of the
+// stderr output, e.g. "FAILED: 3/120 tests, 1 suite crashed, 1m34s": the
+// status, the failed and errored tests of the total, and how long the run
+// took. Like WriteText it is in lang except for the status marker.
+func SummaryLine(out *Output, elapsed time.Duration, lang i18n.Lang) string {
+	s := out.Summary
+	line := strings.ToUpper(s.Status) + ": "
+	if bad := s.Failed + s.Errors; bad > 0 {
+		line += lang.Sprintf("%d/%d tests", bad, s.Total)
+	} else {
+		line += lang.Sprintf("%d tests", s.Total)
+	}
+	if s.Crashed {
+		// Each crashed Godot took down the suite it was running.
+		crashed := 0
+		for _, p := range out.Projects {
+			if p.Summary != nil && p.Summary.Crashed {
+				crashed++
+			}
+		}
+		if crashed <= 1 {
+			line += lang.T(", 1 suite crashed")
+		} else {
+			line += lang.Sprintf(", %d suites crashed", crashed)
+		}
+	}
+	if out.Incomplete {
+		line += lang.T(", incomplete")
+	}
+	return line + lang.Sprintf(", %s", elapsed.Round(time.Second))
+}
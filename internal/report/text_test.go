@@ -0,0 +1,75 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/i18n"
+)
+
+func TestWriteText(t *testing.T) {
+	out := &Output{
+		Summary: Summary{Total: 3, Passed: 1, Failed: 2, Crashed: true, Status: "crashed"},
+		Failures: []Failure{
+			{Class: "TestMath", Method: "test_sub", File: "res://tests/test_math.gd", Line: 7, Expected: "1", Actual: "2"},
+			{Class: "TestUi", Method: "test_menu", File: "res://tests/test_ui.gd", Line: 3, Message: "\x1b[31mline one\x1b[0m\nline two"},
+		},
+		CrashDetails: &CrashDetails{ScriptErrors: "SCRIPT ERROR: boom\n   at: res://a.gd:1"},
+	}
+
+	var sb strings.Builder
+	if err := WriteText(&sb, out, i18n.English); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "gdUnit4: crashed (1 passed, 2 failed of 3)\n" +
+		"\nFAILED TestMath.test_sub (res://tests/test_math.gd:7)\n  expected: 1\n  actual:   2\n" +
+		"\nFAILED TestUi.test_menu (res://tests/test_ui.gd:3)\n  line one\n  line two\n" +
+		"\nCRASHED\n  SCRIPT ERROR: boom\n     at: res://a.gd:1\n"
+	if sb.String() != want {
+		t.Errorf("WriteText =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
+
+func TestWriteText_Japanese(t *testing.T) {
+	out := &Output{
+		Summary:  Summary{Total: 10, Passed: 8, Failed: 1, Skipped: 1, Status: "failed"},
+		Failures: []Failure{{Class: "TestMath", Method: "test_sub", File: "res://tests/test_math.gd", Line: 7, Expected: "1", Actual: "2"}},
+	}
+
+	var sb strings.Builder
+	if err := WriteText(&sb, out, i18n.Japanese); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "gdUnit4: 失敗 (全 10 件中 成功 8 件、失敗 1 件、スキップ 1 件)\n" +
+		"\nFAILED TestMath.test_sub (res://tests/test_math.gd:7)\n  期待値: 1\n  実際値: 2\n"
+	if sb.String() != want {
+		t.Errorf("WriteText =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
+
+func TestSummaryLine(t *testing.T) {
+	tests := []struct {
+		name string
+		out  *Output
+		lang i18n.Lang
+		want string
+	}{
+		{"passed", &Output{Summary: Summary{Total: 120, Passed: 120, Status: "passed"}}, i18n.English, "PASSED: 120 tests, 1m34s"},
+		{"failed", &Output{Summary: Summary{Total: 120, Passed: 117, Failed: 2, Errors: 1, Status: "failed"}}, i18n.English, "FAILED: 3/120 tests, 1m34s"},
+		{"crashed", &Output{Summary: Summary{Total: 120, Failed: 3, Crashed: true, Status: "crashed"}}, i18n.English, "CRASHED: 3/120 tests, 1 suite crashed, 1m34s"},
+		{"crashed projects", &Output{
+			Summary:  Summary{Total: 10, Crashed: true, Status: "crashed"},
+			Projects: []ProjectResult{{Summary: &Summary{Crashed: true}}, {Summary: &Summary{}}, {Summary: &Summary{Crashed: true}}, {}},
+		}, i18n.English, "CRASHED: 10 tests, 2 suites crashed, 1m34s"},
+		{"incomplete", &Output{Summary: Summary{Total: 5, Passed: 5, Status: "passed"}, Incomplete: true}, i18n.English, "PASSED: 5 tests, incomplete, 1m34s"},
+		{"japanese", &Output{Summary: Summary{Total: 120, Failed: 3, Status: "failed"}}, i18n.Japanese, "FAILED: 3/120 件のテスト、1m34s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SummaryLine(tt.out, 94*time.Second+300*time.Millisecond, tt.lang); got != tt.want {
+				t.Errorf("SummaryLine = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// TransientErrors are signatures of Godot failures that say nothing about the
+// tests and usually go away on the next attempt.
+var TransientErrors = []string{
+	// The GPU driver reset or the device went away, typically on shared CI runners.
+	`VK_ERROR_DEVICE_LOST`,
+	`(?i)vulkan.*device (was )?lost`,
+	// No display server yet, e.g. an Xvfb still starting.
+	`(?i)(can ?not|could ?n[o']t|failed to) (open|connect to) (the )?(x11 |wayland )?display`,
+	`(?i)X11 Display is not available`,
+	// The first run after a checkout, before Godot built its class cache,
+	// can find the gdUnit4 addon's classes missing.
+	`Could not find (type|base class) "GdUnit\w*"`,
+	`Unable to load addon script from path: '[^']*gdUnit4`,
+}
+
+// FindTransient returns the first line of the Godot log at logPath matching
+// one of patterns, if the log holds no script errors other than such lines.
+// An empty string means the failure does not look transient.
+func FindTransient(logPath string, patterns []*regexp.Regexp) (string, error) {
+	if len(patterns) == 0 {
+		return "", nil
+	}
+	f, err := os.Open(logPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	var found string
+	other := false
+	err = StreamLog(f, 0, func(line string) error {
+		for _, re := range patterns {
+			if re.MatchString(line) {
+				if found == "" {
+					found = line
+				}
+				return nil
+			}
+		}
+		// Crash lines are expected after a lost device; script errors are not.
+		if ClassifyLine(line) == LineScriptError {
+			other = true
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read log file: %w", err)
+	}
+	if other {
+		return "", nil
+	}
+	return found, nil
+}
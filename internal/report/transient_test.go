@@ -0,0 +1,55 @@
+package report
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFindTransient(t *testing.T) {
+	var patterns []*regexp.Regexp
+	for _, p := range TransientErrors {
+		patterns = append(patterns, regexp.MustCompile(p))
+	}
+	tests := []struct {
+		name string
+		log  string
+		want string
+	}{
+		{
+			name: "device lost",
+			log:  "Godot Engine v4.3.stable\nERROR: Vulkan: VK_ERROR_DEVICE_LOST\nhandle_crash: signal 11\n",
+			want: "ERROR: Vulkan: VK_ERROR_DEVICE_LOST",
+		},
+		{
+			name: "no display",
+			log:  "X11 Display is not available\n",
+			want: "X11 Display is not available",
+		},
+		{
+			name: "addon classes missing",
+			log:  "SCRIPT ERROR: Parse Error: Could not find base class \"GdUnitTestSuite\".\n",
+			want: "SCRIPT ERROR: Parse Error: Could not find base class \"GdUnitTestSuite\".",
+		},
+		{
+			name: "with a real script error",
+			log:  "ERROR: Vulkan: VK_ERROR_DEVICE_LOST\nSCRIPT ERROR: Invalid call. Nonexistent function 'foo'.\n",
+			want: "",
+		},
+		{
+			name: "plain crash",
+			log:  "handle_crash: signal 11\n",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindTransient(writeLog(t, tt.log), patterns)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FindTransient = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
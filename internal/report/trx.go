@@ -0,0 +1,216 @@
+package report
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// TRX fixed IDs: the unit test type, and the default test list every result
+// belongs to.
+const (
+	trxUnitTestType = "13cdc9d9-ddb5-4fa4-a97d-d965ccfc6d4b"
+	trxListNotInAny = "8c84fa94-04c1-424b-9868-57a2d4851a1d"
+	trxListAll      = "19431567-8539-422a-85d7-44ee4e166bda"
+)
+
+// trxRun is the root <TestRun> element of a Visual Studio test results file.
+type trxRun struct {
+	XMLName     xml.Name         `xml:"TestRun"`
+	Xmlns       string           `xml:"xmlns,attr"`
+	ID          string           `xml:"id,attr"`
+	Name        string           `xml:"name,attr"`
+	Times       trxTimes         `xml:"Times"`
+	Results     []trxResult      `xml:"Results>UnitTestResult"`
+	Definitions []trxUnitTest    `xml:"TestDefinitions>UnitTest"`
+	Entries     []trxEntry       `xml:"TestEntries>TestEntry"`
+	Lists       []trxList        `xml:"TestLists>TestList"`
+	Summary     trxResultSummary `xml:"ResultSummary"`
+}
+
+type trxTimes struct {
+	Creation string `xml:"creation,attr"`
+	Queuing  string `xml:"queuing,attr"`
+	Start    string `xml:"start,attr"`
+	Finish   string `xml:"finish,attr"`
+}
+
+type trxResult struct {
+	ExecutionID  string     `xml:"executionId,attr"`
+	TestID       string     `xml:"testId,attr"`
+	TestName     string     `xml:"testName,attr"`
+	ComputerName string     `xml:"computerName,attr"`
+	Duration     string     `xml:"duration,attr"`
+	StartTime    string     `xml:"startTime,attr"`
+	EndTime      string     `xml:"endTime,attr"`
+	TestType     string     `xml:"testType,attr"`
+	Outcome      string     `xml:"outcome,attr"`
+	TestListID   string     `xml:"testListId,attr"`
+	Output       *trxOutput `xml:"Output,omitempty"`
+}
+
+type trxOutput struct {
+	StdOut    string        `xml:"StdOut,omitempty"`
+	ErrorInfo *trxErrorInfo `xml:"ErrorInfo,omitempty"`
+}
+
+type trxErrorInfo struct {
+	Message    string `xml:"Message"`
+	StackTrace string `xml:"StackTrace,omitempty"`
+}
+
+type trxUnitTest struct {
+	Name      string        `xml:"name,attr"`
+	Storage   string        `xml:"storage,attr"`
+	ID        string        `xml:"id,attr"`
+	Execution trxExecution  `xml:"Execution"`
+	Method    trxTestMethod `xml:"TestMethod"`
+}
+
+type trxExecution struct {
+	ID string `xml:"id,attr"`
+}
+
+type trxTestMethod struct {
+	CodeBase        string `xml:"codeBase,attr"`
+	AdapterTypeName string `xml:"adapterTypeName,attr"`
+	ClassName       string `xml:"className,attr"`
+	Name            string `xml:"name,attr"`
+}
+
+type trxEntry struct {
+	TestID      string `xml:"testId,attr"`
+	ExecutionID string `xml:"executionId,attr"`
+	TestListID  string `xml:"testListId,attr"`
+}
+
+type trxList struct {
+	Name string `xml:"name,attr"`
+	ID   string `xml:"id,attr"`
+}
+
+type trxResultSummary struct {
+	Outcome  string      `xml:"outcome,attr"`
+	Counters trxCounters `xml:"Counters"`
+}
+
+type trxCounters struct {
+	Total       int `xml:"total,attr"`
+	Executed    int `xml:"executed,attr"`
+	Passed      int `xml:"passed,attr"`
+	Failed      int `xml:"failed,attr"`
+	Error       int `xml:"error,attr"`
+	NotExecuted int `xml:"notExecuted,attr"`
+}
+
+// WriteTRX writes suites as a Visual Studio test results (TRX) document to w,
+// for Azure DevOps and VS tooling. Like WriteJUnitXML it keeps gdUnit4's own
+// results, and if suites is nil (Godot produced no report) a single failed
+// test describes the crash. stop is when the run ended; gdUnit4 reports only
+// durations, so the tests' start times are laid out back from it.
+func WriteTRX(w io.Writer, suites *JUnitTestSuites, crash *CrashDetails, stop time.Time) error {
+	if suites == nil {
+		suites = crashSuites(crash)
+	}
+	host, _ := os.Hostname()
+	start := runStart(suites, stop)
+	run := trxRun{
+		Xmlns: "http://microsoft.com/schemas/VisualStudio/TeamTest/2010",
+		ID:    newUUID(),
+		Name:  "gdunit4-test-runner " + stop.Format("2006-01-02 15:04:05"),
+		Times: trxTimes{Creation: trxTime(stop), Queuing: trxTime(start), Start: trxTime(start), Finish: trxTime(stop)},
+		Lists: []trxList{{Name: "Results Not in a List", ID: trxListNotInAny}, {Name: "All Loaded Results", ID: trxListAll}},
+	}
+	c := &run.Summary.Counters
+
+	at := start
+	for _, s := range suites.Suites {
+		for _, tc := range s.TestCases {
+			end := at.Add(time.Duration(tc.Time * float64(time.Second)))
+			name := tc.Classname + "." + tc.Name
+			testID, execID := guid(md5Hex(cmp.Or(s.Package, s.Name)+"::"+name)), newUUID()
+			r := trxResult{
+				ExecutionID:  execID,
+				TestID:       testID,
+				TestName:     name,
+				ComputerName: host,
+				Duration:     trxDuration(end.Sub(at)),
+				StartTime:    trxTime(at),
+				EndTime:      trxTime(end),
+				TestType:     trxUnitTestType,
+				Outcome:      "Passed",
+				TestListID:   trxListNotInAny,
+			}
+			c.Total++
+			switch f := cmp.Or(tc.Failure, tc.Error); {
+			case tc.Skipped != nil:
+				r.Outcome = "NotExecuted"
+				r.Output = &trxOutput{StdOut: cmp.Or(tc.Skipped.Message, tc.Skipped.Text)}
+				c.NotExecuted++
+			case f != nil:
+				r.Outcome = "Failed"
+				r.Output = &trxOutput{ErrorInfo: &trxErrorInfo{Message: f.Message, StackTrace: strings.TrimSpace(f.Text)}}
+				c.Executed++
+				c.Failed++
+			default:
+				c.Executed++
+				c.Passed++
+			}
+			run.Results = append(run.Results, r)
+			run.Definitions = append(run.Definitions, trxUnitTest{
+				Name:      name,
+				Storage:   s.Package,
+				ID:        testID,
+				Execution: trxExecution{ID: execID},
+				Method: trxTestMethod{
+					CodeBase:        s.Package,
+					AdapterTypeName: "executor://gdunit4/",
+					ClassName:       tc.Classname,
+					Name:            tc.Name,
+				},
+			})
+			run.Entries = append(run.Entries, trxEntry{TestID: testID, ExecutionID: execID, TestListID: trxListNotInAny})
+			at = end
+		}
+	}
+	run.Summary.Outcome = "Completed"
+	if c.Failed > 0 || crash != nil {
+		run.Summary.Outcome = "Failed"
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write TRX: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(run); err != nil {
+		return fmt.Errorf("failed to write TRX: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write TRX: %w", err)
+	}
+	return nil
+}
+
+// trxTime formats t as TRX timestamps are.
+func trxTime(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05.0000000-07:00")
+}
+
+// trxDuration formats d as a TRX duration, hh:mm:ss.fffffff.
+func trxDuration(d time.Duration) string {
+	h := d / time.Hour
+	m := d % time.Hour / time.Minute
+	s := d % time.Minute / time.Second
+	frac := d % time.Second / 100
+	return fmt.Sprintf("%02d:%02d:%02d.%07d", h, m, s, frac)
+}
+
+// guid formats the first 32 hex digits of h as a GUID.
+func guid(h string) string {
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTRX(t *testing.T) {
+	suites := &JUnitTestSuites{Suites: []JUnitTestSuite{{
+		Name:    "MathTest",
+		Package: "res://tests/MathTest.gd",
+		TestCases: []JUnitTestCase{
+			{Name: "test_add", Classname: "MathTest", Time: 1.25},
+			{Name: "test_sub", Classname: "MathTest", Time: 0.5, Failure: &JUnitFailure{Message: "FAILED: res://tests/MathTest.gd:7", Text: "Expected '1' but was '2'"}},
+			{Name: "test_div", Classname: "MathTest", Skipped: &JUnitFailure{Message: "not yet"}},
+		},
+	}}}
+	var sb strings.Builder
+	if err := WriteTRX(&sb, suites, nil, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var run trxRun
+	if err := xml.Unmarshal([]byte(sb.String()), &run); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, sb.String())
+	}
+	if len(run.Results) != 3 || len(run.Definitions) != 3 || len(run.Entries) != 3 {
+		t.Fatalf("got %d results, %d definitions, %d entries; want 3 each", len(run.Results), len(run.Definitions), len(run.Entries))
+	}
+	outcomes := []string{run.Results[0].Outcome, run.Results[1].Outcome, run.Results[2].Outcome}
+	if strings.Join(outcomes, ",") != "Passed,Failed,NotExecuted" {
+		t.Errorf("outcomes = %v", outcomes)
+	}
+	if r := run.Results[0]; r.Duration != "00:00:01.2500000" || r.StartTime != "2024-05-01T11:59:58.2500000+00:00" {
+		t.Errorf("test_add duration %s, start %s", r.Duration, r.StartTime)
+	}
+	if e := run.Results[1].Output; e == nil || e.ErrorInfo == nil || e.ErrorInfo.StackTrace != "Expected '1' but was '2'" {
+		t.Errorf("test_sub output = %+v", e)
+	}
+	if run.Results[0].TestID != run.Definitions[0].ID || run.Definitions[0].Execution.ID != run.Results[0].ExecutionID {
+		t.Error("result and definition IDs do not match")
+	}
+	c := run.Summary.Counters
+	if run.Summary.Outcome != "Failed" || c.Total != 3 || c.Executed != 2 || c.Passed != 1 || c.Failed != 1 || c.NotExecuted != 1 {
+		t.Errorf("summary = %+v", run.Summary)
+	}
+
+	// Test IDs are stable across runs.
+	var again strings.Builder
+	if err := WriteTRX(&again, suites, nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(again.String(), `testId="`+run.Results[0].TestID+`"`) {
+		t.Error("test ID changed between runs")
+	}
+}
+
+func TestWriteTRX_Crash(t *testing.T) {
+	var sb strings.Builder
+	if err := WriteTRX(&sb, nil, &CrashDetails{CrashInfo: "SIGSEGV"}, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(sb.String(), `outcome="Failed"`) || !strings.Contains(sb.String(), "SIGSEGV") {
+		t.Errorf("TRX = %s, want a failed test describing the crash", sb.String())
+	}
+}
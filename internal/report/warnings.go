@@ -0,0 +1,143 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// scriptErrorLocRe matches the file and line in the location Godot prints below a script
+// error, e.g. "   at: GDScript::reload (res://tests/test_math.gd:12)" or
+// "   at: res://tests/test_math.gd:12 (TestMath::test_add)".
+var scriptErrorLocRe = regexp.MustCompile(`(res://[^\s():]+):(\d+)`)
+
+// ScriptError is a GDScript error reported in the Godot log.
+type ScriptError struct {
+	Message string
+	File    string // res:// path; empty if Godot printed no location
+	Line    int
+}
+
+// ParseScriptErrors splits CrashDetails.ScriptErrors into individual errors,
+// attaching the "at:" location line that follows each one.
+func ParseScriptErrors(s string) []ScriptError {
+	var errs []ScriptError
+	for _, line := range strings.Split(s, "\n") {
+		switch {
+		case strings.HasPrefix(line, "SCRIPT ERROR:"):
+			errs = append(errs, ScriptError{Message: strings.TrimSpace(strings.TrimPrefix(line, "SCRIPT ERROR:"))})
+		case len(errs) > 0 && strings.HasPrefix(strings.TrimSpace(line), "at:"):
+			if m := scriptErrorLocRe.FindStringSubmatch(line); m != nil {
+				last := &errs[len(errs)-1]
+				last.File = m[1]
+				last.Line, _ = strconv.Atoi(m[2])
+			}
+		}
+	}
+	return errs
+}
+
+// warningsNGIssue is an issue in the native JSON format of the Jenkins Warnings
+// Next Generation plugin. See https://github.com/jenkinsci/warnings-ng-plugin/blob/main/doc/Documentation.md#export-your-issues-into-a-supported-format.
+type warningsNGIssue struct {
+	FileName  string `json:"fileName"`
+	LineStart int    `json:"lineStart,omitempty"`
+	Severity  string `json:"severity"`
+	Category  string `json:"category"`
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+}
+
+// WriteWarningsNG writes the script errors of out as a warnings-ng issue report.
+// pathPrefix is prepended to the project-relative file of each error so that file
+// names resolve against the Jenkins workspace.
+func WriteWarningsNG(w io.Writer, out *Output, pathPrefix string) error {
+	issues := []warningsNGIssue{}
+	if out.CrashDetails != nil {
+		for _, e := range ParseScriptErrors(out.CrashDetails.ScriptErrors) {
+			issue := warningsNGIssue{Severity: "ERROR", Category: "GDScript", Type: "SCRIPT ERROR", Message: e.Message, LineStart: e.Line}
+			if e.File != "" {
+				issue.FileName = path.Join(pathPrefix, strings.TrimPrefix(e.File, "res://"))
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(struct {
+		Issues []warningsNGIssue `json:"issues"`
+	}{issues}); err != nil {
+		return fmt.Errorf("failed to write warnings-ng report: %w", err)
+	}
+	return nil
+}
+
+// checkstyleFile lists the errors in one file of a Checkstyle report.
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// checkstyleVersion is the Checkstyle release whose report format
+// WriteCheckstyle writes.
+const checkstyleVersion = "8.0"
+
+// WriteCheckstyle writes the script errors of out as a Checkstyle XML report,
+// as reviewdog and many CI annotation plugins read, with a file element per
+// script. Parse errors have the source GDScript.ParseError and other errors
+// GDScript.ScriptError. pathPrefix is prepended to the project-relative file of
+// each error; errors Godot printed no location for are left out, as Checkstyle
+// ties every error to a file.
+func WriteCheckstyle(w io.Writer, out *Output, pathPrefix string) error {
+	var files []checkstyleFile
+	index := map[string]int{}
+	if out.CrashDetails != nil {
+		for _, e := range ParseScriptErrors(out.CrashDetails.ScriptErrors) {
+			if e.File == "" {
+				continue
+			}
+			name := path.Join(pathPrefix, strings.TrimPrefix(e.File, "res://"))
+			i, ok := index[name]
+			if !ok {
+				i = len(files)
+				index[name] = i
+				files = append(files, checkstyleFile{Name: name})
+			}
+			source := "GDScript.ScriptError"
+			if strings.HasPrefix(e.Message, "Parse Error") {
+				source = "GDScript.ParseError"
+			}
+			files[i].Errors = append(files[i].Errors, checkstyleError{Line: e.Line, Severity: "error", Message: e.Message, Source: source})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write Checkstyle report: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(struct {
+		XMLName xml.Name         `xml:"checkstyle"`
+		Version string           `xml:"version,attr"`
+		Files   []checkstyleFile `xml:"file"`
+	}{Version: checkstyleVersion, Files: files}); err != nil {
+		return fmt.Errorf("failed to write Checkstyle report: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write Checkstyle report: %w", err)
+	}
+	return nil
+}
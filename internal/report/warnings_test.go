@@ -0,0 +1,103 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseScriptErrors(t *testing.T) {
+	s := "SCRIPT ERROR: Parse Error: Identifier \"x\" not declared.\n" +
+		"   at: GDScript::reload (res://tests/test_math.gd:12)\n" +
+		"SCRIPT ERROR: Invalid call.\n" +
+		"   at: res://tests/unit/TestSuiteA.gd:55 (TestSuiteA::test_crash)\n" +
+		"SCRIPT ERROR: no location"
+	got := ParseScriptErrors(s)
+	want := []ScriptError{
+		{Message: "Parse Error: Identifier \"x\" not declared.", File: "res://tests/test_math.gd", Line: 12},
+		{Message: "Invalid call.", File: "res://tests/unit/TestSuiteA.gd", Line: 55},
+		{Message: "no location"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d errors, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("error %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWriteWarningsNG(t *testing.T) {
+	out := &Output{CrashDetails: &CrashDetails{ScriptErrors: "SCRIPT ERROR: Invalid call.\n   at: test_a (res://tests/a.gd:5)"}}
+	var sb strings.Builder
+	if err := WriteWarningsNG(&sb, out, "game"); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Issues []map[string]any `json:"issues"`
+	}
+	if err := json.Unmarshal([]byte(sb.String()), &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", sb.String(), err)
+	}
+	if len(doc.Issues) != 1 {
+		t.Fatalf("issues = %v, want 1", doc.Issues)
+	}
+	is := doc.Issues[0]
+	if is["fileName"] != "game/tests/a.gd" || is["lineStart"] != float64(5) || is["severity"] != "ERROR" || is["message"] != "Invalid call." {
+		t.Errorf("issue = %v", is)
+	}
+
+	sb.Reset()
+	if err := WriteWarningsNG(&sb, &Output{}, ""); err != nil || !strings.Contains(sb.String(), `"issues": []`) {
+		t.Errorf("empty report = %q, %v", sb.String(), err)
+	}
+}
+
+func TestWriteCheckstyle(t *testing.T) {
+	out := &Output{CrashDetails: &CrashDetails{ScriptErrors: "SCRIPT ERROR: Parse Error: Identifier \"x\" not declared.\n" +
+		"   at: GDScript::reload (res://tests/a.gd:12)\n" +
+		"SCRIPT ERROR: Invalid call.\n   at: test_a (res://tests/a.gd:5)\n" +
+		"SCRIPT ERROR: Invalid index.\n   at: test_b (res://tests/b.gd:3)\n" +
+		"SCRIPT ERROR: no location"}}
+	var sb strings.Builder
+	if err := WriteCheckstyle(&sb, out, "game"); err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="8.0">
+  <file name="game/tests/a.gd">
+    <error line="12" severity="error" message="Parse Error: Identifier &#34;x&#34; not declared." source="GDScript.ParseError"></error>
+    <error line="5" severity="error" message="Invalid call." source="GDScript.ScriptError"></error>
+  </file>
+  <file name="game/tests/b.gd">
+    <error line="3" severity="error" message="Invalid index." source="GDScript.ScriptError"></error>
+  </file>
+</checkstyle>
+`
+	if sb.String() != want {
+		t.Errorf("WriteCheckstyle =\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	sb.Reset()
+	if err := WriteCheckstyle(&sb, &Output{}, ""); err != nil || !strings.Contains(sb.String(), `<checkstyle version="8.0"></checkstyle>`) {
+		t.Errorf("empty report = %q, %v", sb.String(), err)
+	}
+}
+
+func TestDetectCrash_KeepsScriptErrorLocation(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "godot.log")
+	content := "SCRIPT ERROR: Invalid call.\n   at: test_a (res://tests/a.gd:5)\nsome output\n   at: unrelated\n"
+	if err := os.WriteFile(log, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := DetectCrash(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ScriptErrors != "SCRIPT ERROR: Invalid call.\n   at: test_a (res://tests/a.gd:5)" {
+		t.Errorf("ScriptErrors = %q", got.ScriptErrors)
+	}
+}
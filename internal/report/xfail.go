@@ -0,0 +1,56 @@
+package report
+
+import "slices"
+
+// TagXFail is the tag ("# @tag: xfail") of a test expected to fail.
+const TagXFail = "xfail"
+
+// ApplyXFail reports the tests for which expected returns true as expected
+// failures: their failed and errored cases move from out.Failures to
+// out.XFailures and are counted as xfail instead of failed, and their passed
+// cases are counted as xpass, and those without a failed case listed in
+// out.XPassed. The status follows the new counts. suites are the results out
+// was built from.
+func ApplyXFail(out *Output, suites *JUnitTestSuites, expected func(class, method string) bool) {
+	if suites == nil {
+		return
+	}
+	s := &out.Summary
+	for _, suite := range suites.Suites {
+		for _, tc := range suite.TestCases {
+			method := tc.Name
+			if m := paramCaseRe.FindStringSubmatch(tc.Name); m != nil {
+				method = m[1]
+			}
+			if tc.Skipped != nil || !expected(tc.Classname, method) {
+				continue
+			}
+			switch {
+			case tc.Failure != nil:
+				s.Failed--
+				s.XFail++
+			case tc.Error != nil:
+				s.Errors--
+				s.XFail++
+			default:
+				s.Passed--
+				s.XPass++
+				if name := tc.Classname + "." + method; !slices.Contains(out.XPassed, name) {
+					out.XPassed = append(out.XPassed, name)
+				}
+			}
+		}
+	}
+	out.Failures = slices.DeleteFunc(out.Failures, func(f Failure) bool {
+		if expected(f.Class, f.Method) {
+			out.XFailures = append(out.XFailures, f)
+			return true
+		}
+		return false
+	})
+	// A parameterized test with failed cases did fail as expected.
+	out.XPassed = slices.DeleteFunc(out.XPassed, func(name string) bool {
+		return slices.ContainsFunc(out.XFailures, func(f Failure) bool { return f.Class+"."+f.Method == name })
+	})
+	s.Status = Status(s.Crashed, s.Failed, s.Errors)
+}
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyXFail(t *testing.T) {
+	src := `<testsuites tests="5" failures="2" errors="0">
+  <testsuite name="MathTest" tests="5" failures="2" errors="0">
+    <testcase name="test_known_bug" classname="MathTest">
+      <failure message="FAILED: res://tests/MathTest.gd:5"/>
+    </testcase>
+    <testcase name="test_fixed_bug" classname="MathTest"/>
+    <testcase name="test_real" classname="MathTest">
+      <failure message="FAILED: res://tests/MathTest.gd:9"/>
+    </testcase>
+    <testcase name="test_plain" classname="MathTest"/>
+    <testcase name="test_later" classname="MathTest">
+      <skipped message="SKIPPED: res://tests/MathTest.gd:20">not on this platform</skipped>
+    </testcase>
+  </testsuite>
+</testsuites>`
+	out, err := BuildOutputFromXML(strings.NewReader(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Summary.Skipped != 1 || out.Summary.Passed != 2 {
+		t.Fatalf("Summary = %+v, want 1 skipped and 2 passed", out.Summary)
+	}
+	if want := []Skip{{Class: "MathTest", Method: "test_later", Reason: "not on this platform"}}; !reflect.DeepEqual(out.Skipped, want) {
+		t.Errorf("Skipped = %+v, want %+v", out.Skipped, want)
+	}
+
+	var suites *JUnitTestSuites
+	if err := xml.Unmarshal([]byte(src), &suites); err != nil {
+		t.Fatal(err)
+	}
+	ApplyXFail(out, suites, func(class, method string) bool {
+		return method == "test_known_bug" || method == "test_fixed_bug" || method == "test_later"
+	})
+	s := out.Summary
+	if s.Failed != 1 || s.XFail != 1 || s.XPass != 1 || s.Passed != 1 || s.Skipped != 1 || s.Status != "failed" {
+		t.Errorf("Summary = %+v, want 1 failed, 1 xfail, 1 xpass, 1 passed, 1 skipped", s)
+	}
+	if len(out.Failures) != 1 || out.Failures[0].Method != "test_real" {
+		t.Errorf("Failures = %+v, want only test_real", out.Failures)
+	}
+	if len(out.XFailures) != 1 || out.XFailures[0].Method != "test_known_bug" {
+		t.Errorf("XFailures = %+v, want test_known_bug", out.XFailures)
+	}
+	if !reflect.DeepEqual(out.XPassed, []string{"MathTest.test_fixed_bug"}) {
+		t.Errorf("XPassed = %v, want [MathTest.test_fixed_bug]", out.XPassed)
+	}
+}
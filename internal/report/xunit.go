@@ -0,0 +1,140 @@
+package report
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// xunitAssemblies is the root of an xUnit.net v2 XML report.
+type xunitAssemblies struct {
+	XMLName    xml.Name        `xml:"assemblies"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Assemblies []xunitAssembly `xml:"assembly"`
+}
+
+// xunitAssembly holds the whole run; xUnit.net has an assembly per test DLL.
+type xunitAssembly struct {
+	Name          string            `xml:"name,attr"`
+	TestFramework string            `xml:"test-framework,attr"`
+	RunDate       string            `xml:"run-date,attr"`
+	RunTime       string            `xml:"run-time,attr"`
+	Total         int               `xml:"total,attr"`
+	Passed        int               `xml:"passed,attr"`
+	Failed        int               `xml:"failed,attr"`
+	Skipped       int               `xml:"skipped,attr"`
+	Time          string            `xml:"time,attr"`
+	Errors        int               `xml:"errors,attr"`
+	ErrorList     struct{}          `xml:"errors"`
+	Collections   []xunitCollection `xml:"collection"`
+}
+
+// xunitCollection holds the tests of a suite.
+type xunitCollection struct {
+	Name    string      `xml:"name,attr"`
+	Total   int         `xml:"total,attr"`
+	Passed  int         `xml:"passed,attr"`
+	Failed  int         `xml:"failed,attr"`
+	Skipped int         `xml:"skipped,attr"`
+	Time    string      `xml:"time,attr"`
+	Tests   []xunitTest `xml:"test"`
+}
+
+type xunitTest struct {
+	Name    string        `xml:"name,attr"`
+	Type    string        `xml:"type,attr"`
+	Method  string        `xml:"method,attr"`
+	Time    string        `xml:"time,attr"`
+	Result  string        `xml:"result,attr"` // Pass, Fail or Skip
+	Failure *xunitFailure `xml:"failure,omitempty"`
+	Reason  string        `xml:"reason,omitempty"`
+}
+
+type xunitFailure struct {
+	ExceptionType string `xml:"exception-type,attr"`
+	Message       string `xml:"message"`
+	StackTrace    string `xml:"stack-trace,omitempty"`
+}
+
+// WriteXUnit writes suites as an xUnit.net v2 XML document to w, with a
+// collection per suite. Like WriteJUnitXML it keeps gdUnit4's own results,
+// and if suites is nil (Godot produced no report) a single failed test
+// describes the crash. stop is when the run ended.
+func WriteXUnit(w io.Writer, suites *JUnitTestSuites, crash *CrashDetails, stop time.Time) error {
+	if suites == nil {
+		suites = crashSuites(crash)
+	}
+	a := xunitAssembly{
+		Name:          "gdUnit4",
+		TestFramework: "gdUnit4",
+		RunDate:       stop.Format("2006-01-02"),
+		RunTime:       stop.Format("15:04:05"),
+	}
+	var total float64
+	for _, s := range suites.Suites {
+		c := xunitCollection{Name: cmp.Or(s.Package, s.Name)}
+		var elapsed float64
+		for _, tc := range s.TestCases {
+			method := tc.Name
+			if m := paramCaseRe.FindStringSubmatch(tc.Name); m != nil {
+				method = m[1]
+			}
+			t := xunitTest{
+				Name:   tc.Classname + "." + tc.Name,
+				Type:   tc.Classname,
+				Method: method,
+				Time:   xunitSeconds(tc.Time),
+				Result: "Pass",
+			}
+			c.Total++
+			switch {
+			case tc.Skipped != nil:
+				t.Result = "Skip"
+				t.Reason = cmp.Or(tc.Skipped.Message, tc.Skipped.Text)
+				c.Skipped++
+			case tc.Failure != nil || tc.Error != nil:
+				f, kind := tc.Failure, "failure"
+				if f == nil {
+					f, kind = tc.Error, "error"
+				}
+				t.Result = "Fail"
+				t.Failure = &xunitFailure{ExceptionType: kind, Message: f.Message, StackTrace: strings.TrimSpace(f.Text)}
+				c.Failed++
+			default:
+				c.Passed++
+			}
+			elapsed += tc.Time
+			c.Tests = append(c.Tests, t)
+		}
+		c.Time = xunitSeconds(elapsed)
+		total += elapsed
+		a.Total += c.Total
+		a.Passed += c.Passed
+		a.Failed += c.Failed
+		a.Skipped += c.Skipped
+		a.Collections = append(a.Collections, c)
+	}
+	a.Time = xunitSeconds(total)
+	doc := xunitAssemblies{Timestamp: stop.Format("01/02/2006 15:04:05"), Assemblies: []xunitAssembly{a}}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write xUnit XML: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write xUnit XML: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write xUnit XML: %w", err)
+	}
+	return nil
+}
+
+// xunitSeconds formats seconds as xUnit.net times are.
+func xunitSeconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
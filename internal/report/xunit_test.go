@@ -0,0 +1,45 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteXUnit(t *testing.T) {
+	suites := &JUnitTestSuites{Suites: []JUnitTestSuite{{
+		Name:    "MathTest",
+		Package: "res://tests/MathTest.gd",
+		TestCases: []JUnitTestCase{
+			{Name: "test_add:0 (1, 2)", Classname: "MathTest", Time: 0.25},
+			{Name: "test_sub", Classname: "MathTest", Time: 0.5, Failure: &JUnitFailure{Message: "FAILED: res://tests/MathTest.gd:7", Text: "Expected '1' but was '2'"}},
+			{Name: "test_div", Classname: "MathTest", Skipped: &JUnitFailure{Message: "not yet"}},
+		},
+	}}}
+	var sb strings.Builder
+	if err := WriteXUnit(&sb, suites, nil, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<assemblies timestamp="05/01/2024 12:00:00">
+  <assembly name="gdUnit4" test-framework="gdUnit4" run-date="2024-05-01" run-time="12:00:00" total="3" passed="1" failed="1" skipped="1" time="0.750" errors="0">
+    <errors></errors>
+    <collection name="res://tests/MathTest.gd" total="3" passed="1" failed="1" skipped="1" time="0.750">
+      <test name="MathTest.test_add:0 (1, 2)" type="MathTest" method="test_add" time="0.250" result="Pass"></test>
+      <test name="MathTest.test_sub" type="MathTest" method="test_sub" time="0.500" result="Fail">
+        <failure exception-type="failure">
+          <message>FAILED: res://tests/MathTest.gd:7</message>
+          <stack-trace>Expected &#39;1&#39; but was &#39;2&#39;</stack-trace>
+        </failure>
+      </test>
+      <test name="MathTest.test_div" type="MathTest" method="test_div" time="0.000" result="Skip">
+        <reason>not yet</reason>
+      </test>
+    </collection>
+  </assembly>
+</assemblies>
+`
+	if sb.String() != want {
+		t.Errorf("WriteXUnit =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
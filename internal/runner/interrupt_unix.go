@@ -0,0 +1,17 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// newProcessGroup prepares cmd for interrupt; nothing is needed outside Windows.
+func newProcessGroup(cmd *exec.Cmd) {}
+
+// interrupt asks the process to quit with SIGTERM.
+func interrupt(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// ctrlBreakEvent is CTRL_BREAK_EVENT of GenerateConsoleCtrlEvent.
+const ctrlBreakEvent = 1
+
+var generateConsoleCtrlEvent = syscall.NewLazyDLL("kernel32.dll").NewProc("GenerateConsoleCtrlEvent")
+
+// newProcessGroup starts cmd in a process group of its own, so that interrupt
+// can send it CTRL_BREAK without also hitting the runner.
+func newProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// interrupt asks the process to quit with CTRL_BREAK, Windows' closest
+// equivalent of SIGTERM for console programs.
+func interrupt(p *os.Process) error {
+	if r, _, err := generateConsoleCtrlEvent.Call(ctrlBreakEvent, uintptr(p.Pid)); r == 0 {
+		return err
+	}
+	return nil
+}
@@ -1,11 +1,16 @@
 package runner
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -14,15 +19,27 @@ import (
 type RunResult struct {
 	ExitCode int
 	LogFile  string // caller is responsible for removing this file
+
+	Duration time.Duration // wall-clock time from starting Godot until it exited
+	CPUTime  time.Duration // user and system CPU time of Godot
+	PID      int           // process ID Godot ran with; names its crash dumps
 }
 
+// DefaultCmdTool is the res:// path of gdUnit4's command line tool, the script
+// Godot runs, where the Asset Library installs the addon.
+const DefaultCmdTool = "res://addons/gdUnit4/bin/GdUnitCmdTool.gd"
+
 // BuildArgs constructs the Godot command arguments for gdUnit4.
 // Each path in resPaths is passed as a separate -a flag.
 func BuildArgs(resPaths []string) []string {
+	return buildArgs(DefaultCmdTool, resPaths)
+}
+
+func buildArgs(cmdTool string, resPaths []string) []string {
 	args := []string{
 		"--headless",
 		"-s",
-		"res://addons/gdUnit4/bin/GdUnitCmdTool.gd",
+		cmdTool,
 	}
 	for _, p := range resPaths {
 		args = append(args, "-a", p)
@@ -31,85 +48,220 @@ func BuildArgs(resPaths []string) []string {
 	return args
 }
 
+// Godot output channels selectable with Options.Streams.
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+	StreamAll    = "all"
+)
+
+// Options controls how Godot is executed.
+type Options struct {
+	Verbose    bool              // also write Godot output to VerboseOut
+	VerboseOut io.Writer         // where Verbose writes; nil means os.Stderr
+	Streams    string            // channels Verbose writes: StreamStdout, StreamStderr or StreamAll (the default)
+	Timeout    time.Duration     // stop Godot after this duration; 0 means no timeout
+	OnLine     func(line string) // called for each line of Godot output, if set
+	TempDir    string            // directory for the log file; empty means the OS default
+	Env        []string          // extra KEY=VALUE environment variables for Godot
+
+	// ReportDir is passed to gdUnit4 as its report directory (-rd); empty keeps
+	// gdUnit4's default, reports/ in the project.
+	ReportDir string
+
+	// CmdTool is the res:// path of the GdUnitCmdTool.gd script to run; empty
+	// means DefaultCmdTool.
+	CmdTool string
+
+	// ConfigFile, if set, is where a RunnerConfig selecting resPaths is written
+	// and passed to gdUnit4 with -conf instead of one -a argument per path.
+	ConfigFile string
+
+	// KillGrace, if set, makes stopping Godot (on Timeout or when the context
+	// is done) ask it to quit first, with SIGTERM or CTRL_BREAK on Windows, and
+	// kill it only if it still runs this long after, so that it can flush its
+	// report and log. Zero kills it at once.
+	KillGrace time.Duration
+
+	// GodotVerbose runs Godot with --verbose, which logs every resource load.
+	GodotVerbose bool
+
+	// RemoteDebug is the URI of a debugger, e.g. tcp://127.0.0.1:6007 for a
+	// Godot editor, that Godot connects to with --remote-debug; empty means none.
+	RemoteDebug string
+
+	// Interactive runs Godot with its local debugger (-d) on the runner's
+	// stdin, and writes its output to VerboseOut as it comes, prompts
+	// included, so that a developer can use the debugger prompt Godot opens
+	// on a script error. For local debugging only.
+	Interactive bool
+
+	// Render runs Godot without --headless, on a display and GPU, for tests
+	// that render.
+	Render bool
+
+	// MaxLogSize caps the log file in bytes; 0 means unlimited. Past the cap the
+	// log keeps its first and last MaxLogSize/2 bytes and notes what was cut.
+	MaxLogSize int64
+}
+
 // Run executes Godot with gdUnit4 arguments from projectDir.
 // Output is captured to a temporary log file; if verbose is true it is also written to stderr.
 // If timeout > 0, the process is killed after that duration.
 func Run(godotPath, projectDir string, resPaths []string, verbose bool, timeout time.Duration) (*RunResult, error) {
-	args := BuildArgs(resPaths)
+	res, err := RunContext(context.Background(), godotPath, projectDir, resPaths, Options{
+		Verbose: verbose,
+		Timeout: timeout,
+	})
+	if err != nil && res != nil {
+		os.Remove(res.LogFile)
+		return nil, err
+	}
+	return res, err
+}
 
-	var cmd *exec.Cmd
-	var cancelCtx context.CancelFunc
-	if timeout > 0 {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		cancelCtx = cancel
-		cmd = exec.CommandContext(ctx, godotPath, args...)
-	} else {
-		cmd = exec.Command(godotPath, args...)
+// RunContext is like Run but stops Godot when ctx is done. A stopped run
+// returns an error together with a result holding the log of the run and exit
+// code -1, for callers to salvage what Godot wrote; they must remove the log.
+func RunContext(ctx context.Context, godotPath, projectDir string, resPaths []string, opts Options) (*RunResult, error) {
+	cmdTool := cmp.Or(opts.CmdTool, DefaultCmdTool)
+	args := buildArgs(cmdTool, resPaths)
+	if opts.ConfigFile != "" {
+		if err := NewRunnerConfig(resPaths).WriteFile(opts.ConfigFile); err != nil {
+			return nil, err
+		}
+		args = buildConfigArgs(cmdTool, opts.ConfigFile)
+	}
+	if opts.ReportDir != "" {
+		args = append(args, "-rd", opts.ReportDir)
 	}
+	if opts.Render {
+		args = slices.DeleteFunc(args, func(a string) bool { return a == "--headless" })
+	}
+	if opts.GodotVerbose {
+		args = append([]string{"--verbose"}, args...)
+	}
+	if opts.RemoteDebug != "" {
+		args = append([]string{"--remote-debug", opts.RemoteDebug}, args...)
+	}
+	if opts.Interactive {
+		args = append([]string{"-d"}, args...)
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, godotPath, args...)
 	cmd.Dir = projectDir
+	if opts.KillGrace > 0 {
+		newProcessGroup(cmd)
+		cmd.Cancel = func() error { return interrupt(cmd.Process) }
+		cmd.WaitDelay = opts.KillGrace
+	}
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
 
-	tmpFile, err := os.CreateTemp("", "gdunit4-runner-*.log")
+	logFile, err := os.CreateTemp(opts.TempDir, "gdunit4-runner-*.log")
 	if err != nil {
-		if cancelCtx != nil {
-			cancelCtx()
-		}
 		return nil, fmt.Errorf("failed to create temp log file: %w", err)
 	}
-	tmpPath := tmpFile.Name()
+	tmpPath := logFile.Name()
+	defer logFile.Close()
 
 	// Always pass *os.File directly — avoids pipe creation that hangs on Windows
 	// when child processes inherit the pipe handle and keep it open after Godot exits.
-	cmd.Stdout = tmpFile
-	cmd.Stderr = tmpFile
+	// Each channel gets its own file; tailing merges them into the log file.
+	names := [2]string{StreamStdout, StreamStderr}
+	var channels [2]*os.File
+	for i, name := range names {
+		f, err := createAppend(opts.TempDir, "gdunit4-runner-*."+name)
+		if err != nil {
+			logFile.Close()
+			_ = os.Remove(tmpPath)
+			return nil, fmt.Errorf("failed to create temp %s file: %w", name, err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		channels[i] = f
+	}
+	cmd.Stdout = channels[0]
+	cmd.Stderr = channels[1]
 
 	// Redirect stdin from /dev/null (NUL on Windows) so Godot immediately gets
 	// EOF on any stdin read. This avoids hangs when Godot tries to read input.
 	devNull, devNullErr := os.Open(os.DevNull)
 	if devNullErr != nil {
-		tmpFile.Close()
+		logFile.Close()
 		_ = os.Remove(tmpPath)
-		if cancelCtx != nil {
-			cancelCtx()
-		}
 		return nil, fmt.Errorf("failed to open devnull: %w", devNullErr)
 	}
 	defer devNull.Close()
 	cmd.Stdin = devNull
 
+	verboseOut := opts.VerboseOut
+	if verboseOut == nil {
+		verboseOut = os.Stderr
+	}
+	if opts.Interactive {
+		// The debugger prompt has no newline, so the output is copied
+		// through pipes rather than echoed line by line from the channel
+		// files; WaitDelay keeps a pipe held open by a child from hanging Wait.
+		out := &syncWriter{w: verboseOut}
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = io.MultiWriter(channels[0], out)
+		cmd.Stderr = io.MultiWriter(channels[1], out)
+		cmd.WaitDelay = max(cmd.WaitDelay, time.Second)
+	}
+
+	sink := &lineSink{log: logFile, maxSize: opts.MaxLogSize, onLine: opts.OnLine, now: time.Now}
+	if opts.Verbose && !opts.Interactive {
+		sink.verbose = verboseOut
+		sink.streams = opts.Streams
+	}
 	var wg sync.WaitGroup
-	var stopTail chan struct{}
-	if verbose {
-		stopTail = make(chan struct{})
+	stopTail := make(chan struct{})
+	for i, f := range channels {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			tailToStderr(tmpPath, stopTail)
+			tailLog(f.Name(), stopTail, func(line string) { sink.write(names[i], line) }, func(size int64) bool {
+				// Once the log is truncating, a channel file past half the cap
+				// only holds lines that are already in the log or dropped.
+				return sink.truncating() && size > opts.MaxLogSize/2
+			})
 		}()
 	}
 
+	start := time.Now()
 	runErr := cmd.Run()
+	duration := time.Since(start)
 
-	if cancelCtx != nil {
-		cancelCtx()
+	close(stopTail)
+	wg.Wait()
+	if flushErr := sink.flush(); flushErr != nil && runErr == nil {
+		runErr = flushErr
 	}
 
-	// Close the temp file before returning so callers can read it.
-	if closeErr := tmpFile.Close(); closeErr != nil && runErr == nil {
+	// Close the log file before returning so callers can read it.
+	if closeErr := logFile.Close(); closeErr != nil && runErr == nil {
 		runErr = closeErr
 	}
 
-	if verbose {
-		close(stopTail)
-		wg.Wait()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		stopped := &RunResult{ExitCode: -1, LogFile: tmpPath}
+		if errors.Is(ctxErr, context.DeadlineExceeded) && opts.Timeout > 0 {
+			return stopped, fmt.Errorf("Godot process timed out after %s", opts.Timeout)
+		}
+		return stopped, fmt.Errorf("Godot process cancelled: %w", ctxErr)
 	}
 
 	exitCode := 0
 	if runErr != nil {
 		if exitErr, ok := runErr.(*exec.ExitError); ok {
 			exitCode = exitErr.ExitCode()
-		} else if timeout > 0 && runErr == context.DeadlineExceeded {
-			_ = os.Remove(tmpPath)
-			return nil, fmt.Errorf("Godot process timed out after %s", timeout)
 		} else {
 			// Non-exit error (e.g. binary not found at exec time).
 			_ = os.Remove(tmpPath)
@@ -117,34 +269,193 @@ func Run(godotPath, projectDir string, resPaths []string, verbose bool, timeout
 		}
 	}
 
-	return &RunResult{
+	res := &RunResult{
 		ExitCode: exitCode,
 		LogFile:  tmpPath,
-	}, nil
+		Duration: duration,
+	}
+	if ps := cmd.ProcessState; ps != nil {
+		res.CPUTime = ps.UserTime() + ps.SystemTime()
+		res.PID = ps.Pid()
+	}
+	return res, nil
+}
+
+// Version returns the version the Godot binary reports with --version, e.g.
+// "4.3.stable.official.77dcf97d8".
+func Version(ctx context.Context, godotPath string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, godotPath, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the Godot version: %w", err)
+	}
+	// Some builds print a banner first; the version is the last line.
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
 }
 
-// tailToStderr reads path and writes new data to stderr until stop is closed,
-// then drains any remaining data and returns.
-func tailToStderr(path string, stop <-chan struct{}) {
+// syncWriter serializes the writes of the copies of Godot's stdout and stderr.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// createAppend creates a temp file like os.CreateTemp but opened for appending,
+// so that Godot keeps writing at the end after tailLog truncates it.
+func createAppend(dir, pattern string) (*os.File, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	name := f.Name()
+	f.Close()
+	if f, err = os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0); err != nil {
+		os.Remove(name)
+		return nil, err
+	}
+	return f, nil
+}
+
+// maxLine is the longest line tailLog buffers; longer output without a newline
+// is passed on in pieces of this size.
+const maxLine = 1 << 20
+
+// lineSink merges the lines of both channels into the log file in arrival order,
+// echoes the selected channels to verbose with a channel tag and a timestamp,
+// e.g. "[12:03:04.123 stderr] ERROR: ...", and passes unprefixed lines to onLine.
+//
+// With maxSize set, lines are written to the log until it holds maxSize/2 bytes;
+// after that the last maxSize/2 bytes of lines are kept in memory and written
+// by flush, after a note on how much was cut.
+type lineSink struct {
+	mu      sync.Mutex
+	log     io.Writer
+	maxSize int64
+	verbose io.Writer // nil unless Options.Verbose
+	streams string
+	onLine  func(string)
+	now     func() time.Time
+
+	written      int64    // bytes written to log
+	tail         []string // lines kept for flush once the head is full
+	tailSize     int64
+	droppedLines int
+	droppedSize  int64
+}
+
+func (s *lineSink) write(channel, line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(line + "\n")
+	if s.verbose != nil && (s.streams == "" || s.streams == StreamAll || s.streams == channel) {
+		fmt.Fprintf(s.verbose, "[%s %s] %s\n", s.now().Format("15:04:05.000"), channel, line)
+	}
+	if s.onLine != nil {
+		s.onLine(line)
+	}
+}
+
+func (s *lineSink) store(line string) {
+	n := int64(len(line))
+	if s.maxSize <= 0 || (s.tail == nil && s.written+n <= s.maxSize/2) {
+		io.WriteString(s.log, line)
+		s.written += n
+		return
+	}
+	s.tail = append(s.tail, line)
+	s.tailSize += n
+	for s.tailSize > s.maxSize/2 && len(s.tail) > 0 {
+		s.droppedLines++
+		s.droppedSize += int64(len(s.tail[0]))
+		s.tailSize -= int64(len(s.tail[0]))
+		s.tail = s.tail[1:]
+	}
+}
+
+// truncating reports whether the log head is full.
+func (s *lineSink) truncating() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tail != nil
+}
+
+// flush writes the kept tail of a truncated log.
+func (s *lineSink) flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.droppedLines > 0 {
+		if _, err := fmt.Fprintf(s.log, "\n... gdunit4-test-runner: log exceeded %d bytes, %d lines (%d bytes) omitted ...\n\n", s.maxSize, s.droppedLines, s.droppedSize); err != nil {
+			return err
+		}
+	}
+	for _, line := range s.tail {
+		if _, err := io.WriteString(s.log, line); err != nil {
+			return err
+		}
+	}
+	s.tail = nil
+	return nil
+}
+
+// tailLog reads path and passes complete lines to onLine until stop is closed,
+// then drains any remaining data, including a final unterminated line, and returns.
+// Whenever it has read everything and rotate approves of the amount read, it
+// truncates the file to keep a runaway process from filling the disk; output
+// written in the instant between the last read and the truncation is lost.
+func tailLog(path string, stop <-chan struct{}, onLine func(string), rotate func(size int64) bool) {
 	f, err := os.Open(path)
 	if err != nil {
 		return
 	}
 	defer f.Close()
 
-	buf := make([]byte, 4096)
+	var pending []byte
+	var offset int64
+	emit := func(data []byte) {
+		offset += int64(len(data))
+		pending = append(pending, data...)
+		for {
+			i := bytes.IndexByte(pending, '\n')
+			if i < 0 {
+				break
+			}
+			onLine(strings.TrimSuffix(string(pending[:i]), "\r"))
+			pending = pending[i+1:]
+		}
+		for len(pending) >= maxLine {
+			onLine(string(pending[:maxLine]))
+			pending = pending[maxLine:]
+		}
+	}
+
+	buf := make([]byte, 32*1024)
 	for {
 		n, err := f.Read(buf)
 		if n > 0 {
-			os.Stderr.Write(buf[:n])
+			emit(buf[:n])
 		}
 		if err != nil {
 			select {
 			case <-stop:
 				// Process exited — drain remaining data and return.
-				io.Copy(os.Stderr, f)
+				rest, _ := io.ReadAll(f)
+				emit(rest)
+				if len(pending) > 0 {
+					onLine(strings.TrimSuffix(string(pending), "\r"))
+				}
 				return
 			default:
+				if rotate != nil && rotate(offset) && os.Truncate(path, 0) == nil {
+					f.Seek(0, io.SeekStart)
+					offset = 0
+				}
 				time.Sleep(50 * time.Millisecond)
 			}
 		}
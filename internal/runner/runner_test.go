@@ -1,11 +1,17 @@
 package runner
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
-	"runtime"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/testutil"
 )
 
 func TestBuildArgs_SinglePath(t *testing.T) {
@@ -85,17 +91,9 @@ func TestBuildArgs_MultiplePaths(t *testing.T) {
 }
 
 func TestRun_CapturesOutput(t *testing.T) {
-	if runtime.GOOS == "windows" {
-		t.Skip("skipping shell script test on Windows")
-	}
-
 	dir := t.TempDir()
-	script := filepath.Join(dir, "fake-godot.sh")
 	// Write a fake godot script that prints to stdout and exits 0
-	content := "#!/bin/sh\necho 'hello from godot'\necho 'error line' >&2\nexit 0\n"
-	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
-		t.Fatal(err)
-	}
+	script := testutil.FakeGodot(t, "echo 'hello from godot'\necho 'error line' >&2\nexit 0\n")
 
 	result, err := Run(script, dir, []string{"res://tests"}, false, 0)
 	if err != nil {
@@ -117,16 +115,8 @@ func TestRun_CapturesOutput(t *testing.T) {
 }
 
 func TestRun_NonZeroExitCode(t *testing.T) {
-	if runtime.GOOS == "windows" {
-		t.Skip("skipping shell script test on Windows")
-	}
-
 	dir := t.TempDir()
-	script := filepath.Join(dir, "fake-godot-fail.sh")
-	content := "#!/bin/sh\necho 'test failed'\nexit 100\n"
-	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
-		t.Fatal(err)
-	}
+	script := testutil.FakeGodot(t, "echo 'test failed'\nexit 100\n")
 
 	result, err := Run(script, dir, []string{"res://tests"}, false, 0)
 	if err != nil {
@@ -140,15 +130,8 @@ func TestRun_NonZeroExitCode(t *testing.T) {
 }
 
 func TestRun_LogFileExists(t *testing.T) {
-	if runtime.GOOS == "windows" {
-		t.Skip("skipping shell script test on Windows")
-	}
-
 	dir := t.TempDir()
-	script := filepath.Join(dir, "fake-godot-noop.sh")
-	if err := os.WriteFile(script, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
-		t.Fatal(err)
-	}
+	script := testutil.FakeGodot(t, "exit 0\n")
 
 	result, err := Run(script, dir, []string{"res://tests"}, false, 0)
 	if err != nil {
@@ -171,6 +154,129 @@ func TestRun_BinaryNotFound(t *testing.T) {
 	}
 }
 
+func TestRunContext_OnLine(t *testing.T) {
+	dir := t.TempDir()
+	script := testutil.FakeGodot(t, "echo 'line one'\necho 'line two'\nprintf 'no newline'\n")
+
+	var lines []string
+	result, err := RunContext(context.Background(), script, dir, []string{"res://tests"}, Options{
+		OnLine: func(line string) { lines = append(lines, line) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(result.LogFile)
+
+	want := []string{"line one", "line two", "no newline"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestRunContext_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+	script := testutil.FakeGodot(t, "sleep 5\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := RunContext(ctx, script, dir, []string{"res://tests"}, Options{})
+	if err == nil {
+		t.Fatal("expected error for cancelled run, got nil")
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	dir := t.TempDir()
+	script := testutil.FakeGodot(t, "exec sleep 5\n")
+
+	_, err := Run(script, dir, []string{"res://tests"}, false, 100*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected timeout error, got nil")
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("error should mention timeout, got: %v", err)
+	}
+}
+
+func TestRunContext_KillGrace(t *testing.T) {
+	dir := t.TempDir()
+	script := testutil.FakeGodot(t, "trap 'echo flushed on SIGTERM; exit 1' TERM\necho started\nsleep 5 &\nwait\n")
+
+	res, err := RunContext(context.Background(), script, dir, []string{"res://tests"}, Options{
+		Timeout:   300 * time.Millisecond,
+		KillGrace: 5 * time.Second,
+		TempDir:   dir,
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+	if res == nil {
+		t.Fatal("result = nil, want the log of the stopped run")
+	}
+	if res.ExitCode != -1 {
+		t.Errorf("ExitCode = %d, want -1", res.ExitCode)
+	}
+	log, err := os.ReadFile(res.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), "flushed on SIGTERM") {
+		t.Errorf("log = %q, want the output Godot wrote after SIGTERM", log)
+	}
+}
+
+func TestRunContext_GodotOptions(t *testing.T) {
+	dir := t.TempDir()
+	script := testutil.FakeGodot(t, "echo \"$@\"\n")
+
+	result, err := RunContext(context.Background(), script, dir, []string{"res://tests"}, Options{Render: true, GodotVerbose: true, RemoteDebug: "tcp://127.0.0.1:6007"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(result.LogFile)
+
+	data, err := os.ReadFile(result.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "--remote-debug tcp://127.0.0.1:6007 --verbose -s ") || strings.Contains(string(data), "--headless") {
+		t.Errorf("args = %q, want --remote-debug and --verbose first and no --headless", data)
+	}
+}
+
+func TestRunContext_Interactive(t *testing.T) {
+	dir := t.TempDir()
+	script := testutil.FakeGodot(t, "echo \"$1\"\nprintf 'debug> '\nread cmd\necho \"got $cmd\"\n")
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteString("bt\n")
+	w.Close()
+	defer r.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	var out bytes.Buffer
+	result, err := RunContext(context.Background(), script, dir, []string{"res://tests"}, Options{Interactive: true, Verbose: true, VerboseOut: &out})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(result.LogFile)
+
+	if want := "-d\ndebug> got bt\n"; out.String() != want {
+		t.Errorf("terminal output = %q, want %q", out.String(), want)
+	}
+	data, err := os.ReadFile(result.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "got bt") {
+		t.Errorf("log = %q, want the output also captured", data)
+	}
+}
+
 // contains reports whether slice contains elem.
 func contains(slice []string, elem string) bool {
 	for _, s := range slice {
@@ -190,3 +296,173 @@ func indexOf(slice []string, elem string) int {
 	}
 	return -1
 }
+
+func TestRunContext_MergesStderrIntoLog(t *testing.T) {
+	dir := t.TempDir()
+	script := testutil.FakeGodot(t, "echo 'to stdout'\necho 'to stderr' >&2\n")
+
+	result, err := RunContext(context.Background(), script, dir, []string{"res://tests"}, Options{TempDir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(result.LogFile)
+
+	data, err := os.ReadFile(result.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"to stdout\n", "to stderr\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("log file should contain %q, got: %s", want, data)
+		}
+	}
+	// The per-channel capture files are removed once merged.
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".stdout") || strings.HasSuffix(e.Name(), ".stderr") {
+			t.Errorf("leftover capture file %s", e.Name())
+		}
+	}
+}
+
+func TestLineSink_Verbose(t *testing.T) {
+	now := func() time.Time { return time.Date(2024, 1, 2, 12, 3, 4, 123e6, time.UTC) }
+	tests := []struct {
+		streams string
+		want    string
+	}{
+		{"", "[12:03:04.123 stdout] out\n[12:03:04.123 stderr] err\n"},
+		{StreamAll, "[12:03:04.123 stdout] out\n[12:03:04.123 stderr] err\n"},
+		{StreamStdout, "[12:03:04.123 stdout] out\n"},
+		{StreamStderr, "[12:03:04.123 stderr] err\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.streams, func(t *testing.T) {
+			var log, verbose strings.Builder
+			var lines []string
+			s := &lineSink{log: &log, verbose: &verbose, streams: tt.streams, now: now,
+				onLine: func(line string) { lines = append(lines, line) }}
+			s.write(StreamStdout, "out")
+			s.write(StreamStderr, "err")
+
+			if verbose.String() != tt.want {
+				t.Errorf("verbose = %q, want %q", verbose.String(), tt.want)
+			}
+			if log.String() != "out\nerr\n" {
+				t.Errorf("log = %q, want unprefixed lines of both channels", log.String())
+			}
+			if strings.Join(lines, "|") != "out|err" {
+				t.Errorf("onLine got %q", lines)
+			}
+		})
+	}
+}
+
+func TestLineSink_MaxSize(t *testing.T) {
+	var log strings.Builder
+	s := &lineSink{log: &log, maxSize: 20, now: time.Now}
+	for _, line := range []string{"aaaa", "bbbb", "cccc", "dddd", "eeee", "ffff"} {
+		s.write(StreamStdout, line)
+	}
+	if !s.truncating() {
+		t.Error("truncating() = false after exceeding the head")
+	}
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "aaaa\nbbbb\n\n... gdunit4-test-runner: log exceeded 20 bytes, 2 lines (10 bytes) omitted ...\n\neeee\nffff\n"
+	if log.String() != want {
+		t.Errorf("log = %q, want %q", log.String(), want)
+	}
+}
+
+func TestRunContext_MaxLogSize(t *testing.T) {
+	dir := t.TempDir()
+	script := testutil.FakeGodot(t, "i=0\nwhile [ $i -lt 5000 ]; do echo \"spam line $i\"; i=$((i+1)); done\necho 'last words'\n")
+
+	result, err := RunContext(context.Background(), script, dir, []string{"res://tests"}, Options{MaxLogSize: 4096})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(result.LogFile)
+
+	data, err := os.ReadFile(result.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) > 4096+200 {
+		t.Errorf("log is %d bytes, want about 4096", len(data))
+	}
+	log := string(data)
+	for _, want := range []string{"spam line 0\n", "lines (", "last words\n"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log should contain %q", want)
+		}
+	}
+}
+
+func TestNewRunnerConfig(t *testing.T) {
+	c := NewRunnerConfig([]string{
+		"res://tests/test_math.gd:test_add",
+		"res://tests/test_math.gd:test_sub",
+		"res://tests/unit",
+		"res://tests/test_io.gd:test_read",
+		"res://tests/test_io.gd",
+	})
+	want := map[string][]string{
+		"res://tests/test_math.gd": {"test_add", "test_sub"},
+		"res://tests/unit":         {},
+		"res://tests/test_io.gd":   {},
+	}
+	if !reflect.DeepEqual(c.Included, want) {
+		t.Errorf("Included = %v, want %v", c.Included, want)
+	}
+	if c.Version != RunnerConfigVersion {
+		t.Errorf("Version = %q, want %q", c.Version, RunnerConfigVersion)
+	}
+
+	// The suites are written in the order given, not sorted.
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{"version":"1.0","included":{"res://tests/test_math.gd":["test_add","test_sub"],"res://tests/unit":[],"res://tests/test_io.gd":[]},"skipped":{}}`
+	if string(data) != wantJSON {
+		t.Errorf("JSON = %s, want %s", data, wantJSON)
+	}
+}
+
+func TestRunContext_ConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	script := testutil.FakeGodot(t, "echo \"$@\"\n")
+	conf := filepath.Join(dir, "GdUnitRunner.cfg")
+
+	opts := Options{ConfigFile: conf, CmdTool: "res://vendor/gdUnit4/bin/GdUnitCmdTool.gd"}
+	result, err := RunContext(context.Background(), script, dir, []string{"res://tests/test_math.gd:test_add"}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(result.LogFile)
+
+	data, err := os.ReadFile(result.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "-conf "+conf) || strings.Contains(string(data), "-a ") {
+		t.Errorf("args = %q, want -conf %s and no -a", data, conf)
+	}
+	if !strings.Contains(string(data), "-s "+opts.CmdTool+" ") {
+		t.Errorf("args = %q, want -s %s", data, opts.CmdTool)
+	}
+	var c RunnerConfig
+	data, err = os.ReadFile(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Included["res://tests/test_math.gd"]; !reflect.DeepEqual(got, []string{"test_add"}) {
+		t.Errorf("included tests = %v, want [test_add]", got)
+	}
+}
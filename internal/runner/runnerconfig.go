@@ -0,0 +1,150 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// RunnerConfigVersion is the version of gdUnit4's runner configuration format.
+const RunnerConfigVersion = "1.0"
+
+// RunnerConfig is a gdUnit4 runner configuration (GdUnitRunner.cfg), passed to
+// GdUnitCmdTool with -conf in place of -a arguments. It maps each res:// path to
+// the tests to run (or skip) in it; an empty list means all of them.
+type RunnerConfig struct {
+	Version  string              `json:"version"`
+	Included map[string][]string `json:"included"`
+	Skipped  map[string][]string `json:"skipped"`
+
+	order []string // the keys of Included in the order given to NewRunnerConfig
+}
+
+// NewRunnerConfig builds a RunnerConfig running resPaths, where an entry may
+// name a single test as "res://tests/test_math.gd:test_add".
+func NewRunnerConfig(resPaths []string) *RunnerConfig {
+	c := &RunnerConfig{
+		Version:  RunnerConfigVersion,
+		Included: map[string][]string{},
+		Skipped:  map[string][]string{},
+	}
+	for _, p := range resPaths {
+		suite, test := SplitTest(p)
+		tests, ok := c.Included[suite]
+		if !ok {
+			c.order = append(c.order, suite)
+		}
+		switch {
+		case test == "":
+			c.Included[suite] = []string{}
+		case !ok || len(tests) > 0:
+			c.Included[suite] = append(tests, test)
+		}
+	}
+	return c
+}
+
+// MarshalJSON writes Included in the order of the paths c was built from:
+// Godot keeps the key order of the JSON, and gdUnit4 runs the suites in it.
+func (c RunnerConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Version  string              `json:"version"`
+		Included orderedTests        `json:"included"`
+		Skipped  map[string][]string `json:"skipped"`
+	}{c.Version, orderedTests{c.Included, c.order}, c.Skipped})
+}
+
+// orderedTests is a map of tests by suite encoded with its keys in order,
+// followed by any keys order does not list, sorted.
+type orderedTests struct {
+	tests map[string][]string
+	order []string
+}
+
+func (o orderedTests) MarshalJSON() ([]byte, error) {
+	if o.tests == nil {
+		return []byte("null"), nil
+	}
+	keys := slices.Clone(o.order)
+	var rest []string
+	for k := range o.tests {
+		if !slices.Contains(keys, k) {
+			rest = append(rest, k)
+		}
+	}
+	slices.Sort(rest)
+	keys = append(keys, rest...)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(o.tests[k])
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// SplitTest splits "res://tests/test_math.gd:test_add" into the suite and the
+// test name; the test name is empty for a path naming a suite or directory.
+func SplitTest(resPath string) (suite, test string) {
+	rest := strings.TrimPrefix(resPath, "res://")
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		return resPath[:len(resPath)-len(rest)+i], rest[i+1:]
+	}
+	return resPath, ""
+}
+
+// SelectsTests reports whether any of resPaths names a single test, which is
+// what a RunnerConfig carries better than -a arguments.
+func SelectsTests(resPaths []string) bool {
+	for _, p := range resPaths {
+		if _, test := SplitTest(p); test != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteFile writes c to path as JSON, the format gdUnit4 loads.
+func (c *RunnerConfig) WriteFile(path string) error {
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write gdUnit4 runner config: %w", err)
+	}
+	return nil
+}
+
+// BuildConfigArgs constructs the Godot command arguments running the tests of
+// the gdUnit4 runner configuration at configPath.
+func BuildConfigArgs(configPath string) []string {
+	return buildConfigArgs(DefaultCmdTool, configPath)
+}
+
+func buildConfigArgs(cmdTool, configPath string) []string {
+	return []string{
+		"--headless",
+		"-s",
+		cmdTool,
+		"-conf", configPath,
+		"--ignoreHeadlessMode", "-c",
+	}
+}
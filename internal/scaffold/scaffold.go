@@ -0,0 +1,64 @@
+// Package scaffold generates gdUnit4 test suite skeletons for GDScript sources.
+package scaffold
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// publicFuncRe matches a top-level, non-underscore function declaration.
+var publicFuncRe = regexp.MustCompile(`^(?:static\s+)?func\s+([A-Za-z]\w*)\s*\(`)
+
+// classNameRe matches a class_name declaration.
+var classNameRe = regexp.MustCompile(`(?m)^class_name\s+(\w+)`)
+
+// PublicFuncs returns the names of the top-level public functions of a GDScript
+// source in declaration order. Functions of inner classes and those starting with
+// an underscore (virtuals and private helpers) are skipped.
+func PublicFuncs(src []byte) []string {
+	var names []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		if m := publicFuncRe.FindStringSubmatch(scanner.Text()); m != nil && !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// TestPath returns the res:// path of the suite for the source at resPath: the
+// source's directory is mirrored under testsDir and the file is prefixed with
+// "test_" (res://scripts/player.gd -> res://test/scripts/test_player.gd).
+func TestPath(resPath, testsDir string) string {
+	rel := strings.TrimPrefix(resPath, "res://")
+	dir, file := path.Split(rel)
+	return "res://" + path.Join(strings.Trim(testsDir, "/"), dir, "test_"+file)
+}
+
+// Suite returns a gdUnit4 test suite skeleton for the source at resPath, with one
+// test stub per public function.
+func Suite(resPath string, src []byte) []byte {
+	var b strings.Builder
+	b.WriteString("# GdUnit generated TestSuite\n")
+	if m := classNameRe.FindSubmatch(src); m != nil {
+		fmt.Fprintf(&b, "class_name %sTest\n", m[1])
+	}
+	b.WriteString("extends GdUnitTestSuite\n")
+	b.WriteString("@warning_ignore('unused_parameter')\n")
+	b.WriteString("@warning_ignore('return_value_discarded')\n\n")
+	b.WriteString("# TestSuite generated from\n")
+	fmt.Fprintf(&b, "const __source = '%s'\n", resPath)
+
+	for _, name := range PublicFuncs(src) {
+		fmt.Fprintf(&b, "\n\nfunc test_%s() -> void:\n", name)
+		b.WriteString("\t# remove this line and complete your test\n")
+		b.WriteString("\tassert_not_yet_implemented()\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,84 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+)
+
+const playerSrc = `class_name Player
+extends CharacterBody2D
+
+signal died
+
+func _ready() -> void:
+	pass
+
+func jump(height: float) -> void:
+	pass
+
+static func create() -> Player:
+	return Player.new()
+
+func _private_helper():
+	pass
+
+class Inner:
+	func inner_method():
+		pass
+
+func take_damage(amount):
+	pass
+`
+
+func TestPublicFuncs(t *testing.T) {
+	got := strings.Join(PublicFuncs([]byte(playerSrc)), ",")
+	if want := "jump,create,take_damage"; got != want {
+		t.Errorf("PublicFuncs = %s, want %s", got, want)
+	}
+}
+
+func TestTestPath(t *testing.T) {
+	tests := []struct {
+		resPath  string
+		testsDir string
+		want     string
+	}{
+		{"res://scripts/player.gd", "test", "res://test/scripts/test_player.gd"},
+		{"res://player.gd", "tests/", "res://tests/test_player.gd"},
+		{"res://a/b/enemy.gd", "test/unit", "res://test/unit/a/b/test_enemy.gd"},
+	}
+	for _, tt := range tests {
+		if got := TestPath(tt.resPath, tt.testsDir); got != tt.want {
+			t.Errorf("TestPath(%q, %q) = %q, want %q", tt.resPath, tt.testsDir, got, tt.want)
+		}
+	}
+}
+
+func TestSuite(t *testing.T) {
+	got := string(Suite("res://scripts/player.gd", []byte(playerSrc)))
+
+	for _, want := range []string{
+		"class_name PlayerTest\nextends GdUnitTestSuite\n",
+		"const __source = 'res://scripts/player.gd'\n",
+		"func test_jump() -> void:\n\t# remove this line and complete your test\n\tassert_not_yet_implemented()\n",
+		"func test_create() -> void:",
+		"func test_take_damage() -> void:",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Suite missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "test__ready") || strings.Contains(got, "test_inner_method") {
+		t.Errorf("Suite contains stubs for non-public funcs:\n%s", got)
+	}
+}
+
+func TestSuite_NoClassName(t *testing.T) {
+	got := string(Suite("res://util.gd", []byte("extends Node\n\nfunc clamp_value(x):\n\treturn x\n")))
+	if strings.Contains(got, "class_name") {
+		t.Errorf("Suite declares class_name for a script without one:\n%s", got)
+	}
+	if !strings.HasPrefix(got, "# GdUnit generated TestSuite\nextends GdUnitTestSuite\n") {
+		t.Errorf("unexpected header:\n%s", got)
+	}
+}
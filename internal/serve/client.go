@@ -0,0 +1,105 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// pollInterval is how often RunRemote checks the state of a queued or running run.
+const pollInterval = 500 * time.Millisecond
+
+// RunRemote queues a run for paths, narrowed to the tests matching filter, on the
+// daemon at baseURL (e.g. "http://localhost:8080") and waits for it to finish. Paths
+// are resolved by the daemon, so callers should pass absolute paths.
+func RunRemote(ctx context.Context, baseURL string, paths, filter []string) (RunInfo, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	body, err := json.Marshal(pathsParams{Paths: paths, Filter: filter})
+	if err != nil {
+		return RunInfo{}, err
+	}
+	var info RunInfo
+	if err := doJSON(ctx, http.MethodPost, baseURL+"/runs", body, &info); err != nil {
+		return RunInfo{}, err
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for info.Status == StatusQueued || info.Status == StatusRunning {
+		select {
+		case <-ctx.Done():
+			return info, ctx.Err()
+		case <-ticker.C:
+		}
+		if err := doJSON(ctx, http.MethodGet, baseURL+"/runs/"+info.ID, nil, &info); err != nil {
+			return info, err
+		}
+	}
+	return info, nil
+}
+
+// CancelRemote cancels run id on the daemon at baseURL and returns the run as
+// it ended, with the output of the tests it got to.
+func CancelRemote(ctx context.Context, baseURL, id string) (RunInfo, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	// The daemon answers once the run has stopped.
+	var cancelled map[string]bool
+	if err := doJSON(ctx, http.MethodPost, baseURL+"/runs/"+id+"/cancel", nil, &cancelled); err != nil {
+		return RunInfo{}, err
+	}
+	var info RunInfo
+	err := doJSON(ctx, http.MethodGet, baseURL+"/runs/"+id, nil, &info)
+	return info, err
+}
+
+// RemoteLog returns the last lines of Godot output the daemon at baseURL kept
+// of run id.
+func RemoteLog(ctx context.Context, baseURL, id string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/runs/"+id+"/log", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach daemon: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("daemon returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+// doJSON performs a request and decodes a JSON response into v.
+func doJSON(ctx context.Context, method, url string, body []byte, v any) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("daemon returned %s: %s", resp.Status, apiErr.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode daemon response: %w", err)
+	}
+	return nil
+}
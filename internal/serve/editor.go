@@ -0,0 +1,39 @@
+package serve
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+)
+
+// EditorFileName is the file in the project's state directory through which
+// the Godot editor plugin finds the server of serve --editor.
+const EditorFileName = "editor.json"
+
+// EditorInfo is the content of EditorFileName.
+type EditorInfo struct {
+	URL     string `json:"url"` // base URL of the HTTP API
+	PID     int    `json:"pid"`
+	Version string `json:"version,omitempty"`
+}
+
+// EditorFile returns the path of EditorFileName in projectDir.
+func EditorFile(projectDir string) string {
+	return filepath.Join(projectDir, config.StateDir, EditorFileName)
+}
+
+// WriteEditorInfo advertises info to the editor plugin of projectDir and
+// returns the path written; the caller removes it when the server stops.
+func WriteEditorInfo(projectDir string, info EditorInfo) (string, error) {
+	path := EditorFile(projectDir)
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, append(data, '\n'), 0o644)
+}
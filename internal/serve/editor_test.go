@@ -0,0 +1,33 @@
+package serve
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+)
+
+func TestWriteEditorInfo(t *testing.T) {
+	project := t.TempDir()
+	path, err := WriteEditorInfo(project, EditorInfo{URL: "http://127.0.0.1:41234", PID: 42})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The plugin reads it from res://.gdunit4-runner/editor.json.
+	if want := filepath.Join(project, config.StateDir, EditorFileName); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info EditorInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatalf("invalid editor file: %v\n%s", err, data)
+	}
+	if info.URL != "http://127.0.0.1:41234" || info.PID != 42 {
+		t.Errorf("info = %+v", info)
+	}
+}
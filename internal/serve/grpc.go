@@ -0,0 +1,320 @@
+package serve
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// grpcService is the path prefix of the methods of the TestRunner service of
+// proto/gdunit4runner/v1/runner.proto.
+const grpcService = "/gdunit4runner.v1.TestRunner/"
+
+// maxGRPCMessage is the largest request message accepted, gRPC's default.
+const maxGRPCMessage = 4 << 20
+
+// gRPC status codes.
+const (
+	grpcOK                = 0
+	grpcInvalidArgument   = 3
+	grpcResourceExhausted = 8
+	grpcUnimplemented     = 12
+	grpcInternal          = 13
+)
+
+// grpcError is a failed call with its gRPC status code.
+type grpcError struct {
+	code int
+	msg  string
+}
+
+func (e *grpcError) Error() string { return e.msg }
+
+// NewGRPCHandler returns the gRPC API for m: the TestRunner service of
+// proto/gdunit4runner/v1/runner.proto. It is served over HTTP/2, which for
+// plain-text connections needs a server with unencrypted HTTP/2 enabled.
+// Messages are not compressed.
+func NewGRPCHandler(m *Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+
+		method, ok := strings.CutPrefix(r.URL.Path, grpcService)
+		if !ok {
+			writeGRPCStatus(w, &grpcError{grpcUnimplemented, "unknown service " + r.URL.Path})
+			return
+		}
+		req, err := readGRPCMessage(r.Body)
+		if err != nil {
+			writeGRPCStatus(w, err)
+			return
+		}
+
+		var resp protoBuf
+		switch method {
+		case "RunTests":
+			var p pathsParams
+			if err = decodeRunTestsRequest(req, &p); err == nil {
+				var info RunInfo
+				if info, err = m.start(p); err == nil {
+					resp = encodeRunInfo(info)
+				} else {
+					err = &grpcError{grpcInvalidArgument, err.Error()}
+				}
+			}
+		case "ListTests":
+			var paths []string
+			if err = decodeStrings(req, 1, &paths); err == nil {
+				var res *DiscoverResult
+				if res, err = m.Discover(paths); err == nil {
+					resp = encodeDiscoverResult(res)
+				} else {
+					err = &grpcError{grpcInvalidArgument, err.Error()}
+				}
+			}
+		case "Cancel":
+			var ids []string
+			if err = decodeStrings(req, 1, &ids); err == nil {
+				id := ""
+				if len(ids) > 0 {
+					id = ids[len(ids)-1] // the last value of a repeated singular field wins
+				}
+				resp.bool(1, m.Cancel(id))
+			}
+		case "StreamEvents":
+			streamGRPCEvents(w, r, m)
+			return
+		default:
+			err = &grpcError{grpcUnimplemented, "unknown method " + method}
+		}
+		if err == nil {
+			err = writeGRPCMessage(w, resp)
+		}
+		writeGRPCStatus(w, err)
+	})
+}
+
+// streamGRPCEvents writes the events of m to w until the call ends, or ends
+// it with RESOURCE_EXHAUSTED when the client falls behind.
+func streamGRPCEvents(w http.ResponseWriter, r *http.Request, m *Manager) {
+	events, lost, unsubscribe := m.SubscribeBuffered(eventBuffer)
+	defer unsubscribe()
+
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-lost:
+			writeGRPCStatus(w, &grpcError{grpcResourceExhausted, "client fell behind the event stream"})
+			return
+		case ev := <-events:
+			if err := writeGRPCMessage(w, encodeEvent(ev)); err != nil {
+				return
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+	}
+}
+
+// readGRPCMessage reads the one request message of a call from r.
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil // an empty message may be sent as no message at all
+		}
+		return nil, &grpcError{grpcInvalidArgument, "malformed request: " + err.Error()}
+	}
+	if prefix[0] != 0 {
+		return nil, &grpcError{grpcUnimplemented, "compressed messages are not supported"}
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxGRPCMessage {
+		return nil, &grpcError{grpcResourceExhausted, fmt.Sprintf("request message of %d bytes exceeds %d", size, maxGRPCMessage)}
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, "malformed request: " + err.Error()}
+	}
+	return msg, nil
+}
+
+// writeGRPCMessage writes msg to w as an uncompressed gRPC message.
+func writeGRPCMessage(w io.Writer, msg []byte) error {
+	prefix := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(msg)))
+	_, err := w.Write(append(prefix, msg...))
+	return err
+}
+
+// writeGRPCStatus sets the trailers ending a call: OK if err is nil, otherwise
+// the code of a grpcError, or INTERNAL.
+func writeGRPCStatus(w http.ResponseWriter, err error) {
+	code, msg := grpcOK, ""
+	if err != nil {
+		code, msg = grpcInternal, err.Error()
+		var gerr *grpcError
+		if errors.As(err, &gerr) {
+			code = gerr.code
+		}
+	}
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+	if msg != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", grpcPercentEncode(msg))
+	}
+}
+
+// grpcPercentEncode encodes a grpc-message value: bytes outside printable
+// ASCII, and "%", as %XX.
+func grpcPercentEncode(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// decodeStrings appends the values of the string field num of msg to dst.
+func decodeStrings(msg []byte, num int, dst *[]string) error {
+	err := protoFields(msg, func(n, typ int, _ uint64, data []byte) error {
+		if n == num && typ == wireBytes {
+			*dst = append(*dst, string(data))
+		}
+		return nil
+	})
+	if err != nil {
+		return &grpcError{grpcInvalidArgument, err.Error()}
+	}
+	return nil
+}
+
+// decodeRunTestsRequest decodes a RunTestsRequest into p.
+func decodeRunTestsRequest(msg []byte, p *pathsParams) error {
+	err := protoFields(msg, func(n, typ int, _ uint64, data []byte) error {
+		if typ != wireBytes {
+			return nil
+		}
+		switch n {
+		case 1:
+			p.Paths = append(p.Paths, string(data))
+		case 2:
+			p.Filter = append(p.Filter, string(data))
+		case 3:
+			p.Tests = append(p.Tests, string(data))
+		}
+		return nil
+	})
+	if err != nil {
+		return &grpcError{grpcInvalidArgument, err.Error()}
+	}
+	return nil
+}
+
+func encodeRunInfo(info RunInfo) protoBuf {
+	var b protoBuf
+	b.string(1, info.ID)
+	b.strings(2, info.Paths)
+	b.strings(3, info.Filter)
+	b.string(4, info.Status)
+	b.optionalInt(5, info.ExitCode)
+	if info.Output != nil {
+		b.message(6, encodeOutput(info.Output))
+	}
+	b.string(7, info.Error)
+	return b
+}
+
+func encodeEvent(ev Event) protoBuf {
+	var b protoBuf
+	b.string(1, ev.RunID)
+	b.string(2, ev.Type)
+	b.string(3, ev.Line)
+	b.string(4, ev.Suite)
+	b.string(5, ev.Test)
+	b.string(6, ev.ID)
+	b.string(7, ev.Status)
+	b.optionalInt(8, ev.ExitCode)
+	if ev.Output != nil {
+		b.message(9, encodeOutput(ev.Output))
+	}
+	b.string(10, ev.Error)
+	return b
+}
+
+// encodeOutput encodes out as an Output: its summary, and the whole of it as JSON.
+func encodeOutput(out *report.Output) protoBuf {
+	s := out.Summary
+	var sum protoBuf
+	sum.int(1, s.Total)
+	sum.int(2, s.Passed)
+	sum.int(3, s.Failed)
+	sum.int(4, s.Errors)
+	sum.bool(5, s.Crashed)
+	sum.string(6, s.Status)
+	sum.int(7, s.Skipped)
+	sum.int(8, s.XFail)
+	sum.int(9, s.XPass)
+
+	var b protoBuf
+	b.message(1, sum)
+	if data, err := json.Marshal(out); err == nil {
+		b.string(2, string(data))
+	}
+	return b
+}
+
+func encodeDiscoverResult(res *DiscoverResult) protoBuf {
+	var b protoBuf
+	b.string(1, res.ProjectDir)
+	for _, s := range res.Suites {
+		b.message(2, encodeSuite(s))
+	}
+	return b
+}
+
+func encodeSuite(s discovery.Suite) protoBuf {
+	var b protoBuf
+	b.string(1, s.ResPath)
+	b.string(2, s.Class)
+	b.strings(3, s.Tests)
+	b.strings(4, s.Tags)
+	// Map fields are repeated entries of key 1 and value 2, here in key order.
+	for _, test := range slices.Sorted(maps.Keys(s.TestTags)) {
+		var tags, entry protoBuf
+		tags.strings(1, s.TestTags[test])
+		entry.string(1, test)
+		entry.message(2, tags)
+		b.message(5, entry)
+	}
+	for _, test := range slices.Sorted(maps.Keys(s.Lines)) {
+		var entry protoBuf
+		entry.string(1, test)
+		entry.int(2, s.Lines[test])
+		b.message(6, entry)
+	}
+	return b
+}
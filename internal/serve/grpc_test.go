@@ -0,0 +1,212 @@
+package serve
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// newGRPCServer serves the gRPC API of m over h2c and returns its URL and a
+// client speaking h2c to it.
+func newGRPCServer(t *testing.T, m *Manager) (string, *http.Client) {
+	t.Helper()
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	srv := httptest.NewUnstartedServer(NewGRPCHandler(m))
+	srv.Config.Protocols = &protocols
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv.URL, &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+}
+
+// grpcCall makes a unary call and returns the response message and the
+// grpc-status trailer.
+func grpcCall(t *testing.T, client *http.Client, url, method string, req protoBuf) ([]byte, string) {
+	t.Helper()
+	var body bytes.Buffer
+	if err := writeGRPCMessage(&body, req); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Post(url+grpcService+method, "application/grpc", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("%s answered over HTTP/%d, want HTTP/2", method, resp.ProtoMajor)
+	}
+	var msg []byte
+	if len(data) >= 5 {
+		msg = data[5:]
+	}
+	return msg, resp.Trailer.Get("Grpc-Status")
+}
+
+// protoField returns the string values of field num of msg.
+func protoField(t *testing.T, msg []byte, num int) []string {
+	t.Helper()
+	var vals []string
+	if err := decodeStrings(msg, num, &vals); err != nil {
+		t.Fatal(err)
+	}
+	return vals
+}
+
+func TestGRPC_ListTests(t *testing.T) {
+	url, client := newGRPCServer(t, NewManager(makeProject(t)))
+
+	msg, status := grpcCall(t, client, url, "ListTests", nil)
+	if status != "0" {
+		t.Fatalf("grpc-status = %q, want 0", status)
+	}
+	suites := protoField(t, msg, 2)
+	if len(suites) != 1 {
+		t.Fatalf("suites = %d, want 1", len(suites))
+	}
+	if got := protoField(t, []byte(suites[0]), 1); !slices.Equal(got, []string{"res://tests/test_math.gd"}) {
+		t.Errorf("res_path = %v, want res://tests/test_math.gd", got)
+	}
+	if got := protoField(t, []byte(suites[0]), 3); !slices.Equal(got, []string{"test_add"}) {
+		t.Errorf("tests = %v, want [test_add]", got)
+	}
+}
+
+func TestGRPC_RunTestsStreamsEvents(t *testing.T) {
+	m := NewManager(makeProject(t))
+	url, client := newGRPCServer(t, m)
+
+	var body bytes.Buffer
+	if err := writeGRPCMessage(&body, nil); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Post(url+grpcService+"StreamEvents", "application/grpc", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var req protoBuf
+	req.strings(3, []string{"res://tests/test_math.gd:test_add"})
+	msg, status := grpcCall(t, client, url, "RunTests", req)
+	if status != "0" {
+		t.Fatalf("RunTests grpc-status = %q, want 0", status)
+	}
+	runID := protoField(t, msg, 1)
+	if len(runID) != 1 || runID[0] == "" {
+		t.Fatalf("run_id = %v, want one", runID)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	var types []string
+	for !slices.Contains(types, EventFinished) {
+		var prefix [5]byte
+		if _, err := io.ReadFull(r, prefix[:]); err != nil {
+			t.Fatalf("reading events after %v: %v", types, err)
+		}
+		ev := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+		if _, err := io.ReadFull(r, ev); err != nil {
+			t.Fatal(err)
+		}
+		typ := protoField(t, ev, 2)[0]
+		types = append(types, typ)
+		if typ != EventFinished {
+			continue
+		}
+		output := protoField(t, ev, 9)
+		if len(output) != 1 {
+			t.Fatalf("finished event output = %v, want one", output)
+		}
+		summary := protoField(t, []byte(output[0]), 1)
+		if got := protoField(t, []byte(summary[0]), 6); !slices.Equal(got, []string{"passed"}) {
+			t.Errorf("summary status = %v, want passed", got)
+		}
+		if json := protoField(t, []byte(output[0]), 2); len(json) != 1 || !strings.Contains(json[0], `"status":"passed"`) {
+			t.Errorf("output json = %v, want the output document", json)
+		}
+	}
+	if !slices.Contains(types, EventStarted) {
+		t.Errorf("events = %v, want a %q event", types, EventStarted)
+	}
+}
+
+func TestGRPC_Errors(t *testing.T) {
+	url, client := newGRPCServer(t, NewManager(makeProject(t)))
+
+	var req protoBuf
+	req.strings(3, []string{"res://tests/test_math.gd:test_missing"})
+	if _, status := grpcCall(t, client, url, "RunTests", req); status != "3" {
+		t.Errorf("RunTests of an unknown test: grpc-status = %q, want 3 (INVALID_ARGUMENT)", status)
+	}
+	if _, status := grpcCall(t, client, url, "Watch", nil); status != "12" {
+		t.Errorf("unknown method: grpc-status = %q, want 12 (UNIMPLEMENTED)", status)
+	}
+	if _, status := grpcCall(t, client, url, "ListTests", protoBuf{0x0a, 0x05}); status != "3" {
+		t.Errorf("malformed request: grpc-status = %q, want 3 (INVALID_ARGUMENT)", status)
+	}
+
+	msg, status := grpcCall(t, client, url, "Cancel", nil)
+	if status != "0" || len(msg) != 0 {
+		t.Errorf("Cancel with no run = %x (grpc-status %q), want cancelled false", msg, status)
+	}
+}
+
+// TestProtoMatchesJSON checks that the messages of runner.proto carry the
+// fields of the JSON they correspond to.
+func TestProtoMatchesJSON(t *testing.T) {
+	src, err := os.ReadFile("../../proto/gdunit4runner/v1/runner.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := map[string][]string{}
+	field := regexp.MustCompile(`^\s*(?:(?:repeated |optional )?\w+|map<[^>]+>) (\w+) = \d+;`)
+	var current string
+	for line := range strings.Lines(string(src)) {
+		if name, ok := strings.CutPrefix(line, "message "); ok {
+			current = strings.Fields(name)[0]
+			continue
+		}
+		if m := field.FindStringSubmatch(line); m != nil && current != "" {
+			messages[current] = append(messages[current], m[1])
+		}
+	}
+
+	tests := []struct {
+		message string
+		typ     any
+	}{
+		{"RunInfo", RunInfo{}},
+		{"Event", Event{}},
+		{"ListTestsResponse", DiscoverResult{}},
+		{"Suite", discovery.Suite{}},
+		{"Summary", report.Summary{}},
+	}
+	for _, tt := range tests {
+		typ := reflect.TypeOf(tt.typ)
+		var want []string
+		for i := range typ.NumField() {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			want = append(want, name)
+		}
+		got := slices.Sorted(slices.Values(messages[tt.message]))
+		slices.Sort(want)
+		if !slices.Equal(got, want) {
+			t.Errorf("message %s has fields %v, want those of %s: %v", tt.message, got, typ, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package serve
+
+import (
+	"errors"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/history"
+)
+
+// DefaultHistorySince is the period HistoryStats looks at unless told otherwise.
+const DefaultHistorySince = 7 * 24 * time.Hour
+
+// HistoryStats is what the run history says about the server's project over a
+// period: the outcome of each run for a trend, and the flaky tests.
+type HistoryStats struct {
+	Project string              `json:"project"` // empty if the server's paths span several projects
+	Since   string              `json:"since"`
+	Runs    []HistoryRun        `json:"runs"` // oldest first
+	Flaky   []history.FlakyTest `json:"flaky"`
+}
+
+// HistoryRun is the outcome of one run of the history.
+type HistoryRun struct {
+	ID      string    `json:"run_id"`
+	Time    time.Time `json:"time"`
+	Project string    `json:"project"`
+	Passed  int       `json:"passed"`
+	Failed  int       `json:"failed"`
+}
+
+// errNoHistory reports that the Manager records no run history.
+var errNoHistory = errors.New("no run history is recorded")
+
+// HistoryStats reads the runs of the server's project started in the last
+// since from the run history m records in (see RecordHistory), including
+// those of other clients of the same history such as CLI runs.
+func (m *Manager) HistoryStats(since time.Duration) (*HistoryStats, error) {
+	if m.historyDir == "" {
+		return nil, errNoHistory
+	}
+	project := ""
+	if detected, err := detector.DetectIn(m.base.ProjectDir, m.base.TestPaths); err == nil {
+		project = detected.ProjectDir
+	}
+	runs, err := history.Read(m.historyDir, project, time.Now().Add(-since))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &HistoryStats{
+		Project: project,
+		Since:   history.FormatSince(since),
+		Runs:    make([]HistoryRun, len(runs)),
+		Flaky:   history.Flaky(runs),
+	}
+	for i, r := range runs {
+		stats.Runs[i] = HistoryRun{ID: r.ID, Time: r.Time, Project: r.Project, Passed: len(r.Passed), Failed: len(r.Failed)}
+	}
+	if stats.Flaky == nil {
+		stats.Flaky = []history.FlakyTest{}
+	}
+	return stats, nil
+}
@@ -0,0 +1,186 @@
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+)
+
+// eventBuffer is the number of events buffered for each streaming client.
+const eventBuffer = 256
+
+// NewHTTPHandler returns the HTTP API for m.
+//
+// Endpoints:
+//
+//	POST /runs              queue a run; body {"paths": [...]} (optional) -> 202 run info
+//	GET  /runs              recent, active and queued runs              -> [run info]
+//	GET  /runs/{id}         a single run                                -> run info
+//	GET  /runs/{id}/log     trailing Godot output of a run              -> text/plain
+//	POST /runs/{id}/cancel  cancel an active or queued run              -> {"cancelled": bool}
+//	GET  /status            active run, or the last finished run        -> run info
+//	GET  /results           last finished run including its output      -> run info
+//	GET  /discover?path=... list test suites                            -> {project_dir, suites}
+//	GET  /history?since=7d  runs and flaky tests of the run history     -> {project, since, runs, flaky}
+//	GET  /events            Server-Sent Events stream of run events; closed if the client falls behind
+//
+// If ui is true, the embedded dashboard is served at /.
+func NewHTTPHandler(m *Manager, ui bool) http.Handler {
+	mux := http.NewServeMux()
+
+	if ui {
+		mux.Handle("GET /{$}", dashboardHandler())
+	}
+
+	mux.HandleFunc("POST /runs", func(w http.ResponseWriter, r *http.Request) {
+		var p pathsParams
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+				return
+			}
+		}
+		info, err := m.start(p)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, info)
+	})
+
+	mux.HandleFunc("GET /runs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, m.List())
+	})
+
+	mux.HandleFunc("GET /runs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		info, ok := m.Get(r.PathValue("id"))
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("unknown run %q", r.PathValue("id")))
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	})
+
+	mux.HandleFunc("GET /runs/{id}/log", func(w http.ResponseWriter, r *http.Request) {
+		lines, ok := m.Log(r.PathValue("id"))
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("unknown run %q", r.PathValue("id")))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, l := range lines {
+			fmt.Fprintln(w, l)
+		}
+	})
+
+	mux.HandleFunc("POST /runs/{id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]bool{"cancelled": m.Cancel(r.PathValue("id"))})
+	})
+
+	mux.HandleFunc("POST /shutdown", func(w http.ResponseWriter, r *http.Request) {
+		// Answer at once: the active run may take --kill-grace to stop, and
+		// the server stops serving when it has (see Manager.Done).
+		go m.Shutdown()
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		info, ok := m.Status()
+		if !ok {
+			writeError(w, http.StatusNotFound, errors.New("no run has been started"))
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	})
+
+	mux.HandleFunc("GET /results", func(w http.ResponseWriter, r *http.Request) {
+		info, ok := m.Last()
+		if !ok {
+			writeError(w, http.StatusNotFound, errors.New("no run has finished"))
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	})
+
+	mux.HandleFunc("GET /discover", func(w http.ResponseWriter, r *http.Request) {
+		res, err := m.Discover(r.URL.Query()["path"])
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	mux.HandleFunc("GET /history", func(w http.ResponseWriter, r *http.Request) {
+		since := DefaultHistorySince
+		if s := r.URL.Query().Get("since"); s != "" {
+			var err error
+			if since, err = config.ParsePeriod(s); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since: %w", err))
+				return
+			}
+		}
+		stats, err := m.HistoryStats(since)
+		switch {
+		case errors.Is(err, errNoHistory):
+			writeError(w, http.StatusNotFound, err)
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+		default:
+			writeJSON(w, http.StatusOK, stats)
+		}
+	})
+
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+			return
+		}
+
+		// A client too slow to keep up loses its log lines, and its connection
+		// if it falls behind on other events; it never blocks the run.
+		events, lost, unsubscribe := m.SubscribeBuffered(eventBuffer)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-lost:
+				return
+			case ev := <-events:
+				data, err := json.Marshal(ev)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+				flusher.Flush()
+			}
+		}
+	})
+
+	return mux
+}
+
+// writeJSON writes v as the JSON response body with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeError writes {"error": message} with the given status.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
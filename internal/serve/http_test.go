@@ -0,0 +1,309 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+)
+
+func TestHTTP_RunAndResults(t *testing.T) {
+	m := NewManager(makeProject(t))
+	srv := httptest.NewServer(NewHTTPHandler(m, false))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /results before any run = %d, want 404", resp.StatusCode)
+	}
+
+	resp, err = http.Post(srv.URL+"/runs", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST /runs = %d, want 202", resp.StatusCode)
+	}
+	m.Wait()
+
+	resp, err = http.Get(srv.URL + "/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var info RunInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Status != StatusFinished {
+		t.Errorf("Status = %q, want %q", info.Status, StatusFinished)
+	}
+	if info.Output == nil || info.Output.Summary.Status != "passed" {
+		t.Errorf("Output = %+v, want passed summary", info.Output)
+	}
+}
+
+func TestHTTP_EventsStream(t *testing.T) {
+	m := NewManager(makeProject(t))
+	srv := httptest.NewServer(NewHTTPHandler(m, false))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	if _, err := m.Start(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan bool)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if scanner.Text() == "event: "+EventFinished {
+				done <- true
+				return
+			}
+		}
+		done <- false
+	}()
+
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Error("stream ended without a finished event")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for finished event")
+	}
+}
+
+func TestSubscribeBuffered_NeverBlocks(t *testing.T) {
+	m := NewManager(&config.Config{})
+	events, lost, unsubscribe := m.SubscribeBuffered(2)
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Nobody reads: log lines beyond the buffer are dropped, and the
+		// next state change marks the subscriber as lost.
+		for range 10 {
+			m.publish(Event{RunID: "run-1", Type: EventLog, Line: "line"})
+		}
+		m.publish(Event{RunID: "run-1", Type: EventFinished, Status: StatusFinished})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish blocked on a subscriber that does not read")
+	}
+	select {
+	case <-lost:
+	default:
+		t.Error("lost is open after a state change overflowed the buffer")
+	}
+	if len(events) != 2 {
+		t.Errorf("buffered %d events, want 2", len(events))
+	}
+}
+
+func TestHTTP_Discover(t *testing.T) {
+	m := NewManager(makeProject(t))
+	srv := httptest.NewServer(NewHTTPHandler(m, false))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/discover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var res DiscoverResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Suites) != 1 {
+		t.Errorf("len(Suites) = %d, want 1", len(res.Suites))
+	}
+}
+
+func TestRunRemote(t *testing.T) {
+	m := NewManager(makeProject(t))
+	srv := httptest.NewServer(NewHTTPHandler(m, false))
+	defer srv.Close()
+
+	info, err := RunRemote(context.Background(), srv.URL, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Status != StatusFinished {
+		t.Errorf("Status = %q, want %q", info.Status, StatusFinished)
+	}
+	if info.ExitCode == nil || *info.ExitCode != 0 {
+		t.Errorf("ExitCode = %v, want 0", info.ExitCode)
+	}
+}
+
+func TestRunRemote_Filter(t *testing.T) {
+	m := NewManager(makeProject(t))
+	srv := httptest.NewServer(NewHTTPHandler(m, false))
+	defer srv.Close()
+
+	info, err := RunRemote(context.Background(), srv.URL, nil, []string{"test_math.test_add"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.ExitCode == nil || *info.ExitCode != 0 || len(info.Filter) != 1 {
+		t.Errorf("info = %+v, want a passed run of the filter", info)
+	}
+	log, err := RemoteLog(context.Background(), srv.URL, info.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(log, "hello from godot") {
+		t.Errorf("log = %q, want the Godot output", log)
+	}
+
+	info, err = RunRemote(context.Background(), srv.URL, nil, []string{"test_missing"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Error == "" {
+		t.Errorf("info = %+v, want an error for a filter matching nothing", info)
+	}
+}
+
+func TestHTTP_Dashboard(t *testing.T) {
+	m := NewManager(makeProject(t))
+
+	srv := httptest.NewServer(NewHTTPHandler(m, true))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("GET / = %d %q, want 200 text/html", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	noUI := httptest.NewServer(NewHTTPHandler(m, false))
+	defer noUI.Close()
+	resp, err = http.Get(noUI.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET / without ui = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHTTP_RunLog(t *testing.T) {
+	m := NewManager(makeProject(t))
+	srv := httptest.NewServer(NewHTTPHandler(m, false))
+	defer srv.Close()
+
+	info, err := m.Start(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Wait()
+
+	resp, err := http.Get(srv.URL + "/runs/" + info.ID + "/log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "hello from godot") {
+		t.Errorf("log = %q, want it to contain the Godot output", body)
+	}
+}
+
+func TestHTTP_History(t *testing.T) {
+	m := NewManager(makeProject(t))
+	srv := httptest.NewServer(NewHTTPHandler(m, false))
+	defer srv.Close()
+
+	get := func(path string) *http.Response {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	resp := get("/history")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /history without a run history = %d, want 404", resp.StatusCode)
+	}
+
+	m.RecordHistory(t.TempDir())
+	for range 2 {
+		if _, err := m.Start(nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.Wait()
+
+	resp = get("/history?since=1d")
+	defer resp.Body.Close()
+	var stats HistoryStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Since != "1d" || len(stats.Runs) != 2 || stats.Runs[1].Passed != 1 || stats.Runs[1].Failed != 0 {
+		t.Errorf("history = %+v, want the 2 runs of the last 1d with 1 test passed", stats)
+	}
+	if stats.Project == "" || stats.Flaky == nil {
+		t.Errorf("history = %+v, want the server's project and no flaky tests", stats)
+	}
+
+	resp = get("/history?since=soon")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET /history?since=soon = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestHTTP_Shutdown(t *testing.T) {
+	m := NewManager(makeProject(t))
+	srv := httptest.NewServer(NewHTTPHandler(m, false))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/shutdown", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST /shutdown = %d, want 202", resp.StatusCode)
+	}
+	select {
+	case <-m.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done not closed after POST /shutdown")
+	}
+	if _, err := m.Start(nil, nil); err == nil {
+		t.Error("Start after POST /shutdown: expected error, got nil")
+	}
+}
@@ -0,0 +1,446 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/detector"
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+	"github.com/minami110/gdunit4-test-runner/internal/pipeline"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// maxRecent is the number of finished runs kept in memory.
+const maxRecent = 20
+
+// maxLogLines is the number of trailing Godot output lines kept per run.
+const maxLogLines = 500
+
+// Run status values.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusFinished  = "finished"
+	StatusCancelled = "cancelled"
+)
+
+// Event types streamed to subscribers.
+const (
+	EventQueued   = "queued"
+	EventStarted  = "started"
+	EventLog      = "log"
+	EventTest     = "test"
+	EventFinished = "finished"
+)
+
+// Event is a single progress notification for a run. A test event reports
+// that a test is done: Status is then "passed", "failed", "error" or "skipped".
+type Event struct {
+	RunID    string         `json:"run_id"`
+	Type     string         `json:"type"`
+	Line     string         `json:"line,omitempty"`
+	Suite    string         `json:"suite,omitempty"` // res:// path of the suite of a test event
+	Test     string         `json:"test,omitempty"`  // test of a test event
+	ID       string         `json:"id,omitempty"`    // test ID of a test event; see TestID
+	Status   string         `json:"status,omitempty"`
+	ExitCode *int           `json:"exit_code,omitempty"`
+	Output   *report.Output `json:"output,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// RunInfo is a snapshot of a run's state.
+type RunInfo struct {
+	ID       string         `json:"run_id"`
+	Paths    []string       `json:"paths"`
+	Filter   []string       `json:"filter,omitempty"`
+	Status   string         `json:"status"`
+	ExitCode *int           `json:"exit_code,omitempty"`
+	Output   *report.Output `json:"output,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// DiscoverResult is the response to a discovery request.
+type DiscoverResult struct {
+	ProjectDir string            `json:"project_dir"`
+	Suites     []discovery.Suite `json:"suites"`
+}
+
+// Manager queues runs, executes them one at a time, keeps recent results in memory
+// and fans out events to subscribers. Runs are serialized because gdUnit4 writes
+// reports into the project directory.
+type Manager struct {
+	base       *config.Config
+	historyDir string // run history runs are recorded in; see RecordHistory
+
+	mu          sync.Mutex
+	nextID      int
+	working     bool
+	closed      bool          // Shutdown was called; no more runs are started
+	done        chan struct{} // closed when closed is set; see Done
+	current     *run
+	queue       []*run
+	recent      []*run // finished runs, oldest first, at most maxRecent
+	subscribers map[int]func(Event)
+	nextSub     int
+}
+
+type run struct {
+	info   RunInfo
+	log    []string // last maxLogLines lines of Godot output; guarded by Manager.mu
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewManager returns a Manager that starts runs using base as the default configuration.
+func NewManager(base *config.Config) *Manager {
+	return &Manager{
+		base:        base,
+		done:        make(chan struct{}),
+		subscribers: map[int]func(Event){},
+	}
+}
+
+// RecordHistory makes m record the runs it executes in the run history in dir
+// (see internal/history), which stats flaky reads. It must be called before
+// the first run starts.
+func (m *Manager) RecordHistory(dir string) {
+	m.historyDir = dir
+}
+
+// Subscribe registers fn to receive every event. fn is called synchronously from
+// the run goroutine and must not block for long. The returned func unsubscribes.
+func (m *Manager) Subscribe(fn func(Event)) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.nextSub
+	m.nextSub++
+	m.subscribers[id] = fn
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.subscribers, id)
+	}
+}
+
+// SubscribeBuffered registers a subscriber that receives events through a
+// channel buffering up to size of them, so that a slow reader never blocks a
+// run. When the buffer is full, log events are dropped; any other event closes
+// lost instead, since the reader would miss a change of a run's state, and
+// the reader should then give up. The returned func unsubscribes.
+func (m *Manager) SubscribeBuffered(size int) (events <-chan Event, lost <-chan struct{}, unsubscribe func()) {
+	ch := make(chan Event, size)
+	overflow := make(chan struct{})
+	var once sync.Once
+	unsubscribe = m.Subscribe(func(ev Event) {
+		select {
+		case ch <- ev:
+		default:
+			if ev.Type != EventLog {
+				once.Do(func() { close(overflow) })
+			}
+		}
+	})
+	return ch, overflow, unsubscribe
+}
+
+// Discover lists the test suites under paths (or the base paths if empty).
+func (m *Manager) Discover(paths []string) (*DiscoverResult, error) {
+	if len(paths) == 0 {
+		paths = m.base.TestPaths
+	}
+	detected, err := detector.DetectIn(m.base.ProjectDir, paths)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := discovery.Discover(detected.ProjectDir, detected.ResPaths)
+	if err != nil {
+		return nil, err
+	}
+	if suites == nil {
+		suites = []discovery.Suite{}
+	}
+	return &DiscoverResult{ProjectDir: detected.ProjectDir, Suites: suites}, nil
+}
+
+// Start queues a run for paths (or the base paths if empty), narrowed to the
+// tests matching filter (or the base filter if empty). It starts immediately
+// if no other run is active, and fails once the Manager is shut down.
+func (m *Manager) Start(paths, filter []string) (RunInfo, error) {
+	if len(paths) == 0 {
+		paths = m.base.TestPaths
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return RunInfo{}, errors.New("the server is shutting down")
+	}
+	m.nextID++
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &run{
+		info: RunInfo{
+			ID:     fmt.Sprintf("run-%d", m.nextID),
+			Paths:  paths,
+			Filter: filter,
+			Status: StatusQueued,
+		},
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.queue = append(m.queue, r)
+	startWorker := !m.working
+	m.working = true
+	info := r.info
+	m.mu.Unlock()
+
+	m.publish(Event{RunID: info.ID, Type: EventQueued, Status: StatusQueued})
+	if startWorker {
+		go m.work()
+	}
+	return info, nil
+}
+
+// work executes queued runs until the queue is empty.
+func (m *Manager) work() {
+	for {
+		m.mu.Lock()
+		if len(m.queue) == 0 {
+			m.working = false
+			m.mu.Unlock()
+			return
+		}
+		r := m.queue[0]
+		m.queue = m.queue[1:]
+		r.info.Status = StatusRunning
+		m.current = r
+		m.mu.Unlock()
+
+		m.publish(Event{RunID: r.info.ID, Type: EventStarted, Status: StatusRunning})
+		m.execute(r)
+	}
+}
+
+// execute runs the pipeline for r and publishes its events.
+func (m *Manager) execute(r *run) {
+	cfg := *m.base
+	cfg.TestPaths = r.info.Paths
+	if len(r.info.Filter) > 0 {
+		cfg.Filter = r.info.Filter
+	}
+
+	res, err := pipeline.Execute(r.ctx, &cfg, pipeline.Options{
+		HistoryDir: m.historyDir,
+		OnLine: func(line string) {
+			m.mu.Lock()
+			r.log = append(r.log, line)
+			if len(r.log) > maxLogLines {
+				r.log = r.log[len(r.log)-maxLogLines:]
+			}
+			m.mu.Unlock()
+			m.publish(Event{RunID: r.info.ID, Type: EventLog, Line: line})
+			if suite, test, status, ok := report.TestResult(line); ok {
+				m.publish(Event{RunID: r.info.ID, Type: EventTest, Suite: suite, Test: test, ID: TestID(suite, test), Status: status})
+			}
+		},
+	})
+
+	m.mu.Lock()
+	code := res.ExitCode
+	r.info.ExitCode = &code
+	r.info.Output = res.Output
+	r.info.Status = StatusFinished
+	if r.ctx.Err() != nil {
+		r.info.Status = StatusCancelled
+	}
+	if err != nil {
+		r.info.Error = err.Error()
+	}
+	m.current = nil
+	m.finishLocked(r)
+	m.mu.Unlock()
+}
+
+// finishLocked records r as finished, publishes its final event and releases waiters.
+// m.mu must be held; it is released while publishing.
+func (m *Manager) finishLocked(r *run) {
+	m.recent = append(m.recent, r)
+	if len(m.recent) > maxRecent {
+		m.recent = m.recent[len(m.recent)-maxRecent:]
+	}
+	info := r.info
+	r.cancel()
+
+	m.mu.Unlock()
+	m.publish(Event{
+		RunID:    info.ID,
+		Type:     EventFinished,
+		Status:   info.Status,
+		ExitCode: info.ExitCode,
+		Output:   info.Output,
+		Error:    info.Error,
+	})
+	close(r.done)
+	m.mu.Lock()
+}
+
+// Cancel stops the run with the given ID, or the active run if id is empty.
+// Queued runs are removed from the queue. It reports whether a matching run was found.
+func (m *Manager) Cancel(id string) bool {
+	m.mu.Lock()
+	if r := m.current; r != nil && (id == "" || r.info.ID == id) {
+		m.mu.Unlock()
+		r.cancel()
+		<-r.done
+		return true
+	}
+	for i, r := range m.queue {
+		if r.info.ID != id {
+			continue
+		}
+		m.queue = append(m.queue[:i], m.queue[i+1:]...)
+		r.info.Status = StatusCancelled
+		m.finishLocked(r)
+		m.mu.Unlock()
+		return true
+	}
+	m.mu.Unlock()
+	return false
+}
+
+// Shutdown stops the Manager: it refuses new runs, cancels the queued runs,
+// then cancels the active run and waits for it to finish, so that no Godot
+// process outlives the server.
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.done)
+	}
+	queued := m.queue
+	m.queue = nil
+	for _, r := range queued {
+		r.info.Status = StatusCancelled
+		m.finishLocked(r)
+	}
+	r := m.current
+	m.mu.Unlock()
+
+	if r != nil {
+		r.cancel()
+		<-r.done
+	}
+}
+
+// Done returns a channel that is closed once Shutdown is called, such as by a
+// client's shutdown request, so that the server can stop serving.
+func (m *Manager) Done() <-chan struct{} {
+	return m.done
+}
+
+// Status returns the active run if any, otherwise the last finished run.
+// ok is false if no run has been started yet.
+func (m *Manager) Status() (info RunInfo, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case m.current != nil:
+		return m.current.info, true
+	case len(m.queue) > 0:
+		return m.queue[0].info, true
+	case len(m.recent) > 0:
+		return m.recent[len(m.recent)-1].info, true
+	}
+	return RunInfo{}, false
+}
+
+// Last returns the most recently finished run. ok is false if no run has finished yet.
+func (m *Manager) Last() (info RunInfo, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.recent) == 0 {
+		return RunInfo{}, false
+	}
+	return m.recent[len(m.recent)-1].info, true
+}
+
+// Get returns the run with the given ID if it is active, queued, or among the recent runs.
+func (m *Manager) Get(id string) (info RunInfo, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.all() {
+		if r.info.ID == id {
+			return r.info, true
+		}
+	}
+	return RunInfo{}, false
+}
+
+// Log returns the trailing Godot output lines of the run with the given ID.
+func (m *Manager) Log(id string) (lines []string, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.all() {
+		if r.info.ID == id {
+			return append([]string{}, r.log...), true
+		}
+	}
+	return nil, false
+}
+
+// List returns the recent, active and queued runs, oldest first.
+func (m *Manager) List() []RunInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	runs := m.all()
+	infos := make([]RunInfo, 0, len(runs))
+	for _, r := range runs {
+		infos = append(infos, r.info)
+	}
+	return infos
+}
+
+// all returns every known run, oldest first. m.mu must be held.
+func (m *Manager) all() []*run {
+	runs := append([]*run{}, m.recent...)
+	if m.current != nil {
+		runs = append(runs, m.current)
+	}
+	return append(runs, m.queue...)
+}
+
+// Wait blocks until the active run and all queued runs have finished.
+func (m *Manager) Wait() {
+	for {
+		m.mu.Lock()
+		var r *run
+		switch {
+		case len(m.queue) > 0:
+			r = m.queue[len(m.queue)-1]
+		case m.current != nil:
+			r = m.current
+		}
+		m.mu.Unlock()
+		if r == nil {
+			return
+		}
+		<-r.done
+	}
+}
+
+// publish delivers ev to every subscriber.
+func (m *Manager) publish(ev Event) {
+	m.mu.Lock()
+	subs := make([]func(Event), 0, len(m.subscribers))
+	for _, fn := range m.subscribers {
+		subs = append(subs, fn)
+	}
+	m.mu.Unlock()
+	for _, fn := range subs {
+		fn(ev)
+	}
+}
@@ -0,0 +1,114 @@
+package serve
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Protocol buffer wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// protoBuf builds a protocol buffer message. As in proto3, scalar fields with
+// their zero value are left out.
+type protoBuf []byte
+
+func (b *protoBuf) tag(num, typ int) {
+	*b = binary.AppendUvarint(*b, uint64(num)<<3|uint64(typ))
+}
+
+func (b *protoBuf) bytes(num int, v []byte) {
+	b.tag(num, wireBytes)
+	*b = binary.AppendUvarint(*b, uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *protoBuf) string(num int, s string) {
+	if s != "" {
+		b.bytes(num, []byte(s))
+	}
+}
+
+// strings appends a repeated string field, keeping empty elements.
+func (b *protoBuf) strings(num int, ss []string) {
+	for _, s := range ss {
+		b.bytes(num, []byte(s))
+	}
+}
+
+// int appends an int32 field; negative values take ten bytes, as in protobuf.
+func (b *protoBuf) int(num, v int) {
+	if v != 0 {
+		b.optionalInt(num, &v)
+	}
+}
+
+// optionalInt appends an optional int32 field, which is present even when 0.
+func (b *protoBuf) optionalInt(num int, v *int) {
+	if v != nil {
+		b.tag(num, wireVarint)
+		*b = binary.AppendUvarint(*b, uint64(int64(*v)))
+	}
+}
+
+func (b *protoBuf) bool(num int, v bool) {
+	if v {
+		b.tag(num, wireVarint)
+		*b = append(*b, 1)
+	}
+}
+
+// message appends an embedded message field.
+func (b *protoBuf) message(num int, m protoBuf) {
+	b.bytes(num, m)
+}
+
+// protoFields calls fn for every field of the protocol buffer message b with
+// its number, its wire type and its value: v for varints, data for
+// length-delimited fields. Fixed-size fields are skipped.
+func protoFields(b []byte, fn func(num, typ int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errors.New("malformed protobuf field key")
+		}
+		b = b[n:]
+		num, typ := int(key>>3), int(key&7)
+		var v uint64
+		var data []byte
+		switch typ {
+		case wireVarint:
+			if v, n = binary.Uvarint(b); n <= 0 {
+				return fmt.Errorf("malformed protobuf varint of field %d", num)
+			}
+			b = b[n:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || size > uint64(len(b)-n) {
+				return fmt.Errorf("malformed protobuf length of field %d", num)
+			}
+			data, b = b[n:n+int(size)], b[n+int(size):]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if typ == wireFixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return fmt.Errorf("truncated protobuf field %d", num)
+			}
+			b = b[size:]
+			continue
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d of field %d", typ, num)
+		}
+		if err := fn(num, typ, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package serve
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeServerError    = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// pathsParams is the params object for discover and run. Discover ignores
+// Filter and Tests; a run of Tests ignores Paths and Filter.
+type pathsParams struct {
+	Paths  []string `json:"paths"`
+	Filter []string `json:"filter,omitempty"`
+	Tests  []string `json:"tests,omitempty"` // test IDs; see TestID
+}
+
+// cancelParams is the params object for cancel.
+type cancelParams struct {
+	RunID string `json:"run_id"`
+}
+
+// ServeStdio speaks newline-delimited JSON-RPC 2.0 on r and w until r reaches EOF
+// or a shutdown request is received. Run events are sent as "event" notifications,
+// among them a "test" event for every test done, so that a test explorer such
+// as VS Code's can show results as they come.
+//
+// Methods:
+//
+//	discover {paths}  -> {project_dir, suites}
+//	run      {paths, filter} -> {run_id, paths, status}; queued behind any active run
+//	run      {tests}  -> the same for the suites and tests with these IDs
+//	cancel   {run_id} -> {cancelled}
+//	status   {}       -> run info of the active or last run
+//	runs     {}       -> [run info] for recent, active and queued runs
+//	shutdown {}       -> null; cancels any active run and returns
+func ServeStdio(m *Manager, r io.Reader, w io.Writer) error {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	send := func(v any) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = enc.Encode(v)
+	}
+
+	unsubscribe := m.Subscribe(func(ev Event) {
+		send(rpcNotification{JSONRPC: "2.0", Method: "event", Params: ev})
+	})
+	defer unsubscribe()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			send(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			send(rpcResponse{JSONRPC: "2.0", ID: idOrNull(req.ID), Error: &rpcError{Code: codeInvalidRequest, Message: "invalid request"}})
+			continue
+		}
+
+		result, rerr := dispatch(m, req)
+		if req.Method == "shutdown" && rerr == nil {
+			send(rpcResponse{JSONRPC: "2.0", ID: idOrNull(req.ID), Result: json.RawMessage("null")})
+			return nil
+		}
+		if req.ID == nil {
+			// Notification from the client; no response expected.
+			continue
+		}
+		send(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr})
+	}
+
+	// Client closed the stream: don't leave Godot running behind it.
+	m.Shutdown()
+	return scanner.Err()
+}
+
+// dispatch executes a single request against m.
+func dispatch(m *Manager, req rpcRequest) (any, *rpcError) {
+	switch req.Method {
+	case "discover":
+		var p pathsParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		res, err := m.Discover(p.Paths)
+		if err != nil {
+			return nil, &rpcError{Code: codeServerError, Message: err.Error()}
+		}
+		return res, nil
+	case "run":
+		var p pathsParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		info, err := m.start(p)
+		if err != nil {
+			return nil, &rpcError{Code: codeServerError, Message: err.Error()}
+		}
+		return info, nil
+	case "cancel":
+		var p cancelParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return map[string]bool{"cancelled": m.Cancel(p.RunID)}, nil
+	case "status":
+		info, ok := m.Status()
+		if !ok {
+			return nil, &rpcError{Code: codeServerError, Message: "no run has been started"}
+		}
+		return info, nil
+	case "runs":
+		return m.List(), nil
+	case "shutdown":
+		m.Shutdown()
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// decodeParams unmarshals raw into v; absent params leave v at its zero value.
+func decodeParams(raw json.RawMessage, v any) *rpcError {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// idOrNull returns id, or a JSON null if the request had none.
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return json.RawMessage("null")
+	}
+	return id
+}
@@ -0,0 +1,295 @@
+package serve
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/config"
+	"github.com/minami110/gdunit4-test-runner/internal/history"
+	"github.com/minami110/gdunit4-test-runner/internal/testutil"
+)
+
+const passingXML = `<testsuites tests="1" failures="0" errors="0">
+  <testsuite name="test_math" tests="1"><testcase name="test_add" classname="test_math"/></testsuite>
+</testsuites>`
+
+// makeProject creates a Godot project with one suite and a fake godot script that
+// writes a passing report. It returns a base config for the project.
+func makeProject(t *testing.T) *config.Config {
+	t.Helper()
+	script := testutil.FakeGodot(t, "echo 'hello from godot'\n"+
+		"mkdir -p reports/report_1 && cp tests/results.xml.src reports/report_1/results.xml\n")
+	root := testutil.Project(t, map[string]string{
+		"tests/test_math.gd":    "extends GdUnitTestSuite\n\nfunc test_add() -> void:\n\tpass\n",
+		"tests/results.xml.src": passingXML,
+	})
+	return &config.Config{TestPaths: []string{filepath.Join(root, "tests")}, GodotPath: script}
+}
+
+// rpcClient drives ServeStdio through in-memory pipes.
+type rpcClient struct {
+	t      *testing.T
+	w      io.WriteCloser
+	lines  chan map[string]any
+	nextID int
+}
+
+func newRPCClient(t *testing.T, m *Manager) *rpcClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &rpcClient{t: t, w: inW, lines: make(chan map[string]any, 100)}
+
+	go func() {
+		_ = ServeStdio(m, inR, outW)
+		outW.Close()
+	}()
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			var msg map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err == nil {
+				c.lines <- msg
+			}
+		}
+		close(c.lines)
+	}()
+	t.Cleanup(func() { inW.Close() })
+	return c
+}
+
+// call sends a request and returns its response, skipping notifications.
+func (c *rpcClient) call(method string, params any) map[string]any {
+	c.t.Helper()
+	c.nextID++
+	req, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+	fmt.Fprintf(c.w, "%s\n", req)
+	for {
+		msg := c.next()
+		if id, ok := msg["id"].(float64); ok && int(id) == c.nextID {
+			return msg
+		}
+	}
+}
+
+// next returns the next message from the server.
+func (c *rpcClient) next() map[string]any {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.lines:
+		if !ok {
+			c.t.Fatal("server closed the stream")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for server message")
+	}
+	return nil
+}
+
+func TestServeStdio_Discover(t *testing.T) {
+	c := newRPCClient(t, NewManager(makeProject(t)))
+
+	resp := c.call("discover", nil)
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	result := resp["result"].(map[string]any)
+	suites := result["suites"].([]any)
+	if len(suites) != 1 {
+		t.Fatalf("len(suites) = %d, want 1", len(suites))
+	}
+	if got := suites[0].(map[string]any)["res_path"]; got != "res://tests/test_math.gd" {
+		t.Errorf("res_path = %v, want res://tests/test_math.gd", got)
+	}
+}
+
+func TestServeStdio_RunStreamsEvents(t *testing.T) {
+	c := newRPCClient(t, NewManager(makeProject(t)))
+
+	resp := c.call("run", nil)
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+
+	sawLog := false
+	for {
+		msg := c.next()
+		if msg["method"] != "event" {
+			continue
+		}
+		ev := msg["params"].(map[string]any)
+		switch ev["type"] {
+		case EventLog:
+			if ev["line"] == "hello from godot" {
+				sawLog = true
+			}
+		case EventFinished:
+			if !sawLog {
+				t.Error("expected a log event before finished")
+			}
+			if ev["exit_code"] != float64(0) {
+				t.Errorf("exit_code = %v, want 0", ev["exit_code"])
+			}
+			return
+		}
+	}
+}
+
+func TestServeStdio_UnknownMethod(t *testing.T) {
+	c := newRPCClient(t, NewManager(makeProject(t)))
+
+	resp := c.call("bogus", nil)
+	rerr, ok := resp["error"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected error response, got %v", resp)
+	}
+	if rerr["code"] != float64(codeMethodNotFound) {
+		t.Errorf("code = %v, want %d", rerr["code"], codeMethodNotFound)
+	}
+}
+
+func TestManager_QueuesWhileBusy(t *testing.T) {
+	cfg := makeProject(t)
+	slow := testutil.FakeGodot(t, "exec sleep 5\n")
+	cfg.GodotPath = slow
+	m := NewManager(cfg)
+
+	first, err := m.Start(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := m.Start(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.Status != StatusQueued {
+		t.Errorf("second Status = %q, want %q", second.Status, StatusQueued)
+	}
+
+	if !m.Cancel(second.ID) {
+		t.Error("Cancel should find the queued run")
+	}
+	if !m.Cancel(first.ID) {
+		t.Error("Cancel should find the active run")
+	}
+	m.Wait()
+
+	runs := m.List()
+	if len(runs) != 2 {
+		t.Fatalf("len(List()) = %d, want 2", len(runs))
+	}
+	for _, info := range runs {
+		if info.Status != StatusCancelled {
+			t.Errorf("run %s Status = %q, want %q", info.ID, info.Status, StatusCancelled)
+		}
+	}
+	if _, ok := m.Get(first.ID); !ok {
+		t.Errorf("Get(%q) should find the finished run", first.ID)
+	}
+}
+
+func TestManager_ShutdownCancelsQueue(t *testing.T) {
+	cfg := makeProject(t)
+	slow := testutil.FakeGodot(t, "exec sleep 5\n")
+	cfg.GodotPath = slow
+	m := NewManager(cfg)
+
+	for range 3 {
+		if _, err := m.Start(nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	start := time.Now()
+	m.Shutdown()
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("Shutdown took %v, want the active run cancelled rather than waited for", elapsed)
+	}
+
+	runs := m.List()
+	if len(runs) != 3 {
+		t.Fatalf("len(List()) = %d, want 3", len(runs))
+	}
+	for _, info := range runs {
+		if info.Status != StatusCancelled {
+			t.Errorf("run %s Status = %q, want %q", info.ID, info.Status, StatusCancelled)
+		}
+	}
+	if _, err := m.Start(nil, nil); err == nil {
+		t.Error("Start after Shutdown: expected error, got nil")
+	}
+}
+
+func TestManager_RecordHistory(t *testing.T) {
+	m := NewManager(makeProject(t))
+	dir := t.TempDir()
+	m.RecordHistory(dir)
+
+	info, err := m.Start(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.Wait()
+
+	runs, err := history.Read(dir, "", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || !slices.Equal(runs[0].Passed, []string{"test_math.test_add"}) {
+		t.Fatalf("history = %+v, want the run of %s with test_math.test_add passed", runs, info.ID)
+	}
+}
+
+func TestServeStdio_RunTestsStreamsResults(t *testing.T) {
+	cfg := makeProject(t)
+	content := "echo 'Run Test: res://tests/test_math.gd > test_add :PASSED 1ms'\n" +
+		"mkdir -p reports/report_1 && cp tests/results.xml.src reports/report_1/results.xml\n"
+	script := testutil.FakeGodot(t, content)
+	cfg.GodotPath = script
+	c := newRPCClient(t, NewManager(cfg))
+
+	resp := c.call("run", map[string]any{"tests": []string{"res://tests/test_math.gd:test_add"}})
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	if filter := resp["result"].(map[string]any)["filter"]; fmt.Sprint(filter) != "[test_math.test_add]" {
+		t.Errorf("filter = %v, want [test_math.test_add]", filter)
+	}
+
+	sawTest := false
+	for {
+		msg := c.next()
+		if msg["method"] != "event" {
+			continue
+		}
+		ev := msg["params"].(map[string]any)
+		switch ev["type"] {
+		case EventTest:
+			if ev["id"] != "res://tests/test_math.gd:test_add" || ev["status"] != "passed" {
+				t.Errorf("test event = %v, want test_add passed", ev)
+			}
+			sawTest = true
+		case EventFinished:
+			if !sawTest {
+				t.Error("expected a test event before finished")
+			}
+			return
+		}
+	}
+}
+
+func TestServeStdio_RunTestsUnknown(t *testing.T) {
+	c := newRPCClient(t, NewManager(makeProject(t)))
+
+	for _, id := range []string{"res://tests/test_math.gd:test_nope", "res://tests/test_none.gd", "tests/test_math.gd"} {
+		resp := c.call("run", map[string]any{"tests": []string{id}})
+		if resp["error"] == nil {
+			t.Errorf("run %s: expected an error, got %v", id, resp["result"])
+		}
+	}
+}
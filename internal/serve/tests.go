@@ -0,0 +1,106 @@
+package serve
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+)
+
+// TestID returns the ID of test in the suite at the res:// path suite, in the
+// "res://path/suite.gd:test_name" form gdUnit4 selects tests by. A suite's own
+// ID is its res:// path.
+func TestID(suite, test string) string {
+	return suite + ":" + test
+}
+
+// splitTestID splits a test ID into its suite and test; test is empty for the
+// ID of a whole suite.
+func splitTestID(id string) (suite, test string) {
+	// The colon of "res://" is not a separator.
+	if i := strings.LastIndex(id, ":"); i > len("res:/") {
+		return id[:i], id[i+1:]
+	}
+	return id, ""
+}
+
+// StartTests queues a run of the suites and tests with the given IDs (see
+// TestID), as a test explorer selects them. It fails if an ID names no
+// discovered suite or test.
+func (m *Manager) StartTests(ids []string) (RunInfo, error) {
+	var paths []string
+	tests := map[string][]string{} // selected tests by suite; nil for a whole suite
+	for _, id := range ids {
+		suite, test := splitTestID(id)
+		if !strings.HasPrefix(suite, "res://") {
+			return RunInfo{}, fmt.Errorf("invalid test ID %q: want res://path/suite.gd or res://path/suite.gd:test_name", id)
+		}
+		selected, seen := tests[suite]
+		if !seen {
+			paths = append(paths, suite)
+		}
+		switch {
+		case test == "" || (seen && selected == nil):
+			tests[suite] = nil
+		case !slices.Contains(selected, test):
+			tests[suite] = append(selected, test)
+		}
+	}
+	if len(paths) == 0 {
+		return RunInfo{}, errors.New("no test IDs given")
+	}
+
+	// The IDs are res:// paths of the project of the server's paths, which
+	// need not be the working directory's.
+	base, err := m.Discover(nil)
+	if err != nil {
+		return RunInfo{}, err
+	}
+	found, err := discovery.Discover(base.ProjectDir, paths)
+	if err != nil {
+		return RunInfo{}, err
+	}
+	suites := map[string]discovery.Suite{}
+	for _, s := range found {
+		suites[s.ResPath] = s
+	}
+
+	// Narrow the run with class-qualified filters only if some suite is not
+	// run as a whole; the filter then has to let the whole suites through too.
+	var files, filter []string
+	narrowed := false
+	for _, p := range paths {
+		s, ok := suites[p]
+		if !ok {
+			return RunInfo{}, fmt.Errorf("no test suite %s", p)
+		}
+		files = append(files, filepath.Join(base.ProjectDir, filepath.FromSlash(strings.TrimPrefix(p, "res://"))))
+		if tests[p] == nil {
+			filter = append(filter, s.Class+".*")
+			continue
+		}
+		narrowed = true
+		for _, test := range tests[p] {
+			if !slices.Contains(s.Tests, test) {
+				return RunInfo{}, fmt.Errorf("no test %s in %s", test, p)
+			}
+			filter = append(filter, s.Class+"."+test)
+		}
+	}
+	if !narrowed {
+		filter = nil
+	}
+	return m.Start(files, filter)
+}
+
+// start queues the run p asks for: of its test IDs if any, otherwise of its
+// paths and filter.
+func (m *Manager) start(p pathsParams) (RunInfo, error) {
+	if len(p.Tests) > 0 {
+		return m.StartTests(p.Tests)
+	}
+	return m.Start(p.Paths, p.Filter)
+}
@@ -0,0 +1,22 @@
+package serve
+
+import "testing"
+
+func TestSplitTestID(t *testing.T) {
+	tests := []struct {
+		id, suite, test string
+	}{
+		{"res://tests/test_math.gd:test_add", "res://tests/test_math.gd", "test_add"},
+		{"res://tests/test_math.gd", "res://tests/test_math.gd", ""},
+		{"res://test_math.gd", "res://test_math.gd", ""},
+	}
+	for _, tt := range tests {
+		suite, test := splitTestID(tt.id)
+		if suite != tt.suite || test != tt.test {
+			t.Errorf("splitTestID(%q) = %q, %q, want %q, %q", tt.id, suite, test, tt.suite, tt.test)
+		}
+		if tt.test != "" && TestID(suite, test) != tt.id {
+			t.Errorf("TestID(%q, %q) = %q, want %q", suite, test, TestID(suite, test), tt.id)
+		}
+	}
+}
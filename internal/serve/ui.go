@@ -0,0 +1,23 @@
+package serve
+
+import (
+	"embed"
+	"net/http"
+)
+
+//go:embed ui/index.html
+var uiFS embed.FS
+
+// dashboardHandler serves the embedded single-page dashboard.
+// The page talks to the same HTTP API (/runs, /events, ...) as any other client.
+func dashboardHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := uiFS.ReadFile("ui/index.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(data)
+	})
+}
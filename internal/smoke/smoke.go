@@ -0,0 +1,155 @@
+// Package smoke exports a Godot project with an export preset and runs a scene
+// or script against the exported binary, to catch breakages that only show in
+// exported builds, e.g. resources excluded from the export or editor-only code.
+package smoke
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Options describes a smoke run.
+type Options struct {
+	GodotPath  string // Godot editor binary used to export
+	ProjectDir string
+	Preset     string // export preset name from export_presets.cfg
+	ExportPath string // where the build is written; its extension must suit the preset's platform
+	Run        string // res:// scene or .gd script to run; empty runs the main scene
+	Timeout    time.Duration
+	LogFile    string // captures the output of the export and of the run
+}
+
+// Export exports the project with opts.Preset to opts.ExportPath.
+func Export(ctx context.Context, opts Options) error {
+	if err := os.MkdirAll(filepath.Dir(opts.ExportPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, opts.GodotPath, "--headless", "--path", opts.ProjectDir, "--export-release", opts.Preset, opts.ExportPath)
+	out, err := cmd.CombinedOutput()
+	appendLog(opts.LogFile, out)
+	if err == nil {
+		if _, statErr := os.Stat(opts.ExportPath); statErr != nil {
+			err = errors.New("Godot wrote no build")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("export with preset %q failed: %w\n%s", opts.Preset, err, lastLines(string(out), 20))
+	}
+	return nil
+}
+
+// Run runs opts.Run with the exported build and reports it in the runner's
+// output format as a single test: passed if the build exited with 0 and
+// printed no script error, failed on another exit code, crashed otherwise.
+func Run(ctx context.Context, opts Options) (*report.Output, error) {
+	binary, err := executable(opts.ExportPath)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"--headless"}
+	switch {
+	case strings.HasSuffix(opts.Run, ".gd"):
+		args = append(args, "-s", opts.Run)
+	case opts.Run != "":
+		args = append(args, opts.Run)
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	logFile, err := os.CreateTemp("", "gdunit4-smoke-*.log")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp log file: %w", err)
+	}
+	defer os.Remove(logFile.Name())
+	defer logFile.Close()
+
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Dir = filepath.Dir(binary)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	runErr := cmd.Run()
+	logFile.Close()
+	if data, err := os.ReadFile(logFile.Name()); err == nil {
+		appendLog(opts.LogFile, data)
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("smoke run of %s did not finish: %w", binary, ctx.Err())
+	}
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return nil, fmt.Errorf("failed to run %s: %w", binary, runErr)
+	}
+
+	crash, err := report.DetectCrash(logFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	out := &report.Output{
+		Summary:      report.Summary{Total: 1, Crashed: crash != nil},
+		CrashDetails: crash,
+		Failures:     []report.Failure{},
+	}
+	switch {
+	case crash != nil:
+		out.Summary.Status = "crashed"
+	case exitErr != nil:
+		name := cmp.Or(opts.Run, "main scene")
+		out.Summary.Failed = 1
+		out.Summary.Status = "failed"
+		out.Failures = append(out.Failures, report.Failure{
+			Kind:    report.KindFailure,
+			Class:   "smoke",
+			Method:  strings.TrimSuffix(path.Base(name), path.Ext(name)),
+			File:    opts.Run,
+			Message: fmt.Sprintf("exported build exited with %d", exitErr.ExitCode()),
+		})
+	default:
+		out.Summary.Passed = 1
+		out.Summary.Status = "passed"
+	}
+	return out, nil
+}
+
+// executable returns the binary to start for an export at exportPath: the
+// executable inside a macOS .app bundle, otherwise exportPath itself.
+func executable(exportPath string) (string, error) {
+	if !strings.HasSuffix(exportPath, ".app") {
+		return exportPath, nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(exportPath, "Contents", "MacOS", "*"))
+	if len(matches) != 1 {
+		return "", fmt.Errorf("cannot tell the executable of %s", exportPath)
+	}
+	return matches[0], nil
+}
+
+// appendLog appends out to logFile, if set.
+func appendLog(logFile string, out []byte) {
+	if logFile == "" {
+		return
+	}
+	f, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(out)
+}
+
+// lastLines returns the last n lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	return strings.Join(lines[max(len(lines)-n, 0):], "\n")
+}
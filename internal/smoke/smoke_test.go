@@ -0,0 +1,74 @@
+package smoke
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/testutil"
+)
+
+// fakeGodot writes a Godot stand-in whose export writes build as the exported binary.
+func fakeGodot(t *testing.T, build string) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "build.sh")
+	if err := os.WriteFile(src, []byte(build), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Arguments: --headless --path <project> --export-release <preset> <path>
+	return testutil.FakeGodot(t, "echo \"exporting $5\"\ncp "+src+" \"$6\"\n")
+}
+
+func TestExportAndRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		build  string
+		status string
+	}{
+		{"passed", "#!/bin/sh\necho \"running $@\"\n", "passed"},
+		{"failed", "#!/bin/sh\nexit 3\n", "failed"},
+		{"crashed", "#!/bin/sh\necho 'SCRIPT ERROR: Invalid call.'\necho 'handle_crash: signal 11'\nexit 1\n", "crashed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := Options{
+				GodotPath:  fakeGodot(t, tt.build),
+				ProjectDir: dir,
+				Preset:     "Linux",
+				ExportPath: filepath.Join(dir, "build", "game.x86_64"),
+				Run:        "res://smoke/boot.tscn",
+				LogFile:    filepath.Join(dir, "smoke.log"),
+			}
+			if err := Export(context.Background(), opts); err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			out, err := Run(context.Background(), opts)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if out.Summary.Status != tt.status || out.Summary.Total != 1 {
+				t.Errorf("Summary = %+v, want status %s", out.Summary, tt.status)
+			}
+			log, _ := os.ReadFile(opts.LogFile)
+			if !strings.Contains(string(log), "exporting Linux") {
+				t.Errorf("log = %q, want the export output", log)
+			}
+			if tt.status == "passed" && !strings.Contains(string(log), "running --headless res://smoke/boot.tscn") {
+				t.Errorf("log = %q, want the build run with the scene", log)
+			}
+		})
+	}
+}
+
+func TestExport_NoBuild(t *testing.T) {
+	dir := t.TempDir()
+	godot := testutil.FakeGodot(t, "echo 'ERROR: No export template found'\n")
+	err := Export(context.Background(), Options{GodotPath: godot, ProjectDir: dir, Preset: "Linux", ExportPath: filepath.Join(dir, "game")})
+	if err == nil || !strings.Contains(err.Error(), "No export template found") {
+		t.Errorf("err = %v, want the export error with Godot's output", err)
+	}
+}
@@ -0,0 +1,69 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Attach adds a snapshot diff to every failure in out whose test left a received
+// snapshot under projectDir.
+func Attach(out *report.Output, projectDir string) error {
+	if len(out.Failures) == 0 {
+		return nil
+	}
+	files, err := Find(projectDir)
+	if err != nil {
+		return err
+	}
+
+	received := map[string]File{}
+	for _, f := range files {
+		if f.Kind == Received {
+			received[f.Suite+"."+f.Test] = f
+		}
+	}
+
+	for i := range out.Failures {
+		f, ok := received[out.Failures[i].Class+"."+out.Failures[i].Method]
+		if !ok {
+			continue
+		}
+		d, err := diffFile(f, projectDir)
+		if err != nil {
+			return err
+		}
+		out.Failures[i].Snapshot = d
+	}
+	return nil
+}
+
+// diffFile compares the received snapshot f with its approved counterpart, which may not exist yet.
+func diffFile(f File, projectDir string) (*report.SnapshotDiff, error) {
+	got, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	want, err := os.ReadFile(f.ApprovedPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	approved, received := resPath(f.ApprovedPath(), projectDir), resPath(f.Path, projectDir)
+	return &report.SnapshotDiff{
+		Approved: approved,
+		Received: received,
+		Diff:     Diff(string(want), string(got), approved, received),
+	}, nil
+}
+
+// resPath converts an absolute path inside projectDir to a res:// path.
+func resPath(path, projectDir string) string {
+	rel, err := filepath.Rel(projectDir, path)
+	if err != nil {
+		return path
+	}
+	return "res://" + filepath.ToSlash(rel)
+}
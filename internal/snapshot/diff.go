@@ -0,0 +1,132 @@
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells bounds the size of the LCS table; larger inputs are summarized instead of diffed.
+const maxDiffCells = 4_000_000
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+// edit is one line of an edit script: ' ' (keep), '-' (delete from a) or '+' (insert from b).
+type edit struct {
+	op   byte
+	text string
+	ai   int // index in a of the next line of a at this point
+	bi   int // index in b of the next line of b at this point
+}
+
+// Diff returns a unified diff turning a into b, or "" if they are equal.
+func Diff(a, b, nameA, nameB string) string {
+	if a == b {
+		return ""
+	}
+	la, lb := splitLines(a), splitLines(b)
+	if (len(la)+1)*(len(lb)+1) > maxDiffCells {
+		return fmt.Sprintf("--- %s\n+++ %s\n(files differ: %d and %d lines, too large to diff)\n", nameA, nameB, len(la), len(lb))
+	}
+
+	ops := editScript(la, lb)
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].op == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// Extend the hunk while the next change is close enough to share context.
+		start := max(0, i-contextLines)
+		end := i
+		for {
+			for end < len(ops) && ops[end].op != ' ' {
+				end++
+			}
+			j := end
+			for j < len(ops) && ops[j].op == ' ' && j-end < 2*contextLines {
+				j++
+			}
+			if j < len(ops) && ops[j].op != ' ' {
+				end = j
+				continue
+			}
+			break
+		}
+		stop := min(len(ops), end+contextLines)
+
+		aLen, bLen := 0, 0
+		for _, e := range ops[start:stop] {
+			if e.op != '+' {
+				aLen++
+			}
+			if e.op != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(ops[start].ai, aLen), hunkRange(ops[start].bi, bLen))
+		for _, e := range ops[start:stop] {
+			out.WriteByte(e.op)
+			out.WriteString(e.text)
+			out.WriteByte('\n')
+		}
+		i = stop
+	}
+	return out.String()
+}
+
+// hunkRange formats a unified diff range for a hunk starting at 0-based index start.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// editScript returns a minimal line edit script from a to b using a longest common subsequence.
+func editScript(a, b []string) []edit {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []edit
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, edit{' ', a[i], i, j})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, edit{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, edit{'+', b[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+// splitLines splits s into lines without their terminators.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n"), "\n")
+}
@@ -0,0 +1,82 @@
+package snapshot
+
+import (
+	"sort"
+
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+)
+
+// Entry statuses.
+const (
+	StatusApproved = "approved" // only an approved file exists
+	StatusPending  = "pending"  // a received file differs from the approved one
+	StatusNew      = "new"      // a received file exists without an approved one
+	StatusOrphaned = "orphaned" // the test no longer exists
+)
+
+// Entry is one snapshot with its approved and received files.
+type Entry struct {
+	Suite    string `json:"suite"`
+	Test     string `json:"test"`
+	Approved string `json:"approved,omitempty"` // res:// path, if the file exists
+	Received string `json:"received,omitempty"` // res:// path, if the file exists
+	Status   string `json:"status"`
+
+	files []File
+}
+
+// Files returns the files of the entry on disk.
+func (e Entry) Files() []File {
+	return e.files
+}
+
+// Entries groups the snapshot files under projectDir by snapshot and classifies them
+// against the discovered suites.
+func Entries(projectDir string, suites []discovery.Suite) ([]Entry, error) {
+	files, err := Find(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	tests := map[string]bool{}
+	for _, s := range suites {
+		for _, t := range s.Tests {
+			tests[s.Class+"."+t] = true
+		}
+	}
+
+	byKey := map[string]*Entry{}
+	var keys []string
+	for _, f := range files {
+		e := byKey[f.Key()]
+		if e == nil {
+			e = &Entry{Suite: f.Suite, Test: f.Test}
+			byKey[f.Key()] = e
+			keys = append(keys, f.Key())
+		}
+		e.files = append(e.files, f)
+		if f.Kind == Approved {
+			e.Approved = resPath(f.Path, projectDir)
+		} else {
+			e.Received = resPath(f.Path, projectDir)
+		}
+	}
+	sort.Strings(keys)
+
+	entries := make([]Entry, 0, len(keys))
+	for _, k := range keys {
+		e := byKey[k]
+		switch {
+		case !tests[e.Suite+"."+e.Test]:
+			e.Status = StatusOrphaned
+		case e.Received == "":
+			e.Status = StatusApproved
+		case e.Approved == "":
+			e.Status = StatusNew
+		default:
+			e.Status = StatusPending
+		}
+		entries = append(entries, *e)
+	}
+	return entries, nil
+}
@@ -0,0 +1,115 @@
+// Package snapshot manages the files of approval-style (snapshot) tests.
+//
+// A test compares its output against <Suite>.<test>.approved[.ext]. On a mismatch
+// it writes the actual output next to it as <Suite>.<test>.received[.ext] and fails;
+// approving a snapshot renames the received file over the approved one. Snapshot
+// files may live anywhere in the project outside hidden directories and addons/.
+package snapshot
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EnvUpdate is set to "1" for Godot when snapshots should be rewritten instead of compared.
+const EnvUpdate = "GDUNIT4_UPDATE_SNAPSHOTS"
+
+// Snapshot kinds.
+const (
+	Approved = "approved"
+	Received = "received"
+)
+
+// nameRe splits a snapshot file name into suite, test, kind and extension.
+var nameRe = regexp.MustCompile(`^(.+)\.([^.]+)\.(approved|received)(\.[^.]+)?$`)
+
+// File is a snapshot file on disk.
+type File struct {
+	Path  string // absolute path
+	Suite string
+	Test  string
+	Kind  string // Approved or Received
+}
+
+// Key identifies the snapshot a file belongs to: its directory, suite, test and extension.
+func (f File) Key() string {
+	return strings.Replace(f.Path, "."+f.Kind, "", 1)
+}
+
+// ApprovedPath returns the path of the approved file for f.
+func (f File) ApprovedPath() string {
+	if f.Kind == Approved {
+		return f.Path
+	}
+	dir, name := filepath.Split(f.Path)
+	return dir + strings.Replace(name, "."+Received, "."+Approved, 1)
+}
+
+// Parse reports whether path names a snapshot file and returns it.
+func Parse(path string) (File, bool) {
+	m := nameRe.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return File{}, false
+	}
+	return File{Path: path, Suite: m[1], Test: m[2], Kind: m[3]}, true
+}
+
+// Find returns the snapshot files under projectDir sorted by path.
+func Find(projectDir string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(projectDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != projectDir && (strings.HasPrefix(d.Name(), ".") || p == filepath.Join(projectDir, "addons")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if f, ok := Parse(p); ok {
+			files = append(files, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find snapshots: %w", err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Approve replaces the approved file of the received snapshot f with f.
+func Approve(f File) error {
+	if f.Kind != Received {
+		return fmt.Errorf("%s is not a received snapshot", f.Path)
+	}
+	if err := os.Rename(f.Path, f.ApprovedPath()); err != nil {
+		return fmt.Errorf("failed to approve snapshot: %w", err)
+	}
+	return nil
+}
+
+// ApproveAll approves every received snapshot under projectDir and returns the approved paths.
+func ApproveAll(projectDir string) ([]string, error) {
+	files, err := Find(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	var approved []string
+	for _, f := range files {
+		if f.Kind != Received {
+			continue
+		}
+		if err := Approve(f); err != nil {
+			return approved, err
+		}
+		approved = append(approved, f.ApprovedPath())
+	}
+	return approved, nil
+}
@@ -0,0 +1,170 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minami110/gdunit4-test-runner/internal/discovery"
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// writeFile creates path (and its parent dirs) under root with content.
+func writeFile(t *testing.T, root, path, content string) string {
+	t.Helper()
+	full := filepath.Join(root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return full
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		ok    bool
+		suite string
+		test  string
+		kind  string
+	}{
+		{"Player.test_render.approved.txt", true, "Player", "test_render", Approved},
+		{"Player.test_render.received.json", true, "Player", "test_render", Received},
+		{"test_math.test_add.approved", true, "test_math", "test_add", Approved},
+		{"player.gd", false, "", "", ""},
+		{"approved.txt", false, "", "", ""},
+	}
+	for _, tt := range tests {
+		f, ok := Parse(filepath.Join("/proj", tt.name))
+		if ok != tt.ok || f.Suite != tt.suite || f.Test != tt.test || f.Kind != tt.kind {
+			t.Errorf("Parse(%q) = %+v, %v", tt.name, f, ok)
+		}
+	}
+}
+
+func TestFile_ApprovedPath(t *testing.T) {
+	f, _ := Parse("/proj/snap/Player.test_render.received.txt")
+	if got := f.ApprovedPath(); got != "/proj/snap/Player.test_render.approved.txt" {
+		t.Errorf("ApprovedPath = %q", got)
+	}
+}
+
+func TestFind_SkipsAddonsAndHiddenDirs(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "test/__snapshots__/A.test_x.approved.txt", "x")
+	writeFile(t, root, "addons/lib/B.test_y.approved.txt", "y")
+	writeFile(t, root, ".godot/C.test_z.approved.txt", "z")
+
+	files, err := Find(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Suite != "A" {
+		t.Errorf("Find = %+v, want only A.test_x", files)
+	}
+}
+
+func TestApproveAll(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "snap/A.test_x.approved.txt", "old")
+	writeFile(t, root, "snap/A.test_x.received.txt", "new")
+
+	approved, err := ApproveAll(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approved) != 1 {
+		t.Fatalf("approved = %v, want one file", approved)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "snap", "A.test_x.approved.txt"))
+	if string(data) != "new" {
+		t.Errorf("approved content = %q, want new", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "snap", "A.test_x.received.txt")); !os.IsNotExist(err) {
+		t.Error("received file still exists after approval")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"new file", "", "x\n", "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+x\n"},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"X\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\nY\n",
+			"--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+X\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+Y\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.a, tt.b, "old", "new"); got != tt.want {
+				t.Errorf("Diff =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttach(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "test/snap/Player.test_render.approved.txt", "hp: 10\n")
+	writeFile(t, root, "test/snap/Player.test_render.received.txt", "hp: 9\n")
+
+	out := &report.Output{Failures: []report.Failure{
+		{Class: "Player", Method: "test_render"},
+		{Class: "Player", Method: "test_jump"},
+	}}
+	if err := Attach(out, root); err != nil {
+		t.Fatal(err)
+	}
+
+	s := out.Failures[0].Snapshot
+	if s == nil {
+		t.Fatal("expected a snapshot diff on test_render")
+	}
+	if s.Approved != "res://test/snap/Player.test_render.approved.txt" || s.Received != "res://test/snap/Player.test_render.received.txt" {
+		t.Errorf("paths = %s, %s", s.Approved, s.Received)
+	}
+	if !strings.Contains(s.Diff, "-hp: 10\n+hp: 9\n") {
+		t.Errorf("Diff = %q", s.Diff)
+	}
+	if out.Failures[1].Snapshot != nil {
+		t.Errorf("unexpected snapshot on test_jump: %+v", out.Failures[1].Snapshot)
+	}
+}
+
+func TestEntries(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "snap/P.test_a.approved.txt", "a")
+	writeFile(t, root, "snap/P.test_b.approved.txt", "b")
+	writeFile(t, root, "snap/P.test_b.received.txt", "B")
+	writeFile(t, root, "snap/P.test_c.received.txt", "c")
+	writeFile(t, root, "snap/P.test_gone.approved.txt", "g")
+
+	suites := []discovery.Suite{{Class: "P", Tests: []string{"test_a", "test_b", "test_c"}}}
+	entries, err := Entries(root, suites)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"test_a": StatusApproved, "test_b": StatusPending, "test_c": StatusNew, "test_gone": StatusOrphaned}
+	if len(entries) != len(want) {
+		t.Fatalf("len(entries) = %d, want %d: %+v", len(entries), len(want), entries)
+	}
+	for _, e := range entries {
+		if e.Status != want[e.Test] {
+			t.Errorf("%s status = %s, want %s", e.Test, e.Status, want[e.Test])
+		}
+	}
+	if len(entries[1].Files()) != 2 {
+		t.Errorf("test_b has %d files, want 2", len(entries[1].Files()))
+	}
+}
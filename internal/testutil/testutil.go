@@ -0,0 +1,50 @@
+// Package testutil holds the test fixtures shared by the tests of several
+// packages: a Godot project on disk and a fake Godot binary to run it with.
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// Project creates a Godot project with gdUnit4 installed in a temp directory,
+// holding files by slash-separated project-relative path, and returns its root.
+func Project(t testing.TB, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	all := map[string]string{
+		"project.godot":        "[application]\n",
+		"addons/gdUnit4/.keep": "",
+	}
+	for name, content := range files {
+		all[name] = content
+	}
+	for name, content := range all {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// FakeGodot writes a shell script standing in for Godot that runs script, and
+// returns its path. Godot is started in the project directory, so script can
+// write the gdUnit4 report under reports/. The test is skipped on Windows,
+// which cannot run it.
+func FakeGodot(t testing.TB, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "fake-godot.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
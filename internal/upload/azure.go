@@ -0,0 +1,63 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// azureStore uploads block blobs to Azure Blob Storage authenticated with a SAS token.
+type azureStore struct {
+	client    *http.Client
+	endpoint  *url.URL // https://<account>.blob.core.windows.net unless overridden
+	container string
+	prefix    string
+	sasToken  string // query string without the leading "?"
+}
+
+// newAzureStore configures an Azure store from AZURE_STORAGE_SAS_TOKEN.
+// AZURE_STORAGE_ENDPOINT overrides the account's blob endpoint (e.g. for Azurite).
+func newAzureStore(account, container, prefix string) (*azureStore, error) {
+	sas := strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?")
+	if sas == "" {
+		return nil, errors.New("azblob upload requires AZURE_STORAGE_SAS_TOKEN")
+	}
+	endpoint := &url.URL{Scheme: "https", Host: account + ".blob.core.windows.net"}
+	if ep := os.Getenv("AZURE_STORAGE_ENDPOINT"); ep != "" {
+		u, err := url.Parse(ep)
+		if err != nil || u.Host == "" {
+			return nil, errors.New("invalid AZURE_STORAGE_ENDPOINT " + ep)
+		}
+		endpoint = u
+	}
+	return &azureStore{
+		client:    http.DefaultClient,
+		endpoint:  endpoint,
+		container: container,
+		prefix:    prefix,
+		sasToken:  sas,
+	}, nil
+}
+
+func (s *azureStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	p := strings.TrimSuffix(s.endpoint.Path, "/") + "/" + s.container + "/" + joinKey(s.prefix, key)
+	u := &url.URL{Scheme: s.endpoint.Scheme, Host: s.endpoint.Host, Path: p, RawPath: escapePath(p), RawQuery: s.sasToken}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	req.Header.Set("X-Ms-Version", "2021-08-06")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp)
+}
@@ -0,0 +1,237 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Store uploads objects with S3 PUT Object requests signed with AWS Signature Version 4.
+// Google Cloud Storage accepts the same requests on its XML API when authenticated
+// with HMAC keys, so gs:// URLs share this implementation.
+type s3Store struct {
+	client       *http.Client
+	endpoint     *url.URL // scheme and host requests are sent to
+	pathStyle    bool     // put the bucket in the path instead of the host name
+	bucket       string
+	prefix       string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	bearerToken  string // OAuth token used instead of signing, if set
+	now          func() time.Time
+}
+
+// newS3Store configures an S3 store from the standard AWS environment variables.
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL selects an S3-compatible service (MinIO, R2, ...)
+// and switches to path-style addressing.
+func newS3Store(bucket, prefix string) (*s3Store, error) {
+	s := &s3Store{
+		client:       http.DefaultClient,
+		bucket:       bucket,
+		prefix:       prefix,
+		region:       firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		now:          time.Now,
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, errors.New("s3 upload requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	if ep := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); ep != "" {
+		u, err := url.Parse(ep)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", ep)
+		}
+		s.endpoint = u
+		s.pathStyle = true
+	} else {
+		s.endpoint = &url.URL{Scheme: "https", Host: "s3." + s.region + ".amazonaws.com"}
+	}
+	return s, nil
+}
+
+// newGCSStore configures a Cloud Storage store. GOOGLE_OAUTH_ACCESS_TOKEN (for example
+// from `gcloud auth print-access-token`) is used if set; otherwise requests are signed
+// with the HMAC key in GCS_HMAC_ACCESS_KEY_ID and GCS_HMAC_SECRET.
+func newGCSStore(bucket, prefix string) (*s3Store, error) {
+	s := &s3Store{
+		client:      http.DefaultClient,
+		endpoint:    &url.URL{Scheme: "https", Host: "storage.googleapis.com"},
+		pathStyle:   true,
+		bucket:      bucket,
+		prefix:      prefix,
+		region:      "auto",
+		accessKey:   os.Getenv("GCS_HMAC_ACCESS_KEY_ID"),
+		secretKey:   os.Getenv("GCS_HMAC_SECRET"),
+		bearerToken: os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+		now:         time.Now,
+	}
+	if s.bearerToken == "" && (s.accessKey == "" || s.secretKey == "") {
+		return nil, errors.New("gs upload requires GOOGLE_OAUTH_ACCESS_TOKEN or GCS_HMAC_ACCESS_KEY_ID and GCS_HMAC_SECRET")
+	}
+	return s, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	objectPath := "/" + joinKey(s.prefix, key)
+	host := s.endpoint.Host
+	if s.pathStyle {
+		objectPath = "/" + s.bucket + objectPath
+	} else {
+		host = s.bucket + "." + host
+	}
+
+	u := &url.URL{Scheme: s.endpoint.Scheme, Host: host, Path: objectPath, RawPath: escapePath(objectPath)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	if s.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.bearerToken)
+	} else {
+		s.sign(req, data)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp)
+}
+
+// sign adds AWS Signature Version 4 headers to req.
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv.html.
+func (s *s3Store) sign(req *http.Request, payload []byte) {
+	t := s.now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.secretKey, date, s.region, "s3"), stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// signingKey derives the Signature Version 4 signing key for a date, region and service.
+func signingKey(secret, date, region, service string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secret), date)
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, service)
+	return hmacSHA256(k, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// escapePath percent-encodes every byte of p outside the RFC 3986 unreserved set,
+// keeping "/" separators, as Signature Version 4 requires.
+func escapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// joinKey joins a store prefix and an object key.
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
+
+// firstEnv returns the value of the first of names that is set.
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// checkResponse closes resp and turns a non-2xx status into an error that includes
+// the start of the response body.
+func checkResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
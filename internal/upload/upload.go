@@ -0,0 +1,161 @@
+// Package upload pushes a run's results to an object store so they outlive the machine
+// that produced them.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+// Store writes objects under a fixed prefix of a bucket or directory.
+type Store interface {
+	// Put stores data at key, which is relative to the store's prefix and uses "/" separators.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// Run describes the results of one test run to upload.
+type Run struct {
+	Commit    string                  // commit the run tested; see Commit
+	ID        string                  // unique run ID; see pipeline.NewRunID
+	Output    *report.Output          // JSON output of the run
+	Suites    *report.JUnitTestSuites // parsed report; nil if Godot produced none
+	ReportDir string                  // gdUnit4 report directory (results.xml, HTML report); empty if none
+}
+
+// Open returns the Store for rawURL. Supported schemes are:
+//
+//	s3://bucket/prefix       Amazon S3 or an S3-compatible service
+//	gs://bucket/prefix       Google Cloud Storage
+//	azblob://account/container/prefix  Azure Blob Storage
+//	file:///dir              a local directory
+func Open(rawURL string) (Store, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload URL %q: %w", rawURL, err)
+	}
+	prefix := strings.Trim(u.Path, "/")
+
+	switch u.Scheme {
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid upload URL %q: missing bucket", rawURL)
+		}
+		return newS3Store(u.Host, prefix)
+	case "gs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid upload URL %q: missing bucket", rawURL)
+		}
+		return newGCSStore(u.Host, prefix)
+	case "azblob":
+		container, prefix, _ := strings.Cut(prefix, "/")
+		if u.Host == "" || container == "" {
+			return nil, fmt.Errorf("invalid upload URL %q: want azblob://account/container/prefix", rawURL)
+		}
+		return newAzureStore(u.Host, container, prefix)
+	case "file":
+		return &fileStore{dir: filepath.FromSlash(u.Path)}, nil
+	}
+	return nil, fmt.Errorf("unsupported upload URL %q; want s3://, gs://, azblob:// or file://", rawURL)
+}
+
+// Upload writes run to store under <commit>/<run id>/ and returns the keys written:
+// output.json, junit.xml and every file of the gdUnit4 report directory under report/.
+func Upload(ctx context.Context, store Store, run Run) ([]string, error) {
+	base := run.Commit + "/" + run.ID + "/"
+	var keys []string
+	put := func(name string, data []byte, contentType string) error {
+		if err := store.Put(ctx, base+name, data, contentType); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", name, err)
+		}
+		keys = append(keys, base+name)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf, run.Output); err != nil {
+		return keys, err
+	}
+	if err := put("output.json", buf.Bytes(), "application/json"); err != nil {
+		return keys, err
+	}
+
+	buf.Reset()
+	if err := report.WriteJUnitXML(&buf, run.Suites, run.Output.CrashDetails); err != nil {
+		return keys, err
+	}
+	if err := put("junit.xml", buf.Bytes(), "application/xml"); err != nil {
+		return keys, err
+	}
+
+	if run.ReportDir == "" {
+		return keys, nil
+	}
+	err := filepath.WalkDir(run.ReportDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(run.ReportDir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return put(path.Join("report", filepath.ToSlash(rel)), data, contentType(p))
+	})
+	return keys, err
+}
+
+// contentType guesses the MIME type of a report file from its extension.
+func contentType(name string) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// commitEnvVars are the variables CI systems use to expose the commit under test.
+var commitEnvVars = []string{"GITHUB_SHA", "CI_COMMIT_SHA", "GIT_COMMIT", "BUILD_VCS_NUMBER", "BUILDKITE_COMMIT"}
+
+// Commit returns the commit under test: the first set CI variable of commitEnvVars,
+// then `git rev-parse HEAD` in dir, then "unknown".
+func Commit(dir string) string {
+	for _, name := range commitEnvVars {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	if out, err := cmd.Output(); err == nil {
+		if sha := strings.TrimSpace(string(out)); sha != "" {
+			return sha
+		}
+	}
+	return "unknown"
+}
+
+// fileStore writes objects into a local directory.
+type fileStore struct {
+	dir string
+}
+
+func (s *fileStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	full := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(full, data, 0o644)
+}
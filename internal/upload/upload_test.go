@@ -0,0 +1,209 @@
+package upload
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minami110/gdunit4-test-runner/internal/report"
+)
+
+func TestOpen(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_S3", "")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "token")
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "?sv=1&sig=x")
+
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"s3://bucket/prefix", false},
+		{"gs://bucket", false},
+		{"azblob://account/container/prefix", false},
+		{"file:///tmp/results", false},
+		{"s3:///prefix", true},
+		{"azblob://account", true},
+		{"ftp://host/dir", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			_, err := Open(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Open(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpen_MissingCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if _, err := Open("s3://bucket"); err == nil {
+		t.Error("expected error without AWS credentials, got nil")
+	}
+}
+
+func TestUpload_FileStore(t *testing.T) {
+	reportDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(reportDir, "css"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(reportDir, "results.xml"), []byte("<testsuites/>"), 0o644)
+	os.WriteFile(filepath.Join(reportDir, "css", "style.css"), []byte("body{}"), 0o644)
+
+	dest := t.TempDir()
+	store, err := Open("file://" + filepath.ToSlash(dest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := report.BuildOutput(&report.JUnitTestSuites{Tests: 1}, nil)
+	keys, err := Upload(context.Background(), store, Run{Commit: "abc", ID: "run1", Output: out, ReportDir: reportDir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"abc/run1/output.json", "abc/run1/junit.xml", "abc/run1/report/css/style.css", "abc/run1/report/results.xml"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+	for _, k := range want {
+		if _, err := os.Stat(filepath.Join(dest, filepath.FromSlash(k))); err != nil {
+			t.Errorf("missing uploaded file %s: %v", k, err)
+		}
+	}
+}
+
+// recorder is an httptest handler that records PUT requests.
+type recorder struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, string(body))
+	r.mu.Unlock()
+}
+
+func TestS3Store_Put(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL_S3", srv.URL)
+
+	store, err := newS3Store("bucket", "ci/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	if err := store.Put(context.Background(), "abc/run 1/output.json", []byte("{}"), "application/json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := rec.requests[0]
+	if req.Method != http.MethodPut {
+		t.Errorf("method = %s, want PUT", req.Method)
+	}
+	if got := req.URL.EscapedPath(); got != "/bucket/ci/results/abc/run%201/output.json" {
+		t.Errorf("path = %s", got)
+	}
+	if rec.bodies[0] != "{}" {
+		t.Errorf("body = %q, want {}", rec.bodies[0])
+	}
+	if req.Header.Get("X-Amz-Security-Token") != "session" {
+		t.Errorf("missing session token header")
+	}
+	authRe := regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=AKID/20260102/eu-west-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token, Signature=[0-9a-f]{64}$`)
+	if auth := req.Header.Get("Authorization"); !authRe.MatchString(auth) {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
+
+func TestS3Store_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error>AccessDenied</Error>", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	store := &s3Store{client: srv.Client(), endpoint: u, pathStyle: true, bucket: "b", bearerToken: "t", now: time.Now}
+	err := store.Put(context.Background(), "k", nil, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("err = %v, want AccessDenied", err)
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation.
+	got := hex.EncodeToString(signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam"))
+	want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if got != want {
+		t.Errorf("signingKey = %s, want %s", got, want)
+	}
+}
+
+func TestAzureStore_Put(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "?sv=2021&sig=abc")
+	t.Setenv("AZURE_STORAGE_ENDPOINT", srv.URL+"/devstoreaccount1")
+
+	store, err := newAzureStore("devstoreaccount1", "results", "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(context.Background(), "abc/run1/junit.xml", []byte("<x/>"), "application/xml"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := rec.requests[0]
+	if req.URL.Path != "/devstoreaccount1/results/ci/abc/run1/junit.xml" {
+		t.Errorf("path = %s", req.URL.Path)
+	}
+	if req.URL.RawQuery != "sv=2021&sig=abc" {
+		t.Errorf("query = %s", req.URL.RawQuery)
+	}
+	if req.Header.Get("X-Ms-Blob-Type") != "BlockBlob" {
+		t.Errorf("X-Ms-Blob-Type = %q, want BlockBlob", req.Header.Get("X-Ms-Blob-Type"))
+	}
+}
+
+func TestCommit(t *testing.T) {
+	for _, name := range commitEnvVars {
+		t.Setenv(name, "")
+	}
+	t.Setenv("CI_COMMIT_SHA", "deadbeef")
+	if got := Commit(t.TempDir()); got != "deadbeef" {
+		t.Errorf("Commit = %q, want deadbeef", got)
+	}
+
+	t.Setenv("CI_COMMIT_SHA", "")
+	if got := Commit(t.TempDir()); got != "unknown" {
+		t.Errorf("Commit outside a repo = %q, want unknown", got)
+	}
+}